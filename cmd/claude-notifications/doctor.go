@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+func doctor() {
+	cfg, err := config.LoadFromPluginRoot(getPluginRoot())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	printDoctor(os.Stdout, cfg, platform.DetectHeadless(), platform.IsRemoteSession())
+}
+
+// printDoctor prints what the environment lets the plugin deliver: desktop notifications
+// and sounds are skipped in SSH sessions and headless environments, webhooks never are
+func printDoctor(w io.Writer, cfg *config.Config, headless platform.Headless, remote bool) {
+	desktop := cfg.Notifications.Desktop
+
+	fmt.Fprintf(w, "Platform:        %s\n", platform.OS())
+	fmt.Fprintf(w, "Headless:        %s\n", headless)
+	fmt.Fprintf(w, "SSH session:     %s\n", yesNo(remote))
+	if desktop.ForceEnabled {
+		fmt.Fprintln(w, "Force enabled:   yes (desktop.forceEnabled overrides the detection)")
+		headless, remote = platform.Headless{}, false
+	}
+	fmt.Fprintln(w)
+
+	switch {
+	case !cfg.IsDesktopEnabled():
+		fmt.Fprintln(w, "➖ Desktop notifications disabled in config")
+	case remote:
+		fmt.Fprintln(w, "⚠️  Desktop notifications skipped: SSH session without a display")
+	case headless.NoDesktop():
+		fmt.Fprintf(w, "⚠️  Desktop notifications skipped: %s\n", headless)
+	default:
+		fmt.Fprintln(w, "✅ Desktop notifications available")
+	}
+
+	switch {
+	case !cfg.IsDesktopEnabled() || !desktop.Sound:
+		fmt.Fprintln(w, "➖ Sounds disabled in config")
+	case remote || headless.NoDesktop():
+		fmt.Fprintln(w, "⚠️  Sounds skipped with desktop notifications")
+	case headless.NoSound():
+		fmt.Fprintf(w, "⚠️  Sounds skipped: %s\n", headless)
+	default:
+		fmt.Fprintln(w, "✅ Sounds available")
+	}
+
+	if cfg.IsWebhookEnabled() {
+		fmt.Fprintln(w, "✅ Webhooks enabled (sent in any environment)")
+	} else {
+		fmt.Fprintln(w, "➖ Webhooks disabled in config")
+	}
+}
+
+// yesNo formats a boolean for printDoctor
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
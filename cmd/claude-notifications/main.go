@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/errorhandler"
 	"github.com/777genius/claude-notifications/internal/hooks"
 	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/webhook"
 )
 
 const version = "1.0.3"
@@ -37,6 +45,30 @@ func main() {
 			os.Exit(1)
 		}
 		handleHook(os.Args[2])
+	case "--socket":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "Error: socket path required\n")
+			printUsage()
+			os.Exit(1)
+		}
+		serveSocket(os.Args[2])
+	case hooks.ThrottleFlushCommand:
+		// Started in the background by a hook that opened a throttle window
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "Error: session ID required\n")
+			os.Exit(1)
+		}
+		flushThrottle(os.Args[2])
+	case "--send-test":
+		sendTest()
+	case "--test-webhook":
+		testWebhook()
+	case "stats":
+		showStats()
+	case "replay":
+		replay(os.Args[2:])
+	case "doctor":
+		doctor()
 	case "version", "--version", "-v":
 		fmt.Printf("claude-notifications v%s\n", version)
 	case "help", "--help", "-h":
@@ -69,6 +101,10 @@ func handleHook(hookEvent string) {
 		os.Exit(1)
 	}
 
+	// Dump webhook metrics on SIGUSR1 (no-op on Windows)
+	stopDump := installMetricsDumpHandler(handler)
+	defer stopDump()
+
 	// Handle hook
 	if err := handler.HandleHook(hookEvent, os.Stdin); err != nil {
 		errorhandler.HandleCriticalError(err, "Failed to handle hook")
@@ -76,6 +112,174 @@ func handleHook(hookEvent string) {
 	}
 }
 
+func flushThrottle(sessionID string) {
+	defer errorhandler.HandlePanic()
+
+	pluginRoot := getPluginRoot()
+
+	if _, err := logging.InitLogger(pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to create handler")
+		os.Exit(1)
+	}
+
+	if err := handler.FlushThrottle(sessionID); err != nil {
+		logging.Warn("Failed to flush throttle window: %v", err)
+	}
+}
+
+func serveSocket(socketPath string) {
+	defer errorhandler.HandlePanic()
+
+	pluginRoot := getPluginRoot()
+
+	if _, err := logging.InitLogger(pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to create handler")
+		os.Exit(1)
+	}
+
+	stopDump := installMetricsDumpHandler(handler)
+	defer stopDump()
+
+	if err := hooks.ServeUnixSocket(socketPath, handler); err != nil {
+		errorhandler.HandleCriticalError(err, "Socket server failed")
+		os.Exit(1)
+	}
+}
+
+func sendTest() {
+	defer errorhandler.HandlePanic()
+
+	pluginRoot := getPluginRoot()
+
+	if _, err := logging.InitLogger(pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to create handler")
+		os.Exit(1)
+	}
+
+	if err := handler.SendTest(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Test notification sent")
+}
+
+// webhookHealthCheckTimeout bounds how long --test-webhook waits for all endpoints
+const webhookHealthCheckTimeout = 30 * time.Second
+
+func testWebhook() {
+	defer errorhandler.HandlePanic()
+
+	pluginRoot := getPluginRoot()
+
+	if _, err := logging.InitLogger(pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	cfg, err := config.LoadFromPluginRoot(pluginRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookHealthCheckTimeout)
+	defer cancel()
+
+	if !printHealthCheck(os.Stdout, webhook.New(cfg).HealthCheck(ctx)) {
+		os.Exit(1)
+	}
+}
+
+// printHealthCheck prints ✅ for a passing health check or one ❌ line per failing
+// endpoint, and reports whether the check passed
+func printHealthCheck(w io.Writer, err error) bool {
+	if err == nil {
+		fmt.Fprintln(w, "✅ Webhook reachable")
+		return true
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, e := range errs {
+		fmt.Fprintf(w, "❌ %v\n", e)
+	}
+	return false
+}
+
+func showStats() {
+	path := webhook.DefaultStatsPath()
+	snapshot, err := webhook.ReadStatsFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("No webhook metrics recorded yet (%s)\n", path)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printStats(os.Stdout, snapshot)
+}
+
+// printStats prints a human-readable metrics summary
+func printStats(w io.Writer, snapshot *webhook.StatsSnapshot) {
+	fmt.Fprintf(w, "Webhook metrics (updated %s)\n", snapshot.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Total requests:   %d\n", snapshot.TotalRequests)
+	fmt.Fprintf(w, "  Successful:       %d\n", snapshot.SuccessfulRequests)
+	fmt.Fprintf(w, "  Failed:           %d\n", snapshot.FailedRequests)
+	fmt.Fprintf(w, "  Rate limited:     %d\n", snapshot.RateLimitedRequests)
+	fmt.Fprintf(w, "  Circuit open:     %d\n", snapshot.CircuitOpenRequests)
+	fmt.Fprintf(w, "  Success rate:     %.1f%%\n", snapshot.SuccessRate())
+	fmt.Fprintf(w, "  Average latency:  %dms\n", snapshot.AverageLatencyMs)
+	fmt.Fprintf(w, "  Latency p50/p95/p99: %d/%d/%dms\n", snapshot.P50(), snapshot.P95(), snapshot.P99())
+	fmt.Fprintf(w, "  Circuit breaker:  %s\n", snapshot.CircuitBreakerState)
+
+	if len(snapshot.StatusCounts) == 0 {
+		return
+	}
+
+	statuses := make([]string, 0, len(snapshot.StatusCounts))
+	for status := range snapshot.StatusCounts {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  By status:")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "    %-22s %d\n", status, snapshot.StatusCounts[analyzer.Status(status)])
+	}
+}
+
 func getPluginRoot() string {
 	// Try CLAUDE_PLUGIN_ROOT environment variable first
 	if root := os.Getenv("CLAUDE_PLUGIN_ROOT"); root != "" {
@@ -109,12 +313,28 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  claude-notifications handle-hook <HookName>")
+	fmt.Println("  claude-notifications --socket <path>")
+	fmt.Println("  claude-notifications --send-test")
+	fmt.Println("  claude-notifications --test-webhook")
+	fmt.Println("  claude-notifications stats")
+	fmt.Println("  claude-notifications replay --transcript <path> [--since <time>]")
+	fmt.Println("  claude-notifications doctor")
 	fmt.Println("  claude-notifications version")
 	fmt.Println("  claude-notifications help")
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  handle-hook <HookName>  Handle a Claude Code hook event")
 	fmt.Println("                          HookName: PreToolUse, Stop, SubagentStop, Notification")
+	fmt.Println("  --socket <path>         Run as a daemon, reading hook JSON from a Unix socket")
+	fmt.Println("                          (one message per connection, event from hook_event_name)")
+	fmt.Println("                          add \"env\": {\"TMUX_PANE\": ...} for clickToFocus")
+	fmt.Println("  --send-test             Send a test notification (desktop and webhook if enabled)")
+	fmt.Println("  --test-webhook          Check that each enabled webhook endpoint answers a test payload with 2xx")
+	fmt.Println("  stats                   Show accumulated webhook metrics")
+	fmt.Println("  replay                  Send the notification a missed Stop hook would have sent")
+	fmt.Println("                          --since: RFC3339, Unix seconds, or e.g. \"30 minutes ago\"")
+	fmt.Println("  doctor                  Show whether this environment (SSH, CI, no display or audio)")
+	fmt.Println("                          lets desktop notifications, sounds and webhooks through")
 	fmt.Println("  version                 Show version information")
 	fmt.Println("  help                    Show this help message")
 	fmt.Println()
@@ -125,7 +345,16 @@ func printUsage() {
 	fmt.Println("  # Handle Stop hook")
 	fmt.Println("  echo '{\"session_id\":\"test\",\"transcript_path\":\"/path/to/transcript.jsonl\"}' | claude-notifications handle-hook Stop")
 	fmt.Println()
+	fmt.Println("  # Replay a notification missed while the machine was off")
+	fmt.Println("  claude-notifications replay --transcript /path/to/transcript.jsonl --since \"2 hours ago\"")
+	fmt.Println()
+	fmt.Println("  # Send a hook to a running socket daemon")
+	fmt.Println("  echo '{\"session_id\":\"test\",\"hook_event_name\":\"Notification\"}' | nc -U /tmp/claude-notifications.sock")
+	fmt.Println()
 	fmt.Println("Environment Variables:")
 	fmt.Println("  CLAUDE_PLUGIN_ROOT  Plugin root directory (auto-detected if not set)")
 	fmt.Println()
+	fmt.Println("Signals:")
+	fmt.Println("  SIGUSR1  Add unrecorded webhook metrics to the stats file (Unix only)")
+	fmt.Println()
 }
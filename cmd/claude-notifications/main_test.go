@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+func TestPrintStats(t *testing.T) {
+	snapshot := &webhook.StatsSnapshot{
+		Stats: webhook.Stats{
+			TotalRequests:       4,
+			SuccessfulRequests:  3,
+			FailedRequests:      1,
+			AverageLatencyMs:    120,
+			CircuitBreakerState: webhook.StateClosed,
+			StatusCounts: map[analyzer.Status]int64{
+				analyzer.StatusTaskComplete: 2,
+				analyzer.StatusQuestion:     1,
+			},
+		},
+		UpdatedAt: time.Now(),
+	}
+
+	var buf bytes.Buffer
+	printStats(&buf, snapshot)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Total requests:   4",
+		"Successful:       3",
+		"Failed:           1",
+		"Success rate:     75.0%",
+		"Average latency:  120ms",
+		"Circuit breaker:  closed",
+		"task_complete",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printStats() output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Statuses are listed in sorted order
+	if strings.Index(out, "question") > strings.Index(out, "task_complete") {
+		t.Errorf("expected statuses sorted alphabetically:\n%s", out)
+	}
+}
+
+func TestPrintHealthCheck(t *testing.T) {
+	var buf bytes.Buffer
+	if !printHealthCheck(&buf, nil) {
+		t.Error("expected a nil error to pass")
+	}
+	if got := buf.String(); got != "✅ Webhook reachable\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+
+	buf.Reset()
+	err := errors.Join(
+		fmt.Errorf("slack webhook: %w", &webhook.HTTPError{StatusCode: 404, Status: "404 Not Found", Body: "no_service"}),
+		errors.New("telegram webhook: HTTP request failed"),
+	)
+	if printHealthCheck(&buf, err) {
+		t.Error("expected failures to fail the check")
+	}
+	want := "❌ slack webhook: HTTP 404: 404 Not Found - no_service\n❌ telegram webhook: HTTP request failed\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2024-01-15T10:00:00Z", time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"1736942400", time.Unix(1736942400, 0)},
+		{"30 minutes ago", now.Add(-30 * time.Minute)},
+		{"1 hour ago", now.Add(-time.Hour)},
+		{"2h ago", now.Add(-2 * time.Hour)},
+		{"45s ago", now.Add(-45 * time.Second)},
+		{"3 Days Ago", now.Add(-72 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseSince(tt.input, now)
+			if err != nil {
+				t.Fatalf("parseSince(%q) error = %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "yesterday", "5 fortnights ago", "minutes ago"} {
+		if _, err := parseSince(invalid, now); err == nil {
+			t.Errorf("parseSince(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestPrintDoctor(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *config.Config)
+		headless platform.Headless
+		remote   bool
+		want     []string
+	}{
+		{
+			name: "desktop",
+			want: []string{"Headless:        not headless", "SSH session:     no", "✅ Desktop notifications available", "✅ Sounds available"},
+		},
+		{
+			name:     "CI",
+			headless: platform.Headless{CI: "GITHUB_ACTIONS", NoDisplay: true},
+			want:     []string{"Headless:        CI (GITHUB_ACTIONS), no display", "Desktop notifications skipped: CI (GITHUB_ACTIONS), no display", "Sounds skipped with desktop notifications"},
+		},
+		{
+			name:     "no audio device",
+			headless: platform.Headless{NoAudio: true},
+			want:     []string{"✅ Desktop notifications available", "Sounds skipped: no audio device"},
+		},
+		{
+			name:   "SSH",
+			remote: true,
+			want:   []string{"SSH session:     yes", "Desktop notifications skipped: SSH session"},
+		},
+		{
+			name:     "forced",
+			modify:   func(cfg *config.Config) { cfg.Notifications.Desktop.ForceEnabled = true },
+			headless: platform.Headless{NoDisplay: true},
+			remote:   true,
+			want:     []string{"Force enabled:   yes", "✅ Desktop notifications available"},
+		},
+		{
+			name: "disabled",
+			modify: func(cfg *config.Config) {
+				cfg.Notifications.Desktop.Enabled = false
+				cfg.Notifications.Webhook = config.WebhookList{{Enabled: true, URL: "https://example.com/hook"}}
+			},
+			headless: platform.Headless{CI: "CI"},
+			want:     []string{"➖ Desktop notifications disabled in config", "➖ Sounds disabled in config", "✅ Webhooks enabled"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			if tt.modify != nil {
+				tt.modify(cfg)
+			}
+
+			var buf bytes.Buffer
+			printDoctor(&buf, cfg, tt.headless, tt.remote)
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("printDoctor() output missing %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
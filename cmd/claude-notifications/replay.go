@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+func replay(args []string) {
+	defer errorhandler.HandlePanic()
+
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	transcript := flags.String("transcript", "", "path to the session transcript (.jsonl)")
+	sinceFlag := flags.String("since", "", `only replay if Claude replied after this time (RFC3339, Unix seconds, or e.g. "30 minutes ago")`)
+	flags.Parse(args)
+
+	if *transcript == "" {
+		fmt.Fprintf(os.Stderr, "Error: --transcript is required\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		since, err = parseSince(*sinceFlag, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	pluginRoot := getPluginRoot()
+
+	if _, err := logging.InitLogger(pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(pluginRoot)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to create handler")
+		os.Exit(1)
+	}
+
+	status, err := handler.Replay(*transcript, since)
+	if errors.Is(err, hooks.ErrNothingToReplay) {
+		fmt.Println("Nothing to replay")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Replayed notification: %s\n", status)
+}
+
+// sinceUnits maps the units accepted in "<n> <unit> ago" to their duration
+var sinceUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour,
+}
+
+// parseSince parses an RFC3339 time, a Unix timestamp in seconds, or a relative
+// time like "30 minutes ago" / "2h ago" measured back from now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+
+	if rest, ok := strings.CutSuffix(strings.ToLower(value), " ago"); ok {
+		rest = strings.TrimSpace(rest)
+		// Split "30 minutes" or "30minutes" into number and unit
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(rest[:i])
+		unit := strings.TrimSuffix(strings.TrimSpace(rest[i:]), "s")
+		if unit == "" && strings.HasSuffix(rest, "s") {
+			unit = "s"
+		}
+		if d, known := sinceUnits[unit]; err == nil && known {
+			return now.Add(-time.Duration(n) * d), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(`invalid --since %q (use RFC3339, Unix seconds, or e.g. "30 minutes ago")`, value)
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// installMetricsDumpHandler merges webhook metrics into the stats file on SIGUSR1.
+// Returns a function that stops listening for the signal.
+func installMetricsDumpHandler(handler *hooks.Handler) func() {
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigCh, syscall.SIGUSR1)
+
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				if err := handler.FlushMetrics(); err != nil {
+					logging.Warn("Failed to dump metrics: %v", err)
+				} else {
+					logging.Info("Metrics written to %s", webhook.DefaultStatsPath())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "github.com/777genius/claude-notifications/internal/hooks"
+
+// installMetricsDumpHandler is a no-op on Windows (no SIGUSR1)
+func installMetricsDumpHandler(handler *hooks.Handler) func() {
+	return func() {}
+}
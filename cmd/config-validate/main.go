@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// minSoundSize is the size below which a sound file is reported as likely truncated
+const minSoundSize = 1024
+
+// ANSI colors for the report
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorReset  = "\033[0m"
+)
+
+// report collects the problems found in a config
+type report struct {
+	Errors   []string
+	Warnings []string
+}
+
+func main() {
+	path := flag.String("config", config.FindConfigFile(defaultPluginRoot()), "Config file to check (config.json or config.yaml)")
+	noColor := flag.Bool("no-color", false, "Disable colored output")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: config-validate [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Checks a claude-notifications config for invalid values, missing sound files and bad webhook URLs.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  config-validate\n")
+		fmt.Fprintf(os.Stderr, "  config-validate --config ~/.claude/plugins/claude-notifications/config/config.json\n")
+	}
+	flag.Parse()
+
+	color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+
+	if !platform.FileExists(*path) {
+		fmt.Fprintln(os.Stderr, paint(color, colorRed, fmt.Sprintf("Error: config file not found: %s", *path)))
+		os.Exit(1)
+	}
+
+	// Sound paths in the bundled config use ${CLAUDE_PLUGIN_ROOT}; outside Claude Code
+	// resolve it relative to the config file (<root>/config/config.json)
+	if os.Getenv("CLAUDE_PLUGIN_ROOT") == "" {
+		_ = os.Setenv("CLAUDE_PLUGIN_ROOT", filepath.Dir(filepath.Dir(*path)))
+	}
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, paint(color, colorRed, fmt.Sprintf("Error: %v", err)))
+		os.Exit(1)
+	}
+
+	r := check(cfg)
+	printReport(os.Stdout, *path, r, color)
+	if len(r.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// defaultPluginRoot returns CLAUDE_PLUGIN_ROOT or the current directory
+func defaultPluginRoot() string {
+	if root := os.Getenv("CLAUDE_PLUGIN_ROOT"); root != "" {
+		return root
+	}
+	return "."
+}
+
+// check validates cfg, its status sounds and its webhook URLs
+func check(cfg *config.Config) report {
+	var r report
+
+	if err := cfg.Validate(); err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+
+	statuses := make([]string, 0, len(cfg.Statuses))
+	for status := range cfg.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	for _, status := range statuses {
+		sound := cfg.Statuses[status].Sound
+		if sound == "" {
+			continue
+		}
+		info, err := os.Stat(sound)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("status %s: sound file not found: %s", status, sound))
+			continue
+		}
+		if info.Size() < minSoundSize {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("status %s: sound file looks truncated or corrupt: %s\n  - size: %d bytes\n  + expected: at least %d bytes",
+				status, sound, info.Size(), minSoundSize))
+		}
+	}
+
+	if sound := cfg.Notifications.Desktop.AttentionSound; sound != "" && !platform.FileExists(sound) {
+		r.Errors = append(r.Errors, fmt.Sprintf("attentionSound: sound file not found: %s", sound))
+	}
+
+	for i, wh := range cfg.Notifications.Webhook {
+		if wh.URL == "" {
+			continue
+		}
+		if err := checkURL(wh.URL); err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("webhook %d (%s): invalid URL %q: %v", i+1, presetName(wh.Preset), wh.URL, err))
+		}
+	}
+
+	return r
+}
+
+// checkURL reports whether rawURL is an absolute http(s) URL
+func checkURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("must use http or https scheme")
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("must have a host")
+	}
+	return nil
+}
+
+// presetName labels a webhook by its preset
+func presetName(preset string) string {
+	if preset == "" {
+		return "custom"
+	}
+	return preset
+}
+
+// printReport prints the errors and warnings found in the config at path
+func printReport(w io.Writer, path string, r report, color bool) {
+	for _, msg := range r.Errors {
+		fmt.Fprintln(w, paint(color, colorRed, "error: "+msg))
+	}
+	for _, msg := range r.Warnings {
+		fmt.Fprintln(w, paint(color, colorYellow, "warning: "+msg))
+	}
+
+	switch {
+	case len(r.Errors) > 0:
+		fmt.Fprintln(w, paint(color, colorRed, fmt.Sprintf("%s: %d error(s), %d warning(s)", path, len(r.Errors), len(r.Warnings))))
+	case len(r.Warnings) > 0:
+		fmt.Fprintln(w, paint(color, colorYellow, fmt.Sprintf("%s: valid, %d warning(s)", path, len(r.Warnings))))
+	default:
+		fmt.Fprintln(w, paint(color, colorGreen, fmt.Sprintf("%s: valid", path)))
+	}
+}
+
+// paint wraps text in an ANSI color when color is enabled
+func paint(color bool, code, text string) string {
+	if !color {
+		return text
+	}
+	return code + text + colorReset
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// writeSound creates a sound file of size bytes in dir
+func writeSound(t *testing.T, dir, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testConfig returns a valid config whose only status plays sound
+func testConfig(sound string) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Statuses = map[string]config.StatusInfo{
+		"task_complete": {Title: "Task Complete", Sound: sound},
+	}
+	return cfg
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	good := writeSound(t, dir, "good.mp3", 4096)
+	small := writeSound(t, dir, "small.mp3", 100)
+
+	tests := []struct {
+		name         string
+		cfg          func() *config.Config
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "valid",
+			cfg:  func() *config.Config { return testConfig(good) },
+		},
+		{
+			name:       "missing sound",
+			cfg:        func() *config.Config { return testConfig(filepath.Join(dir, "missing.mp3")) },
+			wantErrors: []string{"status task_complete: sound file not found"},
+		},
+		{
+			name:         "truncated sound",
+			cfg:          func() *config.Config { return testConfig(small) },
+			wantWarnings: []string{"looks truncated or corrupt", "- size: 100 bytes", "+ expected: at least 1024 bytes"},
+		},
+		{
+			name: "invalid value",
+			cfg: func() *config.Config {
+				cfg := testConfig(good)
+				cfg.Notifications.Desktop.Volume = 2
+				return cfg
+			},
+			wantErrors: []string{"volume must be between 0.0 and 1.0"},
+		},
+		{
+			name: "missing attention sound",
+			cfg: func() *config.Config {
+				cfg := testConfig(good)
+				cfg.Notifications.Desktop.AttentionSound = filepath.Join(dir, "chime.mp3")
+				return cfg
+			},
+			wantErrors: []string{"attentionSound: sound file not found"},
+		},
+		{
+			name: "bad webhook URL",
+			cfg: func() *config.Config {
+				cfg := testConfig(good)
+				cfg.Notifications.Webhook[0].Preset = "slack"
+				cfg.Notifications.Webhook[0].URL = "hooks.slack.com/services/x"
+				return cfg
+			},
+			wantErrors: []string{"webhook 1 (slack): invalid URL", "must use http or https scheme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := check(tt.cfg())
+
+			errs := strings.Join(r.Errors, "\n")
+			if len(tt.wantErrors) == 0 && len(r.Errors) > 0 {
+				t.Errorf("unexpected errors: %v", r.Errors)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(errs, want) {
+					t.Errorf("errors missing %q: %v", want, r.Errors)
+				}
+			}
+
+			warnings := strings.Join(r.Warnings, "\n")
+			if len(tt.wantWarnings) == 0 && len(r.Warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", r.Warnings)
+			}
+			for _, want := range tt.wantWarnings {
+				if !strings.Contains(warnings, want) {
+					t.Errorf("warnings missing %q: %v", want, r.Warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintReport(t *testing.T) {
+	tests := []struct {
+		name  string
+		r     report
+		color bool
+		want  []string
+	}{
+		{"valid", report{}, false, []string{"config.json: valid"}},
+		{"warnings only", report{Warnings: []string{"small"}}, false, []string{"warning: small", "valid, 1 warning(s)"}},
+		{"errors", report{Errors: []string{"bad"}}, false, []string{"error: bad", "1 error(s), 0 warning(s)"}},
+		{"colored", report{Errors: []string{"bad"}}, true, []string{colorRed + "error: bad" + colorReset}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printReport(&buf, "config.json", tt.r, tt.color)
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			if !tt.color && strings.Contains(out, "\033[") {
+				t.Errorf("expected no colors:\n%s", out)
+			}
+		})
+	}
+}
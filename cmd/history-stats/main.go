@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/history"
+)
+
+func main() {
+	path := flag.String("file", history.DefaultPath(), "History file to read (with its rotated <file>.1)")
+	topTools := flag.Int("tools", 5, "Number of most used tools to show")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: history-stats [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Summarizes the notification history recorded by claude-notifications.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  history-stats\n")
+		fmt.Fprintf(os.Stderr, "  history-stats --file ~/.claude-notifications-history.jsonl --tools 10\n")
+	}
+	flag.Parse()
+
+	entries, err := history.ReadAll(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printSummary(os.Stdout, history.Summarize(entries), *topTools)
+}
+
+// printSummary prints the totals, statuses and top tools as aligned tables
+func printSummary(w io.Writer, s history.Summary, topTools int) {
+	if s.Notifications == 0 {
+		fmt.Fprintln(w, "No notification history yet")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Notifications\t%d\n", s.Notifications)
+	fmt.Fprintf(tw, "Sessions\t%d\n", s.Sessions)
+	fmt.Fprintf(tw, "Success rate\t%.0f%%\n", s.SuccessRate*100)
+	fmt.Fprintf(tw, "Average duration\t%s\n", formatDuration(s.AverageDuration))
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCOUNT")
+	for _, status := range sortedByCount(s.Statuses) {
+		fmt.Fprintf(tw, "%s\t%d\n", status, s.Statuses[status])
+	}
+	tw.Flush()
+
+	if len(s.Tools) == 0 {
+		return
+	}
+	if topTools > 0 && len(s.Tools) > topTools {
+		s.Tools = s.Tools[:topTools]
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tUSES")
+	for _, tool := range s.Tools {
+		fmt.Fprintf(tw, "%s\t%d\n", tool.Name, tool.Count)
+	}
+	tw.Flush()
+}
+
+// sortedByCount returns the keys of counts, highest count first
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// formatDuration rounds d to the second, or "-" if no duration was recorded
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/history"
+)
+
+func TestPrintSummary(t *testing.T) {
+	s := history.Summarize([]history.HistoryEntry{
+		{SessionID: "s1", Status: "task_complete", Duration: 95 * time.Second, Tools: map[string]int{"Edit": 4, "Read": 2, "Bash": 1}},
+		{SessionID: "s2", Status: "error", Tools: map[string]int{"Bash": 1}},
+		{SessionID: "s2", Status: "task_complete"},
+	})
+
+	var buf bytes.Buffer
+	printSummary(&buf, s, 2)
+	out := buf.String()
+
+	for _, want := range []string{"Notifications     3", "Sessions          2", "Success rate      67%", "Average duration  1m35s", "task_complete  2", "Edit  4"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// Only the top 2 tools are listed
+	if strings.Contains(out, "Read") {
+		t.Errorf("expected tools to be limited to 2:\n%s", out)
+	}
+}
+
+func TestPrintSummaryEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	printSummary(&buf, history.Summarize(nil), 5)
+	if !strings.Contains(buf.String(), "No notification history yet") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	if got := formatDuration(0); got != "-" {
+		t.Errorf("formatDuration(0) = %q, want -", got)
+	}
+	if got := formatDuration(61500 * time.Millisecond); got != "1m2s" {
+		t.Errorf("formatDuration(61.5s) = %q, want 1m2s", got)
+	}
+}
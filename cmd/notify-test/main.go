@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+func main() {
+	port := flag.Int("port", 8080, "Port to listen on (localhost only)")
+	pluginRoot := flag.String("plugin-root", ".", "Plugin root directory containing config/config.json (or config.yaml)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: notify-test [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Starts a local HTTP server for sending test webhooks with your config.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  go run ./cmd/notify-test --port 8080\n")
+		fmt.Fprintf(os.Stderr, "  curl -X POST localhost:8080/ -d '{\"status\":\"task_complete\",\"message\":\"test\",\"session_id\":\"test-123\"}'\n")
+		fmt.Fprintf(os.Stderr, "  open http://localhost:8080/test-form\n")
+	}
+	flag.Parse()
+
+	cfg, err := config.LoadFromPluginRoot(*pluginRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if !cfg.IsWebhookEnabled() {
+		fmt.Fprintf(os.Stderr, "Warning: webhooks are disabled in %s, test sends will be skipped\n",
+			config.FindConfigFile(*pluginRoot))
+	}
+
+	// Log to the console so send results are visible while debugging
+	if _, err := logging.InitLogger(*pluginRoot); err == nil {
+		logging.EnableConsoleOutput()
+		defer logging.Close()
+	}
+
+	sender := webhook.New(cfg)
+	defer func() { _ = sender.Shutdown(5 * time.Second) }()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", *port)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           webhook.NewTestHandler(sender),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	fmt.Printf("Webhook test server listening on http://%s (form: http://%s/test-form)\n", addr, addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
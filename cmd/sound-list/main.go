@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+// soundInfo describes one playable sound file
+type soundInfo struct {
+	Name            string  `json:"name"`
+	Path            string  `json:"path"`
+	Source          string  `json:"source"` // "plugin" or "system"
+	Format          string  `json:"format"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// soundDir is a directory scanned for sounds
+type soundDir struct {
+	Source string
+	Path   string
+}
+
+func main() {
+	pluginRoot := flag.String("plugin-root", defaultPluginRoot(), "Plugin root directory containing sounds/")
+	jsonOutput := flag.Bool("json", false, "Print a JSON array instead of a table")
+	play := flag.Bool("play", false, "Play each sound in order after listing")
+	volume := flag.Float64("volume", 1.0, "Volume level for --play (0.0 to 1.0)")
+	listDevices := flag.Bool("list-devices", false, "List the audio outputs desktop.soundDevice can select (Linux) instead of sounds")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: sound-list [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Lists the plugin's bundled sounds and the system sounds directory.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  sound-list\n")
+		fmt.Fprintf(os.Stderr, "  sound-list --json\n")
+		fmt.Fprintf(os.Stderr, "  sound-list --play --volume 0.3\n")
+		fmt.Fprintf(os.Stderr, "  sound-list --list-devices\n")
+	}
+	flag.Parse()
+
+	if *listDevices {
+		devices, err := notifier.ListSoundDevices()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *jsonOutput {
+			err = printDevicesJSON(os.Stdout, devices)
+		} else {
+			printDevices(os.Stdout, devices)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *volume < 0.0 || *volume > 1.0 {
+		fmt.Fprintf(os.Stderr, "Error: Volume must be between 0.0 and 1.0 (got %.2f)\n", *volume)
+		os.Exit(1)
+	}
+
+	var sounds []soundInfo
+	for _, dir := range soundDirs(*pluginRoot, runtime.GOOS) {
+		found, err := scanSounds(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		sounds = append(sounds, found...)
+	}
+
+	if *jsonOutput {
+		if err := printJSON(os.Stdout, sounds); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		printTable(os.Stdout, sounds)
+	}
+
+	if *play {
+		playAll(sounds, *volume)
+	}
+}
+
+// defaultPluginRoot returns CLAUDE_PLUGIN_ROOT or the current directory
+func defaultPluginRoot() string {
+	if root := os.Getenv("CLAUDE_PLUGIN_ROOT"); root != "" {
+		return root
+	}
+	return "."
+}
+
+// soundDirs returns the directories to scan: the plugin's sounds and the platform's system sounds
+func soundDirs(pluginRoot, goos string) []soundDir {
+	dirs := []soundDir{{Source: "plugin", Path: filepath.Join(pluginRoot, "sounds")}}
+
+	switch goos {
+	case "darwin":
+		dirs = append(dirs, soundDir{Source: "system", Path: "/System/Library/Sounds"})
+	case "linux":
+		dirs = append(dirs, soundDir{Source: "system", Path: "/usr/share/sounds"})
+	}
+
+	return dirs
+}
+
+// scanSounds recursively finds supported audio files in dir, sorted by path.
+// A missing directory is not an error (e.g. no system sounds installed).
+func scanSounds(dir soundDir) ([]soundInfo, error) {
+	if _, err := os.Stat(dir.Path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var sounds []soundInfo
+	err := filepath.WalkDir(dir.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable subdirectories rather than aborting the scan
+			if d != nil && d.IsDir() && path != dir.Path {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !notifier.IsAudioFile(path) {
+			return nil
+		}
+
+		info := soundInfo{
+			Name:   d.Name(),
+			Path:   path,
+			Source: dir.Source,
+			Format: strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+		}
+		duration, err := probeDuration(path)
+		if err != nil {
+			info.Error = err.Error()
+		} else {
+			info.DurationSeconds = duration
+		}
+		sounds = append(sounds, info)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir.Path, err)
+	}
+
+	sort.Slice(sounds, func(i, j int) bool { return sounds[i].Path < sounds[j].Path })
+	return sounds, nil
+}
+
+// probeDuration decodes the file and returns its length in seconds
+func probeDuration(path string) (float64, error) {
+	streamer, format, err := notifier.DecodeAudio(path)
+	if err != nil {
+		return 0, err
+	}
+	defer streamer.Close()
+
+	if format.SampleRate <= 0 {
+		return 0, fmt.Errorf("invalid sample rate")
+	}
+	return float64(streamer.Len()) / float64(format.SampleRate), nil
+}
+
+// printTable prints sounds as an aligned table
+func printTable(w io.Writer, sounds []soundInfo) {
+	if len(sounds) == 0 {
+		fmt.Fprintln(w, "No sounds found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tFILE\tFORMAT\tDURATION\tPATH")
+	for _, s := range sounds {
+		duration := fmt.Sprintf("%.2fs", s.DurationSeconds)
+		if s.Error != "" {
+			duration = "error"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.Source, s.Name, s.Format, duration, s.Path)
+	}
+	tw.Flush()
+}
+
+// printDevices prints audio outputs as an aligned table
+func printDevices(w io.Writer, devices []notifier.SoundDevice) {
+	if len(devices) == 0 {
+		fmt.Fprintln(w, "No audio devices found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BACKEND\tSOUND DEVICE\tDESCRIPTION")
+	for _, d := range devices {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Backend, d.Name, d.Description)
+	}
+	tw.Flush()
+}
+
+// printJSON prints sounds as a JSON array (an empty list prints [])
+func printJSON(w io.Writer, sounds []soundInfo) error {
+	if sounds == nil {
+		sounds = []soundInfo{}
+	}
+	return encodeJSON(w, sounds)
+}
+
+// printDevicesJSON prints audio outputs as a JSON array (an empty list prints [])
+func printDevicesJSON(w io.Writer, devices []notifier.SoundDevice) error {
+	if devices == nil {
+		devices = []notifier.SoundDevice{}
+	}
+	return encodeJSON(w, devices)
+}
+
+// encodeJSON prints v as indented JSON
+func encodeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+// playAll plays each decodable sound in order
+func playAll(sounds []soundInfo, volume float64) {
+	n := notifier.New(config.DefaultConfig())
+	defer n.Close()
+
+	for _, s := range sounds {
+		if s.Error != "" {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "🔊 Playing: %s\n", s.Path)
+		if err := n.PlaySound(s.Path, volume); err != nil {
+			fmt.Fprintf(os.Stderr, "Error playing sound: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/notifier"
+)
+
+func TestSoundDirs(t *testing.T) {
+	tests := []struct {
+		goos      string
+		wantDirs  int
+		wantSysFS string
+	}{
+		{"darwin", 2, "/System/Library/Sounds"},
+		{"linux", 2, "/usr/share/sounds"},
+		{"windows", 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			dirs := soundDirs("/plugin", tt.goos)
+			if len(dirs) != tt.wantDirs {
+				t.Fatalf("soundDirs() returned %d dirs, want %d", len(dirs), tt.wantDirs)
+			}
+			if dirs[0].Path != filepath.Join("/plugin", "sounds") || dirs[0].Source != "plugin" {
+				t.Errorf("first dir = %+v, want plugin sounds", dirs[0])
+			}
+			if tt.wantSysFS != "" && dirs[1].Path != tt.wantSysFS {
+				t.Errorf("system dir = %q, want %q", dirs[1].Path, tt.wantSysFS)
+			}
+		})
+	}
+}
+
+func TestScanSounds_PluginSounds(t *testing.T) {
+	sounds, err := scanSounds(soundDir{Source: "plugin", Path: filepath.Join("..", "..", "sounds")})
+	if err != nil {
+		t.Fatalf("scanSounds() error = %v", err)
+	}
+	if len(sounds) == 0 {
+		t.Skip("plugin sounds directory not found")
+	}
+
+	for _, s := range sounds {
+		if s.Format != "mp3" {
+			t.Errorf("%s: format = %q, want mp3", s.Name, s.Format)
+		}
+		if s.Error != "" {
+			t.Errorf("%s: unexpected decode error: %s", s.Name, s.Error)
+		}
+		if s.DurationSeconds <= 0 {
+			t.Errorf("%s: duration = %v, want > 0", s.Name, s.DurationSeconds)
+		}
+	}
+}
+
+func TestScanSounds_FiltersAndReportsErrors(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"readme.txt", "broken.wav", filepath.Join("nested", "broken.flac")} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("not audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sounds, err := scanSounds(soundDir{Source: "system", Path: dir})
+	if err != nil {
+		t.Fatalf("scanSounds() error = %v", err)
+	}
+	if len(sounds) != 2 {
+		t.Fatalf("scanSounds() found %d sounds, want 2 (non-audio files skipped): %+v", len(sounds), sounds)
+	}
+	for _, s := range sounds {
+		if s.Source != "system" {
+			t.Errorf("%s: source = %q, want system", s.Name, s.Source)
+		}
+		if s.Error == "" {
+			t.Errorf("%s: expected decode error for invalid audio", s.Name)
+		}
+	}
+}
+
+func TestScanSounds_ListsOpus(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "chime.opus"), []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sounds, err := scanSounds(soundDir{Source: "plugin", Path: dir})
+	if err != nil {
+		t.Fatalf("scanSounds() error = %v", err)
+	}
+	if len(sounds) != 1 || sounds[0].Format != "opus" {
+		t.Fatalf("scanSounds() = %+v, want one opus sound", sounds)
+	}
+}
+
+func TestScanSounds_MissingDirectory(t *testing.T) {
+	sounds, err := scanSounds(soundDir{Source: "system", Path: filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatalf("scanSounds() error = %v, want nil for missing directory", err)
+	}
+	if len(sounds) != 0 {
+		t.Errorf("scanSounds() found %d sounds in missing directory", len(sounds))
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	sounds := []soundInfo{
+		{Name: "question.mp3", Path: "sounds/question.mp3", Source: "plugin", Format: "mp3", DurationSeconds: 1.25},
+		{Name: "bad.wav", Path: "sounds/bad.wav", Source: "plugin", Format: "wav", Error: "failed to decode WAV"},
+	}
+
+	var buf bytes.Buffer
+	printTable(&buf, sounds)
+	output := buf.String()
+
+	for _, want := range []string{"SOURCE", "DURATION", "question.mp3", "1.25s", "bad.wav", "error"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("table should contain %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printJSON(&buf, nil); err != nil {
+		t.Fatalf("printJSON() error = %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty list should print [], got %q", buf.String())
+	}
+
+	buf.Reset()
+	sounds := []soundInfo{{Name: "question.mp3", Path: "sounds/question.mp3", Source: "plugin", Format: "mp3", DurationSeconds: 1.25}}
+	if err := printJSON(&buf, sounds); err != nil {
+		t.Fatalf("printJSON() error = %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0]["name"] != "question.mp3" || decoded[0]["durationSeconds"] != 1.25 {
+		t.Errorf("unexpected JSON: %s", buf.String())
+	}
+	if _, ok := decoded[0]["error"]; ok {
+		t.Error("error field should be omitted when empty")
+	}
+}
+
+func TestPrintDevices(t *testing.T) {
+	var buf bytes.Buffer
+	printDevices(&buf, nil)
+	if got := buf.String(); got != "No audio devices found\n" {
+		t.Errorf("unexpected output for no devices: %q", got)
+	}
+
+	buf.Reset()
+	printDevices(&buf, []notifier.SoundDevice{
+		{Name: "alsa_output.usb-Headset-00.analog-stereo", Backend: "pulseaudio", Description: "s16le 2ch 48000Hz running"},
+		{Name: "alsa:PCH", Backend: "alsa", Description: "HDA Intel PCH"},
+	})
+	output := buf.String()
+	for _, want := range []string{"SOUND DEVICE", "alsa_output.usb-Headset-00.analog-stereo", "alsa:PCH", "HDA Intel PCH"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("table should contain %q:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	if err := printDevicesJSON(&buf, nil); err != nil {
+		t.Fatalf("printDevicesJSON() error = %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty list should print [], got %q", buf.String())
+	}
+}
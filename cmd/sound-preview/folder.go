@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gopxl/beep"
+)
+
+// defaultGap is the pause between sounds in --all mode
+const defaultGap = 500 * time.Millisecond
+
+// supportedExtensions are the file extensions decodeAudio can play
+var supportedExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".flac": true, ".ogg": true, ".aiff": true, ".aif": true,
+	".opus": true, ".m4a": true, ".aac": true,
+}
+
+// listSounds returns the supported sound files in dir, sorted by name
+func listSounds(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && supportedExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// playlist plays sounds strictly one after another: a sound has finished (or been cut
+// at maxDuration) before the gap starts, and the next sound is only decoded after it
+type playlist struct {
+	gap         time.Duration
+	maxDuration time.Duration // 0 = play each sound to the end
+
+	decode func(path string) (beep.StreamSeekCloser, beep.Format, error)
+	play   func(streamer beep.Streamer, format beep.Format) error // blocks until the sound has finished
+	sleep  func(time.Duration)
+}
+
+// run plays paths in order. A sound that fails is reported and skipped; the error
+// counts the failures.
+func (p *playlist) run(paths []string) error {
+	failed := 0
+	for i, path := range paths {
+		if i > 0 && p.gap > 0 {
+			p.sleep(p.gap)
+		}
+		fmt.Printf("▶ [%d/%d] %s\n", i+1, len(paths), filepath.Base(path))
+		if err := p.playOne(path); err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ %v\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sounds failed to play", failed, len(paths))
+	}
+	return nil
+}
+
+// playOne decodes and plays a single sound
+func (p *playlist) playOne(path string) error {
+	streamer, format, err := p.decode(path)
+	if err != nil {
+		return err
+	}
+	defer streamer.Close()
+	return p.play(limitDuration(streamer, format, p.maxDuration), format)
+}
+
+// playFolder plays every supported sound in dir, one after another
+func playFolder(dir string, volume float64, gap, maxDuration time.Duration) error {
+	paths, err := listSounds(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read folder: %w", err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no supported sounds in %s", dir)
+	}
+
+	if err := initSpeaker(); err != nil {
+		return fmt.Errorf("failed to initialize speaker: %w", err)
+	}
+
+	fmt.Printf("🔊 Playing %d sounds from %s\n", len(paths), dir)
+	timeout := playbackTimeout(maxDuration)
+	p := &playlist{
+		gap:         gap,
+		maxDuration: maxDuration,
+		decode:      decodeAudio,
+		play: func(streamer beep.Streamer, format beep.Format) error {
+			return playStreamer(streamer, format, volume, timeout)
+		},
+		sleep: time.Sleep,
+	}
+	return p.run(paths)
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/gopxl/beep/speaker"
 	"github.com/gopxl/beep/vorbis"
 	"github.com/gopxl/beep/wav"
+
+	"github.com/777genius/claude-notifications/internal/notifier"
 )
 
 var (
@@ -30,15 +32,20 @@ var (
 func main() {
 	// Define flags
 	volumeFlag := flag.Float64("volume", 1.0, "Volume level (0.0 to 1.0)")
+	allFlag := flag.Bool("all", false, "Play every supported sound in the given folder, one after another")
+	gapFlag := flag.Duration("gap", defaultGap, "Pause between sounds in --all mode")
+	maxDurationFlag := flag.Duration("max-duration", 0, "Cut each sound after this long (0 = play to the end)")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: sound-preview [options] <path-to-audio-file>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: sound-preview [options] <path-to-audio-file>\n")
+		fmt.Fprintf(os.Stderr, "       sound-preview --all [options] <folder>\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nSupported formats: MP3, WAV, FLAC, OGG/Vorbis, AIFF\n\n")
+		fmt.Fprintf(os.Stderr, "\nSupported formats: MP3, WAV, FLAC, OGG/Vorbis, AIFF, Opus, M4A/AAC (Opus and M4A/AAC need ffmpeg, or afconvert on macOS)\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  sound-preview sounds/task-complete.mp3\n")
 		fmt.Fprintf(os.Stderr, "  sound-preview --volume 0.3 /System/Library/Sounds/Glass.aiff\n")
 		fmt.Fprintf(os.Stderr, "  sound-preview --volume 0.5 sounds/question.mp3\n")
+		fmt.Fprintf(os.Stderr, "  sound-preview --all --gap 1s --max-duration 3s /System/Library/Sounds\n")
 	}
 	flag.Parse()
 
@@ -48,6 +55,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *gapFlag < 0 || *maxDurationFlag < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --gap and --max-duration must not be negative\n")
+		os.Exit(1)
+	}
+
 	// Check if sound path is provided
 	if flag.NArg() < 1 {
 		flag.Usage()
@@ -56,6 +68,15 @@ func main() {
 
 	soundPath := flag.Arg(0)
 
+	if *allFlag {
+		if err := playFolder(soundPath, *volumeFlag, *gapFlag, *maxDurationFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Playback completed")
+		return
+	}
+
 	// Check if file exists
 	if _, err := os.Stat(soundPath); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: Sound file not found: %s\n", soundPath)
@@ -71,7 +92,7 @@ func main() {
 	}
 
 	// Play the sound with volume control
-	if err := playSound(soundPath, *volumeFlag); err != nil {
+	if err := playSound(soundPath, *volumeFlag, *maxDurationFlag); err != nil {
 		fmt.Fprintf(os.Stderr, "Error playing sound: %v\n", err)
 		os.Exit(1)
 	}
@@ -172,9 +193,17 @@ func decodeAudio(soundPath string) (beep.StreamSeekCloser, beep.Format, error) {
 
 		return streamer, format, nil
 
+	case ".opus", ".m4a", ".aac":
+		f.Close()
+		streamer, format, err := notifier.DecodeWithExternalTool(soundPath)
+		if err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to decode %s: %w", strings.ToUpper(ext[1:]), err)
+		}
+		return streamer, format, nil
+
 	default:
 		f.Close()
-		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s (supported: .mp3, .wav, .flac, .ogg, .aiff)", ext)
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s (supported: .mp3, .wav, .flac, .ogg, .aiff, .opus, .m4a, .aac)", ext)
 	}
 }
 
@@ -258,8 +287,9 @@ func (s *aiffStreamer) Close() error {
 	return nil
 }
 
-// playSound plays a sound file using gopxl/beep with volume control
-func playSound(soundPath string, volume float64) error {
+// playSound plays a sound file using gopxl/beep with volume control, cut after
+// maxDuration (0 = play to the end)
+func playSound(soundPath string, volume float64, maxDuration time.Duration) error {
 	// Initialize speaker once
 	if err := initSpeaker(); err != nil {
 		return fmt.Errorf("failed to initialize speaker: %w", err)
@@ -272,6 +302,11 @@ func playSound(soundPath string, volume float64) error {
 	}
 	defer streamer.Close()
 
+	return playStreamer(limitDuration(streamer, format, maxDuration), format, volume, playbackTimeout(maxDuration))
+}
+
+// playStreamer plays a decoded sound with volume control and blocks until it has finished
+func playStreamer(streamer beep.Streamer, format beep.Format, volume float64, timeout time.Duration) error {
 	// Resample if needed (convert to speaker's sample rate: 44100 Hz)
 	resampled := beep.Resample(4, format.SampleRate, beep.SampleRate(44100), streamer)
 
@@ -287,7 +322,7 @@ func playSound(soundPath string, volume float64) error {
 	}
 
 	// Create done channel to wait for playback completion
-	done := make(chan bool)
+	done := make(chan bool, 1)
 
 	// Play sound with callback when finished
 	speaker.Play(beep.Seq(gainStreamer, beep.Callback(func() {
@@ -298,11 +333,32 @@ func playSound(soundPath string, volume float64) error {
 	select {
 	case <-done:
 		return nil
-	case <-time.After(30 * time.Second):
+	case <-time.After(timeout):
+		// Stop the sound, so it can't overlap the next one in --all mode
+		speaker.Clear()
 		return fmt.Errorf("playback timed out")
 	}
 }
 
+// defaultPlaybackTimeout bounds how long a sound may play before playback is abandoned
+const defaultPlaybackTimeout = 30 * time.Second
+
+// playbackTimeout returns the playback timeout, leaving room for a maxDuration longer than the default
+func playbackTimeout(maxDuration time.Duration) time.Duration {
+	if maxDuration+5*time.Second > defaultPlaybackTimeout {
+		return maxDuration + 5*time.Second
+	}
+	return defaultPlaybackTimeout
+}
+
+// limitDuration cuts streamer after maxDuration (0 = no limit)
+func limitDuration(streamer beep.Streamer, format beep.Format, maxDuration time.Duration) beep.Streamer {
+	if maxDuration <= 0 {
+		return streamer
+	}
+	return beep.Take(format.SampleRate.N(maxDuration), streamer)
+}
+
 // volumeToGain converts linear volume (0.0-1.0) to gain value for effects.Gain
 // effects.Gain formula: output = input * (1 + Gain)
 // Examples: volume 1.0 → Gain 0.0 (100%), volume 0.3 → Gain -0.7 (30%), volume 0.5 → Gain -0.5 (50%)
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/gopxl/beep"
 )
 
 // TestDecodeAudio tests the audio decoding for various formats
@@ -39,6 +45,16 @@ func TestDecodeAudio(t *testing.T) {
 			ext:     ".aiff",
 			wantErr: false,
 		},
+		{
+			name:    "Opus format",
+			ext:     ".opus",
+			wantErr: false,
+		},
+		{
+			name:    "M4A format",
+			ext:     ".m4a",
+			wantErr: false,
+		},
 		{
 			name:        "Unsupported format",
 			ext:         ".xyz",
@@ -222,3 +238,174 @@ func TestVolumeToGain(t *testing.T) {
 		})
 	}
 }
+
+// stubStreamer is a silent sound of a fixed number of samples that logs when it is closed
+type stubStreamer struct {
+	name      string
+	remaining int
+	events    *[]string
+}
+
+func (s *stubStreamer) Stream(samples [][2]float64) (int, bool) {
+	if s.remaining <= 0 {
+		return 0, false
+	}
+	n := min(len(samples), s.remaining)
+	s.remaining -= n
+	return n, true
+}
+
+func (s *stubStreamer) Err() error     { return nil }
+func (s *stubStreamer) Len() int       { return 0 }
+func (s *stubStreamer) Position() int  { return 0 }
+func (s *stubStreamer) Seek(int) error { return nil }
+func (s *stubStreamer) Close() error   { *s.events = append(*s.events, "close "+s.name); return nil }
+
+// TestPlaylistRun tests that --all plays sounds one after another, with the gap between them
+func TestPlaylistRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		paths       []string
+		gap         time.Duration
+		maxDuration time.Duration
+		wantErr     bool
+		want        []string
+	}{
+		{
+			name:  "sequential with gap",
+			paths: []string{"a.wav", "b.wav", "c.wav"},
+			gap:   250 * time.Millisecond,
+			want: []string{
+				"decode a.wav", "play a.wav 100", "close a.wav",
+				"gap 250ms",
+				"decode b.wav", "play b.wav 100", "close b.wav",
+				"gap 250ms",
+				"decode c.wav", "play c.wav 100", "close c.wav",
+			},
+		},
+		{
+			name:  "no gap",
+			paths: []string{"a.wav", "b.wav"},
+			want: []string{
+				"decode a.wav", "play a.wav 100", "close a.wav",
+				"decode b.wav", "play b.wav 100", "close b.wav",
+			},
+		},
+		{
+			name:        "max duration cuts each sound",
+			paths:       []string{"a.wav", "b.wav"},
+			gap:         time.Second,
+			maxDuration: 40 * time.Millisecond,
+			want: []string{
+				"decode a.wav", "play a.wav 40", "close a.wav",
+				"gap 1s",
+				"decode b.wav", "play b.wav 40", "close b.wav",
+			},
+		},
+		{
+			name:        "max duration longer than the sound",
+			paths:       []string{"a.wav"},
+			maxDuration: time.Second,
+			want:        []string{"decode a.wav", "play a.wav 100", "close a.wav"},
+		},
+		{
+			name:    "failed sound is skipped",
+			paths:   []string{"a.wav", "broken.wav", "c.wav"},
+			gap:     time.Second,
+			wantErr: true,
+			want: []string{
+				"decode a.wav", "play a.wav 100", "close a.wav",
+				"gap 1s",
+				"decode broken.wav",
+				"gap 1s",
+				"decode c.wav", "play c.wav 100", "close c.wav",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			playing := ""
+			p := &playlist{
+				gap:         tt.gap,
+				maxDuration: tt.maxDuration,
+				decode: func(path string) (beep.StreamSeekCloser, beep.Format, error) {
+					events = append(events, "decode "+path)
+					if path == "broken.wav" {
+						return nil, beep.Format{}, errors.New("failed to decode WAV")
+					}
+					// 100 samples at 1000 Hz = 100ms
+					return &stubStreamer{name: path, remaining: 100, events: &events},
+						beep.Format{SampleRate: 1000, NumChannels: 2, Precision: 2}, nil
+				},
+				play: func(streamer beep.Streamer, format beep.Format) error {
+					if playing != "" {
+						t.Errorf("a sound started while %s was still playing", playing)
+					}
+					name := events[len(events)-1][len("decode "):]
+					playing = name
+					total := 0
+					buf := make([][2]float64, 16)
+					for {
+						n, ok := streamer.Stream(buf)
+						total += n
+						if !ok {
+							break
+						}
+					}
+					playing = ""
+					events = append(events, fmt.Sprintf("play %s %d", name, total))
+					return nil
+				},
+				sleep: func(d time.Duration) {
+					events = append(events, "gap "+d.String())
+				},
+			}
+
+			err := p.run(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("events =\n%v\nwant\n%v", events, tt.want)
+			}
+		})
+	}
+}
+
+// TestListSounds tests that --all picks up supported sounds only, in name order
+func TestListSounds(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.mp3", "a.WAV", "notes.txt", "c.aiff"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "nested.mp3"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := listSounds(dir)
+	if err != nil {
+		t.Fatalf("listSounds() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.WAV"), filepath.Join(dir, "b.mp3"), filepath.Join(dir, "c.aiff")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("listSounds() = %v, want %v", paths, want)
+	}
+
+	if _, err := listSounds(filepath.Join(dir, "missing")); err == nil {
+		t.Error("listSounds() expected error for a missing folder")
+	}
+}
+
+// TestPlaybackTimeout tests that a long --max-duration extends the playback timeout
+func TestPlaybackTimeout(t *testing.T) {
+	if got := playbackTimeout(0); got != defaultPlaybackTimeout {
+		t.Errorf("playbackTimeout(0) = %s, want %s", got, defaultPlaybackTimeout)
+	}
+	if got := playbackTimeout(time.Minute); got != time.Minute+5*time.Second {
+		t.Errorf("playbackTimeout(1m) = %s, want 1m5s", got)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/watcher"
+)
+
+func main() {
+	errorhandler.Init(true, false, true)
+	defer errorhandler.HandlePanic()
+
+	transcript := flag.String("transcript", "", "Transcript file to watch (~/.claude/projects/<project>/<session>.jsonl)")
+	pollInterval := flag.Duration("poll-interval", watcher.DefaultPollInterval, "How often to check the transcript for changes")
+	pluginRoot := flag.String("plugin-root", defaultPluginRoot(), "Plugin root directory containing config/config.json (or config.yaml)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: watch --transcript <file> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Sends notifications for a Claude Code transcript without hooks, by watching the file.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  watch --transcript ~/.claude/projects/-home-me-app/0b7c2f4e.jsonl\n")
+		fmt.Fprintf(os.Stderr, "  watch --transcript session.jsonl --poll-interval 500ms --plugin-root ~/.claude/plugins/claude-notifications\n")
+	}
+	flag.Parse()
+
+	if *transcript == "" {
+		fmt.Fprintf(os.Stderr, "Error: --transcript is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if _, err := logging.InitLogger(*pluginRoot); err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to initialize logger")
+		os.Exit(1)
+	}
+	defer logging.Close()
+
+	handler, err := hooks.NewHandler(*pluginRoot)
+	if err != nil {
+		errorhandler.HandleCriticalError(err, "Failed to create handler")
+		os.Exit(1)
+	}
+	handler.KeepAlive()
+	defer func() {
+		if err := handler.Close(); err != nil {
+			logging.Warn("Failed to close handler: %v", err)
+		}
+	}()
+
+	w := watcher.New(*pollInterval)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	errorhandler.SafeGo(func() {
+		<-signals
+		w.Stop()
+	})
+
+	fmt.Printf("Watching %s (Ctrl+C to stop)\n", *transcript)
+	if err := w.Watch(*transcript, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// defaultPluginRoot returns CLAUDE_PLUGIN_ROOT or the current directory
+func defaultPluginRoot() string {
+	if root := os.Getenv("CLAUDE_PLUGIN_ROOT"); root != "" {
+		return root
+	}
+	return "."
+}
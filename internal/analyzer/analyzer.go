@@ -1,7 +1,11 @@
 package analyzer
 
 import (
+	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/pkg/jsonl"
@@ -28,6 +32,30 @@ var (
 	PassiveTools  = []string{"Read", "Grep", "Glob", "WebFetch", "WebSearch", "Search", "Fetch", "Task"}
 )
 
+// TranscriptTailLines is how many trailing transcript lines are parsed for status detection
+// and summaries. Both only look at the current response, so this is generous; it keeps
+// the Stop hook fast on multi-hundred-MB transcripts from long sessions.
+const TranscriptTailLines = 5000
+
+// DefaultErrorKeywords are the failure signals looked for in Claude's final text
+// when config doesn't set notifications.errorKeywords
+var DefaultErrorKeywords = []string{"error:", "failed", "traceback"}
+
+// resolvedPhrases mark error keywords as talking about a problem that is already solved
+// ("the test that failed now passes") or that didn't happen ("0 failed")
+var resolvedPhrases = []string{"fixed", "resolved", "now pass", "no longer", "no errors", "without error"}
+
+// zeroFailuresPattern matches test summaries reporting that nothing failed ("0 failed")
+var zeroFailuresPattern = regexp.MustCompile(`(?i)\b0 (failed|failures|errors)\b`)
+
+// errorTextWindow is how many trailing characters of Claude's final text are checked for
+// error keywords, so a word mentioned in passing earlier in the response doesn't count
+const errorTextWindow = 200
+
+// DefaultWindowSize is how many recent messages of the current response are analyzed
+// when config doesn't set notifications.analysisWindow
+const DefaultWindowSize = 15
+
 // Status represents the current task status
 type Status string
 
@@ -35,48 +63,61 @@ const (
 	StatusTaskComplete        Status = "task_complete"
 	StatusReviewComplete      Status = "review_complete"
 	StatusQuestion            Status = "question"
+	StatusPermission          Status = "permission" // Claude is waiting for approval to run a tool
 	StatusPlanReady           Status = "plan_ready"
 	StatusSessionLimitReached Status = "session_limit_reached"
+	StatusLimitWarning        Status = "limit_warning"
+	StatusSubagentComplete    Status = "subagent_complete"
 	StatusAPIError            Status = "api_error"
+	StatusError               Status = "error" // Task ended in a failure (failed command or error reported by Claude)
+	StatusTest                Status = "test"  // Sent on demand to verify the notification setup, never detected
+	StatusLongRunningCommand  Status = "long_running_command"
 	StatusUnknown             Status = "unknown"
 )
 
 // AnalyzeTranscript analyzes a transcript file and determines the current status
 func AnalyzeTranscript(transcriptPath string, cfg *config.Config) (Status, error) {
-	// Parse JSONL file
-	messages, err := jsonl.ParseFile(transcriptPath)
+	// Parse the tail of the JSONL file
+	messages, err := jsonl.TailMessages(transcriptPath, TranscriptTailLines)
 	if err != nil {
 		return StatusUnknown, err
 	}
+	return AnalyzeMessages(messages, cfg), nil
+}
 
+// AnalyzeMessages determines the current status from the tail of a transcript, as read
+// by jsonl.TailMessages(path, TranscriptTailLines)
+func AnalyzeMessages(messages []jsonl.Message, cfg *config.Config) Status {
 	// PRIORITY CHECK 1: Session limit reached
 	// This takes precedence over all other status detection
 	if detectSessionLimitReached(messages) {
-		return StatusSessionLimitReached, nil
+		return StatusSessionLimitReached
 	}
 
 	// PRIORITY CHECK 2: API authentication error
 	// Check for API 401 errors requiring re-login
 	if detectAPIError(messages) {
-		return StatusAPIError, nil
+		return StatusAPIError
 	}
 
-	// Find last user message timestamp
-	// This ensures we only analyze tools from the CURRENT response,
-	// not from previous user requests (avoids "ghost" ExitPlanMode problem)
-	userTS := jsonl.GetLastUserTimestamp(messages)
+	// PRIORITY CHECK 3: Approaching session/usage limit
+	// Heads-up before the limit is actually hit
+	if detectLimitWarning(messages, cfg) {
+		return StatusLimitWarning
+	}
 
-	// Filter assistant messages AFTER last user message
-	filteredMessages := jsonl.FilterMessagesAfterTimestamp(messages, userTS)
+	// Only analyze tools from the CURRENT response, not from previous
+	// user requests (avoids "ghost" ExitPlanMode problem)
+	filteredMessages := currentResponse(messages)
 
 	if len(filteredMessages) == 0 {
-		return StatusUnknown, nil
+		return StatusUnknown
 	}
 
-	// Take last 15 messages (temporal window) from filtered set
+	// Take last N messages (temporal window) from filtered set
 	recentMessages := filteredMessages
-	if len(filteredMessages) > 15 {
-		recentMessages = filteredMessages[len(filteredMessages)-15:]
+	if window := WindowSize(cfg); len(filteredMessages) > window {
+		recentMessages = filteredMessages[len(filteredMessages)-window:]
 	}
 
 	// Extract tools with positions
@@ -90,24 +131,29 @@ func AnalyzeTranscript(transcriptPath string, cfg *config.Config) (Status, error
 
 		// 1a. Last tool is ExitPlanMode → plan just created
 		if lastTool == "ExitPlanMode" {
-			return StatusPlanReady, nil
+			return StatusPlanReady
 		}
 
 		// 1b. Last tool is AskUserQuestion → waiting for user
 		if lastTool == "AskUserQuestion" {
-			return StatusQuestion, nil
+			return StatusQuestion
+		}
+
+		// 1c. Response ended in a failure → error
+		if detectTaskError(messages, recentMessages, tools, cfg) {
+			return StatusError
 		}
 
-		// 1c. ExitPlanMode exists AND tools after it → plan executed
+		// 1d. ExitPlanMode exists AND tools after it → plan executed
 		exitPlanPos := jsonl.FindToolPosition(tools, "ExitPlanMode")
 		if exitPlanPos >= 0 {
 			toolsAfter := jsonl.CountToolsAfterPosition(tools, exitPlanPos)
 			if toolsAfter > 0 {
-				return StatusTaskComplete, nil
+				return StatusTaskComplete
 			}
 		}
 
-		// 1d. Review detection: only read-like tools + long text response
+		// 1e. Review detection: only read-like tools + long text response
 		// Read-like tools: Read, Grep, Glob (searching/analyzing code)
 		// No active tools: no Write, Edit, Bash, etc.
 		// Long text: >200 chars (indicates substantial analysis/review)
@@ -120,22 +166,91 @@ func AnalyzeTranscript(transcriptPath string, cfg *config.Config) (Status, error
 			recentText := jsonl.ExtractRecentText(recentMessages, 5)
 
 			if len(recentText) > 200 {
-				return StatusReviewComplete, nil
+				return StatusReviewComplete
 			}
 		}
 
-		// 1e. Last tool is active (Write/Edit/Bash) → work completed
+		// 1f. Last tool is active (Write/Edit/Bash) → work completed
 		if contains(ActiveTools, lastTool) {
-			return StatusTaskComplete, nil
+			return StatusTaskComplete
 		}
 
-		// 1f. Any tool usage at all → likely task completed
+		// 1g. Any tool usage at all → likely task completed
 		// (matches bash version: toolCount >= 1 → task_complete)
-		return StatusTaskComplete, nil
+		return StatusTaskComplete
+	}
+
+	// 2. No tools found → keyword fallback, unknown (skip notification) if nothing matches
+	return classifyByKeywords(recentMessages, cfg)
+}
+
+// currentResponse returns the assistant messages answering the last user message.
+// They are found by timestamp, unless timestamps are out of order: then a late-written
+// message from a previous turn could pass as current, so the parentUuid chain from the
+// last user message is followed instead.
+func currentResponse(messages []jsonl.Message) []jsonl.Message {
+	if jsonl.HasOutOfOrderTimestamps(messages) {
+		if chain := jsonl.GetMessageChainSince(messages, jsonl.GetLastUserUUID(messages)); chain != nil {
+			var response []jsonl.Message
+			for _, msg := range chain {
+				if msg.Type == "assistant" {
+					response = append(response, msg)
+				}
+			}
+			return response
+		}
 	}
 
-	// 2. No tools found → unknown (skip notification)
-	return StatusUnknown, nil
+	// Filter assistant messages AFTER last user message
+	return jsonl.FilterMessagesAfterTimestamp(messages, jsonl.GetLastUserTimestamp(messages))
+}
+
+// keywordExcludedStatuses are never chosen by keyword: they have their own detectors,
+// anchored to the messages Claude Code prints, and words like "login" or "limit" are
+// common in ordinary replies
+var keywordExcludedStatuses = map[Status]bool{
+	StatusUnknown:             true,
+	StatusAPIError:            true,
+	StatusSessionLimitReached: true,
+	StatusLimitWarning:        true,
+}
+
+// classifyByKeywords matches the configured status keywords against the recent assistant text
+// when notifications.classifyByKeywords is on. Keywords match whole words only. The status
+// whose keyword appears closest to the end of the text wins (ties go to the alphabetically
+// first status). Returns StatusUnknown if no keyword matches.
+func classifyByKeywords(recentMessages []jsonl.Message, cfg *config.Config) Status {
+	if cfg == nil || !cfg.Notifications.ClassifyByKeywords || len(cfg.Statuses) == 0 {
+		return StatusUnknown
+	}
+
+	text := strings.ToLower(strings.Join(jsonl.ExtractTextFromMessages(recentMessages), " "))
+	if text == "" {
+		return StatusUnknown
+	}
+
+	names := make([]string, 0, len(cfg.Statuses))
+	for name := range cfg.Statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestPos := StatusUnknown, -1
+	for _, name := range names {
+		if keywordExcludedStatuses[Status(name)] {
+			continue
+		}
+		for _, keyword := range cfg.Statuses[name].Keywords {
+			if keyword == "" {
+				continue
+			}
+			if pos := lastWordIndex(text, strings.ToLower(keyword)); pos > bestPos {
+				best, bestPos = Status(name), pos
+			}
+		}
+	}
+
+	return best
 }
 
 // contains checks if a slice contains a string
@@ -148,18 +263,50 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
+// LongCommandThreshold is the length above which a Bash command counts as long running
+const LongCommandThreshold = 50
+
 // GetStatusForPreToolUse determines status for PreToolUse hook
-// This is called BEFORE tool execution, so we only have the tool name
-func GetStatusForPreToolUse(toolName string) Status {
+// This is called BEFORE tool execution, so we only have the tool name and its input
+func GetStatusForPreToolUse(toolName string, toolInput map[string]interface{}) Status {
 	if toolName == "ExitPlanMode" {
 		return StatusPlanReady
 	}
 	if toolName == "AskUserQuestion" {
 		return StatusQuestion
 	}
+	if toolName == "Bash" && len(BashCommand(toolInput)) > LongCommandThreshold {
+		return StatusLongRunningCommand
+	}
 	return StatusUnknown
 }
 
+// BashCommand returns the command of a Bash tool input, or "" if there is none
+func BashCommand(toolInput map[string]interface{}) string {
+	command, _ := toolInput["command"].(string)
+	return command
+}
+
+// GetStatusForNotification determines status for the Notification hook, which fires both
+// for permission prompts and when Claude is waiting for an answer. The hook message is
+// checked first ("Claude needs your permission to use Bash"), then the transcript: a tool
+// call still waiting for its result is a permission prompt unless it is AskUserQuestion
+// or ExitPlanMode. Anything else stays a question.
+func GetStatusForNotification(message string, messages []jsonl.Message) Status {
+	if strings.Contains(strings.ToLower(message), "permission") {
+		return StatusPermission
+	}
+
+	if pending := jsonl.FindPendingToolUse(messages); pending != nil {
+		if contains(QuestionTools, pending.Name) || pending.Name == "ExitPlanMode" {
+			return StatusQuestion
+		}
+		return StatusPermission
+	}
+
+	return StatusQuestion
+}
+
 // detectSessionLimitReached checks if the last assistant messages contain "Session limit reached"
 func detectSessionLimitReached(messages []jsonl.Message) bool {
 	// Check last 3 assistant messages for the session limit text
@@ -182,6 +329,140 @@ func detectSessionLimitReached(messages []jsonl.Message) bool {
 	return false
 }
 
+// detectLimitWarning checks if the last assistant messages warn about an approaching usage limit
+// (e.g. "Approaching usage limit" or "You've used 90% of your session limit")
+func detectLimitWarning(messages []jsonl.Message, cfg *config.Config) bool {
+	recentMessages := jsonl.GetLastAssistantMessages(messages, 3)
+	if len(recentMessages) == 0 {
+		return false
+	}
+
+	keywords := LimitWarningKeywords(cfg)
+	texts := jsonl.ExtractTextFromMessages(recentMessages)
+	for _, text := range texts {
+		if IsLimitWarningText(text, keywords) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitWarningPattern matches the usage warnings Claude Code prints at the start of a line,
+// such as "Approaching usage limit · resets at 5pm", "Approaching Opus usage limit" or
+// "You've used 90% of your weekly limit"
+var limitWarningPattern = regexp.MustCompile(`(?im)^\s*(?:approaching (?:\w+ )?usage limit|you['’]ve used \d+% of your (?:\w+ )?limit)\b`)
+
+// LimitWarningKeywords returns the keywords configured for the limit_warning status
+func LimitWarningKeywords(cfg *config.Config) []string {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Statuses[string(StatusLimitWarning)].Keywords
+}
+
+// IsLimitWarningText reports whether a line of text starts with one of Claude Code's
+// approaching-limit warnings, or with one of keywords as a whole word. Only line starts
+// count, so a reply that merely mentions a usage limit isn't a warning.
+func IsLimitWarningText(text string, keywords []string) bool {
+	if limitWarningPattern.MatchString(text) {
+		return true
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		for _, keyword := range keywords {
+			keyword = strings.ToLower(keyword)
+			if keyword != "" && strings.HasPrefix(line, keyword) && isWordBoundary(line[len(keyword):], keyword, false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detectTaskError checks if the current response ended in a failure. When the last tool was
+// a Bash command with a recorded result, its is_error flag decides: a command that succeeded
+// can still print "0 failed". Otherwise Claude's final text is checked for an error report,
+// but only when active tools were used, since a read-only response that mentions failures
+// is describing them (e.g. reviewing CI logs), not failing.
+func detectTaskError(messages, recentMessages []jsonl.Message, tools []jsonl.ToolUse, cfg *config.Config) bool {
+	if failed, known := lastBashOutcome(messages, recentMessages); known {
+		return failed
+	}
+	if !jsonl.HasAnyActiveTool(tools, ActiveTools) {
+		return false
+	}
+
+	texts := jsonl.ExtractTextFromMessages(recentMessages)
+	if len(texts) == 0 {
+		return false
+	}
+	return IsErrorText(texts[len(texts)-1], ErrorKeywords(cfg))
+}
+
+// lastBashOutcome reports whether the last tool in recentMessages is a Bash command whose
+// tool_result (found in the full transcript) is marked as an error. known is false when the
+// last tool isn't Bash or its result isn't in the transcript.
+func lastBashOutcome(messages, recentMessages []jsonl.Message) (failed, known bool) {
+	var lastTool *jsonl.Content
+	for _, msg := range recentMessages {
+		for i := range msg.Message.Content {
+			if msg.Message.Content[i].Type == "tool_use" {
+				lastTool = &msg.Message.Content[i]
+			}
+		}
+	}
+	if lastTool == nil || lastTool.Name != "Bash" {
+		return false, false
+	}
+
+	result := jsonl.FindToolResult(messages, lastTool.ID)
+	if result == nil {
+		return false, false
+	}
+	return result.IsError, true
+}
+
+// WindowSize returns the configured analysis window, or DefaultWindowSize if none is set
+func WindowSize(cfg *config.Config) int {
+	if cfg != nil && cfg.Notifications.AnalysisWindow > 0 {
+		return cfg.Notifications.AnalysisWindow
+	}
+	return DefaultWindowSize
+}
+
+// ErrorKeywords returns the configured error keywords, or DefaultErrorKeywords if none are set
+func ErrorKeywords(cfg *config.Config) []string {
+	if cfg != nil && len(cfg.Notifications.ErrorKeywords) > 0 {
+		return cfg.Notifications.ErrorKeywords
+	}
+	return DefaultErrorKeywords
+}
+
+// IsErrorText reports whether the end of text reports a failure.
+// Only the last errorTextWindow characters are checked, keywords match whole words, and
+// text saying the problem was fixed (or that nothing failed) doesn't count.
+func IsErrorText(text string, keywords []string) bool {
+	if runes := []rune(text); len(runes) > errorTextWindow {
+		text = string(runes[len(runes)-errorTextWindow:])
+	}
+
+	for _, phrase := range resolvedPhrases {
+		if containsIgnoreCase(text, phrase) {
+			return false
+		}
+	}
+	if zeroFailuresPattern.MatchString(text) {
+		return false
+	}
+	for _, keyword := range keywords {
+		if containsWord(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // detectAPIError checks if the last assistant messages contain API 401 authentication error
 func detectAPIError(messages []jsonl.Message) bool {
 	// Check last 3 assistant messages for API error
@@ -212,6 +493,50 @@ func detectAPIError(messages []jsonl.Message) bool {
 	return hasAPIError && hasLoginPrompt
 }
 
+// lastWordIndex returns the position of the last occurrence of word in text that is not
+// part of a longer word, or -1. Both should have the same case. A boundary is only required
+// where word itself starts or ends with a letter or digit, so "error:" matches "error:x".
+func lastWordIndex(text, word string) int {
+	for end := len(text); ; {
+		pos := strings.LastIndex(text[:end], word)
+		if pos < 0 {
+			return -1
+		}
+		if isWordBoundary(text[:pos], word, true) && isWordBoundary(text[pos+len(word):], word, false) {
+			return pos
+		}
+		// Search again before this occurrence, allowing an overlapping earlier one
+		end = pos + len(word) - 1
+	}
+}
+
+// isWordBoundary reports whether the text next to word (before it, or after it) doesn't
+// continue the word
+func isWordBoundary(neighbor, word string, before bool) bool {
+	var edge, next rune
+	if before {
+		edge, _ = utf8.DecodeRuneInString(word)
+		next, _ = utf8.DecodeLastRuneInString(neighbor)
+	} else {
+		edge, _ = utf8.DecodeLastRuneInString(word)
+		next, _ = utf8.DecodeRuneInString(neighbor)
+	}
+	if neighbor == "" || !isWordRune(edge) {
+		return true
+	}
+	return !isWordRune(next)
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// containsWord reports whether text contains word as a whole word, ignoring case
+func containsWord(text, word string) bool {
+	return word != "" && lastWordIndex(strings.ToLower(text), strings.ToLower(word)) >= 0
+}
+
 // containsIgnoreCase checks if string contains substring (case insensitive)
 func containsIgnoreCase(s, substr string) bool {
 	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
@@ -558,19 +558,27 @@ func TestAnalyzeTranscript_EdgeCases(t *testing.T) {
 // === Unit Tests for Helper Functions ===
 
 func TestGetStatusForPreToolUse(t *testing.T) {
+	longCommand := map[string]interface{}{"command": "go test -race -coverprofile=coverage.out ./internal/... ./pkg/..."}
+
 	tests := []struct {
-		toolName string
-		expected Status
+		name      string
+		toolName  string
+		toolInput map[string]interface{}
+		expected  Status
 	}{
-		{"ExitPlanMode", StatusPlanReady},
-		{"AskUserQuestion", StatusQuestion},
-		{"Write", StatusUnknown},
-		{"", StatusUnknown},
+		{"ExitPlanMode", "ExitPlanMode", nil, StatusPlanReady},
+		{"AskUserQuestion", "AskUserQuestion", nil, StatusQuestion},
+		{"Write", "Write", nil, StatusUnknown},
+		{"empty", "", nil, StatusUnknown},
+		{"short Bash command", "Bash", map[string]interface{}{"command": "ls -la"}, StatusUnknown},
+		{"long Bash command", "Bash", longCommand, StatusLongRunningCommand},
+		{"Bash without command", "Bash", nil, StatusUnknown},
+		{"long command on other tool", "Write", longCommand, StatusUnknown},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.toolName, func(t *testing.T) {
-			status := GetStatusForPreToolUse(tt.toolName)
+		t.Run(tt.name, func(t *testing.T) {
+			status := GetStatusForPreToolUse(tt.toolName, tt.toolInput)
 			if status != tt.expected {
 				t.Errorf("got %v, want %v", status, tt.expected)
 			}
@@ -578,6 +586,45 @@ func TestGetStatusForPreToolUse(t *testing.T) {
 	}
 }
 
+func TestGetStatusForNotification(t *testing.T) {
+	pendingTool := func(name string) []jsonl.Message {
+		return []jsonl.Message{
+			buildUserMessage("Clean up"),
+			{Type: "assistant", Timestamp: "2025-01-01T12:00:01Z", Message: jsonl.MessageContent{
+				Content: []jsonl.Content{{Type: "tool_use", ID: "toolu_1", Name: name}},
+			}},
+		}
+	}
+	answeredTool := append(pendingTool("Bash"), jsonl.Message{
+		Type: "user", Timestamp: "2025-01-01T12:00:02Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "tool_result", ToolUseID: "toolu_1"}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		message  string
+		messages []jsonl.Message
+		expected Status
+	}{
+		{"permission message", "Claude needs your permission to use Bash", nil, StatusPermission},
+		{"waiting message", "Claude is waiting for your input", nil, StatusQuestion},
+		{"pending bash", "", pendingTool("Bash"), StatusPermission},
+		{"pending AskUserQuestion", "", pendingTool("AskUserQuestion"), StatusQuestion},
+		{"pending ExitPlanMode", "", pendingTool("ExitPlanMode"), StatusQuestion},
+		{"answered tool", "", answeredTool, StatusQuestion},
+		{"no transcript", "", nil, StatusQuestion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetStatusForNotification(tt.message, tt.messages); got != tt.expected {
+				t.Errorf("GetStatusForNotification() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestAnalyzeTranscript_SessionLimitReached(t *testing.T) {
 	cfg := &config.Config{}
 
@@ -739,6 +786,294 @@ func TestAnalyzeTranscript_APIError(t *testing.T) {
 	})
 }
 
+func TestAnalyzeTranscript_LimitWarning(t *testing.T) {
+	cfg := &config.Config{}
+
+	tests := []struct {
+		name     string
+		text     string
+		tools    []string
+		expected Status
+	}{
+		{"approaching_usage_limit", "Approaching usage limit · resets at 5pm", []string{"Write"}, StatusLimitWarning},
+		{"approaching_case_insensitive", "APPROACHING OPUS USAGE LIMIT", []string{}, StatusLimitWarning},
+		{"percentage_of_limit", "You've used 90% of your session limit", []string{"Read"}, StatusLimitWarning},
+		{"limit_reached_takes_priority", "Session limit reached. Approaching usage limit.", []string{}, StatusSessionLimitReached},
+		{"no_limit_text", "Task completed successfully", []string{"Write"}, StatusTaskComplete},
+		{"approaching_without_limit", "Approaching the end of the refactor", []string{"Write"}, StatusTaskComplete},
+		{"warning_on_later_line", "Done.\nApproaching usage limit · resets at 5pm", []string{"Write"}, StatusLimitWarning},
+		{"limit_mentioned_in_reply", "The client retries when approaching usage limit of the API", []string{"Write"}, StatusTaskComplete},
+		{"percentage_mentioned_in_reply", "The dashboard shows you've used 90% of your storage limit", []string{"Write"}, StatusTaskComplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := []jsonl.Message{
+				buildUserMessage("Continue working"),
+				buildAssistantWithTools(tt.tools, tt.text),
+			}
+			transcriptPath := buildTranscriptFile(t, messages)
+
+			status, err := AnalyzeTranscript(transcriptPath, cfg)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("got %v, want %v", status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsLimitWarningText(t *testing.T) {
+	keywords := []string{"Rate limit warning"}
+
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"cli_warning", "Approaching usage limit · resets at 5pm", true},
+		{"curly_apostrophe", "You’ve used 75% of your weekly limit", true},
+		{"keyword_at_line_start", "rate limit warning: 80% used", true},
+		{"keyword_mid_line", "I added a rate limit warning to the form", false},
+		{"keyword_inside_word", "Rate limit warnings are logged", false},
+		{"unrelated", "Approaching the deadline", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLimitWarningText(tt.text, keywords); got != tt.want {
+				t.Errorf("IsLimitWarningText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// buildBashWithResult creates an assistant Bash call followed by its tool_result
+func buildBashWithResult(id string, isError bool, timestamp string) []jsonl.Message {
+	return []jsonl.Message{
+		{
+			Type: "assistant",
+			Message: jsonl.MessageContent{
+				Role:    "assistant",
+				Content: []jsonl.Content{{Type: "tool_use", ID: id, Name: "Bash"}},
+			},
+			Timestamp: timestamp,
+		},
+		{
+			Type: "user",
+			Message: jsonl.MessageContent{
+				Role:    "user",
+				Content: []jsonl.Content{{Type: "tool_result", ToolUseID: id, IsError: isError}},
+			},
+			Timestamp: timestamp,
+		},
+	}
+}
+
+func TestAnalyzeTranscript_Error(t *testing.T) {
+	t.Run("failed_bash_is_last_tool", func(t *testing.T) {
+		messages := append([]jsonl.Message{buildUserMessage("Run the tests")},
+			buildBashWithResult("toolu_1", true, "2025-01-01T12:00:01Z")...)
+		status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), &config.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != StatusError {
+			t.Errorf("got %v, want %v", status, StatusError)
+		}
+	})
+
+	t.Run("successful_bash_reports_zero_failures", func(t *testing.T) {
+		messages := append([]jsonl.Message{buildUserMessage("Run the tests")},
+			buildBashWithResult("toolu_1", false, "2025-01-01T12:00:01Z")...)
+		messages = append(messages, buildAssistantWithTools(nil, "All 42 tests pass, 0 failed. The build failed earlier only because of the cache."))
+		status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), &config.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != StatusTaskComplete {
+			t.Errorf("got %v, want %v", status, StatusTaskComplete)
+		}
+	})
+
+	t.Run("failed_bash_then_successful_bash", func(t *testing.T) {
+		messages := []jsonl.Message{buildUserMessage("Run the tests")}
+		messages = append(messages, buildBashWithResult("toolu_1", true, "2025-01-01T12:00:01Z")...)
+		messages = append(messages, buildBashWithResult("toolu_2", false, "2025-01-01T12:00:02Z")...)
+		status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), &config.Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != StatusTaskComplete {
+			t.Errorf("got %v, want %v", status, StatusTaskComplete)
+		}
+	})
+
+	textTests := []struct {
+		name     string
+		text     string
+		tools    []string
+		keywords []string
+		expected Status
+	}{
+		{"final_text_reports_failure", "I ran the build but it failed with 3 errors.", []string{"Edit", "Bash"}, nil, StatusError},
+		{"traceback_at_end", "Running it again gives:\nTraceback (most recent call last):", []string{"Bash"}, nil, StatusError},
+		{"error_mentioned_early", "Error: handling was missing, so I added it. " + strings.Repeat("Updated the handlers and the docs. ", 10), []string{"Edit"}, nil, StatusTaskComplete},
+		{"error_already_fixed", "The test that failed before now passes.", []string{"Edit", "Bash"}, nil, StatusTaskComplete},
+		{"read_only_review_mentions_failure", strings.Repeat("The CI logs show the deploy step. ", 8) + "It failed on a timeout.", []string{"Read"}, nil, StatusReviewComplete},
+		{"custom_keyword", "The deploy was rejected by the server.", []string{"Bash"}, []string{"rejected"}, StatusError},
+		{"custom_keywords_replace_defaults", "The build failed.", []string{"Bash"}, []string{"rejected"}, StatusTaskComplete},
+		{"zero_failed", "Updated the handler. All 42 tests pass, 0 failed.", []string{"Edit"}, nil, StatusTaskComplete},
+		{"keyword_inside_word", "Updated the failedLogins counter.", []string{"Edit"}, nil, StatusTaskComplete},
+		{"window_counts_characters", "The build failed: " + strings.Repeat("ж", 150), []string{"Edit"}, nil, StatusError},
+	}
+
+	for _, tt := range textTests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := []jsonl.Message{
+				buildUserMessage("Do the work"),
+				buildAssistantWithTools(tt.tools, tt.text),
+			}
+			cfg := &config.Config{}
+			cfg.Notifications.ErrorKeywords = tt.keywords
+
+			status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("got %v, want %v", status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTranscript_KeywordFallback(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{ClassifyByKeywords: true},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete":   {Title: "Task Complete", Keywords: []string{"done", "finished"}},
+			"review_complete": {Title: "Review Complete", Keywords: []string{"Review"}},
+			"deployed":        {Title: "🚀 Deployed", Keywords: []string{"deployed"}},
+			"api_error":       {Title: "API Error", Keywords: []string{"login", "401"}},
+			"limit_warning":   {Title: "Approaching Limit", Keywords: []string{"usage limit"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		tools    []string
+		expected Status
+	}{
+		{"custom_status", "The new version is deployed to staging.", nil, Status("deployed")},
+		{"case_insensitive", "All DONE here.", nil, StatusTaskComplete},
+		{"latest_keyword_wins", "Deployed yesterday, and the review is finished.", nil, StatusTaskComplete},
+		{"no_keyword", "Here is how the flag works.", nil, StatusUnknown},
+		{"tools_take_precedence", "The new version is deployed.", []string{"Edit"}, StatusTaskComplete},
+		{"question_tool_takes_precedence", "Deployed. Which region next?", []string{"AskUserQuestion"}, StatusQuestion},
+		{"whole_words_only", "The old branch was abandoned.", nil, StatusUnknown},
+		{"excluded_statuses", "The login form now returns 401 until you hit the usage limit.", nil, StatusUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := []jsonl.Message{
+				buildUserMessage("Continue"),
+				buildAssistantWithTools(tt.tools, tt.text),
+			}
+
+			status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.expected {
+				t.Errorf("got %v, want %v", status, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTranscript_KeywordFallbackOptIn(t *testing.T) {
+	cfg := &config.Config{
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete", Keywords: []string{"done"}},
+		},
+	}
+	messages := []jsonl.Message{
+		buildUserMessage("Continue"),
+		buildAssistantWithTools(nil, "All done here."),
+	}
+
+	status, err := AnalyzeTranscript(buildTranscriptFile(t, messages), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != StatusUnknown {
+		t.Errorf("got %v, want %v without classifyByKeywords", status, StatusUnknown)
+	}
+}
+
+func TestLastWordIndex(t *testing.T) {
+	tests := []struct {
+		text string
+		word string
+		want int
+	}{
+		{"all done", "done", 4},
+		{"abandoned", "done", -1},
+		{"done, then abandoned", "done", 0},
+		{"done_deal", "done", -1},
+		{"error: x", "error:", 0},
+		{"xerror: x", "error:", -1},
+		{"готово и завершен", "завершен", 16},
+		{"завершено", "завершен", -1},
+		{"aaa", "aa", -1},
+		{"", "done", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+"/"+tt.word, func(t *testing.T) {
+			if got := lastWordIndex(tt.text, tt.word); got != tt.want {
+				t.Errorf("lastWordIndex(%q, %q) = %d, want %d", tt.text, tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTranscript_AnalysisWindow(t *testing.T) {
+	// ExitPlanMode followed by a long chatty stretch without tools
+	messages := []jsonl.Message{
+		buildUserMessage("Plan the refactor"),
+		buildAssistantWithTools([]string{"ExitPlanMode"}, "Here is the plan."),
+	}
+	for i := 0; i < DefaultWindowSize; i++ {
+		messages = append(messages, buildAssistantWithTools(nil, "More detail on the plan."))
+	}
+	path := buildTranscriptFile(t, messages)
+
+	status, err := AnalyzeTranscript(path, config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status == StatusPlanReady {
+		t.Errorf("plan should fall outside the default window of %d messages", DefaultWindowSize)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.AnalysisWindow = DefaultWindowSize + 5
+	status, err = AnalyzeTranscript(path, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != StatusPlanReady {
+		t.Errorf("got %v, want %v with a widened window", status, StatusPlanReady)
+	}
+}
+
 func TestContains(t *testing.T) {
 	slice := []string{"apple", "banana", "cherry"}
 
@@ -752,3 +1087,27 @@ func TestContains(t *testing.T) {
 		t.Error("expected contains not to find anything in empty slice")
 	}
 }
+
+func TestAnalyzeTranscript_OutOfOrderTimestamps(t *testing.T) {
+	// The previous turn's ExitPlanMode was written late, with a timestamp after the
+	// new user message; only the parentUuid chain shows it belongs to the old turn
+	plan := buildAssistantWithTools([]string{"ExitPlanMode"}, "Here is the plan.")
+	plan.UUID, plan.ParentUUID, plan.Timestamp = "a1", "u1", "2025-01-01T12:00:05Z"
+
+	first := buildUserMessage("Plan the refactor")
+	first.UUID = "u1"
+	second := buildUserMessage("Rename the package")
+	second.UUID, second.ParentUUID, second.Timestamp = "u2", "u1", "2025-01-01T12:00:02Z"
+	edit := buildAssistantWithTools([]string{"Edit"}, "Renamed it.")
+	edit.UUID, edit.ParentUUID, edit.Timestamp = "a2", "u2", "2025-01-01T12:00:06Z"
+
+	path := buildTranscriptFile(t, []jsonl.Message{first, second, edit, plan})
+
+	status, err := AnalyzeTranscript(path, config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != StatusTaskComplete {
+		t.Errorf("got %v, want %v (the late plan belongs to the previous turn)", status, StatusTaskComplete)
+	}
+}
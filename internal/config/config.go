@@ -1,86 +1,332 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	_ "time/tzdata" // Quiet hours timezones must resolve on systems without a zoneinfo database (Windows)
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/schedule"
 )
 
+// HookEvents are the Claude Code hook events the plugin handles
+var HookEvents = []string{"PreToolUse", "Notification", "Stop", "SubagentStop"}
+
+// MinMessageLength is the smallest accepted maxSummaryLength and maxWebhookMessageLength,
+// leaving room for a few words plus the "..." truncation marker
+const MinMessageLength = 20
+
+// DefaultMaxWebhookEndpoints is the webhook endpoint cap when maxWebhookEndpoints is unset
+const DefaultMaxWebhookEndpoints = 10
+
+// Notification orders accepted by notificationOrder
+const (
+	NotificationOrderDesktopFirst = "desktop-first"
+	NotificationOrderWebhookFirst = "webhook-first"
+	NotificationOrderParallel     = "parallel"
+)
+
+// Duration formats accepted by durationFormat
+const (
+	DurationFormatWords = "words"
+	DurationFormatClock = "clock"
+	DurationFormatISO   = "iso"
+	DurationFormatNone  = "none"
+)
+
+// CurrentSchemaVersion is the config schema this version of the plugin reads and writes.
+// Load migrates older configs up to it, one MigrateVxToVy step per version.
+const CurrentSchemaVersion = 2
+
 // Config represents the plugin configuration
 type Config struct {
-	Notifications NotificationsConfig   `json:"notifications"`
-	Statuses      map[string]StatusInfo `json:"statuses"`
+	// SchemaVersion is the config schema the file was written for (absent = 1)
+	SchemaVersion int                   `json:"schemaVersion,omitempty" yaml:"schemaVersion,omitempty"`
+	Notifications NotificationsConfig   `json:"notifications" yaml:"notifications"`
+	Statuses      map[string]StatusInfo `json:"statuses" yaml:"statuses"`
+	Metrics       MetricsConfig         `json:"metrics" yaml:"metrics"`
+
+	// unexpanded holds the values Load read before expanding environment variables in them,
+	// keyed like envFields, so Dump can write them back unexpanded
+	unexpanded map[string]string
+}
+
+// MetricsConfig represents metrics export settings
+type MetricsConfig struct {
+	PrometheusAddr string `json:"prometheusAddr" yaml:"prometheusAddr"` // e.g. "127.0.0.1:9464", empty = disabled (socket daemon mode only)
 }
 
 // NotificationsConfig represents notification settings
 type NotificationsConfig struct {
-	Desktop                                     DesktopConfig `json:"desktop"`
-	Webhook                                     WebhookConfig `json:"webhook"`
-	SuppressQuestionAfterTaskCompleteSeconds    int           `json:"suppressQuestionAfterTaskCompleteSeconds"`
-	SuppressQuestionAfterAnyNotificationSeconds int           `json:"suppressQuestionAfterAnyNotificationSeconds"`
+	Desktop                                     DesktopConfig `json:"desktop" yaml:"desktop"`
+	Webhook                                     WebhookList   `json:"webhook" yaml:"webhook"`
+	SuppressQuestionAfterTaskCompleteSeconds    int           `json:"suppressQuestionAfterTaskCompleteSeconds" yaml:"suppressQuestionAfterTaskCompleteSeconds"`
+	SuppressQuestionAfterAnyNotificationSeconds int           `json:"suppressQuestionAfterAnyNotificationSeconds" yaml:"suppressQuestionAfterAnyNotificationSeconds"`
+	ThrottleWindowSeconds                       int           `json:"throttleWindowSeconds" yaml:"throttleWindowSeconds"`       // Merge notifications within this window per session (0 = disabled)
+	NotifyOnUnknown                             bool          `json:"notifyOnUnknown" yaml:"notifyOnUnknown"`                   // Notify when a Stop event can't be classified instead of skipping it
+	IncludeToolTimeline                         bool          `json:"includeToolTimeline" yaml:"includeToolTimeline"`           // Append the tool sequence (e.g. "Read→Edit→Bash") to task summaries
+	ErrorKeywords                               []string      `json:"errorKeywords,omitempty" yaml:"errorKeywords,omitempty"`   // Failure signals in Claude's final text (empty = "error:", "failed", "traceback")
+	AnalysisWindow                              int           `json:"analysisWindow,omitempty" yaml:"analysisWindow,omitempty"` // Recent messages the status analyzer inspects (0 = 15)
+	// StartupGraceSeconds skips notifications this soon after a session's first hook event,
+	// since some hooks fire spuriously right after Claude starts (0 = disabled)
+	StartupGraceSeconds int `json:"startupGraceSeconds,omitempty" yaml:"startupGraceSeconds,omitempty"`
+	// LongTaskThresholdSeconds prefixes task_complete summaries with "⏱ Long task:" when
+	// Claude's response took longer than this (0 = disabled)
+	LongTaskThresholdSeconds int `json:"longTaskThresholdSeconds,omitempty" yaml:"longTaskThresholdSeconds,omitempty"`
+	// SuppressStopAfterNotificationSeconds skips the Stop notification when a Notification hook
+	// already notified for the session this many seconds ago, so one event doesn't notify twice
+	// (0 = 5, -1 = disabled)
+	SuppressStopAfterNotificationSeconds int `json:"suppressStopAfterNotificationSeconds" yaml:"suppressStopAfterNotificationSeconds"`
+	// SuppressConsecutiveIdenticalSeconds drops a notification with the same status and message
+	// as the session's previous one within this many seconds (0 = disabled)
+	SuppressConsecutiveIdenticalSeconds int `json:"suppressConsecutiveIdenticalSeconds,omitempty" yaml:"suppressConsecutiveIdenticalSeconds,omitempty"`
+	// DedupWindowSeconds is how long after one event the same hook event of the session is
+	// treated as a duplicate (0 = 2)
+	DedupWindowSeconds int `json:"dedupWindowSeconds,omitempty" yaml:"dedupWindowSeconds,omitempty"`
+	// CleanupFailureThreshold logs a single warning once this many lock cleanups in a row
+	// have failed, e.g. because the temp dir is not writable (0 = 3)
+	CleanupFailureThreshold int `json:"cleanupFailureThreshold,omitempty" yaml:"cleanupFailureThreshold,omitempty"`
+	// NotifyCleanupFailures also shows a desktop notice when that warning fires
+	NotifyCleanupFailures bool `json:"notifyCleanupFailures,omitempty" yaml:"notifyCleanupFailures,omitempty"`
+	// ClassifyByKeywords picks the status of a response without tools from the statuses'
+	// keywords (whole words), instead of skipping it. api_error, session_limit_reached and
+	// limit_warning are never chosen this way
+	ClassifyByKeywords bool `json:"classifyByKeywords,omitempty" yaml:"classifyByKeywords,omitempty"`
+	// NotifyOnLongCommands notifies when Claude starts a Bash command longer than 50 characters.
+	// Off by default; the PreToolUse hook must also match Bash (see README)
+	NotifyOnLongCommands bool `json:"notifyOnLongCommands,omitempty" yaml:"notifyOnLongCommands,omitempty"`
+	// NotifyOnAnalysisError sends a generic task_complete notification when the transcript
+	// can't be analyzed (e.g. it is corrupt), instead of skipping the Stop event
+	NotifyOnAnalysisError bool `json:"notifyOnAnalysisError,omitempty" yaml:"notifyOnAnalysisError,omitempty"`
+	// SummaryShowFiles lists the changed file names in task summaries ("Edited auth.go, config.go")
+	// instead of counts, when there are only a few files
+	SummaryShowFiles bool `json:"summaryShowFiles,omitempty" yaml:"summaryShowFiles,omitempty"`
+	// NotificationOrder controls how desktop and webhook notifications are sent:
+	// "desktop-first" (default), "webhook-first" or "parallel"
+	NotificationOrder string `json:"notificationOrder,omitempty" yaml:"notificationOrder,omitempty"`
+	// Locale selects the language of generated summary phrases ("en" default, "de", "ru");
+	// unknown locales fall back to English
+	Locale string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	// DurationFormat controls how the response time is shown in task summaries:
+	// "words" ("Took 1m 30s", default), "clock" ("1:30"), "iso" ("PT1M30S") or "none"
+	DurationFormat string `json:"durationFormat,omitempty" yaml:"durationFormat,omitempty"`
+	// MaxWebhookEndpoints caps how many entries the webhook list may have, so a
+	// misconfigured list fails validation instead of fanning out to all of them (0 = 10)
+	MaxWebhookEndpoints int `json:"maxWebhookEndpoints,omitempty" yaml:"maxWebhookEndpoints,omitempty"`
+	// IgnoredHookEvents lists hook events to skip entirely, e.g. ["SubagentStop", "Notification"]
+	IgnoredHookEvents []string `json:"ignoredHookEvents,omitempty" yaml:"ignoredHookEvents,omitempty"`
+	// SummaryWindows overrides how many recent assistant messages each summary looks back over
+	SummaryWindows *SummaryWindowsConfig `json:"summaryWindows,omitempty" yaml:"summaryWindows,omitempty"`
+	// WorkspaceFilter limits hook notifications to project directories matching one of these
+	// rules, checked in order (empty = every directory notifies)
+	WorkspaceFilter []WorkspaceRule `json:"workspaceFilter,omitempty" yaml:"workspaceFilter,omitempty"`
+	// History records delivered notifications for history-stats (nil = disabled)
+	History *HistoryConfig `json:"history,omitempty" yaml:"history,omitempty"`
+}
+
+// DefaultHistoryMaxSizeKB is the history file size at which it is rotated when maxSizeKB is unset
+const DefaultHistoryMaxSizeKB = 1024
+
+// HistoryConfig controls the notification history file
+type HistoryConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// MaxSizeKB rotates the file to <file>.1 once it reaches this size, replacing the previous
+	// rotation, so at most about twice this is kept (0 = 1024)
+	MaxSizeKB int `json:"maxSizeKB,omitempty" yaml:"maxSizeKB,omitempty"`
+}
+
+// SummaryWindowsConfig sets the number of recent assistant messages each summary generator
+// reads (0 = the built-in default for that summary)
+type SummaryWindowsConfig struct {
+	Question int `json:"question,omitempty" yaml:"question,omitempty"` // default 8
+	Review   int `json:"review,omitempty" yaml:"review,omitempty"`     // default 5
+	Task     int `json:"task,omitempty" yaml:"task,omitempty"`         // default 5
 }
 
 // DesktopConfig represents desktop notification settings
 type DesktopConfig struct {
-	Enabled bool    `json:"enabled"`
-	Sound   bool    `json:"sound"`
-	Volume  float64 `json:"volume"` // Volume level 0.0-1.0, default 1.0 (full volume)
-	AppIcon string  `json:"appIcon"`
+	Enabled bool    `json:"enabled" yaml:"enabled"`
+	Sound   bool    `json:"sound" yaml:"sound"`
+	Volume  float64 `json:"volume" yaml:"volume"` // Volume level 0.0-1.0, default 1.0 (full volume)
+	AppIcon string  `json:"appIcon" yaml:"appIcon"`
+	// TitleInBody repeats the title in the notification body for platforms that only show the body:
+	// "" (default) keeps them separate, "prefix" puts the title before the message, "suffix" after it
+	TitleInBody string `json:"titleInBody,omitempty" yaml:"titleInBody,omitempty"`
+	// SoundCooldownMs skips a sound if another one played less than this many ms ago (0 = disabled)
+	SoundCooldownMs int `json:"soundCooldownMs,omitempty" yaml:"soundCooldownMs,omitempty"`
+	// FadeOutMs fades out sounds still playing when the process exits (a hook, watch, daemon) or playback times out, over this many ms (0 = play to the end)
+	FadeOutMs int `json:"fadeOutMs,omitempty" yaml:"fadeOutMs,omitempty"`
+	// QuietHours silences desktop sounds (and optionally notifications) during a daily window
+	QuietHours *QuietHoursConfig `json:"quietHours,omitempty" yaml:"quietHours,omitempty"`
+	// TTS speaks the notification message with the platform's text-to-speech after the sound
+	TTS      bool   `json:"tts,omitempty" yaml:"tts,omitempty"`
+	TTSVoice string `json:"ttsVoice,omitempty" yaml:"ttsVoice,omitempty"` // engine-specific voice name; empty = system default
+	TTSRate  int    `json:"ttsRate,omitempty" yaml:"ttsRate,omitempty"`   // words per minute; 0 = system default
+	// MaxSummaryLength caps the generated notification message in characters (0 = 150)
+	MaxSummaryLength int `json:"maxSummaryLength,omitempty" yaml:"maxSummaryLength,omitempty"`
+	// AttentionSound is a short chime played right before the sound of statuses that wait for
+	// the user (question, permission, plan_ready); empty = none
+	AttentionSound string `json:"attentionSound,omitempty" yaml:"attentionSound,omitempty"`
+	// ForceEnabled shows desktop notifications and plays sounds even in SSH sessions and
+	// headless environments (CI, no display or audio device), which are skipped by default
+	// because there is nothing to present them on (webhooks are sent either way)
+	ForceEnabled bool `json:"forceEnabled,omitempty" yaml:"forceEnabled,omitempty"`
+	// ClickToFocus makes clicking a notification focus the terminal pane Claude runs in
+	// (tmux, screen, kitty, WezTerm, iTerm2); needs terminal-notifier on macOS or notify-send on Linux
+	ClickToFocus bool `json:"clickToFocus,omitempty" yaml:"clickToFocus,omitempty"`
+	// BlockingSound makes SendDesktop wait for the sound (and speech) to finish instead of
+	// playing it in the background, so a short-lived process can't exit before it is heard
+	BlockingSound bool `json:"blockingSound,omitempty" yaml:"blockingSound,omitempty"`
+	// SoundDevice routes sounds to a PulseAudio/PipeWire sink, or an ALSA card as "alsa:<card>"
+	// (Linux only; see sound-list --list-devices); empty = the system default output
+	SoundDevice string `json:"soundDevice,omitempty" yaml:"soundDevice,omitempty"`
 }
 
-// WebhookConfig represents webhook settings
-type WebhookConfig struct {
-	Enabled        bool                 `json:"enabled"`
-	Preset         string               `json:"preset"`
-	URL            string               `json:"url"`
-	ChatID         string               `json:"chat_id"`
-	Format         string               `json:"format"`
-	Headers        map[string]string    `json:"headers"`
-	Retry          RetryConfig          `json:"retry"`
-	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker"`
-	RateLimit      RateLimitConfig      `json:"rateLimit"`
+// QuietHoursConfig represents a daily do-not-disturb window for desktop notifications.
+// Windows may cross midnight (e.g. 22:00-08:00); webhooks are not affected.
+type QuietHoursConfig struct {
+	Start             string   `json:"start" yaml:"start"`                                             // "HH:MM", e.g. "22:00"
+	End               string   `json:"end" yaml:"end"`                                                 // "HH:MM", e.g. "08:00"
+	Weekdays          []string `json:"weekdays,omitempty" yaml:"weekdays,omitempty"`                   // days the window starts on, e.g. ["mon", "fri"]; empty = every day
+	Timezone          string   `json:"timezone,omitempty" yaml:"timezone,omitempty"`                   // IANA name, e.g. "Europe/Berlin"; empty = local time
+	MuteNotifications bool     `json:"muteNotifications,omitempty" yaml:"muteNotifications,omitempty"` // skip the whole desktop notification, not just the sound
+}
+
+// SingleWebhookConfig represents the settings of one webhook endpoint
+type SingleWebhookConfig struct {
+	Enabled        bool                 `json:"enabled" yaml:"enabled"`
+	Preset         string               `json:"preset" yaml:"preset"`
+	URL            string               `json:"url" yaml:"url"`
+	ChatID         string               `json:"chat_id" yaml:"chat_id"`
+	Format         string               `json:"format" yaml:"format"`
+	Headers        map[string]string    `json:"headers" yaml:"headers"`
+	Retry          RetryConfig          `json:"retry" yaml:"retry"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuitBreaker" yaml:"circuitBreaker"`
+	RateLimit      RateLimitConfig      `json:"rateLimit" yaml:"rateLimit"`
+	OfflineQueue   OfflineQueueConfig   `json:"offlineQueue" yaml:"offlineQueue"`
+
+	// IncludeHost adds the machine's hostname and OS to custom JSON payloads and Slack/Discord footers
+	IncludeHost bool `json:"includeHost,omitempty" yaml:"includeHost,omitempty"`
+
+	// EscalateLongTasks marks long-task notifications (see longTaskThresholdSeconds) as high severity:
+	// orange in Slack/Discord and "severity": "high" in custom JSON payloads
+	EscalateLongTasks bool `json:"escalateLongTasks,omitempty" yaml:"escalateLongTasks,omitempty"`
+
+	// CustomPayloadFields adds static fields to custom JSON payloads and footer fields to
+	// Slack/Discord/Telegram messages, e.g. {"environment": "${DEPLOY_ENV}"}
+	CustomPayloadFields map[string]string `json:"customPayloadFields,omitempty" yaml:"customPayloadFields,omitempty"`
+	// CustomHeaderFields adds HTTP headers, applied after (and overriding) headers
+	CustomHeaderFields map[string]string `json:"customHeaderFields,omitempty" yaml:"customHeaderFields,omitempty"`
+	// PriorityHeader names an HTTP header (e.g. "X-Priority") that custom preset requests carry
+	// the status's priority in: high, normal or low
+	PriorityHeader string `json:"priorityHeader,omitempty" yaml:"priorityHeader,omitempty"`
+	// Priorities overrides the priority of individual statuses, e.g. {"task_complete": "low"}
+	Priorities map[string]string `json:"priorities,omitempty" yaml:"priorities,omitempty"`
+	// IncludeCorrelationID sends a UUID generated per notification in the X-Correlation-ID
+	// header and, in custom JSON payloads, the correlation_id field; it is the same for every
+	// endpoint, retry and offline-queue replay of that notification
+	IncludeCorrelationID bool `json:"includeCorrelationID,omitempty" yaml:"includeCorrelationID,omitempty"`
+	// SigningSecret signs every HTTP request body with HMAC-SHA256; the signature is sent as
+	// "sha256=<hex>" in SignatureHeader (e.g. "X-Hub-Signature-256"). Both are set or neither.
+	SigningSecret   string `json:"signingSecret,omitempty" yaml:"signingSecret,omitempty"`
+	SignatureHeader string `json:"signatureHeader,omitempty" yaml:"signatureHeader,omitempty"`
+
+	// TelegramMessageField is the Telegram payload field that carries the message: "text" (default),
+	// or "caption" for methods that send an attachment, such as sendPhoto
+	TelegramMessageField string `json:"telegramMessageField,omitempty" yaml:"telegramMessageField,omitempty"`
+
+	// MaxWebhookMessageLength caps the message text sent to this endpoint in characters (0 = 500)
+	MaxWebhookMessageLength int `json:"maxWebhookMessageLength,omitempty" yaml:"maxWebhookMessageLength,omitempty"`
+
+	// iMessage preset (macOS only): sent by running AppleScript with osascript instead of HTTP
+	RecipientPhone      string `json:"recipientPhone,omitempty" yaml:"recipientPhone,omitempty"`           // phone number or Apple ID email
+	AppleScriptTemplate string `json:"appleScriptTemplate,omitempty" yaml:"appleScriptTemplate,omitempty"` // text/template; empty = send "Title: message" via Messages
 }
 
 // RetryConfig represents retry settings
 type RetryConfig struct {
-	Enabled        bool   `json:"enabled"`
-	MaxAttempts    int    `json:"maxAttempts"`
-	InitialBackoff string `json:"initialBackoff"` // e.g. "1s"
-	MaxBackoff     string `json:"maxBackoff"`     // e.g. "10s"
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	MaxAttempts    int    `json:"maxAttempts" yaml:"maxAttempts"`
+	InitialBackoff string `json:"initialBackoff" yaml:"initialBackoff"` // e.g. "1s"
+	MaxBackoff     string `json:"maxBackoff" yaml:"maxBackoff"`         // e.g. "10s"
 }
 
 // CircuitBreakerConfig represents circuit breaker settings
 type CircuitBreakerConfig struct {
-	Enabled          bool   `json:"enabled"`
-	FailureThreshold int    `json:"failureThreshold"` // failures before opening
-	Timeout          string `json:"timeout"`          // time to wait in open state, e.g. "30s"
-	SuccessThreshold int    `json:"successThreshold"` // successes needed in half-open
+	Enabled          bool   `json:"enabled" yaml:"enabled"`
+	FailureThreshold int    `json:"failureThreshold" yaml:"failureThreshold"` // failures before opening
+	Timeout          string `json:"timeout" yaml:"timeout"`                   // time to wait in open state, e.g. "30s"
+	SuccessThreshold int    `json:"successThreshold" yaml:"successThreshold"` // successes needed in half-open
 }
 
 // RateLimitConfig represents rate limiting settings
 type RateLimitConfig struct {
-	Enabled           bool `json:"enabled"`
-	RequestsPerMinute int  `json:"requestsPerMinute"`
+	Enabled           bool `json:"enabled" yaml:"enabled"`
+	RequestsPerMinute int  `json:"requestsPerMinute" yaml:"requestsPerMinute"`
+}
+
+// OfflineQueueConfig represents settings for persisting failed webhooks for later replay
+type OfflineQueueConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	MaxSize int    `json:"maxSize" yaml:"maxSize"` // max queued entries, oldest are dropped first
+	TTL     string `json:"ttl" yaml:"ttl"`         // drop entries older than this, e.g. "24h"
 }
 
 // StatusInfo represents configuration for a specific status
 type StatusInfo struct {
-	Title string `json:"title"`
-	Sound string `json:"sound"`
+	Title           string   `json:"title" yaml:"title"`
+	Sound           string   `json:"sound" yaml:"sound"`
+	Volume          *float64 `json:"volume,omitempty" yaml:"volume,omitempty"`     // Per-status volume 0.0-1.0, nil falls back to desktop volume
+	CooldownSeconds int      `json:"cooldownSeconds" yaml:"cooldownSeconds"`       // Min seconds between notifications of this status per session (0 = disabled)
+	AutoFocus       bool     `json:"autoFocus" yaml:"autoFocus"`                   // Raise the terminal window when this status is notified
+	Keywords        []string `json:"keywords,omitempty" yaml:"keywords,omitempty"` // Classify responses without tools as this status when their text contains a keyword (needs classifyByKeywords); for limit_warning, lines starting with a keyword are usage warnings
+	// DefaultFallbackMessage replaces the built-in message used when no summary can be generated
+	DefaultFallbackMessage string `json:"defaultFallbackMessage,omitempty" yaml:"defaultFallbackMessage,omitempty"`
+	// WebhookPreset formats this status's webhooks with another preset than the endpoint's own,
+	// e.g. a Discord embed for questions on a Slack-formatted URL (iMessage endpoints are not affected)
+	WebhookPreset string `json:"webhookPreset,omitempty" yaml:"webhookPreset,omitempty"`
 }
 
-// DefaultConfig returns a config with sensible defaults
-func DefaultConfig() *Config {
-	// Get plugin root from environment, fallback to current directory
+// defaultPluginRoot returns $CLAUDE_PLUGIN_ROOT, falling back to the current directory
+func defaultPluginRoot() string {
 	pluginRoot := platform.ExpandEnv("${CLAUDE_PLUGIN_ROOT}")
 	if pluginRoot == "" || pluginRoot == "${CLAUDE_PLUGIN_ROOT}" {
-		pluginRoot = "."
+		return "."
+	}
+	return pluginRoot
+}
+
+// DefaultUnknownStatus returns the status used for unclassified Stop events.
+// It is only added to Statuses when NotifyOnUnknown is enabled.
+func DefaultUnknownStatus() StatusInfo {
+	return StatusInfo{
+		Title: "⚪ Claude Code finished (unclassified)",
+		Sound: filepath.Join(defaultPluginRoot(), "sounds", "task-complete.mp3"), // reuse task complete sound
 	}
+}
+
+// DefaultLongRunningCommandStatus returns the status used for long Bash commands.
+// It is only added to Statuses when NotifyOnLongCommands is enabled.
+func DefaultLongRunningCommandStatus() StatusInfo {
+	return StatusInfo{
+		Title: "⏳ Running Command",
+		Sound: filepath.Join(defaultPluginRoot(), "sounds", "task-complete.mp3"), // reuse task complete sound
+	}
+}
+
+// DefaultConfig returns a config with sensible defaults
+func DefaultConfig() *Config {
+	pluginRoot := defaultPluginRoot()
 
 	return &Config{
+		SchemaVersion: CurrentSchemaVersion,
 		Notifications: NotificationsConfig{
 			Desktop: DesktopConfig{
 				Enabled: true,
@@ -88,7 +334,7 @@ func DefaultConfig() *Config {
 				Volume:  1.0, // Full volume by default
 				AppIcon: filepath.Join(pluginRoot, "claude_icon.png"),
 			},
-			Webhook: WebhookConfig{
+			Webhook: WebhookList{{
 				Enabled: false,
 				Preset:  "custom",
 				URL:     "",
@@ -111,15 +357,30 @@ func DefaultConfig() *Config {
 					Enabled:           true,
 					RequestsPerMinute: 10,
 				},
-			},
+				OfflineQueue: OfflineQueueConfig{
+					Enabled: true,
+					MaxSize: 100,
+					TTL:     "24h",
+				},
+			}},
 			SuppressQuestionAfterTaskCompleteSeconds:    12,
 			SuppressQuestionAfterAnyNotificationSeconds: 12,
+			SuppressStopAfterNotificationSeconds:        5,
+			DedupWindowSeconds:                          2,
 		},
 		Statuses: map[string]StatusInfo{
 			"task_complete": {
 				Title: "✅ Task Completed",
 				Sound: filepath.Join(pluginRoot, "sounds", "task-complete.mp3"),
 			},
+			"subagent_complete": {
+				Title: "🤖 Subagent Completed",
+				Sound: filepath.Join(pluginRoot, "sounds", "task-complete.mp3"), // reuse task complete sound
+			},
+			"test": {
+				Title: "🧪 Test Notification",
+				Sound: filepath.Join(pluginRoot, "sounds", "task-complete.mp3"),
+			},
 			"review_complete": {
 				Title: "🔍 Review Completed",
 				Sound: filepath.Join(pluginRoot, "sounds", "review-complete.mp3"),
@@ -128,6 +389,10 @@ func DefaultConfig() *Config {
 				Title: "❓ Claude Has Questions",
 				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"),
 			},
+			"permission": {
+				Title: "🔐 Permission Required",
+				Sound: filepath.Join(pluginRoot, "sounds", "plan-ready.mp3"), // differs from question so prompts are told apart
+			},
 			"plan_ready": {
 				Title: "📋 Plan Ready for Review",
 				Sound: filepath.Join(pluginRoot, "sounds", "plan-ready.mp3"),
@@ -136,6 +401,14 @@ func DefaultConfig() *Config {
 				Title: "⏱️ Session Limit Reached",
 				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"), // reuse question sound
 			},
+			"limit_warning": {
+				Title: "⚠️ Approaching Usage Limit",
+				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"), // reuse question sound
+			},
+			"error": {
+				Title: "❌ Task Failed",
+				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"), // reuse question sound so failures stand out
+			},
 			"api_error": {
 				Title: "🔴 API Error: 401",
 				Sound: filepath.Join(pluginRoot, "sounds", "question.mp3"), // reuse question sound
@@ -158,20 +431,37 @@ func Load(path string) (*Config, error) {
 	}
 
 	config := DefaultConfig()
-	if err := json.Unmarshal(data, config); err != nil {
+	config.SchemaVersion = 0 // a file without schemaVersion is v1, not the default's version
+	if isYAMLPath(path) {
+		err = yaml.Unmarshal(data, config)
+	} else {
+		err = json.Unmarshal(data, config)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	// Expand environment variables in paths
-	config.Notifications.Desktop.AppIcon = platform.ExpandEnv(config.Notifications.Desktop.AppIcon)
-	config.Notifications.Webhook.URL = platform.ExpandEnv(config.Notifications.Webhook.URL)
-
-	// Expand environment variables in sound paths
-	for status, info := range config.Statuses {
-		info.Sound = platform.ExpandEnv(info.Sound)
-		config.Statuses[status] = info
+	// Upgrade configs written for older schemas
+	if config.SchemaVersion < 1 {
+		config.SchemaVersion = 1
+	}
+	if config.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("config schemaVersion %d is newer than this plugin supports (%d), please update the plugin", config.SchemaVersion, CurrentSchemaVersion)
+	}
+	if config.SchemaVersion < 2 {
+		MigrateV1ToV2(config)
 	}
 
+	// Expand environment variables in paths, URLs, secrets and custom fields, remembering
+	// what was written so Dump doesn't persist the expanded values
+	config.unexpanded = make(map[string]string)
+	config.envFields(func(key string, value *string) {
+		if expanded := platform.ExpandEnv(*value); expanded != *value {
+			config.unexpanded[key] = *value
+			*value = expanded
+		}
+	})
+
 	// Apply defaults for missing fields
 	config.ApplyDefaults()
 
@@ -180,8 +470,158 @@ func Load(path string) (*Config, error) {
 
 // LoadFromPluginRoot loads configuration from plugin root directory
 func LoadFromPluginRoot(pluginRoot string) (*Config, error) {
-	configPath := filepath.Join(pluginRoot, "config", "config.json")
-	return Load(configPath)
+	return Load(FindConfigFile(pluginRoot))
+}
+
+// configFileNames are the config files looked up in <pluginRoot>/config, in order of preference
+var configFileNames = []string{"config.yaml", "config.yml", "config.json"}
+
+// FindConfigFile returns the first existing config file in the plugin's config directory,
+// or the path to config.json if none exists
+func FindConfigFile(pluginRoot string) string {
+	configDir := filepath.Join(pluginRoot, "config")
+	for _, name := range configFileNames {
+		path := filepath.Join(configDir, name)
+		if platform.FileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(configDir, "config.json")
+}
+
+// MigrateV1ToV2 upgrades a v1 config in place. v1 files have none of the v2 fields: the
+// offline queue of each webhook, whose zero value would turn it off, is set to its default,
+// and so are the stop-after-notification and dedup windows, so a config written back by Dump
+// states them. Per-status fields added in v2 (cooldownSeconds, autoFocus, keywords, ...)
+// default to their zero values and are kept as decoded.
+func MigrateV1ToV2(c *Config) {
+	defaults := DefaultConfig()
+	if c.Notifications.SuppressStopAfterNotificationSeconds == 0 {
+		c.Notifications.SuppressStopAfterNotificationSeconds = defaults.Notifications.SuppressStopAfterNotificationSeconds
+	}
+	if c.Notifications.DedupWindowSeconds == 0 {
+		c.Notifications.DedupWindowSeconds = defaults.Notifications.DedupWindowSeconds
+	}
+	for i := range c.Notifications.Webhook {
+		if c.Notifications.Webhook[i].OfflineQueue == (OfflineQueueConfig{}) {
+			c.Notifications.Webhook[i].OfflineQueue = defaults.Notifications.Webhook[0].OfflineQueue
+		}
+	}
+	c.SchemaVersion = 2
+}
+
+// Dump writes the configuration to path, as YAML for a .yaml or .yml path and JSON
+// otherwise, marked with the CurrentSchemaVersion so a migrated config is not migrated again.
+// cfg is not modified. Values Load expanded from environment variables are written as they
+// appeared in the file (e.g. "${WEBHOOK_SECRET}"), unless they were changed since.
+func Dump(cfg *Config, path string) error {
+	out := cfg.clone()
+	out.SchemaVersion = CurrentSchemaVersion
+	out.envFields(func(key string, value *string) {
+		if raw, ok := cfg.unexpanded[key]; ok && *value == platform.ExpandEnv(raw) {
+			*value = raw
+		}
+	})
+
+	if isYAMLPath(path) {
+		return WriteYAML(out, path)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config to JSON: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// envFields calls fn with a key and a pointer for every field that may reference environment
+// variables: the app icon and attention sound, status sounds, and webhook URLs, signing
+// secrets and custom payload and header values
+func (c *Config) envFields(fn func(key string, value *string)) {
+	fn("desktop.appIcon", &c.Notifications.Desktop.AppIcon)
+	fn("desktop.attentionSound", &c.Notifications.Desktop.AttentionSound)
+	for i := range c.Notifications.Webhook {
+		wh := &c.Notifications.Webhook[i]
+		prefix := fmt.Sprintf("webhook.%d.", i)
+		fn(prefix+"url", &wh.URL)
+		fn(prefix+"signingSecret", &wh.SigningSecret)
+		envMapFields(prefix+"customPayloadFields.", wh.CustomPayloadFields, fn)
+		envMapFields(prefix+"customHeaderFields.", wh.CustomHeaderFields, fn)
+	}
+	for status, info := range c.Statuses {
+		fn("statuses."+status+".sound", &info.Sound)
+		c.Statuses[status] = info
+	}
+}
+
+// envMapFields calls fn for every value of fields, writing back what fn leaves in it
+func envMapFields(prefix string, fields map[string]string, fn func(key string, value *string)) {
+	for key, value := range fields {
+		fn(prefix+key, &value)
+		fields[key] = value
+	}
+}
+
+// clone returns a copy of c that shares no maps or slices that envFields writes to
+func (c *Config) clone() *Config {
+	out := *c
+	if c.Notifications.Webhook != nil {
+		out.Notifications.Webhook = make(WebhookList, len(c.Notifications.Webhook))
+		for i, wh := range c.Notifications.Webhook {
+			wh.CustomPayloadFields = copyStringMap(wh.CustomPayloadFields)
+			wh.CustomHeaderFields = copyStringMap(wh.CustomHeaderFields)
+			out.Notifications.Webhook[i] = wh
+		}
+	}
+	if c.Statuses != nil {
+		out.Statuses = make(map[string]StatusInfo, len(c.Statuses))
+		for status, info := range c.Statuses {
+			out.Statuses[status] = info
+		}
+	}
+	return &out
+}
+
+// copyStringMap returns a copy of m, or nil for a nil map
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// WriteYAML writes the configuration to path in YAML format
+func WriteYAML(cfg *Config, path string) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return fmt.Errorf("failed to marshal config to YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to marshal config to YAML: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// isYAMLPath reports whether path has a .yaml or .yml extension
+func isYAMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
 }
 
 // ApplyDefaults fills in missing fields with default values
@@ -191,16 +631,37 @@ func (c *Config) ApplyDefaults() {
 		c.Notifications.Desktop.Volume = 1.0 // Default to full volume
 	}
 	// AppIcon: Keep empty if not set (no default)
+	c.Notifications.Desktop.TitleInBody = normalizeOption(c.Notifications.Desktop.TitleInBody)
+	c.Notifications.NotificationOrder = normalizeOption(c.Notifications.NotificationOrder)
+	c.Notifications.DurationFormat = normalizeOption(c.Notifications.DurationFormat)
+
+	for status, info := range c.Statuses {
+		if info.WebhookPreset != "" {
+			info.WebhookPreset = normalizeOption(info.WebhookPreset)
+			c.Statuses[status] = info
+		}
+	}
 
 	// Webhook defaults
-	if c.Notifications.Webhook.Preset == "" {
-		c.Notifications.Webhook.Preset = "custom"
-	}
-	if c.Notifications.Webhook.Format == "" {
-		c.Notifications.Webhook.Format = "json"
-	}
-	if c.Notifications.Webhook.Headers == nil {
-		c.Notifications.Webhook.Headers = make(map[string]string)
+	for i := range c.Notifications.Webhook {
+		wh := &c.Notifications.Webhook[i]
+		wh.Preset = normalizeOption(wh.Preset)
+		wh.Format = normalizeOption(wh.Format)
+		if wh.Preset == "" {
+			wh.Preset = "custom"
+		}
+		if wh.Format == "" {
+			wh.Format = "json"
+		}
+		if wh.Headers == nil {
+			wh.Headers = make(map[string]string)
+		}
+		if wh.OfflineQueue.MaxSize == 0 {
+			wh.OfflineQueue.MaxSize = 100
+		}
+		if wh.OfflineQueue.TTL == "" {
+			wh.OfflineQueue.TTL = "24h"
+		}
 	}
 
 	// Cooldown defaults
@@ -210,6 +671,12 @@ func (c *Config) ApplyDefaults() {
 	if c.Notifications.SuppressQuestionAfterAnyNotificationSeconds == 0 {
 		c.Notifications.SuppressQuestionAfterAnyNotificationSeconds = 12
 	}
+	if c.Notifications.SuppressStopAfterNotificationSeconds == 0 {
+		c.Notifications.SuppressStopAfterNotificationSeconds = 5
+	}
+	if c.Notifications.DedupWindowSeconds == 0 {
+		c.Notifications.DedupWindowSeconds = 2
+	}
 
 	// Status defaults
 	defaults := DefaultConfig()
@@ -223,6 +690,16 @@ func (c *Config) ApplyDefaults() {
 			}
 		}
 	}
+	if c.Notifications.NotifyOnUnknown {
+		if _, exists := c.Statuses["unknown"]; !exists {
+			c.Statuses["unknown"] = DefaultUnknownStatus()
+		}
+	}
+	if c.Notifications.NotifyOnLongCommands {
+		if _, exists := c.Statuses["long_running_command"]; !exists {
+			c.Statuses["long_running_command"] = DefaultLongRunningCommandStatus()
+		}
+	}
 }
 
 // Validate validates the configuration
@@ -232,44 +709,209 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("desktop volume must be between 0.0 and 1.0 (got %.2f)", c.Notifications.Desktop.Volume)
 	}
 
-	// Validate webhook preset (only if webhooks are enabled)
-	validPresets := map[string]bool{
-		"slack":    true,
-		"discord":  true,
-		"telegram": true,
-		"custom":   true,
+	if c.Notifications.Desktop.SoundCooldownMs < 0 {
+		return fmt.Errorf("desktop soundCooldownMs must be >= 0")
 	}
-	if c.Notifications.Webhook.Enabled && !validPresets[c.Notifications.Webhook.Preset] {
-		return fmt.Errorf("invalid webhook preset: %s (must be one of: slack, discord, telegram, custom)", c.Notifications.Webhook.Preset)
+
+	if c.Notifications.Desktop.FadeOutMs < 0 {
+		return fmt.Errorf("desktop fadeOutMs must be >= 0")
+	}
+
+	if c.Notifications.Desktop.TTSRate < 0 {
+		return fmt.Errorf("desktop ttsRate must be >= 0")
+	}
+
+	if n := c.Notifications.Desktop.MaxSummaryLength; n != 0 && n < MinMessageLength {
+		return fmt.Errorf("desktop maxSummaryLength must be at least %d (or 0 for the default)", MinMessageLength)
+	}
+
+	// Validate title-in-body mode
+	switch normalizeOption(c.Notifications.Desktop.TitleInBody) {
+	case "", "prefix", "suffix":
+	default:
+		return fmt.Errorf("invalid desktop titleInBody: %s (must be one of: prefix, suffix)", c.Notifications.Desktop.TitleInBody)
 	}
 
-	// Validate webhook format (only if webhooks are enabled)
-	validFormats := map[string]bool{
-		"json": true,
-		"text": true,
+	// Validate notification order
+	switch normalizeOption(c.Notifications.NotificationOrder) {
+	case "", NotificationOrderDesktopFirst, NotificationOrderWebhookFirst, NotificationOrderParallel:
+	default:
+		return fmt.Errorf("invalid notificationOrder: %s (must be one of: desktop-first, webhook-first, parallel)", c.Notifications.NotificationOrder)
 	}
-	if c.Notifications.Webhook.Enabled && !validFormats[c.Notifications.Webhook.Format] {
-		return fmt.Errorf("invalid webhook format: %s (must be one of: json, text)", c.Notifications.Webhook.Format)
+
+	// Validate duration format
+	switch normalizeOption(c.Notifications.DurationFormat) {
+	case "", DurationFormatWords, DurationFormatClock, DurationFormatISO, DurationFormatNone:
+	default:
+		return fmt.Errorf("invalid durationFormat: %s (must be one of: words, clock, iso, none)", c.Notifications.DurationFormat)
 	}
 
-	// Validate webhook URL if enabled
-	if c.Notifications.Webhook.Enabled && c.Notifications.Webhook.URL == "" {
-		return fmt.Errorf("webhook URL is required when webhooks are enabled")
+	// Validate quiet hours
+	if q := c.Notifications.Desktop.QuietHours; q != nil {
+		if err := q.Validate(); err != nil {
+			return err
+		}
 	}
 
-	// Validate Telegram chat_id if Telegram preset is used
-	if c.Notifications.Webhook.Enabled && c.Notifications.Webhook.Preset == "telegram" && c.Notifications.Webhook.ChatID == "" {
-		return fmt.Errorf("chat_id is required for Telegram webhook")
+	// Validate webhook endpoints
+	if c.Notifications.MaxWebhookEndpoints < 0 {
+		return fmt.Errorf("maxWebhookEndpoints must be >= 0")
+	}
+	maxEndpoints := c.Notifications.MaxWebhookEndpoints
+	if maxEndpoints == 0 {
+		maxEndpoints = DefaultMaxWebhookEndpoints
+	}
+	if n := len(c.Notifications.Webhook); n > maxEndpoints {
+		return fmt.Errorf("too many webhook endpoints: %d (the limit is %d, raise maxWebhookEndpoints to allow more)", n, maxEndpoints)
+	}
+	for _, wh := range c.Notifications.Webhook {
+		if err := wh.Validate(); err != nil {
+			return err
+		}
 	}
 
 	// Validate cooldown
 	if c.Notifications.SuppressQuestionAfterTaskCompleteSeconds < 0 {
 		return fmt.Errorf("suppressQuestionAfterTaskCompleteSeconds must be >= 0")
 	}
+	if c.Notifications.SuppressStopAfterNotificationSeconds < -1 {
+		return fmt.Errorf("suppressStopAfterNotificationSeconds must be >= 0, or -1 to disable")
+	}
+
+	if c.Notifications.DedupWindowSeconds < 0 {
+		return fmt.Errorf("dedupWindowSeconds must be >= 0")
+	}
+
+	// Validate throttle window
+	if c.Notifications.ThrottleWindowSeconds < 0 {
+		return fmt.Errorf("throttleWindowSeconds must be >= 0")
+	}
+
+	if c.Notifications.CleanupFailureThreshold < 0 {
+		return fmt.Errorf("cleanupFailureThreshold must be >= 0")
+	}
+
+	// Validate startup grace period
+	if c.Notifications.StartupGraceSeconds < 0 {
+		return fmt.Errorf("startupGraceSeconds must be >= 0")
+	}
+
+	// Validate long task threshold
+	if c.Notifications.LongTaskThresholdSeconds < 0 {
+		return fmt.Errorf("longTaskThresholdSeconds must be >= 0")
+	}
+
+	// Validate ignored hook events
+	for _, event := range c.Notifications.IgnoredHookEvents {
+		if !isHookEvent(event) {
+			return fmt.Errorf("invalid ignoredHookEvents entry: %s (must be one of: %s)", event, strings.Join(HookEvents, ", "))
+		}
+	}
+
+	// Validate lookback windows (0 = built-in default)
+	if c.Notifications.AnalysisWindow < 0 {
+		return fmt.Errorf("analysisWindow must be positive (or 0 for the default)")
+	}
+	if w := c.Notifications.SummaryWindows; w != nil && (w.Question < 0 || w.Review < 0 || w.Task < 0) {
+		return fmt.Errorf("summaryWindows values must be positive (or 0 for the default)")
+	}
+
+	if h := c.Notifications.History; h != nil && h.MaxSizeKB < 0 {
+		return fmt.Errorf("history.maxSizeKB must be positive (or 0 for the default)")
+	}
+
+	// Validate workspace rules
+	for i, rule := range c.Notifications.WorkspaceFilter {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("workspaceFilter[%d]: %w", i, err)
+		}
+	}
+
+	// Validate per-status settings
+	for status, info := range c.Statuses {
+		if info.CooldownSeconds < 0 {
+			return fmt.Errorf("cooldownSeconds for status %s must be >= 0", status)
+		}
+		if info.Volume != nil && (*info.Volume < 0.0 || *info.Volume > 1.0) {
+			return fmt.Errorf("volume for status %s must be between 0.0 and 1.0 (got %.2f)", status, *info.Volume)
+		}
+		if preset := normalizeOption(info.WebhookPreset); preset != "" && (!validWebhookPresets[preset] || preset == "imessage") {
+			return fmt.Errorf("invalid webhookPreset for status %s: %s (must be one of: slack, discord, telegram, shortcuts, custom)", status, info.WebhookPreset)
+		}
+		// The status is sent in Telegram's format to every endpoint but iMessage, so each needs a chat
+		if normalizeOption(info.WebhookPreset) == "telegram" {
+			for i, wh := range c.Notifications.Webhook {
+				if wh.Enabled && normalizeOption(wh.Preset) != "imessage" && wh.ChatID == "" {
+					return fmt.Errorf("webhookPreset telegram for status %s requires chat_id on webhook %d (%s)", status, i, wh.Preset)
+				}
+			}
+		}
+	}
 
 	return nil
 }
 
+// Validate checks the quiet hours times, weekdays and timezone
+func (q *QuietHoursConfig) Validate() error {
+	if _, err := schedule.ParseClock(q.Start); err != nil {
+		return fmt.Errorf("quietHours start: %w", err)
+	}
+	if _, err := schedule.ParseClock(q.End); err != nil {
+		return fmt.Errorf("quietHours end: %w", err)
+	}
+	for _, day := range q.Weekdays {
+		if _, err := schedule.ParseWeekday(day); err != nil {
+			return fmt.Errorf("quietHours: %w", err)
+		}
+	}
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("quietHours: invalid timezone %q: %w", q.Timezone, err)
+		}
+	}
+	return nil
+}
+
+// Contains reports whether now falls within the quiet hours window.
+// For windows crossing midnight, the weekday is the day the window started.
+// Invalid settings never match (Validate reports them at load time).
+func (q *QuietHoursConfig) Contains(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	start, err := schedule.ParseClock(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := schedule.ParseClock(q.End)
+	if err != nil || start == end {
+		return false
+	}
+	days := make([]time.Weekday, 0, len(q.Weekdays))
+	for _, name := range q.Weekdays {
+		day, err := schedule.ParseWeekday(name)
+		if err != nil {
+			return false
+		}
+		days = append(days, day)
+	}
+
+	if q.Timezone != "" {
+		loc, err := time.LoadLocation(q.Timezone)
+		if err != nil {
+			return false
+		}
+		now = now.In(loc)
+	}
+	return schedule.New(start, end, days...).Contains(now)
+}
+
+// normalizeOption lower-cases and trims an enum-like option so "Slack" or " slack " match "slack"
+func normalizeOption(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
 // GetStatusInfo returns status information for a given status
 func (c *Config) GetStatusInfo(status string) (StatusInfo, bool) {
 	info, exists := c.Statuses[status]
@@ -281,9 +923,70 @@ func (c *Config) IsDesktopEnabled() bool {
 	return c.Notifications.Desktop.Enabled
 }
 
-// IsWebhookEnabled returns true if webhook notifications are enabled
+// IsWebhookEnabled returns true if at least one webhook endpoint is enabled
 func (c *Config) IsWebhookEnabled() bool {
-	return c.Notifications.Webhook.Enabled
+	for _, wh := range c.Notifications.Webhook {
+		if wh.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHistoryEnabled returns true if delivered notifications are recorded in the history file
+func (c *Config) IsHistoryEnabled() bool {
+	return c.Notifications.History != nil && c.Notifications.History.Enabled
+}
+
+// HistoryMaxBytes returns the size at which the history file is rotated
+func (c *Config) HistoryMaxBytes() int64 {
+	kb := DefaultHistoryMaxSizeKB
+	if h := c.Notifications.History; h != nil && h.MaxSizeKB > 0 {
+		kb = h.MaxSizeKB
+	}
+	return int64(kb) * 1024
+}
+
+// SessionStateMaxAgeSeconds returns how long idle session state must be kept: at least a
+// minute, and at least as long as the longest cooldown or suppression window that reads it
+func (c *Config) SessionStateMaxAgeSeconds() int64 {
+	maxAge := 60
+	for _, window := range []int{
+		c.Notifications.SuppressQuestionAfterTaskCompleteSeconds,
+		c.Notifications.SuppressQuestionAfterAnyNotificationSeconds,
+		c.Notifications.SuppressStopAfterNotificationSeconds,
+		c.Notifications.SuppressConsecutiveIdenticalSeconds,
+	} {
+		if window > maxAge {
+			maxAge = window
+		}
+	}
+	for _, info := range c.Statuses {
+		if info.CooldownSeconds > maxAge {
+			maxAge = info.CooldownSeconds
+		}
+	}
+	return int64(maxAge)
+}
+
+// IsHookEventIgnored returns true if the hook event is listed in notifications.ignoredHookEvents
+func (c *Config) IsHookEventIgnored(event string) bool {
+	for _, ignored := range c.Notifications.IgnoredHookEvents {
+		if strings.EqualFold(strings.TrimSpace(ignored), event) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHookEvent reports whether name is one of HookEvents, ignoring case
+func isHookEvent(name string) bool {
+	for _, event := range HookEvents {
+		if strings.EqualFold(strings.TrimSpace(name), event) {
+			return true
+		}
+	}
+	return false
 }
 
 // IsAnyNotificationEnabled returns true if at least one notification method is enabled
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/platform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,8 +17,10 @@ func TestDefaultConfig(t *testing.T) {
 
 	assert.True(t, cfg.Notifications.Desktop.Enabled)
 	assert.True(t, cfg.Notifications.Desktop.Sound)
-	assert.False(t, cfg.Notifications.Webhook.Enabled)
+	assert.False(t, cfg.Notifications.Webhook[0].Enabled)
 	assert.Equal(t, 12, cfg.Notifications.SuppressQuestionAfterTaskCompleteSeconds)
+	assert.Equal(t, 5, cfg.Notifications.SuppressStopAfterNotificationSeconds)
+	assert.Equal(t, 2, cfg.Notifications.DedupWindowSeconds)
 
 	// Check statuses
 	assert.Contains(t, cfg.Statuses, "task_complete")
@@ -60,8 +65,8 @@ func TestLoadConfig(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.False(t, cfg.Notifications.Desktop.Enabled)
-	assert.True(t, cfg.Notifications.Webhook.Enabled)
-	assert.Equal(t, "slack", cfg.Notifications.Webhook.Preset)
+	assert.True(t, cfg.Notifications.Webhook[0].Enabled)
+	assert.Equal(t, "slack", cfg.Notifications.Webhook[0].Preset)
 	assert.Equal(t, 10, cfg.Notifications.SuppressQuestionAfterTaskCompleteSeconds)
 }
 
@@ -73,6 +78,272 @@ func TestLoadConfigNotExists(t *testing.T) {
 	assert.True(t, cfg.Notifications.Desktop.Enabled)
 }
 
+func TestLoadConfig_YAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configYAML := `notifications:
+  desktop:
+    enabled: false
+    sound: true
+    volume: 0.5
+  webhook:
+    enabled: true
+    preset: Discord
+    url: https://discord.com/api/webhooks/test
+  suppressQuestionAfterTaskCompleteSeconds: 10
+statuses:
+  question:
+    title: Need input
+    volume: 0.8
+    autoFocus: true
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Notifications.Desktop.Enabled)
+	assert.Equal(t, 0.5, cfg.Notifications.Desktop.Volume)
+	assert.Equal(t, "discord", cfg.Notifications.Webhook[0].Preset)
+	assert.Equal(t, "https://discord.com/api/webhooks/test", cfg.Notifications.Webhook[0].URL)
+	assert.Equal(t, 10, cfg.Notifications.SuppressQuestionAfterTaskCompleteSeconds)
+
+	question := cfg.Statuses["question"]
+	assert.Equal(t, "Need input", question.Title)
+	require.NotNil(t, question.Volume)
+	assert.Equal(t, 0.8, *question.Volume)
+	assert.True(t, question.AutoFocus)
+
+	// Statuses not in the file keep their defaults
+	assert.Contains(t, cfg.Statuses, "task_complete")
+}
+
+func TestLoadConfig_MalformedYAML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("notifications: [unclosed"), 0644))
+
+	_, err := Load(configPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+}
+
+func TestFindConfigFile(t *testing.T) {
+	pluginRoot := t.TempDir()
+	configDir := filepath.Join(pluginRoot, "config")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+
+	// Nothing exists yet: fall back to config.json
+	assert.Equal(t, filepath.Join(configDir, "config.json"), FindConfigFile(pluginRoot))
+
+	// Preference order: config.yaml, config.yml, config.json
+	for _, name := range []string{"config.json", "config.yml", "config.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, name), []byte("{}"), 0644))
+		assert.Equal(t, filepath.Join(configDir, name), FindConfigFile(pluginRoot))
+	}
+}
+
+func TestWriteYAML_RoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.Volume = 0.4
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].Preset = "slack"
+	cfg.Notifications.Webhook[0].URL = "https://hooks.slack.com/test"
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, WriteYAML(cfg, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "suppressQuestionAfterTaskCompleteSeconds: 12")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0.4, loaded.Notifications.Desktop.Volume)
+	assert.Equal(t, "https://hooks.slack.com/test", loaded.Notifications.Webhook[0].URL)
+	assert.Equal(t, cfg.Statuses["question"].Title, loaded.Statuses["question"].Title)
+	assert.NoError(t, loaded.Validate())
+}
+
+func TestLoadConfig_MigratesV1(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantQueue bool
+	}{
+		{
+			name:      "v1 without schemaVersion gets v2 defaults",
+			content:   `{"notifications": {"webhook": {"enabled": true, "preset": "slack", "url": "https://hooks.slack.com/x"}}, "statuses": {"question": {"title": "Question?"}}}`,
+			wantQueue: true,
+		},
+		{
+			name:      "v2 keeps an explicit false",
+			content:   `{"schemaVersion": 2, "notifications": {"webhook": {"enabled": true, "preset": "slack", "url": "https://hooks.slack.com/x", "offlineQueue": {"enabled": false}}}, "statuses": {"question": {"title": "Question?"}}}`,
+			wantQueue: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, CurrentSchemaVersion, cfg.SchemaVersion)
+			assert.Equal(t, tt.wantQueue, cfg.Notifications.Webhook[0].OfflineQueue.Enabled)
+			assert.Equal(t, StatusInfo{Title: "Question?"}, cfg.Statuses["question"], "statuses are kept as written")
+		})
+	}
+}
+
+func TestLoadConfig_NewerSchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"schemaVersion": 99}`), 0644))
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schemaVersion 99")
+}
+
+func TestMigrateV1ToV2(t *testing.T) {
+	defaults := DefaultConfig().Notifications
+	tests := []struct {
+		name  string
+		v1    string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "suppressStopAfterNotificationSeconds",
+			v1:   `{"notifications": {"suppressQuestionAfterTaskCompleteSeconds": 20}}`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, defaults.SuppressStopAfterNotificationSeconds, cfg.Notifications.SuppressStopAfterNotificationSeconds)
+				assert.Equal(t, 20, cfg.Notifications.SuppressQuestionAfterTaskCompleteSeconds)
+			},
+		},
+		{
+			name: "dedupWindowSeconds",
+			v1:   `{"notifications": {"desktop": {"enabled": true}}}`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, defaults.DedupWindowSeconds, cfg.Notifications.DedupWindowSeconds)
+			},
+		},
+		{
+			name: "webhook offlineQueue",
+			v1:   `{"notifications": {"webhook": {"enabled": true, "preset": "slack", "url": "https://hooks.slack.com/x"}}}`,
+			check: func(t *testing.T, cfg *Config) {
+				require.Len(t, cfg.Notifications.Webhook, 1)
+				assert.Equal(t, defaults.Webhook[0].OfflineQueue, cfg.Notifications.Webhook[0].OfflineQueue)
+				assert.Equal(t, "https://hooks.slack.com/x", cfg.Notifications.Webhook[0].URL)
+			},
+		},
+		{
+			name: "explicit values are preserved",
+			v1:   `{"notifications": {"suppressStopAfterNotificationSeconds": 30, "dedupWindowSeconds": 7, "webhook": {"offlineQueue": {"maxSize": 5}}}}`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, 30, cfg.Notifications.SuppressStopAfterNotificationSeconds)
+				assert.Equal(t, 7, cfg.Notifications.DedupWindowSeconds)
+				assert.Equal(t, OfflineQueueConfig{MaxSize: 5}, cfg.Notifications.Webhook[0].OfflineQueue)
+			},
+		},
+		{
+			name: "statuses are kept as written",
+			v1:   `{"statuses": {"question": {"sound": "/custom/question.mp3"}, "deployed": {"title": "🚀 Deployed"}}}`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, StatusInfo{Sound: "/custom/question.mp3"}, cfg.Statuses["question"])
+				assert.Equal(t, StatusInfo{Title: "🚀 Deployed"}, cfg.Statuses["deployed"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Decode over a zero config, not DefaultConfig, so only the migration sets defaults
+			cfg := &Config{}
+			require.NoError(t, json.Unmarshal([]byte(tt.v1), cfg))
+
+			MigrateV1ToV2(cfg)
+
+			assert.Equal(t, 2, cfg.SchemaVersion)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestDump(t *testing.T) {
+	for _, name := range []string{"config.json", "config.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SchemaVersion = 1
+			cfg.Notifications.Desktop.Volume = 0.4
+
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, Dump(cfg, path))
+			assert.Equal(t, 1, cfg.SchemaVersion, "Dump doesn't modify cfg")
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "schemaVersion")
+
+			loaded, err := Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, CurrentSchemaVersion, loaded.SchemaVersion)
+			assert.Equal(t, 0.4, loaded.Notifications.Desktop.Volume)
+			assert.Equal(t, cfg.Statuses["question"], loaded.Statuses["question"])
+			assert.NoError(t, loaded.Validate())
+		})
+	}
+}
+
+func TestDump_KeepsEnvReferences(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_SECRET", "s3cret")
+	t.Setenv("TEST_WEBHOOK_HOST", "hooks.example.com")
+	t.Setenv("TEST_DEPLOY_ENV", "prod")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(src, []byte(`{
+		"schemaVersion": 2,
+		"notifications": {"webhook": {
+			"enabled": true, "preset": "custom", "url": "https://${TEST_WEBHOOK_HOST}/notify",
+			"signingSecret": "${TEST_WEBHOOK_SECRET}", "signatureHeader": "X-Signature",
+			"customPayloadFields": {"environment": "${TEST_DEPLOY_ENV}", "team": "core"}
+		}}
+	}`), 0644))
+
+	cfg, err := Load(src)
+	require.NoError(t, err)
+	wh := cfg.Notifications.Webhook[0]
+	require.Equal(t, "s3cret", wh.SigningSecret)
+	require.Equal(t, "https://hooks.example.com/notify", wh.URL)
+
+	// A field changed after loading is written as it is now
+	cfg.Notifications.Webhook[0].CustomPayloadFields["environment"] = "staging"
+
+	for _, name := range []string{"out.json", "out.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, Dump(cfg, path))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "s3cret", "secrets are not written expanded")
+			assert.Contains(t, string(data), "${TEST_WEBHOOK_SECRET}")
+			assert.Contains(t, string(data), "https://${TEST_WEBHOOK_HOST}/notify")
+			assert.Contains(t, string(data), "staging")
+
+			assert.Equal(t, "s3cret", cfg.Notifications.Webhook[0].SigningSecret, "Dump doesn't modify cfg")
+			assert.Equal(t, "https://hooks.example.com/notify", cfg.Notifications.Webhook[0].URL)
+
+			loaded, err := Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, "s3cret", loaded.Notifications.Webhook[0].SigningSecret)
+			assert.Equal(t, "staging", loaded.Notifications.Webhook[0].CustomPayloadFields["environment"])
+			assert.Equal(t, "core", loaded.Notifications.Webhook[0].CustomPayloadFields["team"])
+		})
+	}
+}
+
 func TestValidateConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -88,11 +359,11 @@ func TestValidateConfig(t *testing.T) {
 			name: "invalid webhook preset",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "invalid",
 						URL:     "https://example.com",
-					},
+					}},
 				},
 			},
 			wantErr: true,
@@ -101,11 +372,11 @@ func TestValidateConfig(t *testing.T) {
 			name: "webhook enabled but no URL",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "slack",
 						URL:     "",
-					},
+					}},
 				},
 			},
 			wantErr: true,
@@ -114,12 +385,12 @@ func TestValidateConfig(t *testing.T) {
 			name: "telegram without chat_id",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "telegram",
 						URL:     "https://api.telegram.org",
 						ChatID:  "",
-					},
+					}},
 				},
 			},
 			wantErr: true,
@@ -133,11 +404,11 @@ func TestValidateConfig(t *testing.T) {
 						Sound:   true,
 						Volume:  1.0,
 					},
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: false,
 						Preset:  "none", // Invalid preset, but webhooks are disabled
 						URL:     "",
-					},
+					}},
 				},
 			},
 			wantErr: false,
@@ -221,8 +492,8 @@ func TestLoadFromPluginRoot_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, cfg)
 	assert.False(t, cfg.Notifications.Desktop.Enabled)
-	assert.True(t, cfg.Notifications.Webhook.Enabled)
-	assert.Equal(t, "https://test.com/webhook", cfg.Notifications.Webhook.URL)
+	assert.True(t, cfg.Notifications.Webhook[0].Enabled)
+	assert.Equal(t, "https://test.com/webhook", cfg.Notifications.Webhook[0].URL)
 }
 
 func TestLoadFromPluginRoot_NoConfigFile(t *testing.T) {
@@ -267,6 +538,31 @@ func TestLoadFromPluginRoot_NonexistentRoot(t *testing.T) {
 	assert.True(t, cfg.Notifications.Desktop.Enabled)
 }
 
+func TestLoad_ExpandsCustomFields(t *testing.T) {
+	t.Setenv("TEST_DEPLOY_ENV", "staging")
+	t.Setenv("TEST_API_TOKEN", "secret")
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	configJSON := `{
+		"notifications": {
+			"webhook": {
+				"enabled": true,
+				"url": "https://example.com/hook",
+				"customPayloadFields": {"environment": "${TEST_DEPLOY_ENV}", "team": "backend"},
+				"customHeaderFields": {"X-Api-Token": "$TEST_API_TOKEN"}
+			}
+		}
+	}`
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	wh := cfg.Notifications.Webhook[0]
+	assert.Equal(t, map[string]string{"environment": "staging", "team": "backend"}, wh.CustomPayloadFields)
+	assert.Equal(t, map[string]string{"X-Api-Token": "secret"}, wh.CustomHeaderFields)
+}
+
 func TestLoadFromPluginRoot_EmptyRoot(t *testing.T) {
 	// Empty string as plugin root
 	cfg, err := LoadFromPluginRoot("")
@@ -302,7 +598,7 @@ func TestLoadFromPluginRoot_WithEnvironmentVariables(t *testing.T) {
 	cfg, err := LoadFromPluginRoot(tmpDir)
 
 	require.NoError(t, err)
-	assert.Equal(t, "https://example.com/hook", cfg.Notifications.Webhook.URL)
+	assert.Equal(t, "https://example.com/hook", cfg.Notifications.Webhook[0].URL)
 }
 
 // === Tests for ApplyDefaults ===
@@ -402,12 +698,12 @@ func TestValidateConfig_MoreCases(t *testing.T) {
 			name: "invalid webhook format",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "slack",
 						URL:     "https://example.com",
 						Format:  "invalid_format",
-					},
+					}},
 				},
 			},
 			wantErr: true,
@@ -417,12 +713,12 @@ func TestValidateConfig_MoreCases(t *testing.T) {
 			name: "custom preset with valid URL",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "custom",
 						URL:     "https://my-webhook.com/endpoint",
 						Format:  "json",
-					},
+					}},
 				},
 			},
 			wantErr: false,
@@ -431,12 +727,12 @@ func TestValidateConfig_MoreCases(t *testing.T) {
 			name: "discord preset with valid URL",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "discord",
 						URL:     "https://discord.com/api/webhooks/123/abc",
 						Format:  "json",
-					},
+					}},
 				},
 			},
 			wantErr: false,
@@ -445,13 +741,13 @@ func TestValidateConfig_MoreCases(t *testing.T) {
 			name: "telegram with chat_id",
 			cfg: &Config{
 				Notifications: NotificationsConfig{
-					Webhook: WebhookConfig{
+					Webhook: WebhookList{{
 						Enabled: true,
 						Preset:  "telegram",
 						URL:     "https://api.telegram.org/bot123:ABC/sendMessage",
 						ChatID:  "123456789",
 						Format:  "json",
-					},
+					}},
 				},
 			},
 			wantErr: false,
@@ -498,6 +794,143 @@ func TestValidate_InvalidVolume(t *testing.T) {
 	}
 }
 
+func TestValidate_TitleInBody(t *testing.T) {
+	for _, mode := range []string{"", "prefix", "suffix", "Prefix"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.Desktop.TitleInBody = mode
+		assert.NoError(t, cfg.Validate(), "mode %q should be valid", mode)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.TitleInBody = "both"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid desktop titleInBody")
+}
+
+func TestValidate_NotificationOrder(t *testing.T) {
+	for _, order := range []string{"", "desktop-first", "webhook-first", "parallel", "Parallel"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.NotificationOrder = order
+		assert.NoError(t, cfg.Validate(), "order %q should be valid", order)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.NotificationOrder = "random"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid notificationOrder")
+}
+
+func TestValidate_DurationFormat(t *testing.T) {
+	for _, format := range []string{"", "words", "clock", "iso", "none", "ISO"} {
+		cfg := DefaultConfig()
+		cfg.Notifications.DurationFormat = format
+		assert.NoError(t, cfg.Validate(), "format %q should be valid", format)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.DurationFormat = "seconds"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid durationFormat")
+}
+
+func TestValidate_StatusWebhookPreset(t *testing.T) {
+	for _, preset := range []string{"", "slack", "discord", "telegram", "shortcuts", "custom", "Discord"} {
+		cfg := DefaultConfig()
+		cfg.Statuses["question"] = StatusInfo{Title: "Question", WebhookPreset: preset}
+		assert.NoError(t, cfg.Validate(), "preset %q should be valid", preset)
+	}
+
+	for _, preset := range []string{"teams", "imessage"} {
+		cfg := DefaultConfig()
+		cfg.Statuses["question"] = StatusInfo{Title: "Question", WebhookPreset: preset}
+		err := cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid webhookPreset for status question")
+	}
+}
+
+func TestValidate_StatusTelegramPresetNeedsChatID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = WebhookList{
+		{Enabled: true, Preset: "telegram", URL: "https://api.telegram.org/bot123/sendMessage", ChatID: "42"},
+		{Enabled: true, Preset: "slack", URL: "https://hooks.slack.com/test"},
+	}
+	cfg.ApplyDefaults()
+	require.NoError(t, cfg.Validate())
+
+	cfg.Statuses["question"] = StatusInfo{Title: "Question", WebhookPreset: "Telegram"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires chat_id on webhook 1 (slack)")
+
+	cfg.Notifications.Webhook[1].ChatID = "42"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	// 2024-03-01 is a Friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		q    *QuietHoursConfig
+		now  time.Time
+		want bool
+	}{
+		{"nil config", nil, at(1, 23, 0), false},
+		{"overnight evening", &QuietHoursConfig{Start: "22:00", End: "08:00"}, at(1, 23, 0), true},
+		{"overnight morning", &QuietHoursConfig{Start: "22:00", End: "08:00"}, at(2, 7, 59), true},
+		{"overnight end is exclusive", &QuietHoursConfig{Start: "22:00", End: "08:00"}, at(2, 8, 0), false},
+		{"overnight daytime", &QuietHoursConfig{Start: "22:00", End: "08:00"}, at(1, 12, 0), false},
+		{"same-day window", &QuietHoursConfig{Start: "12:00", End: "13:30"}, at(1, 13, 15), true},
+		{"same-day outside", &QuietHoursConfig{Start: "12:00", End: "13:30"}, at(1, 14, 0), false},
+		{"weekday matches start day", &QuietHoursConfig{Start: "22:00", End: "08:00", Weekdays: []string{"fri"}}, at(2, 3, 0), true},
+		{"weekday does not match", &QuietHoursConfig{Start: "22:00", End: "08:00", Weekdays: []string{"Saturday"}}, at(2, 3, 0), false},
+		{"timezone", &QuietHoursConfig{Start: "22:00", End: "08:00", Timezone: "Asia/Tokyo"}, at(1, 14, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.q.Contains(tt.now))
+		})
+	}
+}
+
+func TestValidate_QuietHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       QuietHoursConfig
+		wantErr string
+	}{
+		{"valid", QuietHoursConfig{Start: "22:00", End: "08:00", Weekdays: []string{"mon", "Friday"}, Timezone: "Europe/Berlin"}, ""},
+		{"invalid start", QuietHoursConfig{Start: "25:00", End: "08:00"}, "quietHours start"},
+		{"missing end", QuietHoursConfig{Start: "22:00"}, "quietHours end"},
+		{"invalid weekday", QuietHoursConfig{Start: "22:00", End: "08:00", Weekdays: []string{"funday"}}, "invalid weekday"},
+		{"invalid timezone", QuietHoursConfig{Start: "22:00", End: "08:00", Timezone: "Mars/Olympus"}, "invalid timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			q := tt.q
+			cfg.Notifications.Desktop.QuietHours = &q
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 func TestValidate_NegativeCooldown(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Notifications.SuppressQuestionAfterTaskCompleteSeconds = -1
@@ -506,3 +939,345 @@ func TestValidate_NegativeCooldown(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "suppressQuestionAfterTaskCompleteSeconds must be >= 0")
 }
+
+func TestValidate_NegativeStopSuppression(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.SuppressStopAfterNotificationSeconds = -2
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "suppressStopAfterNotificationSeconds must be >= 0")
+}
+
+func TestStopSuppressionDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"notifications": {"suppressStopAfterNotificationSeconds": -1}}`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, -1, cfg.Notifications.SuppressStopAfterNotificationSeconds, "-1 is not replaced by the default")
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_NegativeDedupWindow(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.DedupWindowSeconds = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dedupWindowSeconds must be >= 0")
+}
+
+func TestValidate_NegativeCleanupFailureThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.CleanupFailureThreshold = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cleanupFailureThreshold must be >= 0")
+}
+
+func TestValidate_NegativeStartupGrace(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.StartupGraceSeconds = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "startupGraceSeconds must be >= 0")
+}
+
+func TestIgnoredHookEvents(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.IgnoredHookEvents = []string{"SubagentStop", " notification "}
+	assert.NoError(t, cfg.Validate())
+
+	assert.True(t, cfg.IsHookEventIgnored("SubagentStop"))
+	assert.True(t, cfg.IsHookEventIgnored("Notification"))
+	assert.False(t, cfg.IsHookEventIgnored("Stop"))
+
+	cfg.Notifications.IgnoredHookEvents = []string{"SubagentStopp"}
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid ignoredHookEvents entry: SubagentStopp")
+}
+
+func TestValidate_NegativeLongTaskThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.LongTaskThresholdSeconds = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "longTaskThresholdSeconds must be >= 0")
+}
+
+func TestValidate_MessageLengthLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.MaxSummaryLength = 300
+	cfg.Notifications.Webhook[0].MaxWebhookMessageLength = 1000
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Desktop.MaxSummaryLength = 5
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "desktop maxSummaryLength must be at least 20")
+
+	cfg.Notifications.Desktop.MaxSummaryLength = 0
+	cfg.Notifications.Webhook[0].MaxWebhookMessageLength = -1
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook maxWebhookMessageLength must be at least 20")
+}
+
+func TestValidate_NegativeStatusCooldown(t *testing.T) {
+	cfg := DefaultConfig()
+	info := cfg.Statuses["task_complete"]
+	info.CooldownSeconds = -5
+	cfg.Statuses["task_complete"] = info
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cooldownSeconds for status task_complete must be >= 0")
+}
+
+func TestValidate_NegativeTTSRate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.TTSRate = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "desktop ttsRate must be >= 0")
+}
+
+func TestValidate_NegativeFadeOutMs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Desktop.FadeOutMs = -1
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "desktop fadeOutMs must be >= 0")
+}
+
+func TestValidate_LookbackWindows(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.AnalysisWindow = -1
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "analysisWindow must be positive")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.SummaryWindows = &SummaryWindowsConfig{Question: 20, Task: -3}
+	err = cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "summaryWindows values must be positive")
+
+	cfg = DefaultConfig()
+	cfg.Notifications.AnalysisWindow = 40
+	cfg.Notifications.SummaryWindows = &SummaryWindowsConfig{Question: 12}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestHistoryConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.False(t, cfg.IsHistoryEnabled())
+	assert.Equal(t, int64(DefaultHistoryMaxSizeKB*1024), cfg.HistoryMaxBytes())
+
+	cfg.Notifications.History = &HistoryConfig{Enabled: true, MaxSizeKB: 64}
+	assert.True(t, cfg.IsHistoryEnabled())
+	assert.Equal(t, int64(64*1024), cfg.HistoryMaxBytes())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.History.MaxSizeKB = -1
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "history.maxSizeKB must be positive")
+}
+
+func TestApplyDefaults_UnknownStatusOnlyWhenEnabled(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	_, exists := cfg.GetStatusInfo("unknown")
+	assert.False(t, exists, "unknown status should not be configured by default")
+
+	cfg = &Config{Notifications: NotificationsConfig{NotifyOnUnknown: true}}
+	cfg.ApplyDefaults()
+	info, exists := cfg.GetStatusInfo("unknown")
+	require.True(t, exists)
+	assert.Contains(t, info.Title, "Claude Code finished (unclassified)")
+
+	// A user-defined unknown status is kept
+	cfg = &Config{
+		Notifications: NotificationsConfig{NotifyOnUnknown: true},
+		Statuses:      map[string]StatusInfo{"unknown": {Title: "Custom"}},
+	}
+	cfg.ApplyDefaults()
+	assert.Equal(t, "Custom", cfg.Statuses["unknown"].Title)
+}
+
+func TestApplyDefaults_LongRunningCommandOnlyWhenEnabled(t *testing.T) {
+	cfg := &Config{}
+	cfg.ApplyDefaults()
+	_, exists := cfg.GetStatusInfo("long_running_command")
+	assert.False(t, exists, "long_running_command should not be configured by default")
+
+	cfg = &Config{Notifications: NotificationsConfig{NotifyOnLongCommands: true}}
+	cfg.ApplyDefaults()
+	info, exists := cfg.GetStatusInfo("long_running_command")
+	require.True(t, exists)
+	assert.Equal(t, "⏳ Running Command", info.Title)
+}
+
+func TestValidate_WebhookPresets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].Preset = "shortcuts"
+	cfg.Notifications.Webhook[0].URL = "http://localhost:8090/claude"
+	assert.NoError(t, cfg.Validate())
+
+	// iMessage needs no URL but is macOS-only and needs a recipient
+	cfg.Notifications.Webhook[0].Preset = "imessage"
+	cfg.Notifications.Webhook[0].URL = ""
+	cfg.Notifications.Webhook[0].RecipientPhone = "+15555550123"
+	err := cfg.Validate()
+	if platform.IsMacOS() {
+		assert.NoError(t, err)
+
+		cfg.Notifications.Webhook[0].RecipientPhone = ""
+		err = cfg.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "recipientPhone is required")
+	} else {
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "only supported on macOS")
+	}
+}
+
+func TestValidate_TelegramMessageField(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].Preset = "telegram"
+	cfg.Notifications.Webhook[0].URL = "https://api.telegram.org/bot123/sendPhoto"
+	cfg.Notifications.Webhook[0].ChatID = "123456789"
+
+	for _, field := range []string{"", "text", "caption"} {
+		cfg.Notifications.Webhook[0].TelegramMessageField = field
+		assert.NoError(t, cfg.Validate(), "field %q", field)
+	}
+
+	cfg.Notifications.Webhook[0].TelegramMessageField = "body"
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid telegramMessageField: body")
+}
+
+func TestValidate_InvalidStatusVolume(t *testing.T) {
+	cfg := DefaultConfig()
+	volume := 1.5
+	info := cfg.Statuses["question"]
+	info.Volume = &volume
+	cfg.Statuses["question"] = info
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "volume for status question must be between 0.0 and 1.0")
+}
+
+func TestLoadConfig_PerStatusVolume(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{
+		"notifications": {"desktop": {"enabled": true, "volume": 1.0}},
+		"statuses": {
+			"question": {"title": "Question", "sound": "", "volume": 0.8},
+			"task_complete": {"title": "Done", "sound": ""}
+		}
+	}`
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+
+	require.NotNil(t, cfg.Statuses["question"].Volume)
+	assert.Equal(t, 0.8, *cfg.Statuses["question"].Volume)
+	assert.Nil(t, cfg.Statuses["task_complete"].Volume)
+}
+
+func TestValidate_InvalidOfflineQueueTTL(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook[0].OfflineQueue.TTL = "forever"
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid offlineQueue ttl")
+}
+
+func TestDefaultConfig_OfflineQueue(t *testing.T) {
+	cfg := DefaultConfig()
+
+	assert.True(t, cfg.Notifications.Webhook[0].OfflineQueue.Enabled)
+	assert.Equal(t, 100, cfg.Notifications.Webhook[0].OfflineQueue.MaxSize)
+	assert.Equal(t, "24h", cfg.Notifications.Webhook[0].OfflineQueue.TTL)
+}
+
+func TestPresetCasingNormalization(t *testing.T) {
+	tests := []struct {
+		name        string
+		preset      string
+		format      string
+		wantPreset  string
+		wantFormat  string
+		expectError bool
+	}{
+		{"capitalized preset", "Slack", "JSON", "slack", "json", false},
+		{"padded preset", " slack ", " text ", "slack", "text", false},
+		{"upper-case preset", "DISCORD", "json", "discord", "json", false},
+		{"invalid preset", "bogus", "json", "bogus", "json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Notifications.Webhook[0].Enabled = true
+			cfg.Notifications.Webhook[0].URL = "https://example.com/webhook"
+			cfg.Notifications.Webhook[0].Preset = tt.preset
+			cfg.Notifications.Webhook[0].Format = tt.format
+
+			// Validate accepts casing variations even before defaults are applied
+			err := cfg.Validate()
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid webhook preset")
+			} else {
+				assert.NoError(t, err)
+			}
+
+			cfg.ApplyDefaults()
+			assert.Equal(t, tt.wantPreset, cfg.Notifications.Webhook[0].Preset)
+			assert.Equal(t, tt.wantFormat, cfg.Notifications.Webhook[0].Format)
+		})
+	}
+}
+
+func TestValidate_TelegramPresetCasingRequiresChatID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].URL = "https://api.telegram.org/bot123/sendMessage"
+	cfg.Notifications.Webhook[0].Preset = "TELEGRAM"
+
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "chat_id is required")
+}
+
+func TestSessionStateMaxAgeSeconds(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, int64(60), cfg.SessionStateMaxAgeSeconds(), "short windows keep the one minute floor")
+
+	cfg.Notifications.SuppressConsecutiveIdenticalSeconds = 120
+	assert.Equal(t, int64(120), cfg.SessionStateMaxAgeSeconds())
+
+	info := cfg.Statuses["task_complete"]
+	info.CooldownSeconds = 300
+	cfg.Statuses["task_complete"] = info
+	assert.Equal(t, int64(300), cfg.SessionStateMaxAgeSeconds())
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// validWebhookPresets lists the supported webhook presets
+var validWebhookPresets = map[string]bool{
+	"slack":     true,
+	"discord":   true,
+	"telegram":  true,
+	"shortcuts": true,
+	"imessage":  true,
+	"custom":    true,
+}
+
+// validTelegramMessageFields lists the Telegram payload fields a message can be sent in
+var validTelegramMessageFields = map[string]bool{
+	"":        true, // default: text
+	"text":    true,
+	"caption": true,
+}
+
+// Priorities sent in the priorityHeader of custom webhooks
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// validWebhookFormats lists the supported custom webhook payload formats
+var validWebhookFormats = map[string]bool{
+	"json": true,
+	"text": true,
+}
+
+// WebhookList holds the configured webhook endpoints.
+// In config files it may be written as a single object (the original format) or as an array.
+type WebhookList []SingleWebhookConfig
+
+// base returns the settings a newly decoded endpoint starts from: the first
+// entry (the defaults when loading over DefaultConfig), with its own headers map
+func (l WebhookList) base() SingleWebhookConfig {
+	var wh SingleWebhookConfig
+	if len(l) > 0 {
+		wh = l[0]
+	}
+	wh.Headers = nil
+	return wh
+}
+
+// UnmarshalJSON accepts either a single webhook object or an array of them
+func (l *WebhookList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		list := make(WebhookList, 0, len(raw))
+		for i, entry := range raw {
+			wh := l.base()
+			if err := json.Unmarshal(entry, &wh); err != nil {
+				return fmt.Errorf("webhook %d: %w", i, err)
+			}
+			list = append(list, wh)
+		}
+		*l = list
+		return nil
+	}
+
+	wh := l.base()
+	if err := json.Unmarshal(data, &wh); err != nil {
+		return err
+	}
+	*l = WebhookList{wh}
+	return nil
+}
+
+// MarshalJSON writes a single endpoint as an object so existing configs keep their shape
+func (l WebhookList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]SingleWebhookConfig(l))
+}
+
+// UnmarshalYAML accepts either a single webhook mapping or a sequence of them
+func (l *WebhookList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		list := make(WebhookList, 0, len(value.Content))
+		for i, node := range value.Content {
+			wh := l.base()
+			if err := node.Decode(&wh); err != nil {
+				return fmt.Errorf("webhook %d: %w", i, err)
+			}
+			list = append(list, wh)
+		}
+		*l = list
+	default:
+		wh := l.base()
+		if err := value.Decode(&wh); err != nil {
+			return err
+		}
+		*l = WebhookList{wh}
+	}
+	return nil
+}
+
+// MarshalYAML writes a single endpoint as a mapping so existing configs keep their shape
+func (l WebhookList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []SingleWebhookConfig(l), nil
+}
+
+// Validate checks the settings of an enabled endpoint; disabled endpoints only
+// have their offline queue settings checked
+func (w SingleWebhookConfig) Validate() error {
+	preset := normalizeOption(w.Preset)
+
+	if w.Enabled {
+		if !validWebhookPresets[preset] {
+			return fmt.Errorf("invalid webhook preset: %s (must be one of: slack, discord, telegram, shortcuts, imessage, custom)", w.Preset)
+		}
+		if !validWebhookFormats[normalizeOption(w.Format)] {
+			return fmt.Errorf("invalid webhook format: %s (must be one of: json, text)", w.Format)
+		}
+
+		// iMessage sends through Messages instead of a URL
+		if preset == "imessage" {
+			if !platform.IsMacOS() {
+				return fmt.Errorf("imessage webhook preset is only supported on macOS (running on %s)", platform.OS())
+			}
+			if w.RecipientPhone == "" {
+				return fmt.Errorf("recipientPhone is required for iMessage webhook")
+			}
+		} else if w.URL == "" {
+			return fmt.Errorf("webhook URL is required when webhooks are enabled")
+		}
+
+		if preset == "telegram" && w.ChatID == "" {
+			return fmt.Errorf("chat_id is required for Telegram webhook")
+		}
+		if preset == "telegram" && !validTelegramMessageFields[w.TelegramMessageField] {
+			return fmt.Errorf("invalid telegramMessageField: %s (must be one of: text, caption)", w.TelegramMessageField)
+		}
+	}
+
+	for status, priority := range w.Priorities {
+		switch priority {
+		case PriorityHigh, PriorityNormal, PriorityLow:
+		default:
+			return fmt.Errorf("invalid webhook priority for %s: %s (must be one of: high, normal, low)", status, priority)
+		}
+	}
+
+	if w.SigningSecret != "" && w.SignatureHeader == "" {
+		return fmt.Errorf("webhook signingSecret requires signatureHeader")
+	}
+	if w.SignatureHeader != "" && w.SigningSecret == "" {
+		return fmt.Errorf("webhook signatureHeader requires signingSecret")
+	}
+
+	if n := w.MaxWebhookMessageLength; n != 0 && n < MinMessageLength {
+		return fmt.Errorf("webhook maxWebhookMessageLength must be at least %d (or 0 for the default)", MinMessageLength)
+	}
+
+	if w.OfflineQueue.MaxSize < 0 {
+		return fmt.Errorf("offlineQueue maxSize must be >= 0")
+	}
+	if ttl := w.OfflineQueue.TTL; ttl != "" {
+		if _, err := time.ParseDuration(ttl); err != nil {
+			return fmt.Errorf("invalid offlineQueue ttl: %s", ttl)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_WebhookArray(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configJSON := `{
+		"notifications": {
+			"webhook": [
+				{"enabled": true, "preset": "slack", "url": "https://hooks.slack.com/test"},
+				{"enabled": true, "preset": "discord", "url": "https://discord.com/api/webhooks/test", "retry": {"enabled": false}}
+			]
+		}
+	}`
+	require.NoError(t, os.WriteFile(configPath, []byte(configJSON), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.Notifications.Webhook, 2)
+
+	assert.Equal(t, "slack", cfg.Notifications.Webhook[0].Preset)
+	assert.Equal(t, "discord", cfg.Notifications.Webhook[1].Preset)
+
+	// Entries start from the defaults, so unset sections keep their default values
+	assert.True(t, cfg.Notifications.Webhook[0].Retry.Enabled)
+	assert.Equal(t, 3, cfg.Notifications.Webhook[0].Retry.MaxAttempts)
+	assert.False(t, cfg.Notifications.Webhook[1].Retry.Enabled)
+	assert.Equal(t, 3, cfg.Notifications.Webhook[1].Retry.MaxAttempts)
+	assert.True(t, cfg.Notifications.Webhook[1].CircuitBreaker.Enabled)
+
+	require.NoError(t, cfg.Validate())
+	assert.True(t, cfg.IsWebhookEnabled())
+}
+
+func TestLoadConfig_WebhookArrayYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	configYAML := `notifications:
+  webhook:
+    - enabled: true
+      preset: slack
+      url: https://hooks.slack.com/test
+    - enabled: false
+      preset: telegram
+      url: https://api.telegram.org/bot123/sendMessage
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.Notifications.Webhook, 2)
+	assert.Equal(t, "telegram", cfg.Notifications.Webhook[1].Preset)
+	assert.Equal(t, "24h", cfg.Notifications.Webhook[1].OfflineQueue.TTL)
+
+	// Disabled endpoints are not validated (no chat_id needed here)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestWebhookList_MarshalJSON(t *testing.T) {
+	single := WebhookList{{Enabled: true, URL: "https://example.com/a"}}
+	data, err := json.Marshal(single)
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), data[0], "single endpoint should stay an object")
+
+	multi := WebhookList{{URL: "https://example.com/a"}, {URL: "https://example.com/b"}}
+	data, err = json.Marshal(multi)
+	require.NoError(t, err)
+	assert.Equal(t, byte('['), data[0])
+
+	var decoded WebhookList
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "https://example.com/b", decoded[1].URL)
+}
+
+func TestValidate_WebhookArrayChecksEveryEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = WebhookList{
+		{Enabled: true, Preset: "slack", Format: "json", URL: "https://hooks.slack.com/test"},
+		{Enabled: true, Preset: "custom", Format: "json"},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook URL is required")
+}
+
+func TestValidate_WebhookEndpointCap(t *testing.T) {
+	endpoints := func(n int) WebhookList {
+		list := make(WebhookList, n)
+		for i := range list {
+			list[i] = SingleWebhookConfig{Enabled: true, Preset: "custom", Format: "json", URL: "https://example.com/hook"}
+		}
+		return list
+	}
+
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = endpoints(DefaultMaxWebhookEndpoints)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Webhook = endpoints(DefaultMaxWebhookEndpoints + 1)
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many webhook endpoints: 11 (the limit is 10")
+
+	// A raised cap allows more
+	cfg.Notifications.MaxWebhookEndpoints = 20
+	assert.NoError(t, cfg.Validate())
+
+	// A lowered cap is enforced too
+	cfg.Notifications.MaxWebhookEndpoints = 2
+	cfg.Notifications.Webhook = endpoints(3)
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the limit is 2")
+
+	cfg.Notifications.MaxWebhookEndpoints = -1
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "maxWebhookEndpoints must be >= 0")
+}
+
+func TestValidate_WebhookSigning(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook = WebhookList{
+		{Enabled: true, Preset: "custom", Format: "json", URL: "https://example.com/hook",
+			SigningSecret: "s3cret", SignatureHeader: "X-Hub-Signature-256"},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Webhook[0].SignatureHeader = ""
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signingSecret requires signatureHeader")
+
+	cfg.Notifications.Webhook[0].SigningSecret = ""
+	cfg.Notifications.Webhook[0].SignatureHeader = "X-Hub-Signature-256"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signatureHeader requires signingSecret")
+}
+
+func TestValidate_WebhookPriorities(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.Webhook[0].PriorityHeader = "X-Priority"
+	cfg.Notifications.Webhook[0].Priorities = map[string]string{"task_complete": "low", "question": "high"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.Webhook[0].Priorities = map[string]string{"task_complete": "urgent"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid webhook priority for task_complete: urgent")
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/schedule"
+)
+
+// WorkspaceRule enables or disables notifications for the project directories under
+// PathPrefix, optionally only during a schedule
+type WorkspaceRule struct {
+	PathPrefix string `json:"pathPrefix" yaml:"pathPrefix"`                 // e.g. "~/work" or "/home/me/work"
+	Enabled    bool   `json:"enabled" yaml:"enabled"`                       // false silences matching directories
+	Schedule   string `json:"schedule,omitempty" yaml:"schedule,omitempty"` // e.g. "09:00-18:00 Mon-Fri" (local time); empty = always
+}
+
+// Validate checks the path prefix and schedule
+func (r WorkspaceRule) Validate() error {
+	if strings.TrimSpace(r.PathPrefix) == "" {
+		return fmt.Errorf("pathPrefix is required")
+	}
+	if r.Schedule != "" {
+		if _, err := schedule.Parse(r.Schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether dir is PathPrefix or inside it. A leading "~" is the home directory.
+func (r WorkspaceRule) Matches(dir string) bool {
+	if dir == "" {
+		return false
+	}
+	prefix := strings.TrimSpace(r.PathPrefix)
+	if prefix == "~" || strings.HasPrefix(prefix, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		prefix = home + prefix[1:]
+	}
+	prefix, dir = filepath.Clean(prefix), filepath.Clean(dir)
+	if dir == prefix {
+		return true
+	}
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	return strings.HasPrefix(dir, prefix)
+}
+
+// Active reports whether the rule lets notifications through at now. Invalid schedules
+// never match (Validate reports them at load time).
+func (r WorkspaceRule) Active(now time.Time) bool {
+	if !r.Enabled {
+		return false
+	}
+	if r.Schedule == "" {
+		return true
+	}
+	s, err := schedule.Parse(r.Schedule)
+	return err == nil && s.Contains(now)
+}
+
+// IsWorkspaceAllowed reports whether hook notifications for the project directory dir
+// pass notifications.workspaceFilter at now: the first rule matching dir decides, and
+// a directory no rule matches is skipped. An empty filter allows every directory.
+func (c *Config) IsWorkspaceAllowed(dir string, now time.Time) bool {
+	rules := c.Notifications.WorkspaceFilter
+	if len(rules) == 0 {
+		return true
+	}
+	for _, rule := range rules {
+		if rule.Matches(dir) {
+			return rule.Active(now)
+		}
+	}
+	return false
+}
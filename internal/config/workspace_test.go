@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsWorkspaceAllowed(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.IsWorkspaceAllowed("/anywhere", time.Now()), "empty filter allows every directory")
+
+	cfg.Notifications.WorkspaceFilter = []WorkspaceRule{
+		{PathPrefix: "/home/me/work", Enabled: true, Schedule: "09:00-18:00 Mon-Fri"},
+		{PathPrefix: "/home/me/personal", Enabled: true},
+		{PathPrefix: "/home/me", Enabled: false},
+	}
+	monday := time.Date(2025, 1, 6, 10, 0, 0, 0, time.Local)
+	saturday := time.Date(2025, 1, 11, 10, 0, 0, 0, time.Local)
+	mondayNight := time.Date(2025, 1, 6, 20, 0, 0, 0, time.Local)
+
+	assert.True(t, cfg.IsWorkspaceAllowed("/home/me/work/api", monday))
+	assert.False(t, cfg.IsWorkspaceAllowed("/home/me/work/api", saturday), "outside the schedule")
+	assert.False(t, cfg.IsWorkspaceAllowed("/home/me/work/api", mondayNight), "outside the schedule")
+	assert.True(t, cfg.IsWorkspaceAllowed("/home/me/personal/blog", saturday))
+	assert.False(t, cfg.IsWorkspaceAllowed("/home/me/scratch", monday), "disabled rule")
+	assert.False(t, cfg.IsWorkspaceAllowed("/srv/app", monday), "no rule matches")
+}
+
+func TestWorkspaceRule_Matches(t *testing.T) {
+	rule := WorkspaceRule{PathPrefix: "/home/me/work/"}
+	assert.True(t, rule.Matches("/home/me/work"))
+	assert.True(t, rule.Matches("/home/me/work/api/"))
+	assert.False(t, rule.Matches("/home/me/workshop"))
+	assert.False(t, rule.Matches(""))
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	rule = WorkspaceRule{PathPrefix: "~/work"}
+	assert.True(t, rule.Matches(filepath.Join(home, "work", "api")))
+	assert.False(t, rule.Matches("/work/api"))
+}
+
+func TestValidate_WorkspaceFilter(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Notifications.WorkspaceFilter = []WorkspaceRule{
+		{PathPrefix: "~/work", Enabled: true, Schedule: "09:00-18:00 Mon-Fri"},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Notifications.WorkspaceFilter = append(cfg.Notifications.WorkspaceFilter, WorkspaceRule{Enabled: true})
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "workspaceFilter[1]: pathPrefix is required")
+
+	cfg.Notifications.WorkspaceFilter[1] = WorkspaceRule{PathPrefix: "/tmp", Schedule: "9-18 weekdays"}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid schedule")
+}
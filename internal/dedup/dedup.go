@@ -1,30 +1,75 @@
 package dedup
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/777genius/claude-notifications/internal/logging"
 	"github.com/777genius/claude-notifications/internal/platform"
 )
 
+// cleanupFailuresFile keeps the number of consecutive failed lock cleanups, so the
+// count carries over between hook processes. It lives outside the lock directory,
+// whose failures it counts (see SetCleanupFailuresDir).
+const cleanupFailuresFile = "claude-notifications-cleanup-failures"
+
+// Backoff between attempts in AcquireLockWithRetry
+const (
+	lockRetryInitialDelay = 10 * time.Millisecond
+	lockRetryMaxDelay     = 200 * time.Millisecond
+)
+
+// DefaultWindowSeconds is how long a lock marks later events as duplicates by default
+const DefaultWindowSeconds = 2
+
 // Manager handles deduplication using two-phase locking
 type Manager struct {
 	tempDir string
+	window  int64 // seconds a lock stays fresh
+
+	mu              sync.Mutex
+	cleanupFailures int    // consecutive failed cleanups, guarded by mu
+	failuresPath    string // where cleanupFailures is shared between processes ("" = in-memory only)
+}
+
+// NewManager creates a new deduplication manager whose locks stay fresh for
+// windowSeconds (0 = DefaultWindowSeconds)
+func NewManager(windowSeconds int) *Manager {
+	return NewManagerInDir(platform.TempDir(), windowSeconds)
 }
 
-// NewManager creates a new deduplication manager
-func NewManager() *Manager {
+// NewManagerInDir is NewManager with the lock files kept in dir
+func NewManagerInDir(dir string, windowSeconds int) *Manager {
+	if windowSeconds <= 0 {
+		windowSeconds = DefaultWindowSeconds
+	}
 	return &Manager{
-		tempDir: platform.TempDir(),
+		tempDir: dir,
+		window:  int64(windowSeconds),
 	}
 }
 
+// SetCleanupFailuresDir shares the count of failed cleanups with other hook processes
+// through a file in dir, which should not be the lock directory: when cleanups fail
+// because it isn't writable, the count couldn't be stored there either
+func (m *Manager) SetCleanupFailuresDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failuresPath = filepath.Join(dir, cleanupFailuresFile)
+}
+
 // getLockPath returns the path to the lock file for a session and hook event
 // If hookEvent is empty, uses a global lock for the session (backward compatibility)
 func (m *Manager) getLockPath(sessionID string, hookEvent ...string) string {
+	sessionID = platform.SanitizeFileName(sessionID)
 	if len(hookEvent) > 0 && hookEvent[0] != "" {
-		return filepath.Join(m.tempDir, fmt.Sprintf("claude-notification-%s-%s.lock", sessionID, hookEvent[0]))
+		return filepath.Join(m.tempDir, fmt.Sprintf("claude-notification-%s-%s.lock", sessionID, platform.SanitizeFileName(hookEvent[0])))
 	}
 	return filepath.Join(m.tempDir, fmt.Sprintf("claude-notification-%s.lock", sessionID))
 }
@@ -42,8 +87,8 @@ func (m *Manager) CheckEarlyDuplicate(sessionID string, hookEvent ...string) boo
 	// Check lock age
 	age := platform.FileAge(lockPath)
 
-	// If mtime is unavailable (Windows issue) or lock is fresh (<2s), treat as duplicate
-	if age == -1 || (age >= 0 && age < 2) {
+	// If mtime is unavailable (Windows issue) or lock is fresh (within the window), treat as duplicate
+	if age == -1 || (age >= 0 && age < m.window) {
 		return true
 	}
 
@@ -53,25 +98,101 @@ func (m *Manager) CheckEarlyDuplicate(sessionID string, hookEvent ...string) boo
 // AcquireLock performs Phase 2 lock acquisition
 // Returns true if lock was successfully acquired
 // hookEvent parameter is optional - if provided, uses hook-specific lock file
+//
+// Creating the lock file is atomic, so only one process can acquire a new lock. A stale lock
+// is taken over under an advisory file lock (flock on Unix, LockFileEx on Windows), so
+// concurrent hook processes can't both replace it; where advisory locks are unavailable
+// (e.g. some network filesystems) the stale lock is removed and recreated instead.
 func (m *Manager) AcquireLock(sessionID string, hookEvent ...string) (bool, error) {
 	lockPath := m.getLockPath(sessionID, hookEvent...)
 
-	// Try to create lock atomically
-	created, err := platform.AtomicCreateFile(lockPath)
+	for attempt := 0; attempt < maxLockAttempts; attempt++ {
+		// Try to create lock atomically
+		created, err := platform.AtomicCreateFile(lockPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if created {
+			// Lock acquired successfully
+			return true, nil
+		}
+
+		// Lock exists - take it over if it's stale
+		acquired, err := m.takeOverStaleLock(lockPath)
+		switch {
+		case errors.Is(err, errLockFileReplaced):
+			// Released or cleaned up meanwhile - start over
+			continue
+		case errors.Is(err, errFlockUnsupported):
+			logging.Debug("Advisory lock unavailable, falling back to lock file age: %v", err)
+			return m.replaceStaleLock(lockPath)
+		}
+		return acquired, err
+	}
+
+	// The lock file keeps changing under us, so another process is busy with it
+	return false, nil
+}
+
+// maxLockAttempts bounds how often AcquireLock starts over when the lock file is
+// removed or replaced while it is being taken over
+const maxLockAttempts = 3
+
+var (
+	// errFlockUnsupported means advisory locks can't be taken on the lock file
+	errFlockUnsupported = errors.New("advisory file locks are not supported")
+	// errLockFileReplaced means the lock file was removed or replaced before it was locked
+	errLockFileReplaced = errors.New("lock file was replaced")
+)
+
+// takeOverStaleLock refreshes an existing lock file if it is stale, holding an advisory
+// lock on it so concurrent processes check and refresh it one at a time: the first one
+// refreshes it, the others then see a fresh lock
+func (m *Manager) takeOverStaleLock(lockPath string) (bool, error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return false, errLockFileReplaced
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	if err := platform.LockFile(f); err != nil {
+		return false, fmt.Errorf("%w: %v", errFlockUnsupported, err)
+	}
+	defer func() { _ = platform.UnlockFile(f) }()
+
+	// The file may have been removed (ReleaseLock, Cleanup) and recreated while we waited
+	info, err := f.Stat()
 	if err != nil {
-		return false, fmt.Errorf("failed to create lock file: %w", err)
+		return false, fmt.Errorf("failed to stat lock file: %w", err)
+	}
+	if current, err := os.Stat(lockPath); err != nil || !os.SameFile(info, current) {
+		return false, errLockFileReplaced
 	}
 
-	if created {
-		// Lock acquired successfully
-		return true, nil
+	// If lock is fresh (within the window), we're a duplicate
+	age := platform.CurrentTimestamp() - info.ModTime().Unix()
+	if age >= 0 && age < m.window {
+		return false, nil
 	}
 
-	// Lock exists - check if it's stale
+	now := time.Now()
+	if err := os.Chtimes(lockPath, now, now); err != nil {
+		return false, fmt.Errorf("failed to refresh lock file: %w", err)
+	}
+	return true, nil
+}
+
+// replaceStaleLock is the fallback of takeOverStaleLock without advisory locks: a stale
+// lock file is removed and created again, which two processes can race on
+func (m *Manager) replaceStaleLock(lockPath string) (bool, error) {
 	age := platform.FileAge(lockPath)
 
-	// If lock is fresh (<2s), we're a duplicate
-	if age >= 0 && age < 2 {
+	// If lock is fresh (within the window), we're a duplicate
+	if age >= 0 && age < m.window {
 		return false, nil
 	}
 
@@ -79,7 +200,7 @@ func (m *Manager) AcquireLock(sessionID string, hookEvent ...string) (bool, erro
 	_ = os.Remove(lockPath) // Ignore error - someone else might have deleted it
 
 	// Try again
-	created, err = platform.AtomicCreateFile(lockPath)
+	created, err := platform.AtomicCreateFile(lockPath)
 	if err != nil {
 		return false, fmt.Errorf("failed to create lock file after cleanup: %w", err)
 	}
@@ -87,6 +208,30 @@ func (m *Manager) AcquireLock(sessionID string, hookEvent ...string) (bool, erro
 	return created, nil
 }
 
+// AcquireLockWithRetry is the blocking variant of AcquireLock: while the lock is held it
+// retries with exponential backoff (10ms, doubling up to 200ms) until maxWait has passed.
+// It succeeds once the holder releases the lock or the lock ages out of the dedup window,
+// so an event arriving just before the window ends isn't dropped. Returns false if the
+// lock is still held at the deadline.
+func (m *Manager) AcquireLockWithRetry(hookEvent, sessionID string, maxWait time.Duration) (bool, error) {
+	deadline := time.Now().Add(maxWait)
+	delay := lockRetryInitialDelay
+
+	for {
+		acquired, err := m.AcquireLock(sessionID, hookEvent)
+		if err != nil || acquired {
+			return acquired, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		time.Sleep(min(delay, remaining))
+		delay = min(delay*2, lockRetryMaxDelay)
+	}
+}
+
 // ReleaseLock releases a lock (optional, locks are cleaned up automatically)
 // hookEvent parameter is optional - if provided, releases hook-specific lock file
 func (m *Manager) ReleaseLock(sessionID string, hookEvent ...string) error {
@@ -98,8 +243,51 @@ func (m *Manager) ReleaseLock(sessionID string, hookEvent ...string) error {
 }
 
 // Cleanup cleans up old lock files (older than maxAge seconds)
+// and counts consecutive failures (see CleanupFailures)
 func (m *Manager) Cleanup(maxAge int64) error {
-	return platform.CleanupOldFiles(m.tempDir, "claude-notification-*.lock", maxAge)
+	err := platform.CleanupOldFiles(m.tempDir, "claude-notification-*.lock", maxAge)
+	m.recordCleanup(err)
+	return err
+}
+
+// CleanupFailures returns how many lock cleanups in a row have failed, across hook processes
+func (m *Manager) CleanupFailures() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return max(m.cleanupFailures, m.readCleanupFailures())
+}
+
+// recordCleanup resets the failure count after a successful cleanup, or increments it.
+// The count is also kept in memory in case its file can't be written.
+func (m *Manager) recordCleanup(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.cleanupFailures = 0
+		if m.failuresPath != "" {
+			_ = os.Remove(m.failuresPath)
+		}
+		return
+	}
+
+	m.cleanupFailures = max(m.cleanupFailures, m.readCleanupFailures()) + 1
+	if m.failuresPath != "" {
+		_ = os.WriteFile(m.failuresPath, []byte(strconv.Itoa(m.cleanupFailures)), 0644)
+	}
+}
+
+// readCleanupFailures returns the failure count stored by earlier processes (0 if none)
+func (m *Manager) readCleanupFailures() int {
+	if m.failuresPath == "" {
+		return 0
+	}
+	data, err := os.ReadFile(m.failuresPath)
+	if err != nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return count
 }
 
 // CleanupForSession cleans up lock file for a specific session
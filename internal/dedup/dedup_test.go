@@ -1,19 +1,25 @@
 package dedup
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/777genius/claude-notifications/internal/platform"
 )
 
 func TestCheckEarlyDuplicate(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 
 	// First check should be false (no lock exists)
 	isDup := mgr.CheckEarlyDuplicate("test-session")
@@ -39,7 +45,7 @@ func TestCheckEarlyDuplicate(t *testing.T) {
 }
 
 func TestAcquireLock(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 
 	// First acquisition should succeed
 	acquired, err := mgr.AcquireLock("test-session")
@@ -67,7 +73,7 @@ func TestAcquireLock(t *testing.T) {
 }
 
 func TestAcquireLockConcurrent(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 	sessionID := "concurrent-test"
 
 	// Cleanup
@@ -99,8 +105,174 @@ func TestAcquireLockConcurrent(t *testing.T) {
 	assert.Equal(t, 1, successCount)
 }
 
+// helperDirEnv makes TestAcquireLockHelperProcess run as a child of TestAcquireLockConcurrentProcesses
+const helperDirEnv = "DEDUP_HELPER_DIR"
+
+// TestAcquireLockHelperProcess acquires the shared lock once in a child process and prints the result
+func TestAcquireLockHelperProcess(t *testing.T) {
+	dir := os.Getenv(helperDirEnv)
+	if dir == "" {
+		t.Skip("run by TestAcquireLockConcurrentProcesses")
+	}
+
+	// Wait for the start signal, so all children race for the lock at once
+	start := filepath.Join(dir, "start")
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, err := os.Stat(start); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no start signal")
+		}
+	}
+
+	mgr := &Manager{tempDir: dir, window: DefaultWindowSeconds}
+	acquired, err := mgr.AcquireLock("subprocess-session", "Stop")
+	require.NoError(t, err)
+	fmt.Printf("acquired=%v\n", acquired)
+}
+
+func TestAcquireLockConcurrentProcesses(t *testing.T) {
+	tests := []struct {
+		name    string
+		lockAge time.Duration // -1 = no lock file yet
+	}{
+		{"new lock", -1},
+		{"stale lock", time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.lockAge >= 0 {
+				lockPath := (&Manager{tempDir: dir}).getLockPath("subprocess-session", "Stop")
+				require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+				lockTime := time.Now().Add(-tt.lockAge)
+				require.NoError(t, os.Chtimes(lockPath, lockTime, lockTime))
+			}
+
+			const processes = 8
+			cmds := make([]*exec.Cmd, processes)
+			outputs := make([]*bytes.Buffer, processes)
+			for i := range cmds {
+				outputs[i] = &bytes.Buffer{}
+				cmds[i] = exec.Command(os.Args[0], "-test.run=^TestAcquireLockHelperProcess$", "-test.v")
+				cmds[i].Env = append(os.Environ(), helperDirEnv+"="+dir)
+				cmds[i].Stdout = outputs[i]
+				cmds[i].Stderr = outputs[i]
+				require.NoError(t, cmds[i].Start())
+			}
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "start"), nil, 0644))
+
+			successCount := 0
+			for i, cmd := range cmds {
+				require.NoError(t, cmd.Wait(), outputs[i].String())
+				switch {
+				case strings.Contains(outputs[i].String(), "acquired=true"):
+					successCount++
+				case !strings.Contains(outputs[i].String(), "acquired=false"):
+					t.Errorf("unexpected helper output:\n%s", outputs[i])
+				}
+			}
+
+			// Only one process should succeed
+			assert.Equal(t, 1, successCount)
+		})
+	}
+}
+
+func TestReplaceStaleLock(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir(), window: DefaultWindowSeconds}
+	lockPath := mgr.getLockPath("fallback-session", "Stop")
+	require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+
+	// A fresh lock is kept
+	acquired, err := mgr.replaceStaleLock(lockPath)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// A stale lock is recreated
+	oldTime := time.Now().Add(-3 * time.Second)
+	require.NoError(t, os.Chtimes(lockPath, oldTime, oldTime))
+	acquired, err = mgr.replaceStaleLock(lockPath)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Less(t, platform.FileAge(lockPath), int64(DefaultWindowSeconds))
+}
+
+func TestAcquireLockWithRetry(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir(), window: DefaultWindowSeconds}
+
+	// A free lock is acquired on the first attempt
+	acquired, err := mgr.AcquireLockWithRetry("Stop", "retry-session", 500*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// A lock that stays held gives up at the deadline
+	start := time.Now()
+	acquired, err = mgr.AcquireLockWithRetry("Stop", "retry-session", 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// A lock released while waiting is picked up
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = mgr.ReleaseLock("retry-session", "Stop")
+	}()
+	acquired, err = mgr.AcquireLockWithRetry("Stop", "retry-session", 500*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// A lock that ages out of the dedup window while waiting is taken over
+	lockPath := mgr.getLockPath("aging-session", "Stop")
+	require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+	aged := time.Now().Add(-time.Duration(DefaultWindowSeconds-1) * time.Second)
+	require.NoError(t, os.Chtimes(lockPath, aged, aged))
+	acquired, err = mgr.AcquireLockWithRetry("Stop", "aging-session", 1500*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestDedupWindow(t *testing.T) {
+	tests := []struct {
+		name          string
+		windowSeconds int
+		lockAge       time.Duration
+		wantDuplicate bool
+	}{
+		{"default window, fresh lock", 0, 0, true},
+		{"default window, stale lock", 0, 3 * time.Second, false},
+		{"short window, 2s old lock", 1, 2 * time.Second, false},
+		{"long window, 3s old lock", 5, 3 * time.Second, true},
+		{"long window, stale lock", 5, 6 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr := NewManager(tt.windowSeconds)
+			mgr.tempDir = t.TempDir()
+
+			acquired, err := mgr.AcquireLock("window-session", "Stop")
+			require.NoError(t, err)
+			require.True(t, acquired)
+
+			lockPath := mgr.getLockPath("window-session", "Stop")
+			lockTime := time.Now().Add(-tt.lockAge)
+			require.NoError(t, os.Chtimes(lockPath, lockTime, lockTime))
+
+			assert.Equal(t, tt.wantDuplicate, mgr.CheckEarlyDuplicate("window-session", "Stop"), "CheckEarlyDuplicate")
+
+			acquired, err = mgr.AcquireLock("window-session", "Stop")
+			require.NoError(t, err)
+			assert.Equal(t, !tt.wantDuplicate, acquired, "AcquireLock")
+		})
+	}
+}
+
 func TestReleaseLock(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 
 	// Acquire lock
 	acquired, err := mgr.AcquireLock("test-session")
@@ -124,7 +296,7 @@ func TestReleaseLock(t *testing.T) {
 }
 
 func TestCleanup(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 	tempDir := mgr.tempDir
 
 	// Create old lock
@@ -155,8 +327,46 @@ func TestCleanup(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCleanupFailures(t *testing.T) {
+	tempDir := t.TempDir()
+	failuresDir := t.TempDir()
+	newManager := func() *Manager {
+		mgr := NewManagerInDir(tempDir, DefaultWindowSeconds)
+		mgr.SetCleanupFailuresDir(failuresDir)
+		return mgr
+	}
+	mgr := newManager()
+
+	// A non-empty directory matching the lock pattern can't be removed, even by root
+	stuck := filepath.Join(tempDir, "claude-notification-stuck.lock")
+	require.NoError(t, os.MkdirAll(filepath.Join(stuck, "child"), 0755))
+	oldTime := time.Now().Add(-2 * time.Minute)
+	require.NoError(t, os.Chtimes(stuck, oldTime, oldTime))
+
+	for want := 1; want <= 3; want++ {
+		assert.Error(t, mgr.Cleanup(60))
+		assert.Equal(t, want, mgr.CleanupFailures())
+	}
+
+	// The count is stored outside the lock directory it reports on
+	assert.FileExists(t, filepath.Join(failuresDir, cleanupFailuresFile))
+	assert.NoFileExists(t, filepath.Join(tempDir, cleanupFailuresFile))
+
+	// The count carries over to the next hook process
+	next := newManager()
+	assert.Equal(t, 3, next.CleanupFailures())
+	assert.Error(t, next.Cleanup(60))
+	assert.Equal(t, 4, next.CleanupFailures())
+
+	// A successful cleanup resets it
+	require.NoError(t, os.RemoveAll(stuck))
+	assert.NoError(t, next.Cleanup(60))
+	assert.Equal(t, 0, next.CleanupFailures())
+	assert.Equal(t, 0, newManager().CleanupFailures())
+}
+
 func TestCleanupForSession(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 
 	sessionID := "test-session-123"
 
@@ -189,7 +399,7 @@ func TestCleanupForSession(t *testing.T) {
 }
 
 func TestGetLockPath_WithHookEvent(t *testing.T) {
-	mgr := NewManager()
+	mgr := NewManager(DefaultWindowSeconds)
 	sessionID := "test-session-456"
 
 	// Test without hookEvent
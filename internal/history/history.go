@@ -0,0 +1,208 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+// fileName is the history file created in the user's home directory
+const fileName = ".claude-notifications-history.jsonl"
+
+// HistoryEntry is one delivered notification, stored as a JSONL line
+type HistoryEntry struct {
+	SessionID  string         `json:"session_id"`
+	Status     string         `json:"status"`
+	Duration   time.Duration  `json:"duration"` // response time from transcript timestamps (nanoseconds); 0 if unknown
+	ToolCount  int            `json:"tool_count"`
+	Tools      map[string]int `json:"tools,omitempty"` // uses per tool name
+	Timestamp  time.Time      `json:"timestamp"`
+	ProjectDir string         `json:"project_dir"`
+}
+
+// DefaultPath returns the history file location in the user's home directory
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, fileName)
+}
+
+// Append appends entry to the history file at DefaultPath
+func Append(entry HistoryEntry) error {
+	return AppendTo(DefaultPath(), entry)
+}
+
+// AppendTo appends entry to the history file at path, creating it if needed.
+// Each entry is written with a single O_APPEND write, so concurrent hooks don't interleave lines.
+func AppendTo(path string, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// RotatedPath is where the history file at path is moved when it is rotated
+func RotatedPath(path string) string {
+	return path + ".1"
+}
+
+// AppendRotating appends entry like AppendTo, first moving the file to RotatedPath
+// (replacing the previous rotation) once it has reached maxBytes
+func AppendRotating(path string, entry HistoryEntry, maxBytes int64) error {
+	if info, err := os.Stat(path); err == nil && maxBytes > 0 && info.Size() >= maxBytes {
+		// Another hook may have rotated it first
+		if err := os.Rename(path, RotatedPath(path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate history file: %w", err)
+		}
+	}
+	return AppendTo(path, entry)
+}
+
+// ReadAll returns the entries of the rotated history file followed by those of the file at path
+func ReadAll(path string) ([]HistoryEntry, error) {
+	rotated, err := Read(RotatedPath(path))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return append(rotated, entries...), nil
+}
+
+// Read returns all entries in the history file at path, skipping malformed lines.
+// A missing file has no entries.
+func Read(path string) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return entries, nil
+}
+
+// Outcome statuses counted by Summary's success rate
+var (
+	successStatuses = map[string]bool{
+		string(analyzer.StatusTaskComplete):   true,
+		string(analyzer.StatusReviewComplete): true,
+	}
+	failureStatuses = map[string]bool{
+		string(analyzer.StatusError):               true,
+		string(analyzer.StatusAPIError):            true,
+		string(analyzer.StatusSessionLimitReached): true,
+	}
+)
+
+// ToolUsage is a tool name and how often it was used
+type ToolUsage struct {
+	Name  string
+	Count int
+}
+
+// Summary aggregates history entries
+type Summary struct {
+	Notifications int
+	Sessions      int            // distinct session IDs
+	Statuses      map[string]int // notifications per status
+	// SuccessRate is the share of finished tasks that succeeded (task or review complete)
+	// rather than failed (error, API error, session limit); 0 if no task finished
+	SuccessRate     float64
+	AverageDuration time.Duration // over entries with a known duration
+	Tools           []ToolUsage   // most used first
+}
+
+// Summarize aggregates entries into a Summary
+func Summarize(entries []HistoryEntry) Summary {
+	summary := Summary{
+		Notifications: len(entries),
+		Statuses:      make(map[string]int),
+	}
+
+	sessions := make(map[string]bool)
+	tools := make(map[string]int)
+	var successes, outcomes, timed int
+	var totalDuration time.Duration
+
+	for _, entry := range entries {
+		sessions[entry.SessionID] = true
+		summary.Statuses[entry.Status]++
+
+		if successStatuses[entry.Status] {
+			successes++
+			outcomes++
+		} else if failureStatuses[entry.Status] {
+			outcomes++
+		}
+
+		if entry.Duration > 0 {
+			totalDuration += entry.Duration
+			timed++
+		}
+
+		for name, count := range entry.Tools {
+			tools[name] += count
+		}
+	}
+
+	summary.Sessions = len(sessions)
+	if outcomes > 0 {
+		summary.SuccessRate = float64(successes) / float64(outcomes)
+	}
+	if timed > 0 {
+		summary.AverageDuration = totalDuration / time.Duration(timed)
+	}
+
+	for name, count := range tools {
+		summary.Tools = append(summary.Tools, ToolUsage{Name: name, Count: count})
+	}
+	sort.Slice(summary.Tools, func(i, j int) bool {
+		if summary.Tools[i].Count != summary.Tools[j].Count {
+			return summary.Tools[i].Count > summary.Tools[j].Count
+		}
+		return summary.Tools[i].Name < summary.Tools[j].Name
+	})
+
+	return summary
+}
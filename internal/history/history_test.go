@@ -0,0 +1,152 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendToAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []HistoryEntry{
+		{SessionID: "s1", Status: "task_complete", Duration: 90 * time.Second, ToolCount: 3, Tools: map[string]int{"Edit": 2, "Bash": 1}, Timestamp: now, ProjectDir: "/repo"},
+		{SessionID: "s1", Status: "question", Timestamp: now.Add(time.Minute), ProjectDir: "/repo"},
+	}
+	for _, entry := range entries {
+		if err := AppendTo(path, entry); err != nil {
+			t.Fatalf("AppendTo() error = %v", err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Read() returned %d entries, want 2", len(got))
+	}
+	if got[0].Duration != 90*time.Second || got[0].Tools["Edit"] != 2 || !got[0].Timestamp.Equal(now) {
+		t.Errorf("first entry = %+v, want %+v", got[0], entries[0])
+	}
+	if got[1].Status != "question" || got[1].Tools != nil {
+		t.Errorf("second entry = %+v, want %+v", got[1], entries[1])
+	}
+}
+
+func TestAppendRotating(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	entry := HistoryEntry{SessionID: "s1", Status: "task_complete"}
+
+	// Each line is well over 10 bytes, so every append after the first rotates
+	for i := 0; i < 3; i++ {
+		entry.ToolCount = i
+		if err := AppendRotating(path, entry, 10); err != nil {
+			t.Fatalf("AppendRotating() error = %v", err)
+		}
+	}
+
+	current, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(current) != 1 || current[0].ToolCount != 2 {
+		t.Errorf("current file = %+v, want only the last entry", current)
+	}
+
+	all, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	// The first entry was dropped by the second rotation
+	if len(all) != 2 || all[0].ToolCount != 1 || all[1].ToolCount != 2 {
+		t.Errorf("ReadAll() = %+v, want the last two entries in order", all)
+	}
+}
+
+func TestAppendRotatingBelowLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for i := 0; i < 3; i++ {
+		if err := AppendRotating(path, HistoryEntry{SessionID: "s1"}, 1024); err != nil {
+			t.Fatalf("AppendRotating() error = %v", err)
+		}
+	}
+
+	if _, err := os.Stat(RotatedPath(path)); !os.IsNotExist(err) {
+		t.Errorf("expected no rotation below the limit, stat error = %v", err)
+	}
+	if entries, _ := Read(path); len(entries) != 3 {
+		t.Errorf("Read() returned %d entries, want 3", len(entries))
+	}
+}
+
+func TestReadSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"session_id":"s1","status":"task_complete"}
+not json
+
+{"session_id":"s2","status":"error"}
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Read() returned %d entries, want 2", len(entries))
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	entries, err := Read(filepath.Join(t.TempDir(), "missing.jsonl"))
+	if err != nil || entries != nil {
+		t.Errorf("Read() = %v, %v; want no entries and no error", entries, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	entries := []HistoryEntry{
+		{SessionID: "s1", Status: "task_complete", Duration: 60 * time.Second, Tools: map[string]int{"Edit": 3, "Read": 1}},
+		{SessionID: "s1", Status: "question"},
+		{SessionID: "s2", Status: "task_complete", Duration: 120 * time.Second, Tools: map[string]int{"Bash": 2, "Read": 1}},
+		{SessionID: "s3", Status: "error", Tools: map[string]int{"Bash": 1}},
+	}
+
+	s := Summarize(entries)
+
+	if s.Notifications != 4 || s.Sessions != 3 {
+		t.Errorf("Notifications, Sessions = %d, %d; want 4, 3", s.Notifications, s.Sessions)
+	}
+	if s.Statuses["task_complete"] != 2 || s.Statuses["question"] != 1 || s.Statuses["error"] != 1 {
+		t.Errorf("Statuses = %v", s.Statuses)
+	}
+	// 2 of 3 finished tasks succeeded; questions don't count
+	if want := 2.0 / 3.0; s.SuccessRate != want {
+		t.Errorf("SuccessRate = %v, want %v", s.SuccessRate, want)
+	}
+	if s.AverageDuration != 90*time.Second {
+		t.Errorf("AverageDuration = %v, want 1m30s", s.AverageDuration)
+	}
+
+	wantTools := []ToolUsage{{"Bash", 3}, {"Edit", 3}, {"Read", 2}}
+	if len(s.Tools) != len(wantTools) {
+		t.Fatalf("Tools = %v, want %v", s.Tools, wantTools)
+	}
+	for i := range wantTools {
+		if s.Tools[i] != wantTools[i] {
+			t.Errorf("Tools[%d] = %v, want %v", i, s.Tools[i], wantTools[i])
+		}
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	s := Summarize(nil)
+	if s.Notifications != 0 || s.SuccessRate != 0 || s.AverageDuration != 0 || len(s.Tools) != 0 {
+		t.Errorf("Summarize(nil) = %+v, want zero values", s)
+	}
+}
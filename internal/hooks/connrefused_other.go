@@ -0,0 +1,13 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isConnRefused reports whether err means nothing is listening on the socket
+func isConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package hooks
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// isConnRefused reports whether err means nothing is listening on the socket
+func isConnRefused(err error) bool {
+	return errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -2,21 +2,29 @@ package hooks
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/dedup"
 	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/history"
 	"github.com/777genius/claude-notifications/internal/logging"
 	"github.com/777genius/claude-notifications/internal/notifier"
 	"github.com/777genius/claude-notifications/internal/platform"
 	"github.com/777genius/claude-notifications/internal/sessionname"
 	"github.com/777genius/claude-notifications/internal/state"
 	"github.com/777genius/claude-notifications/internal/summary"
+	"github.com/777genius/claude-notifications/internal/throttle"
 	"github.com/777genius/claude-notifications/internal/webhook"
+	"github.com/777genius/claude-notifications/pkg/jsonl"
 )
 
 // HookData represents the data received from Claude Code hooks
@@ -26,17 +34,68 @@ type HookData struct {
 	CWD            string `json:"cwd"`
 	ToolName       string `json:"tool_name,omitempty"`
 	HookEventName  string `json:"hook_event_name,omitempty"`
+	Message        string `json:"message,omitempty"` // Notification hook: e.g. "Claude needs your permission to use Bash"
+
+	// ToolInput is the PreToolUse tool's input, e.g. {"command": "npm install"} for Bash
+	ToolInput map[string]interface{} `json:"tool_input,omitempty"`
+
+	// Env is the environment of the process that sent the hook to the socket daemon, e.g.
+	// {"TMUX_PANE": "%3"}, used by clickToFocus and autoFocus. Not set by Claude Code.
+	Env map[string]string `json:"env,omitempty"`
+
+	// The transcript's tail once read, shared by the status analysis, the summary and history
+	transcript       []jsonl.Message
+	transcriptErr    error
+	transcriptParsed bool
+}
+
+// transcriptMessages returns the tail of the transcript, parsing it only on the first call
+func (hd *HookData) transcriptMessages() ([]jsonl.Message, error) {
+	if !hd.transcriptParsed {
+		hd.transcript, hd.transcriptErr = jsonl.TailMessages(hd.TranscriptPath, analyzer.TranscriptTailLines)
+		hd.transcriptParsed = true
+	}
+	return hd.transcript, hd.transcriptErr
+}
+
+// Environment variables consulted when the hook JSON omits a field,
+// e.g. when the plugin runs under a non-standard Claude Code wrapper
+const (
+	envSessionID      = "CLAUDE_SESSION_ID"
+	envTranscriptPath = "CLAUDE_TRANSCRIPT_PATH"
+)
+
+// resolveHookData fills fields missing from the hook JSON from the environment.
+// A session ID that is still empty becomes "unknown".
+func resolveHookData(hd *HookData) {
+	if hd.SessionID == "" {
+		hd.SessionID = os.Getenv(envSessionID)
+	}
+	if hd.SessionID == "" {
+		hd.SessionID = "unknown"
+		logging.Warn("Session ID is empty, using 'unknown'")
+	}
+
+	if hd.TranscriptPath == "" {
+		hd.TranscriptPath = os.Getenv(envTranscriptPath)
+	}
 }
 
 // notifierInterface defines the interface for sending desktop notifications
 type notifierInterface interface {
-	SendDesktop(status analyzer.Status, message string) error
+	SendDesktopWithFocus(status analyzer.Status, message string, focus notifier.FocusContext) error
+	SendNotice(title, message string) error
 	Close() error
+	Shutdown() error
 }
 
 // webhookInterface defines the interface for sending webhook notifications
 type webhookInterface interface {
 	SendAsync(status analyzer.Status, message, sessionID string)
+	Wait(timeout time.Duration) error
+	GetMetrics() webhook.Stats
+	FlushStats() error
+	Close() error
 }
 
 // Handler handles hook events
@@ -47,6 +106,14 @@ type Handler struct {
 	notifierSvc notifierInterface
 	webhookSvc  webhookInterface
 	pluginRoot  string
+
+	// historyPath is the file delivered notifications are recorded in (empty = disabled)
+	historyPath string
+
+	// keepAlive skips closing the notifier after each hook (long-lived socket server)
+	keepAlive bool
+	// throttleFlushes tracks throttle windows being flushed in the background (keepAlive mode)
+	throttleFlushes sync.WaitGroup
 }
 
 // NewHandler creates a new hook handler
@@ -62,44 +129,137 @@ func NewHandler(pluginRoot string) (*Handler, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	webhookSvc := webhook.New(cfg)
+	webhookSvc.SetStatsPath(webhook.DefaultStatsPath())
+
+	// Lock cleanup failures are counted in the plugin root, since the temp dir
+	// holding the locks may be the thing that isn't writable
+	dedupMgr := dedup.NewManager(cfg.Notifications.DedupWindowSeconds)
+	dedupMgr.SetCleanupFailuresDir(pluginRoot)
+
 	return &Handler{
 		cfg:         cfg,
-		dedupMgr:    dedup.NewManager(),
+		dedupMgr:    dedupMgr,
 		stateMgr:    state.NewManager(),
 		notifierSvc: notifier.New(cfg),
-		webhookSvc:  webhook.New(cfg),
+		webhookSvc:  webhookSvc,
 		pluginRoot:  pluginRoot,
+		historyPath: history.DefaultPath(),
 	}, nil
 }
 
+// testNotificationTimeout bounds how long SendTest waits for the webhook
+const testNotificationTimeout = 30 * time.Second
+
+// SendTest sends the built-in test notification through the regular delivery path
+// (desktop and, if enabled, webhook) and waits for the webhook to complete
+func (h *Handler) SendTest() error {
+	if _, exists := h.cfg.GetStatusInfo(string(analyzer.StatusTest)); !exists {
+		return fmt.Errorf("status %q is not configured", analyzer.StatusTest)
+	}
+
+	logging.Debug("=== Sending test notification ===")
+	h.sendNotifications(analyzer.StatusTest, "Notifications are working", "send-test", h.focusContext(nil))
+
+	if h.cfg.IsWebhookEnabled() {
+		if err := h.webhookSvc.Wait(testNotificationTimeout); err != nil {
+			return fmt.Errorf("test webhook did not complete: %w", err)
+		}
+	}
+
+	if !h.keepAlive {
+		if err := h.notifierSvc.Close(); err != nil {
+			logging.Warn("Failed to close notifier: %v", err)
+		}
+	}
+	return nil
+}
+
+// FlushMetrics merges webhook metrics not yet recorded into the shared stats file
+func (h *Handler) FlushMetrics() error {
+	return h.webhookSvc.FlushStats()
+}
+
+// Config returns the handler's configuration
+func (h *Handler) Config() *config.Config {
+	return h.cfg
+}
+
+// KeepAlive marks the handler as long-lived (e.g. a transcript watcher), so the notifier
+// stays open between notifications until Close is called
+func (h *Handler) KeepAlive() {
+	h.keepAlive = true
+}
+
+// Close waits for throttled notifications still being flushed, drains in-flight
+// webhooks and shuts the notifier down, fading out sounds still playing
+func (h *Handler) Close() error {
+	h.throttleFlushes.Wait()
+	return errors.Join(h.webhookSvc.Close(), h.notifierSvc.Shutdown())
+}
+
+// NotifyTranscript sends the notification for a status determined outside a hook, with
+// the message generated from transcriptPath. It is deduplicated and subject to the same
+// cooldowns as the Stop hook, so a watcher running next to the hooks doesn't notify twice.
+func (h *Handler) NotifyTranscript(transcriptPath string, status analyzer.Status) {
+	if !h.cfg.IsAnyNotificationEnabled() {
+		return
+	}
+	hookData := &HookData{
+		TranscriptPath: transcriptPath,
+		SessionID:      sessionIDFromTranscript(transcriptPath),
+	}
+	if err := h.notify("Stop", hookData, status); err != nil {
+		logging.Warn("Failed to notify for %s: %v", transcriptPath, err)
+	}
+}
+
+// lockWaitTimeout bounds how long HandleHook waits for a held notification lock,
+// so a near-simultaneous event isn't dropped just because it lost the race
+const lockWaitTimeout = 500 * time.Millisecond
+
 // HandleHook handles a hook event
 func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 	// Add panic recovery for robustness
 	defer errorhandler.HandlePanic()
 
-	// Ensure notifier resources are cleaned up when function exits
-	defer func() {
-		if err := h.notifierSvc.Close(); err != nil {
-			logging.Warn("Failed to close notifier: %v", err)
-		}
-	}()
+	// Ensure notifier resources are cleaned up when function exits, fading out sounds
+	// still playing (with desktop.fadeOutMs) rather than holding the hook open
+	if !h.keepAlive {
+		defer func() {
+			if err := h.notifierSvc.Shutdown(); err != nil {
+				logging.Warn("Failed to shut down notifier: %v", err)
+			}
+		}()
+		// Let in-flight webhooks finish before the hook process exits
+		defer func() {
+			if err := h.webhookSvc.Close(); err != nil {
+				logging.Warn("Failed to close webhook sender: %v", err)
+			}
+		}()
+	}
 
 	logging.SetPrefix(fmt.Sprintf("PID:%d", os.Getpid()))
 	logging.Debug("=== Hook triggered: %s ===", hookEvent)
 
+	if h.cfg.IsHookEventIgnored(hookEvent) {
+		logging.Debug("Hook event %s is ignored by config, exiting", hookEvent)
+		return nil
+	}
+
 	// Parse hook data
 	var hookData HookData
 	if err := json.NewDecoder(input).Decode(&hookData); err != nil {
 		return fmt.Errorf("failed to parse hook data: %w", err)
 	}
+	resolveHookData(&hookData)
 
 	logging.Debug("Hook data: session=%s, transcript=%s, tool=%s",
 		hookData.SessionID, hookData.TranscriptPath, hookData.ToolName)
 
-	// Validate session ID
-	if hookData.SessionID == "" {
-		hookData.SessionID = "unknown"
-		logging.Warn("Session ID is empty, using 'unknown'")
+	if !h.cfg.IsWorkspaceAllowed(hookData.CWD, time.Now()) {
+		logging.Debug("Workspace %q is filtered out by workspaceFilter, skipping", hookData.CWD)
+		return nil
 	}
 
 	// Phase 1: Early duplicate check (per hook event type)
@@ -114,6 +274,12 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		return nil
 	}
 
+	// Every event marks the session as seen, so the startup grace period starts at its first hook
+	inStartupGrace, graceErr := h.stateMgr.ShouldSuppressStartup(hookData.SessionID, h.cfg.Notifications.StartupGraceSeconds)
+	if graceErr != nil {
+		logging.Warn("Failed to check startup grace period: %v", graceErr)
+	}
+
 	// Determine status based on hook type
 	var status analyzer.Status
 	var err error
@@ -127,27 +293,54 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		if err != nil {
 			return err
 		}
-	case "Stop", "SubagentStop":
+	case "Stop":
 		// Analyze the transcript to determine status
 		status, err = h.handleStopEvent(&hookData)
+		if errors.Is(err, errStopSuppressed) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 		// Note: We don't delete session state here to preserve cooldown info
 		// State files have TTL and will be cleaned up automatically
 		defer h.cleanupOldLocks()
+	case "SubagentStop":
+		// Subagent finished a sub-task, not the whole session
+		status = h.handleSubagentStopEvent(&hookData)
+		defer h.cleanupOldLocks()
 	default:
 		return fmt.Errorf("unknown hook event: %s", hookEvent)
 	}
 
-	// If status is unknown, skip
+	// If status is unknown, skip unless unclassified Stop events should still notify
 	if status == analyzer.StatusUnknown {
-		logging.Debug("Status is unknown, skipping notification")
+		if hookEvent != "Stop" || !h.cfg.Notifications.NotifyOnUnknown {
+			logging.Debug("Status is unknown, skipping notification")
+			return nil
+		}
+		logging.Debug("Status is unknown, sending unclassified notification (notifyOnUnknown)")
+	}
+
+	// Hooks right after Claude starts can fire spuriously
+	if inStartupGrace {
+		logging.Debug("Status %s suppressed during startup grace period (%ds)", status, h.cfg.Notifications.StartupGraceSeconds)
 		return nil
 	}
 
+	if err := h.notify(hookEvent, &hookData, status); err != nil {
+		return err
+	}
+
+	logging.Debug("=== Hook completed: %s ===", hookEvent)
+	return nil
+}
+
+// notify sends the notification for status unless it is a duplicate of hookEvent or
+// falls in a cooldown, and records it in the session state
+func (h *Handler) notify(hookEvent string, hookData *HookData, status analyzer.Status) error {
 	// Phase 2: Acquire lock before sending (per hook event type)
-	acquired, err := h.dedupMgr.AcquireLock(hookData.SessionID, hookEvent)
+	acquired, err := h.dedupMgr.AcquireLockWithRetry(hookEvent, hookData.SessionID, lockWaitTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to acquire lock: %w", err)
 	}
@@ -157,10 +350,11 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 	}
 
 	logging.Debug("Lock acquired, proceeding with notification")
-	// Note: Lock is NOT released - it ages out naturally after 2s to prevent rapid duplicates
+	// Note: Lock is NOT released - it ages out naturally after dedupWindowSeconds to prevent rapid duplicates
 
-	// Check cooldown for question status BEFORE updating notification time
-	if status == analyzer.StatusQuestion {
+	// Check cooldown for question and permission status BEFORE updating notification time
+	// (both come from the Notification hook, which often follows another notification)
+	if status == analyzer.StatusQuestion || status == analyzer.StatusPermission {
 		logging.Debug("Checking question cooldown: cooldownSeconds=%d", h.cfg.Notifications.SuppressQuestionAfterAnyNotificationSeconds)
 
 		// Load state to log its contents
@@ -203,6 +397,31 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		}
 	}
 
+	// Check per-status cooldown (e.g. task_complete at most once per 30s for the same session)
+	if statusInfo, exists := h.cfg.GetStatusInfo(string(status)); exists && statusInfo.CooldownSeconds > 0 {
+		suppress, err := h.stateMgr.ShouldSuppressStatus(hookData.SessionID, status, statusInfo.CooldownSeconds)
+		if err != nil {
+			logging.Warn("Failed to check status cooldown: %v", err)
+		} else if suppress {
+			logging.Debug("Status %s suppressed due to per-status cooldown (%ds)", status, statusInfo.CooldownSeconds)
+			return nil
+		}
+	}
+
+	// Generate message
+	message := h.generateMessage(hookData, status)
+
+	// Drop a repeat of the previous notification (same status and message)
+	if window := h.cfg.Notifications.SuppressConsecutiveIdenticalSeconds; window > 0 {
+		suppress, err := h.stateMgr.ShouldSuppressIdentical(hookData.SessionID, status, message, window)
+		if err != nil {
+			logging.Warn("Failed to check identical notification: %v", err)
+		} else if suppress {
+			logging.Debug("Status %s suppressed as identical to the previous notification (%ds)", status, window)
+			return nil
+		}
+	}
+
 	// Update state (only for task_complete, PreToolUse already updated state)
 	if status == analyzer.StatusTaskComplete {
 		if err := h.stateMgr.UpdateTaskComplete(hookData.SessionID); err != nil {
@@ -210,18 +429,23 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 		}
 	}
 
-	// Update last notification time AFTER cooldown checks (inside lock region)
-	if err := h.stateMgr.UpdateLastNotification(hookData.SessionID, status); err != nil {
-		logging.Warn("Failed to update last notification time: %v", err)
+	// Update last notification time AFTER cooldown checks (inside lock region).
+	// A long running command is informational: recording it would suppress the
+	// permission prompt that often follows for the same command.
+	if status != analyzer.StatusLongRunningCommand {
+		if err := h.stateMgr.UpdateLastNotificationMessage(hookData.SessionID, status, message); err != nil {
+			logging.Warn("Failed to update last notification time: %v", err)
+		}
+	}
+	if hookEvent == "Notification" {
+		if err := h.stateMgr.UpdateNotificationHook(hookData.SessionID); err != nil {
+			logging.Warn("Failed to update notification hook time: %v", err)
+		}
 	}
-
-	// Generate message
-	message := h.generateMessage(&hookData, status)
 
 	// Send notifications
-	h.sendNotifications(status, message, hookData.SessionID)
-
-	logging.Debug("=== Hook completed: %s ===", hookEvent)
+	h.sendNotifications(status, message, hookData.SessionID, h.focusContext(hookData))
+	h.recordHistory(hookData, status)
 	return nil
 }
 
@@ -229,7 +453,11 @@ func (h *Handler) HandleHook(hookEvent string, input io.Reader) error {
 func (h *Handler) handlePreToolUse(hookData *HookData) analyzer.Status {
 	logging.Debug("PreToolUse: tool_name='%s'", hookData.ToolName)
 
-	status := analyzer.GetStatusForPreToolUse(hookData.ToolName)
+	status := analyzer.GetStatusForPreToolUse(hookData.ToolName, hookData.ToolInput)
+	if status == analyzer.StatusLongRunningCommand && !h.cfg.Notifications.NotifyOnLongCommands {
+		logging.Debug("PreToolUse: long running command ignored (notifyOnLongCommands is off)")
+		return analyzer.StatusUnknown
+	}
 
 	// Write session state BEFORE returning (prevents race with Notification hook)
 	// This matches bash version behavior: state is written BEFORE notification is sent
@@ -244,16 +472,55 @@ func (h *Handler) handlePreToolUse(hookData *HookData) analyzer.Status {
 	return status
 }
 
+// notificationTranscriptWindow is how many trailing transcript messages the Notification hook
+// reads to find the pending tool; the prompt always concerns the end of the transcript
+const notificationTranscriptWindow = 50
+
 // handleNotificationEvent handles Notification hook
-// Always returns StatusQuestion as per design: Notification hook is triggered
-// when Claude needs user input (e.g., permission dialogs, questions)
+// The hook is triggered when Claude needs user input: permission prompts become
+// StatusPermission, everything else (e.g. questions) StatusQuestion
 func (h *Handler) handleNotificationEvent(hookData *HookData) (analyzer.Status, error) {
-	logging.Debug("Notification event received → question status")
-	return analyzer.StatusQuestion, nil
+	var messages []jsonl.Message
+	if hookData.TranscriptPath != "" && platform.FileExists(hookData.TranscriptPath) {
+		parsed, err := jsonl.ParseLastN(hookData.TranscriptPath, notificationTranscriptWindow)
+		if err != nil {
+			logging.Warn("Failed to parse transcript for Notification: %v", err)
+		} else {
+			messages = parsed
+		}
+	}
+
+	status := analyzer.GetStatusForNotification(hookData.Message, messages)
+	logging.Debug("Notification event received → %s status", status)
+	return status, nil
+}
+
+// handleSubagentStopEvent handles SubagentStop hook
+// Subagent completions are partial work, so the transcript analyzer is skipped
+func (h *Handler) handleSubagentStopEvent(hookData *HookData) analyzer.Status {
+	logging.Debug("SubagentStop event received → subagent_complete status")
+	return analyzer.StatusSubagentComplete
 }
 
-// handleStopEvent handles Stop/SubagentStop hooks
+// errStopSuppressed is returned by handleStopEvent when a Notification hook already
+// notified for the same event
+var errStopSuppressed = errors.New("stop notification suppressed after Notification hook")
+
+// handleStopEvent handles Stop hook
+// Claude often fires a Notification hook right before Stop for the same event, so Stop is
+// skipped within SuppressStopAfterNotificationSeconds of a Notification hook's notification
 func (h *Handler) handleStopEvent(hookData *HookData) (analyzer.Status, error) {
+	suppress, err := h.stateMgr.ShouldSuppressStopAfterNotification(
+		hookData.SessionID,
+		h.cfg.Notifications.SuppressStopAfterNotificationSeconds,
+	)
+	if err != nil {
+		logging.Warn("Failed to check Stop suppression: %v", err)
+	} else if suppress {
+		logging.Debug("Stop suppressed due to recent Notification hook (%ds)", h.cfg.Notifications.SuppressStopAfterNotificationSeconds)
+		return analyzer.StatusUnknown, errStopSuppressed
+	}
+
 	if hookData.TranscriptPath == "" {
 		logging.Warn("Transcript path is empty, skipping notification")
 		return analyzer.StatusUnknown, nil
@@ -264,49 +531,267 @@ func (h *Handler) handleStopEvent(hookData *HookData) (analyzer.Status, error) {
 		return analyzer.StatusUnknown, nil
 	}
 
-	status, err := analyzer.AnalyzeTranscript(hookData.TranscriptPath, h.cfg)
+	messages, err := hookData.transcriptMessages()
 	if err != nil {
 		logging.Error("Failed to analyze transcript: %v", err)
+		if h.cfg.Notifications.NotifyOnAnalysisError {
+			logging.Debug("Sending task_complete notification despite the analysis error (notifyOnAnalysisError)")
+			return analyzer.StatusTaskComplete, nil
+		}
 		return analyzer.StatusUnknown, nil
 	}
 
+	status := analyzer.AnalyzeMessages(messages, h.cfg)
 	logging.Debug("Analyzed status: %s", status)
 	return status, nil
 }
 
+// commandPreviewLength is how much of a long running command the notification shows
+const commandPreviewLength = 80
+
 // generateMessage generates a notification message
 func (h *Handler) generateMessage(hookData *HookData, status analyzer.Status) string {
+	if status == analyzer.StatusLongRunningCommand {
+		return commandMessage(analyzer.BashCommand(hookData.ToolInput))
+	}
+
 	if hookData.TranscriptPath != "" && platform.FileExists(hookData.TranscriptPath) {
-		msg := summary.GenerateFromTranscript(hookData.TranscriptPath, status, h.cfg)
-		if msg != "" {
-			return msg
+		if messages, err := hookData.transcriptMessages(); err == nil {
+			if msg := summary.GenerateFromMessages(messages, status, h.cfg); msg != "" {
+				return msg
+			}
 		}
 	}
 
 	return summary.GenerateSimple(status, h.cfg)
 }
 
+// commandMessage describes a long running command by its first commandPreviewLength characters
+func commandMessage(command string) string {
+	command = strings.Join(strings.Fields(command), " ")
+	if runes := []rune(command); len(runes) > commandPreviewLength {
+		command = string(runes[:commandPreviewLength]) + "..."
+	}
+	return "Running: " + command
+}
+
+// defaultCleanupFailureThreshold is how many lock cleanups in a row may fail before
+// warning, when notifications.cleanupFailureThreshold is unset
+const defaultCleanupFailureThreshold = 3
+
+// cleanupFailureNotice is shown with notifyCleanupFailures once the threshold is reached
+const cleanupFailureNotice = "Cleaning up lock files keeps failing, so duplicate notifications may slip through. Check that the temp directory is writable: "
+
+// reportCleanupFailures warns once when the consecutive cleanup failures reach the threshold,
+// since old locks then pile up in the temp dir and eventually break deduplication
+func (h *Handler) reportCleanupFailures(failures int) {
+	threshold := h.cfg.Notifications.CleanupFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultCleanupFailureThreshold
+	}
+	if failures != threshold {
+		return
+	}
+
+	logging.Warn("Lock cleanup failed %d times in a row; check that the temp dir is writable: %s", failures, platform.TempDir())
+	if h.cfg.Notifications.NotifyCleanupFailures {
+		if err := h.notifierSvc.SendNotice("⚠️ Claude Notifications", cleanupFailureNotice+platform.TempDir()); err != nil {
+			logging.Warn("Failed to show cleanup failure notice: %v", err)
+		}
+	}
+}
+
+// recordHistory appends the notification to the history file when notifications.history
+// is enabled, with the response's duration and tool usage when a transcript is available
+func (h *Handler) recordHistory(hookData *HookData, status analyzer.Status) {
+	if h.historyPath == "" || !h.cfg.IsHistoryEnabled() {
+		return
+	}
+
+	entry := history.HistoryEntry{
+		SessionID:  hookData.SessionID,
+		Status:     string(status),
+		Timestamp:  time.Now(),
+		ProjectDir: hookData.CWD,
+	}
+	if hookData.TranscriptPath != "" && platform.FileExists(hookData.TranscriptPath) {
+		messages, err := hookData.transcriptMessages()
+		if err != nil {
+			logging.Warn("Failed to parse transcript for history: %v", err)
+		} else {
+			entry.Duration, _ = summary.ResponseDuration(messages)
+			entry.Tools = summary.CountToolsByType(messages)
+			for _, count := range entry.Tools {
+				entry.ToolCount += count
+			}
+		}
+	}
+
+	if err := history.AppendRotating(h.historyPath, entry, h.cfg.HistoryMaxBytes()); err != nil {
+		logging.Warn("Failed to record notification history: %v", err)
+	}
+}
+
+// isRemoteSession reports whether there is no local desktop to notify (overridable in tests)
+var isRemoteSession = platform.IsRemoteSession
+
+// desktopAvailable reports whether desktop notifications should be shown: they are
+// enabled, and this is not an SSH session unless desktop.forceEnabled is set
+func (h *Handler) desktopAvailable() bool {
+	if !h.cfg.IsDesktopEnabled() {
+		return false
+	}
+	if !h.cfg.Notifications.Desktop.ForceEnabled && isRemoteSession() {
+		logging.Debug("Remote session detected, skipping desktop notification (set desktop.forceEnabled to show it)")
+		return false
+	}
+	return true
+}
+
 // sendNotifications sends desktop and webhook notifications
-func (h *Handler) sendNotifications(status analyzer.Status, message, sessionID string) {
+func (h *Handler) sendNotifications(status analyzer.Status, message, sessionID string, focus notifier.FocusContext) {
 	// Add panic recovery to prevent notification failures from crashing the plugin
 	defer errorhandler.HandlePanic()
 
+	if seconds := h.cfg.Notifications.ThrottleWindowSeconds; seconds > 0 {
+		h.throttleNotification(throttle.NewWindow(sessionID, time.Duration(seconds)*time.Second), status, message, sessionID, focus)
+		return
+	}
+
+	h.deliverNotifications(status, message, sessionID, focus)
+}
+
+// focusContext returns where Claude is running for clickToFocus and autoFocus: the
+// environment a socket client sent with the hook, or else this process's environment.
+// A long-lived handler's own environment says nothing about the session, so it has none.
+func (h *Handler) focusContext(hookData *HookData) notifier.FocusContext {
+	switch {
+	case hookData != nil && len(hookData.Env) > 0:
+		return notifier.FocusContextFromEnv(func(key string) string { return hookData.Env[key] })
+	case h.keepAlive:
+		return notifier.FocusContext{}
+	default:
+		return notifier.FocusContextFromEnv(os.Getenv)
+	}
+}
+
+// throttleNotification buffers the notification in the session's throttle window.
+// The hook that opens the window sends one merged notification once it closes; hooks
+// arriving while it is open only add to the buffer.
+func (h *Handler) throttleNotification(window *throttle.Window, status analyzer.Status, message, sessionID string, focus notifier.FocusContext) {
+	leader, err := window.Add(status, message)
+	if err != nil {
+		logging.Warn("Failed to throttle notification, sending immediately: %v", err)
+		h.deliverNotifications(status, message, sessionID, focus)
+		return
+	}
+	if !leader {
+		logging.Debug("Notification buffered in open throttle window")
+		return
+	}
+
+	// The socket server handles hooks one at a time, so don't hold it up for the window
+	if h.keepAlive {
+		h.throttleFlushes.Add(1)
+		errorhandler.SafeGo(func() {
+			defer h.throttleFlushes.Done()
+			h.flushThrottleWindow(window, sessionID, focus)
+		})
+		return
+	}
+
+	// Don't keep Claude waiting on the hook: a detached process flushes the window
+	if err := startThrottleFlush(h.pluginRoot, sessionID); err != nil {
+		logging.Warn("Failed to start throttle flush process, waiting for the window: %v", err)
+		h.flushThrottleWindow(window, sessionID, focus)
+	}
+}
+
+// ThrottleFlushCommand is the claude-notifications command that flushes a session's
+// throttle window in the background, started by the hook that opened the window
+const ThrottleFlushCommand = "throttle-flush"
+
+// startThrottleFlush runs "claude-notifications throttle-flush <session>" detached from
+// the hook process (overridable in tests)
+var startThrottleFlush = func(pluginRoot, sessionID string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, ThrottleFlushCommand, sessionID)
+	cmd.Env = append(os.Environ(), "CLAUDE_PLUGIN_ROOT="+pluginRoot)
+	platform.DetachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// FlushThrottle waits for the session's throttle window to close and sends its merged
+// notification. It runs in the process started by the hook that opened the window.
+func (h *Handler) FlushThrottle(sessionID string) error {
+	defer errorhandler.HandlePanic()
+
+	seconds := h.cfg.Notifications.ThrottleWindowSeconds
+	h.flushThrottleWindow(throttle.NewWindow(sessionID, time.Duration(seconds)*time.Second), sessionID, h.focusContext(nil))
+
+	return errors.Join(h.webhookSvc.Close(), h.notifierSvc.Close())
+}
+
+// flushThrottleWindow waits for window to close and sends one notification for everything
+// buffered in it
+func (h *Handler) flushThrottleWindow(window *throttle.Window, sessionID string, focus notifier.FocusContext) {
+	entries, err := window.Wait()
+	if err != nil {
+		logging.Warn("Failed to flush throttle window: %v", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	logging.Debug("Flushing throttle window with %d notification(s)", len(entries))
+	mergedStatus, mergedMessage := throttle.Merge(entries, window.Duration())
+	h.deliverNotifications(mergedStatus, mergedMessage, sessionID, focus)
+}
+
+// deliverNotifications sends the desktop and webhook notifications in the configured notificationOrder
+func (h *Handler) deliverNotifications(status analyzer.Status, message, sessionID string, focus notifier.FocusContext) {
 	// Add session name to message (like bash version: "[bold-cat]")
 	sessionName := sessionname.GenerateSessionName(sessionID)
 	enhancedMessage := fmt.Sprintf("[%s] %s", sessionName, message)
 
 	logging.Debug("Session name: %s", sessionName)
 
-	// Send desktop notification
-	if h.cfg.IsDesktopEnabled() {
-		if err := h.notifierSvc.SendDesktop(status, enhancedMessage); err != nil {
-			errorhandler.HandleError(err, "Failed to send desktop notification")
+	sendDesktop := func() {
+		if h.desktopAvailable() {
+			if err := h.notifierSvc.SendDesktopWithFocus(status, enhancedMessage, focus); err != nil {
+				errorhandler.HandleError(err, "Failed to send desktop notification")
+			}
+		}
+	}
+	// Webhooks are delivered asynchronously; this only queues them
+	sendWebhook := func() {
+		if h.cfg.IsWebhookEnabled() {
+			h.webhookSvc.SendAsync(status, enhancedMessage, sessionID)
 		}
 	}
 
-	// Send webhook notification (async)
-	if h.cfg.IsWebhookEnabled() {
-		h.webhookSvc.SendAsync(status, enhancedMessage, sessionID)
+	switch h.cfg.Notifications.NotificationOrder {
+	case config.NotificationOrderWebhookFirst:
+		sendWebhook()
+		sendDesktop()
+	case config.NotificationOrderParallel:
+		var wg sync.WaitGroup
+		wg.Add(1)
+		errorhandler.SafeGo(func() {
+			defer wg.Done()
+			sendDesktop()
+		})
+		sendWebhook()
+		wg.Wait()
+	default:
+		sendDesktop()
+		sendWebhook()
 	}
 }
 
@@ -315,10 +800,11 @@ func (h *Handler) cleanupOldLocks() {
 	// Cleanup old locks (older than 60 seconds)
 	if err := h.dedupMgr.Cleanup(60); err != nil {
 		logging.Warn("Failed to cleanup old locks: %v", err)
+		h.reportCleanupFailures(h.dedupMgr.CleanupFailures())
 	}
 
-	// Cleanup old state files (older than 60 seconds)
-	if err := h.stateMgr.Cleanup(60); err != nil {
+	// Cleanup idle state files, keeping them for the longest cooldown window
+	if err := h.stateMgr.Cleanup(h.cfg.SessionStateMaxAgeSeconds()); err != nil {
 		logging.Warn("Failed to cleanup old state files: %v", err)
 	}
 }
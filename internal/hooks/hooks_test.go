@@ -3,6 +3,7 @@ package hooks
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -14,7 +15,10 @@ import (
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/dedup"
+	"github.com/777genius/claude-notifications/internal/history"
+	"github.com/777genius/claude-notifications/internal/notifier"
 	"github.com/777genius/claude-notifications/internal/state"
+	"github.com/777genius/claude-notifications/internal/webhook"
 	"github.com/777genius/claude-notifications/pkg/jsonl"
 )
 
@@ -23,21 +27,26 @@ import (
 type mockNotifier struct {
 	mu         sync.Mutex
 	calls      []notificationCall
+	notices    []string
 	shouldFail bool
+	closes     int
+	shutdowns  int
 }
 
 type notificationCall struct {
 	status  analyzer.Status
 	message string
+	focus   notifier.FocusContext
 }
 
-func (m *mockNotifier) SendDesktop(status analyzer.Status, message string) error {
+func (m *mockNotifier) SendDesktopWithFocus(status analyzer.Status, message string, focus notifier.FocusContext) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.calls = append(m.calls, notificationCall{
 		status:  status,
 		message: message,
+		focus:   focus,
 	})
 
 	if m.shouldFail {
@@ -46,7 +55,30 @@ func (m *mockNotifier) SendDesktop(status analyzer.Status, message string) error
 	return nil
 }
 
+func (m *mockNotifier) SendNotice(title, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notices = append(m.notices, message)
+	return nil
+}
+
+func (m *mockNotifier) noticeCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.notices)
+}
+
 func (m *mockNotifier) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closes++
+	return nil
+}
+
+func (m *mockNotifier) Shutdown() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdowns++
 	return nil
 }
 
@@ -74,8 +106,10 @@ func (m *mockNotifier) lastCall() *notificationCall {
 // === Mock Webhook ===
 
 type mockWebhook struct {
-	mu    sync.Mutex
-	calls []webhookCall
+	mu      sync.Mutex
+	calls   []webhookCall
+	flushes int
+	closes  int
 }
 
 type webhookCall struct {
@@ -100,6 +134,30 @@ func (m *mockWebhook) Send(status analyzer.Status, message, sessionID string) er
 	return nil
 }
 
+func (m *mockWebhook) Wait(timeout time.Duration) error {
+	return nil
+}
+
+func (m *mockWebhook) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closes++
+	return nil
+}
+
+func (m *mockWebhook) FlushStats() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushes++
+	return nil
+}
+
+func (m *mockWebhook) GetMetrics() webhook.Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return webhook.Stats{TotalRequests: int64(len(m.calls))}
+}
+
 func (m *mockWebhook) wasCalled() bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -183,16 +241,27 @@ func newTestHandler(t *testing.T, cfg *config.Config) (*Handler, *mockNotifier,
 
 	handler := &Handler{
 		cfg:         cfg,
-		dedupMgr:    dedup.NewManager(),
+		dedupMgr:    dedup.NewManager(dedup.DefaultWindowSeconds),
 		stateMgr:    state.NewManager(),
 		notifierSvc: mockNotif,
 		webhookSvc:  mockWH,
 		pluginRoot:  t.TempDir(),
 	}
 
+	// Desktop notifications are expected even when the tests run over SSH
+	setRemoteSession(t, false)
+
 	return handler, mockNotif, mockWH
 }
 
+// setRemoteSession makes isRemoteSession report remote until the test ends
+func setRemoteSession(t *testing.T, remote bool) {
+	t.Helper()
+	original := isRemoteSession
+	isRemoteSession = func() bool { return remote }
+	t.Cleanup(func() { isRemoteSession = original })
+}
+
 // === Integration Tests ===
 
 func TestHandler_PreToolUse_ExitPlanMode(t *testing.T) {
@@ -233,6 +302,30 @@ func TestHandler_PreToolUse_ExitPlanMode(t *testing.T) {
 	}
 }
 
+func TestHandler_HookExitShutsDownNotifier(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{SessionID: "test-session-shutdown", ToolName: "ExitPlanMode"})
+	if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Shutdown fades out sounds still playing when the hook exits; Close would wait for them
+	mockNotif.mu.Lock()
+	defer mockNotif.mu.Unlock()
+	if mockNotif.shutdowns != 1 || mockNotif.closes != 0 {
+		t.Errorf("expected the notifier to be shut down once and not closed, got %d shutdowns and %d closes", mockNotif.shutdowns, mockNotif.closes)
+	}
+}
+
 func TestHandler_PreToolUse_AskUserQuestion(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
@@ -267,6 +360,166 @@ func TestHandler_PreToolUse_AskUserQuestion(t *testing.T) {
 	}
 }
 
+func TestHandler_PreToolUse_LongRunningCommand(t *testing.T) {
+	longCommand := "npm install && npm run build && npm run test -- --coverage --reporter=verbose --bail"
+
+	tests := []struct {
+		name        string
+		command     string
+		disabled    bool
+		wantNotify  bool
+		wantMessage string
+	}{
+		{"short command", "npm install", false, false, ""},
+		{"long command", longCommand, false, true, "Running: " + longCommand[:80] + "..."},
+		{"whitespace collapsed", "cargo build --release\n  --features full --target x86_64-unknown-linux-gnu", false, true,
+			"Running: cargo build --release --features full --target x86_64-unknown-linux-gnu"},
+		{"notifyOnLongCommands off", longCommand, true, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Notifications: config.NotificationsConfig{
+					Desktop:              config.DesktopConfig{Enabled: true},
+					NotifyOnLongCommands: !tt.disabled,
+				},
+				Statuses: map[string]config.StatusInfo{
+					"long_running_command": {Title: "Running Command"},
+				},
+			}
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			hookData := buildHookDataJSON(HookData{
+				SessionID: "test-session-bash-" + strings.ReplaceAll(tt.name, " ", "-"),
+				ToolName:  "Bash",
+				ToolInput: map[string]interface{}{"command": tt.command},
+				CWD:       "/test",
+			})
+			if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mockNotif.wasCalled() != tt.wantNotify {
+				t.Fatalf("notified = %v, want %v", mockNotif.wasCalled(), tt.wantNotify)
+			}
+			if !tt.wantNotify {
+				return
+			}
+			call := mockNotif.lastCall()
+			if call.status != analyzer.StatusLongRunningCommand {
+				t.Errorf("got status %v, want StatusLongRunningCommand", call.status)
+			}
+			if !strings.HasSuffix(call.message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to end with %q", call.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandler_LongRunningCommandKeepsPermissionPrompt(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:              config.DesktopConfig{Enabled: true},
+			NotifyOnLongCommands: true,
+			SuppressQuestionAfterAnyNotificationSeconds: 12,
+		},
+		Statuses: map[string]config.StatusInfo{
+			"long_running_command": {Title: "Running Command"},
+			"permission":           {Title: "Permission Required"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-session-bash-permission"
+
+	err := handler.HandleHook("PreToolUse", buildHookDataJSON(HookData{
+		SessionID: sessionID,
+		ToolName:  "Bash",
+		ToolInput: map[string]interface{}{"command": "npm install && npm run build && npm run test -- --coverage"},
+		CWD:       "/test",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = handler.HandleHook("Notification", buildHookDataJSON(HookData{
+		SessionID: sessionID,
+		Message:   "Claude needs your permission to use Bash",
+		CWD:       "/test",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := mockNotif.callCount(); got != 2 {
+		t.Fatalf("got %d notifications, want the command and the permission prompt", got)
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusPermission {
+		t.Errorf("last status = %v, want StatusPermission", call.status)
+	}
+}
+
+func TestHandler_RemoteSessionSkipsDesktop(t *testing.T) {
+	tests := []struct {
+		name         string
+		remote       bool
+		forceEnabled bool
+		wantDesktop  bool
+	}{
+		{"local", false, false, true},
+		{"remote", true, false, false},
+		{"remote with forceEnabled", true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.ForceEnabled = tt.forceEnabled
+			cfg.Notifications.Webhook[0].Enabled = true
+			cfg.Notifications.Webhook[0].URL = "https://example.com/webhook"
+			handler, mockNotif, mockWH := newTestHandler(t, cfg)
+			setRemoteSession(t, tt.remote)
+
+			handler.sendNotifications(analyzer.StatusTaskComplete, "Done", "test-session-remote", notifier.FocusContext{})
+
+			if mockNotif.wasCalled() != tt.wantDesktop {
+				t.Errorf("desktop notified = %v, want %v", mockNotif.wasCalled(), tt.wantDesktop)
+			}
+			if !mockWH.wasCalled() {
+				t.Error("expected the webhook to be sent")
+			}
+		})
+	}
+}
+
+func TestHandler_FocusContext(t *testing.T) {
+	t.Setenv("TMUX_PANE", "%1")
+
+	tests := []struct {
+		name      string
+		keepAlive bool
+		env       map[string]string
+		wantPane  string
+	}{
+		{"hook process uses its own environment", false, nil, "%1"},
+		{"long-lived handler without env", true, nil, ""},
+		{"long-lived handler with env", true, map[string]string{"TMUX_PANE": "%7"}, "%7"},
+		{"env sent with the hook wins", false, map[string]string{"TMUX_PANE": "%7"}, "%7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, _ := newTestHandler(t, config.DefaultConfig())
+			handler.keepAlive = tt.keepAlive
+
+			got := handler.focusContext(&HookData{Env: tt.env})
+			if got.TmuxPane != tt.wantPane {
+				t.Errorf("focusContext().TmuxPane = %q, want %q", got.TmuxPane, tt.wantPane)
+			}
+		})
+	}
+}
+
 func TestHandler_Stop_ReviewComplete(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
@@ -343,6 +596,77 @@ func TestHandler_Stop_TaskComplete(t *testing.T) {
 	}
 }
 
+func TestHandler_Stop_UnknownStatus(t *testing.T) {
+	// Transcript with text only: the analyzer can't classify it
+	messages := buildTranscriptWithTools(nil, 0)
+	messages[1].Message.Content[0].Text = "The flag enables verbose logging."
+
+	tests := []struct {
+		name            string
+		notifyOnUnknown bool
+		wantNotified    bool
+	}{
+		{"skipped by default", false, false},
+		{"notified when enabled", true, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.NotifyOnUnknown = tt.notifyOnUnknown
+			cfg.ApplyDefaults()
+
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			hookData := buildHookDataJSON(HookData{
+				SessionID:      fmt.Sprintf("test-session-unknown-%d", i),
+				TranscriptPath: createTempTranscript(t, messages),
+				CWD:            "/test",
+			})
+
+			if err := handler.HandleHook("Stop", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mockNotif.wasCalled() != tt.wantNotified {
+				t.Fatalf("notification sent = %v, want %v", mockNotif.wasCalled(), tt.wantNotified)
+			}
+			if !tt.wantNotified {
+				return
+			}
+
+			call := mockNotif.lastCall()
+			if call.status != analyzer.StatusUnknown {
+				t.Errorf("got status %v, want StatusUnknown", call.status)
+			}
+			if !strings.Contains(call.message, "verbose logging") {
+				t.Errorf("expected last reply in message, got %q", call.message)
+			}
+		})
+	}
+}
+
+func TestHandler_PreToolUse_UnknownStatusNeverNotifies(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.NotifyOnUnknown = true
+	cfg.ApplyDefaults()
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-unknown-pretool",
+		ToolName:  "Write",
+		CWD:       "/test",
+	})
+
+	if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Error("PreToolUse for ordinary tools must not notify, even with notifyOnUnknown")
+	}
+}
+
 func TestHandler_Notification_SuppressedAfterExitPlanMode(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
@@ -523,182 +847,760 @@ func TestHandler_QuestionCooldownAfterTaskComplete(t *testing.T) {
 	}
 }
 
-// === Error Handling Tests ===
-
-func TestHandler_InvalidJSON(t *testing.T) {
-	cfg := &config.Config{}
-	handler, _, _ := newTestHandler(t, cfg)
-
-	err := handler.HandleHook("Stop", strings.NewReader("invalid json"))
-
-	if err == nil {
-		t.Error("expected error for invalid JSON")
-	}
-
-	if !strings.Contains(err.Error(), "failed to parse hook data") {
-		t.Errorf("unexpected error message: %v", err)
-	}
-}
-
-func TestHandler_MissingTranscriptFile(t *testing.T) {
+func TestHandler_PerStatusCooldown(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
 		},
 		Statuses: map[string]config.StatusInfo{
-			"task_complete": {Title: "Task Complete"},
+			"task_complete": {Title: "Task Complete", CooldownSeconds: 30},
 		},
 	}
 
-	handler, _, _ := newTestHandler(t, cfg)
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-status-cooldown-1"
+	defer func() { _ = handler.stateMgr.Delete(sessionID) }()
+
+	// Simulate a task_complete notification sent moments ago
+	if err := handler.stateMgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete); err != nil {
+		t.Fatalf("failed to seed state: %v", err)
+	}
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
 
 	hookData := buildHookDataJSON(HookData{
-		SessionID:      "test-session-9",
-		TranscriptPath: "/nonexistent/path.jsonl",
+		SessionID:      sessionID,
+		TranscriptPath: transcriptPath,
 		CWD:            "/test",
 	})
 
-	// Should handle gracefully (degrades, not fails)
-	err := handler.HandleHook("Stop", hookData)
-
-	if err != nil {
-		t.Errorf("should handle missing file gracefully, got error: %v", err)
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// May still send notification with default message
-	// (depends on implementation - this is graceful degradation)
+	if mockNotif.wasCalled() {
+		t.Error("task_complete should be suppressed within per-status cooldown")
+	}
 }
 
-func TestHandler_EmptySessionID(t *testing.T) {
+func TestHandler_PerStatusCooldown_OtherStatusNotSuppressed(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
 		},
 		Statuses: map[string]config.StatusInfo{
-			"plan_ready": {Title: "Plan Ready"},
+			"task_complete": {Title: "Task Complete", CooldownSeconds: 30},
+			"plan_ready":    {Title: "Plan Ready", CooldownSeconds: 60},
 		},
 	}
 
-	handler, _, _ := newTestHandler(t, cfg)
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-status-cooldown-2"
+	defer func() { _ = handler.stateMgr.Delete(sessionID) }()
+
+	if err := handler.stateMgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete); err != nil {
+		t.Fatalf("failed to seed state: %v", err)
+	}
 
 	hookData := buildHookDataJSON(HookData{
-		SessionID: "", // Empty
+		SessionID: sessionID,
 		ToolName:  "ExitPlanMode",
 		CWD:       "/test",
 	})
 
-	// Should handle gracefully (uses "unknown")
-	err := handler.HandleHook("PreToolUse", hookData)
+	if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-	if err != nil {
-		t.Errorf("should handle empty session ID gracefully, got error: %v", err)
+	call := mockNotif.lastCall()
+	if call == nil || call.status != analyzer.StatusPlanReady {
+		t.Error("plan_ready should not be suppressed by task_complete cooldown")
 	}
 }
 
-// === Notification Disabled Tests ===
-
-func TestHandler_NotificationsDisabled(t *testing.T) {
-	cfg := &config.Config{
-		Notifications: config.NotificationsConfig{
-			Desktop: config.DesktopConfig{Enabled: false},
-			Webhook: config.WebhookConfig{Enabled: false},
-		},
+func TestHandler_SuppressConsecutiveIdentical(t *testing.T) {
+	tests := []struct {
+		name         string
+		previous     string // message of the previous task_complete; "" = the one this Stop generates
+		wantNotified bool
+	}{
+		{"identical message suppressed", "", false},
+		{"different message notified", "Created another file", true},
 	}
 
-	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.SuppressConsecutiveIdenticalSeconds = 60
 
-	hookData := buildHookDataJSON(HookData{
-		SessionID: "test-session-10",
-		ToolName:  "ExitPlanMode",
-		CWD:       "/test",
-	})
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+			sessionID := fmt.Sprintf("test-identical-%d", i)
+			defer func() { _ = handler.stateMgr.Delete(sessionID) }()
 
-	err := handler.HandleHook("PreToolUse", hookData)
+			transcriptPath := createTempTranscript(t,
+				buildTranscriptWithTools([]string{"Write"}, 300))
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+			// Simulate the previous task_complete sent moments ago, without taking a dedup lock
+			previous := tt.previous
+			if previous == "" {
+				previous = handler.generateMessage(&HookData{TranscriptPath: transcriptPath}, analyzer.StatusTaskComplete)
+			}
+			if err := handler.stateMgr.UpdateLastNotificationMessage(sessionID, analyzer.StatusTaskComplete, previous); err != nil {
+				t.Fatalf("failed to seed state: %v", err)
+			}
 
-	// Should exit early without sending
-	if mockNotif.wasCalled() {
-		t.Error("should not send notification when disabled")
-	}
+			hookData := buildHookDataJSON(HookData{
+				SessionID:      sessionID,
+				TranscriptPath: transcriptPath,
+				CWD:            "/test",
+			})
 
-	if mockWH.wasCalled() {
-		t.Error("should not send webhook when disabled")
+			if err := handler.HandleHook("Stop", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mockNotif.wasCalled() != tt.wantNotified {
+				t.Errorf("notification sent = %v, want %v", mockNotif.wasCalled(), tt.wantNotified)
+			}
+		})
 	}
 }
 
-// === SubagentStop Tests ===
-
-func TestHandler_SubagentStop(t *testing.T) {
+func TestHandler_StopSuppressedAfterNotification(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
-			Desktop: config.DesktopConfig{Enabled: true},
+			Desktop:                              config.DesktopConfig{Enabled: true},
+			SuppressStopAfterNotificationSeconds: 5,
+			NotifyOnUnknown:                      true, // a suppressed Stop must not fall back to an unclassified notification
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
+			"question":      {Title: "Question"},
+			"unknown":       {Title: "Claude Stopped"},
 		},
 	}
 
 	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-stop-after-notification"
+	defer func() { _ = handler.stateMgr.Delete(sessionID) }()
 
 	transcriptPath := createTempTranscript(t,
 		buildTranscriptWithTools([]string{"Write"}, 300))
-
-	hookData := buildHookDataJSON(HookData{
-		SessionID:      "test-session-11",
+	hookData := HookData{
+		SessionID:      sessionID,
 		TranscriptPath: transcriptPath,
 		CWD:            "/test",
-	})
-
-	err := handler.HandleHook("SubagentStop", hookData)
+	}
 
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	if err := handler.HandleHook("Notification", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("Notification error: %v", err)
+	}
+	if err := handler.HandleHook("Stop", buildHookDataJSON(hookData)); err != nil {
+		t.Fatalf("Stop error: %v", err)
 	}
 
-	if !mockNotif.wasCalled() {
-		t.Error("expected notification for SubagentStop")
+	if mockNotif.callCount() != 1 {
+		t.Fatalf("expected only the Notification hook to notify, got %d notifications", mockNotif.callCount())
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusQuestion {
+		t.Errorf("expected question notification, got %s", call.status)
 	}
 }
 
-// === Unknown Hook Event ===
-
-func TestHandler_UnknownHookEvent(t *testing.T) {
+func TestHandler_StopNotSuppressedWithoutNotification(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
-			Desktop: config.DesktopConfig{Enabled: true},
+			Desktop:                              config.DesktopConfig{Enabled: true},
+			SuppressStopAfterNotificationSeconds: 5,
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
 		},
 	}
-	handler, _, _ := newTestHandler(t, cfg)
 
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-stop-no-notification"
+	defer func() { _ = handler.stateMgr.Delete(sessionID) }()
+
+	// Another hook's notification (e.g. an earlier Stop) doesn't suppress Stop
+	if err := handler.stateMgr.UpdateLastNotification(sessionID, analyzer.StatusPlanReady); err != nil {
+		t.Fatalf("failed to seed state: %v", err)
+	}
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
 	hookData := buildHookDataJSON(HookData{
-		SessionID: "test-session-12",
-		CWD:       "/test",
+		SessionID:      sessionID,
+		TranscriptPath: transcriptPath,
+		CWD:            "/test",
 	})
 
-	err := handler.HandleHook("UnknownEvent", hookData)
-
-	if err == nil {
-		t.Fatal("expected error for unknown hook event")
+	if err := handler.HandleHook("Stop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if !strings.Contains(err.Error(), "unknown hook event") {
-		t.Errorf("unexpected error message: %v", err)
+	call := mockNotif.lastCall()
+	if call == nil || call.status != analyzer.StatusTaskComplete {
+		t.Error("Stop should notify when no Notification hook fired")
 	}
 }
 
-// === Webhook Integration ===
-
-func TestHandler_SendsWebhookWhenEnabled(t *testing.T) {
+func TestHandler_CleanupFailureWarning(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
-			Desktop: config.DesktopConfig{Enabled: true},
-			Webhook: config.WebhookConfig{Enabled: true},
+			Desktop:                 config.DesktopConfig{Enabled: true},
+			CleanupFailureThreshold: 3,
+			NotifyCleanupFailures:   true,
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	lockDir := t.TempDir()
+	handler.dedupMgr = dedup.NewManagerInDir(lockDir, dedup.DefaultWindowSeconds)
+	handler.dedupMgr.SetCleanupFailuresDir(handler.pluginRoot)
+
+	// A non-empty directory named like an old lock can't be removed, so every cleanup fails
+	stuck := filepath.Join(lockDir, "claude-notification-stuck.lock")
+	if err := os.MkdirAll(filepath.Join(stuck, "child"), 0755); err != nil {
+		t.Fatalf("failed to create stuck lock: %v", err)
+	}
+	oldTime := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(stuck, oldTime, oldTime); err != nil {
+		t.Fatalf("failed to age stuck lock: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		handler.cleanupOldLocks()
+
+		want := 0
+		if i >= 3 {
+			want = 1 // a single notice once the threshold is reached
+		}
+		if got := mockNotif.noticeCount(); got != want {
+			t.Fatalf("after %d failed cleanups: %d notices, want %d", i, got, want)
+		}
+	}
+}
+
+func TestHandler_StartupGrace(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:             config.DesktopConfig{Enabled: true},
+			StartupGraceSeconds: 1,
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	// Unique per run: the first-seen marker outlives the session state
+	sessionID := fmt.Sprintf("test-startup-grace-%d", time.Now().UnixNano())
+	defer func() {
+		_ = handler.stateMgr.Delete(sessionID)
+		_ = os.Remove(filepath.Join(os.TempDir(), "claude-session-first-seen-"+sessionID))
+	}()
+
+	planReady := func() io.Reader {
+		return buildHookDataJSON(HookData{SessionID: sessionID, ToolName: "ExitPlanMode", CWD: "/test"})
+	}
+
+	// Inside the grace window of the session's first event
+	if err := handler.HandleHook("PreToolUse", planReady()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Fatal("notification should be suppressed during the startup grace period")
+	}
+
+	// Outside the grace window (also past the 2s dedup lock)
+	time.Sleep(2100 * time.Millisecond)
+	if err := handler.HandleHook("PreToolUse", planReady()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if call := mockNotif.lastCall(); call == nil || call.status != analyzer.StatusPlanReady {
+		t.Error("plan_ready should be sent after the startup grace period")
+	}
+}
+
+func TestHandler_StartupGraceDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	sessionID := "test-startup-grace-disabled"
+	defer func() { _ = handler.stateMgr.Delete(sessionID) }()
+
+	hookData := buildHookDataJSON(HookData{SessionID: sessionID, ToolName: "ExitPlanMode", CWD: "/test"})
+	if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !mockNotif.wasCalled() {
+		t.Error("first event should notify when startupGraceSeconds is 0")
+	}
+}
+
+// === Error Handling Tests ===
+
+func TestHandler_InvalidJSON(t *testing.T) {
+	cfg := &config.Config{}
+	handler, _, _ := newTestHandler(t, cfg)
+
+	err := handler.HandleHook("Stop", strings.NewReader("invalid json"))
+
+	if err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+
+	if !strings.Contains(err.Error(), "failed to parse hook data") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestHandler_MissingTranscriptFile(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-9",
+		TranscriptPath: "/nonexistent/path.jsonl",
+		CWD:            "/test",
+	})
+
+	// Should handle gracefully (degrades, not fails)
+	err := handler.HandleHook("Stop", hookData)
+
+	if err != nil {
+		t.Errorf("should handle missing file gracefully, got error: %v", err)
+	}
+
+	// May still send notification with default message
+	// (depends on implementation - this is graceful degradation)
+}
+
+func TestHandler_EmptySessionID(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "", // Empty
+		ToolName:  "ExitPlanMode",
+		CWD:       "/test",
+	})
+
+	// Should handle gracefully (uses "unknown")
+	err := handler.HandleHook("PreToolUse", hookData)
+
+	if err != nil {
+		t.Errorf("should handle empty session ID gracefully, got error: %v", err)
+	}
+}
+
+func TestResolveHookData(t *testing.T) {
+	tests := []struct {
+		name           string
+		hookData       HookData
+		envSession     string
+		envTranscript  string
+		wantSession    string
+		wantTranscript string
+	}{
+		{"json wins over env", HookData{SessionID: "json-session", TranscriptPath: "/json.jsonl"}, "env-session", "/env.jsonl", "json-session", "/json.jsonl"},
+		{"env fills missing fields", HookData{}, "env-session", "/env.jsonl", "env-session", "/env.jsonl"},
+		{"unknown without env", HookData{}, "", "", "unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envSessionID, tt.envSession)
+			t.Setenv(envTranscriptPath, tt.envTranscript)
+
+			hd := tt.hookData
+			resolveHookData(&hd)
+
+			if hd.SessionID != tt.wantSession {
+				t.Errorf("SessionID = %q, want %q", hd.SessionID, tt.wantSession)
+			}
+			if hd.TranscriptPath != tt.wantTranscript {
+				t.Errorf("TranscriptPath = %q, want %q", hd.TranscriptPath, tt.wantTranscript)
+			}
+		})
+	}
+}
+
+func TestHandler_RecordsHistory(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			History: &config.HistoryConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, _, _ := newTestHandler(t, cfg)
+	handler.historyPath = filepath.Join(t.TempDir(), "history.jsonl")
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Read", "Edit", "Write"}, 300))
+
+	err := handler.HandleHook("Stop", buildHookDataJSON(HookData{
+		SessionID:      "test-session-history",
+		TranscriptPath: transcriptPath,
+		CWD:            "/test",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := history.Read(handler.historyPath)
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 history entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.SessionID != "test-session-history" || entry.Status != "task_complete" || entry.ProjectDir != "/test" {
+		t.Errorf("unexpected history entry: %+v", entry)
+	}
+	if entry.ToolCount != 3 || entry.Tools["Edit"] != 1 {
+		t.Errorf("expected tool usage from the transcript, got %+v", entry)
+	}
+}
+
+func TestHookData_TranscriptParsedOnce(t *testing.T) {
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	hookData := &HookData{TranscriptPath: transcriptPath}
+
+	first, err := hookData.transcriptMessages()
+	if err != nil || len(first) == 0 {
+		t.Fatalf("transcriptMessages() = %d messages, %v; want messages", len(first), err)
+	}
+
+	// Analysis, summary and history share the first read
+	if err := os.Remove(transcriptPath); err != nil {
+		t.Fatal(err)
+	}
+	second, err := hookData.transcriptMessages()
+	if err != nil || len(second) != len(first) {
+		t.Errorf("transcriptMessages() after removal = %d messages, %v; want the cached %d", len(second), err, len(first))
+	}
+}
+
+func TestHandler_HistoryDisabledByDefault(t *testing.T) {
+	handler, mockNotif, _ := newTestHandler(t, config.DefaultConfig())
+	handler.historyPath = filepath.Join(t.TempDir(), "history.jsonl")
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	err := handler.HandleHook("Stop", buildHookDataJSON(HookData{
+		SessionID:      "test-session-no-history",
+		TranscriptPath: transcriptPath,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected a notification")
+	}
+	if _, err := os.Stat(handler.historyPath); !os.IsNotExist(err) {
+		t.Errorf("expected no history file without notifications.history, stat error = %v", err)
+	}
+}
+
+func TestHandler_TranscriptPathFromEnv(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	t.Setenv(envSessionID, "env-session")
+	t.Setenv(envTranscriptPath, createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Read", "Edit", "Write"}, 300)))
+
+	err := handler.HandleHook("Stop", buildHookDataJSON(HookData{CWD: "/test"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected notification to be sent")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusTaskComplete {
+		t.Errorf("got status %v, want StatusTaskComplete", call.status)
+	}
+}
+
+// === Notification Disabled Tests ===
+
+func TestHandler_Notification_PermissionPrompt(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question":   {Title: "Question"},
+			"permission": {Title: "Permission Required"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	err := handler.HandleHook("Notification", buildHookDataJSON(HookData{
+		SessionID: "test-session-permission",
+		Message:   "Claude needs your permission to use Bash",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected notification for permission prompt")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusPermission {
+		t.Errorf("expected permission status, got %s", call.status)
+	}
+}
+
+func TestHandler_NotificationsDisabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: false},
+			Webhook: config.WebhookList{{Enabled: false}},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-10",
+		ToolName:  "ExitPlanMode",
+		CWD:       "/test",
+	})
+
+	err := handler.HandleHook("PreToolUse", hookData)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Should exit early without sending
+	if mockNotif.wasCalled() {
+		t.Error("should not send notification when disabled")
+	}
+
+	if mockWH.wasCalled() {
+		t.Error("should not send webhook when disabled")
+	}
+}
+
+func TestHandler_IgnoredHookEvent(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop:           config.DesktopConfig{Enabled: true},
+			IgnoredHookEvents: []string{"SubagentStop", "pretooluse"},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-ignored",
+		ToolName:  "ExitPlanMode",
+		CWD:       "/test",
+	})
+
+	if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mockNotif.wasCalled() || mockWH.wasCalled() {
+		t.Error("ignored hook event should not send notifications")
+	}
+
+	// Ignored events return before the hook data is read
+	if err := handler.HandleHook("SubagentStop", strings.NewReader("not json")); err != nil {
+		t.Errorf("ignored hook event should not fail, got: %v", err)
+	}
+}
+
+func TestHandler_WorkspaceFilter(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			WorkspaceFilter: []config.WorkspaceRule{
+				{PathPrefix: "/work/secret", Enabled: false},
+				{PathPrefix: "/work", Enabled: true},
+			},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		cwd    string
+		notify bool
+	}{
+		{"enabled rule", "/work/app", true},
+		{"exact prefix", "/work", true},
+		{"earlier disabled rule wins", "/work/secret/app", false},
+		{"prefix is a whole directory", "/workshop", false},
+		{"no rule matches", "/tmp/scratch", false},
+		{"missing cwd", "", false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			hookData := buildHookDataJSON(HookData{
+				SessionID: fmt.Sprintf("test-session-workspace-%d", i),
+				ToolName:  "ExitPlanMode",
+				CWD:       tt.cwd,
+			})
+			if err := handler.HandleHook("PreToolUse", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mockNotif.wasCalled() != tt.notify {
+				t.Errorf("notified = %v, want %v", mockNotif.wasCalled(), tt.notify)
+			}
+		})
+	}
+}
+
+// === SubagentStop Tests ===
+
+func TestHandler_SubagentStop(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete":     {Title: "Task Complete"},
+			"subagent_complete": {Title: "Subagent Completed"},
+		},
+	}
+
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t,
+		buildTranscriptWithTools([]string{"Write"}, 300))
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID:      "test-session-11",
+		TranscriptPath: transcriptPath,
+		CWD:            "/test",
+	})
+
+	err := handler.HandleHook("SubagentStop", hookData)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected notification for SubagentStop")
+	}
+
+	// SubagentStop must not run the transcript analyzer (which would say task_complete)
+	call := mockNotif.lastCall()
+	if call.status != analyzer.StatusSubagentComplete {
+		t.Errorf("expected status %s, got %s", analyzer.StatusSubagentComplete, call.status)
+	}
+	if !strings.Contains(call.message, "Subagent Completed") {
+		t.Errorf("expected default subagent message, got %q", call.message)
+	}
+}
+
+func TestHandler_SubagentStop_WithoutTranscript(t *testing.T) {
+	cfg := config.DefaultConfig()
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-11b",
+		CWD:       "/test",
+	})
+
+	if err := handler.HandleHook("SubagentStop", hookData); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Error("expected notification for SubagentStop even without a transcript")
+	}
+}
+
+// === Unknown Hook Event ===
+
+func TestHandler_UnknownHookEvent(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"task_complete": {Title: "Task Complete"},
+		},
+	}
+	handler, _, _ := newTestHandler(t, cfg)
+
+	hookData := buildHookDataJSON(HookData{
+		SessionID: "test-session-12",
+		CWD:       "/test",
+	})
+
+	err := handler.HandleHook("UnknownEvent", hookData)
+
+	if err == nil {
+		t.Fatal("expected error for unknown hook event")
+	}
+
+	if !strings.Contains(err.Error(), "unknown hook event") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+// === Webhook Integration ===
+
+func TestHandler_SendsWebhookWhenEnabled(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+			Webhook: config.WebhookList{{Enabled: true}},
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
@@ -729,6 +1631,101 @@ func TestHandler_SendsWebhookWhenEnabled(t *testing.T) {
 	}
 }
 
+// orderRecorder records the order in which desktop and webhook notifications are sent.
+// In blocking mode the desktop send waits for the webhook, which only succeeds if both run concurrently.
+type orderRecorder struct {
+	mu       sync.Mutex
+	events   []string
+	block    bool
+	webhooks chan struct{}
+}
+
+func (r *orderRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+type orderedNotifier struct {
+	*mockNotifier
+	rec *orderRecorder
+}
+
+func (n *orderedNotifier) SendDesktopWithFocus(status analyzer.Status, message string, focus notifier.FocusContext) error {
+	if n.rec.block {
+		select {
+		case <-n.rec.webhooks:
+		case <-time.After(time.Second):
+			n.rec.record("desktop timed out waiting for webhook")
+		}
+	}
+	n.rec.record("desktop")
+	return n.mockNotifier.SendDesktopWithFocus(status, message, focus)
+}
+
+type orderedWebhook struct {
+	*mockWebhook
+	rec *orderRecorder
+}
+
+func (w *orderedWebhook) SendAsync(status analyzer.Status, message, sessionID string) {
+	w.rec.record("webhook")
+	w.mockWebhook.SendAsync(status, message, sessionID)
+	close(w.rec.webhooks)
+}
+
+func TestHandler_NotificationOrder(t *testing.T) {
+	tests := []struct {
+		order string
+		block bool
+		want  []string
+	}{
+		{"", false, []string{"desktop", "webhook"}},
+		{config.NotificationOrderDesktopFirst, false, []string{"desktop", "webhook"}},
+		{config.NotificationOrderWebhookFirst, false, []string{"webhook", "desktop"}},
+		{config.NotificationOrderParallel, true, []string{"webhook", "desktop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("order="+tt.order, func(t *testing.T) {
+			cfg := &config.Config{
+				Notifications: config.NotificationsConfig{
+					Desktop:           config.DesktopConfig{Enabled: true},
+					Webhook:           config.WebhookList{{Enabled: true}},
+					NotificationOrder: tt.order,
+				},
+				Statuses: map[string]config.StatusInfo{
+					"task_complete": {Title: "Task Complete"},
+				},
+			}
+
+			handler, mockNotif, mockWH := newTestHandler(t, cfg)
+			rec := &orderRecorder{block: tt.block, webhooks: make(chan struct{})}
+			handler.notifierSvc = &orderedNotifier{mockNotifier: mockNotif, rec: rec}
+			handler.webhookSvc = &orderedWebhook{mockWebhook: mockWH, rec: rec}
+
+			handler.deliverNotifications(analyzer.StatusTaskComplete, "Done", "order-session", notifier.FocusContext{})
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			if strings.Join(rec.events, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("events = %v, want %v", rec.events, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_FlushMetrics(t *testing.T) {
+	handler, _, mockWH := newTestHandler(t, config.DefaultConfig())
+
+	if err := handler.FlushMetrics(); err != nil {
+		t.Fatalf("FlushMetrics failed: %v", err)
+	}
+	if mockWH.flushes != 1 {
+		t.Errorf("expected metrics to be flushed once, got %d", mockWH.flushes)
+	}
+}
+
 // === NewHandler Constructor Tests ===
 
 func TestNewHandler_Success(t *testing.T) {
@@ -1015,3 +2012,189 @@ func TestHandleStopEvent_NonexistentTranscriptFile(t *testing.T) {
 		t.Errorf("should handle nonexistent transcript gracefully, got error: %v", err)
 	}
 }
+
+func TestHandleStopEvent_AnalysisError(t *testing.T) {
+	// Gzip magic bytes followed by garbage: the analyzer fails to decompress the transcript
+	transcriptPath := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(transcriptPath, []byte{0x1f, 0x8b, 'n', 'o', 't', ' ', 'g', 'z', 'i', 'p'}, 0644); err != nil {
+		t.Fatalf("failed to write transcript: %v", err)
+	}
+
+	tests := []struct {
+		name                  string
+		notifyOnAnalysisError bool
+		wantNotified          bool
+	}{
+		{"skipped by default", false, false},
+		{"fallback when enabled", true, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.NotifyOnAnalysisError = tt.notifyOnAnalysisError
+
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+
+			hookData := buildHookDataJSON(HookData{
+				SessionID:      fmt.Sprintf("test-analysis-error-%d", i),
+				TranscriptPath: transcriptPath,
+				CWD:            "/test",
+			})
+
+			if err := handler.HandleHook("Stop", hookData); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if mockNotif.wasCalled() != tt.wantNotified {
+				t.Fatalf("notification sent = %v, want %v", mockNotif.wasCalled(), tt.wantNotified)
+			}
+			if !tt.wantNotified {
+				return
+			}
+
+			call := mockNotif.lastCall()
+			if call.status != analyzer.StatusTaskComplete {
+				t.Errorf("got status %v, want StatusTaskComplete", call.status)
+			}
+			if call.message == "" {
+				t.Error("expected a generic message")
+			}
+		})
+	}
+}
+
+// === Throttle Tests ===
+
+func TestHandler_ThrottleMergesNotifications(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.ThrottleWindowSeconds = 1
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	// Background flushes, as in the socket server
+	handler.keepAlive = true
+
+	sessionID := fmt.Sprintf("throttle-session-%d", time.Now().UnixNano())
+	for i := 0; i < 3; i++ {
+		handler.sendNotifications(analyzer.StatusTaskComplete, "Created a file", sessionID, notifier.FocusContext{})
+	}
+
+	if mockNotif.wasCalled() {
+		t.Fatal("expected notifications to be held until the window closes")
+	}
+
+	handler.throttleFlushes.Wait()
+
+	if mockNotif.callCount() != 1 {
+		t.Fatalf("expected 1 merged notification, got %d", mockNotif.callCount())
+	}
+	call := mockNotif.lastCall()
+	if call.status != analyzer.StatusTaskComplete {
+		t.Errorf("expected task_complete status, got %s", call.status)
+	}
+	if !strings.Contains(call.message, "3 tasks completed in the last 1s") {
+		t.Errorf("expected merged message, got %q", call.message)
+	}
+}
+
+func TestHandler_ThrottleSingleNotificationUnchanged(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.ThrottleWindowSeconds = 1
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	var started []string
+	originalStart := startThrottleFlush
+	startThrottleFlush = func(pluginRoot, sessionID string) error {
+		started = append(started, sessionID)
+		return nil
+	}
+	defer func() { startThrottleFlush = originalStart }()
+
+	// One-shot hook process: the leader hands the window to a flush process and returns
+	sessionID := fmt.Sprintf("throttle-session-%d", time.Now().UnixNano())
+	start := time.Now()
+	handler.sendNotifications(analyzer.StatusQuestion, "Which option?", sessionID, notifier.FocusContext{})
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("hook waited %v for the throttle window", elapsed)
+	}
+	if len(started) != 1 || started[0] != sessionID {
+		t.Fatalf("expected a flush process for %s, started %v", sessionID, started)
+	}
+	if mockNotif.wasCalled() {
+		t.Fatal("expected the hook to leave sending to the flush process")
+	}
+
+	// What the flush process does
+	if err := handler.FlushThrottle(sessionID); err != nil {
+		t.Fatalf("FlushThrottle() error = %v", err)
+	}
+	if mockNotif.callCount() != 1 {
+		t.Fatalf("expected 1 notification, got %d", mockNotif.callCount())
+	}
+	if call := mockNotif.lastCall(); !strings.HasSuffix(call.message, "Which option?") {
+		t.Errorf("expected original message, got %q", call.message)
+	}
+}
+
+func TestHandler_ThrottleFlushesInProcessWhenStartFails(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.ThrottleWindowSeconds = 1
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	originalStart := startThrottleFlush
+	startThrottleFlush = func(pluginRoot, sessionID string) error {
+		return errors.New("no executable")
+	}
+	defer func() { startThrottleFlush = originalStart }()
+
+	sessionID := fmt.Sprintf("throttle-session-%d", time.Now().UnixNano())
+	handler.sendNotifications(analyzer.StatusQuestion, "Which option?", sessionID, notifier.FocusContext{})
+
+	if mockNotif.callCount() != 1 {
+		t.Fatalf("expected the hook to flush the window itself, got %d notification(s)", mockNotif.callCount())
+	}
+}
+
+// === Test Status ===
+
+func TestHandler_SendTest(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].URL = "https://example.com/webhook"
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	if err := handler.SendTest(); err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected desktop notification for test status")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusTest {
+		t.Errorf("expected test status on desktop, got %s", call.status)
+	}
+
+	if !mockWH.wasCalled() {
+		t.Fatal("expected webhook for test status")
+	}
+	if call := mockWH.calls[0]; call.status != analyzer.StatusTest {
+		t.Errorf("expected test status on webhook, got %s", call.status)
+	}
+}
+
+func TestHandler_SendTest_StatusNotConfigured(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	if err := handler.SendTest(); err == nil {
+		t.Fatal("expected error when test status is missing from config")
+	}
+	if mockNotif.wasCalled() {
+		t.Error("expected no notification")
+	}
+}
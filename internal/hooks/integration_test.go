@@ -28,7 +28,7 @@ func TestE2E_FullNotificationCycle(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
-			Webhook: config.WebhookConfig{Enabled: false},
+			Webhook: config.WebhookList{{Enabled: false}},
 			SuppressQuestionAfterAnyNotificationSeconds: 5, // 5s suppression window
 		},
 		Statuses: map[string]config.StatusInfo{
@@ -214,7 +214,7 @@ func TestE2E_WebhookRetry(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: false},
-			Webhook: config.WebhookConfig{
+			Webhook: config.WebhookList{{
 				Enabled: true,
 				URL:     server.URL,
 				Format:  "json",
@@ -230,7 +230,7 @@ func TestE2E_WebhookRetry(t *testing.T) {
 				RateLimit: config.RateLimitConfig{
 					Enabled: false, // Disable for this test
 				},
-			},
+			}},
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
@@ -322,7 +322,7 @@ func TestE2E_ConcurrentSessions(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
-			Webhook: config.WebhookConfig{Enabled: false},
+			Webhook: config.WebhookList{{Enabled: false}},
 			SuppressQuestionAfterAnyNotificationSeconds: 0, // Disabled for concurrent test
 		},
 		Statuses: map[string]config.StatusInfo{
@@ -407,7 +407,7 @@ func TestE2E_ConcurrentSessions(t *testing.T) {
 func newTempDedupManager(t *testing.T) *dedup.Manager {
 	t.Helper()
 	// Dedup manager uses temp dir automatically
-	return dedup.NewManager()
+	return dedup.NewManager(dedup.DefaultWindowSeconds)
 }
 
 // newTempStateManager creates a state manager with temp directory
@@ -426,7 +426,7 @@ func TestE2E_CodeReviewWorkflow(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
-			Webhook: config.WebhookConfig{Enabled: false},
+			Webhook: config.WebhookList{{Enabled: false}},
 		},
 		Statuses: map[string]config.StatusInfo{
 			"review_complete": {Title: "Review Complete"},
@@ -484,7 +484,7 @@ func TestE2E_FixAndTestWorkflow(t *testing.T) {
 	cfg := &config.Config{
 		Notifications: config.NotificationsConfig{
 			Desktop: config.DesktopConfig{Enabled: true},
-			Webhook: config.WebhookConfig{Enabled: false},
+			Webhook: config.WebhookList{{Enabled: false}},
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
@@ -0,0 +1,88 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// ErrNothingToReplay is returned by Replay when the transcript has no assistant
+// activity after the requested time
+var ErrNothingToReplay = errors.New("no assistant messages to replay")
+
+// replayTimeout bounds how long Replay waits for the webhook
+const replayTimeout = 30 * time.Second
+
+// Replay sends the notification a Stop hook would have sent for transcriptPath,
+// for sessions whose hooks were missed (e.g. the machine was restarted).
+// Only transcripts whose last assistant message is after since are replayed (zero = any).
+// Deduplication and cooldowns are skipped since replays are requested by hand.
+func (h *Handler) Replay(transcriptPath string, since time.Time) (analyzer.Status, error) {
+	defer func() {
+		if !h.keepAlive {
+			if err := h.notifierSvc.Close(); err != nil {
+				logging.Warn("Failed to close notifier: %v", err)
+			}
+		}
+	}()
+
+	logging.Debug("=== Replaying transcript: %s ===", transcriptPath)
+
+	messages, err := jsonl.ParseFile(transcriptPath)
+	if err != nil {
+		return analyzer.StatusUnknown, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	lastTS := jsonl.GetLastAssistantTimestamp(messages)
+	if lastTS == "" {
+		return analyzer.StatusUnknown, ErrNothingToReplay
+	}
+	if !since.IsZero() {
+		last, err := time.Parse(time.RFC3339, lastTS)
+		if err != nil {
+			return analyzer.StatusUnknown, fmt.Errorf("invalid timestamp on last assistant message: %w", err)
+		}
+		if !last.After(since) {
+			return analyzer.StatusUnknown, ErrNothingToReplay
+		}
+	}
+
+	hookData := &HookData{
+		TranscriptPath: transcriptPath,
+		SessionID:      sessionIDFromTranscript(transcriptPath),
+		HookEventName:  "Stop",
+	}
+
+	status, err := h.handleStopEvent(hookData)
+	if err != nil {
+		return status, err
+	}
+	if status == analyzer.StatusUnknown && !h.cfg.Notifications.NotifyOnUnknown {
+		logging.Debug("Replay: status is unknown, skipping notification")
+		return status, nil
+	}
+
+	message := h.generateMessage(hookData, status)
+	h.sendNotifications(status, message, hookData.SessionID, h.focusContext(hookData))
+
+	if h.cfg.IsWebhookEnabled() {
+		if err := h.webhookSvc.Wait(replayTimeout); err != nil {
+			return status, fmt.Errorf("replayed webhook did not complete: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// sessionIDFromTranscript returns the session ID Claude Code uses as the transcript file name
+func sessionIDFromTranscript(transcriptPath string) string {
+	if id := strings.TrimSuffix(filepath.Base(transcriptPath), ".jsonl"); id != "" && id != "." {
+		return id
+	}
+	return "unknown"
+}
@@ -0,0 +1,147 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestHandler_Replay(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].URL = "https://example.com/webhook"
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write", "Edit"}, 300))
+	since := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+
+	status, err := handler.Replay(transcriptPath, since)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if status != analyzer.StatusTaskComplete {
+		t.Errorf("expected task_complete, got %s", status)
+	}
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected desktop notification")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusTaskComplete {
+		t.Errorf("expected task_complete on desktop, got %s", call.status)
+	}
+	if !mockWH.wasCalled() {
+		t.Fatal("expected webhook")
+	}
+	if call := mockWH.calls[0]; call.sessionID != "transcript" {
+		t.Errorf("expected session ID from transcript file name, got %q", call.sessionID)
+	}
+}
+
+func TestHandler_Replay_NothingSince(t *testing.T) {
+	cfg := config.DefaultConfig()
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	transcriptPath := createTempTranscript(t, buildTranscriptWithTools([]string{"Write"}, 300))
+	since := time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC)
+
+	_, err := handler.Replay(transcriptPath, since)
+	if !errors.Is(err, ErrNothingToReplay) {
+		t.Fatalf("expected ErrNothingToReplay, got %v", err)
+	}
+	if mockNotif.wasCalled() {
+		t.Error("expected no notification")
+	}
+}
+
+func TestHandler_Replay_MissingTranscript(t *testing.T) {
+	handler, mockNotif, _ := newTestHandler(t, config.DefaultConfig())
+
+	if _, err := handler.Replay(t.TempDir()+"/missing.jsonl", time.Time{}); err == nil {
+		t.Fatal("expected error for missing transcript")
+	}
+	if mockNotif.wasCalled() {
+		t.Error("expected no notification")
+	}
+}
+
+// renameTranscript moves transcriptPath to a name unique to this run, so its session's
+// dedup lock and state don't carry over between test runs
+func renameTranscript(t *testing.T, transcriptPath string) (string, string) {
+	t.Helper()
+	sessionID := fmt.Sprintf("watch-%d", time.Now().UnixNano())
+	renamed := filepath.Join(filepath.Dir(transcriptPath), sessionID+".jsonl")
+	if err := os.Rename(transcriptPath, renamed); err != nil {
+		t.Fatal(err)
+	}
+	return renamed, sessionID
+}
+
+func TestHandler_NotifyTranscript(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Webhook[0].Enabled = true
+	cfg.Notifications.Webhook[0].URL = "https://example.com/webhook"
+	handler, mockNotif, mockWH := newTestHandler(t, cfg)
+
+	transcriptPath, sessionID := renameTranscript(t, createTempTranscript(t, buildTranscriptWithTools([]string{"Write", "Edit"}, 300)))
+	handler.NotifyTranscript(transcriptPath, analyzer.StatusTaskComplete)
+
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected desktop notification")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusTaskComplete {
+		t.Errorf("expected task_complete on desktop, got %s", call.status)
+	}
+	if !mockWH.wasCalled() {
+		t.Fatal("expected webhook")
+	}
+	if call := mockWH.calls[0]; call.sessionID != sessionID {
+		t.Errorf("expected session ID %q from transcript file name, got %q", sessionID, call.sessionID)
+	}
+}
+
+func TestHandler_NotifyTranscript_AfterStopHook(t *testing.T) {
+	cfg := config.DefaultConfig()
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	transcriptPath, sessionID := renameTranscript(t, createTempTranscript(t, buildTranscriptWithTools([]string{"Write", "Edit"}, 300)))
+	if err := handler.HandleHook("Stop", buildHookDataJSON(HookData{
+		SessionID:      sessionID,
+		TranscriptPath: transcriptPath,
+		CWD:            "/test",
+	})); err != nil {
+		t.Fatalf("HandleHook() error = %v", err)
+	}
+
+	// A watcher seeing the same turn must not notify it again
+	handler.NotifyTranscript(transcriptPath, analyzer.StatusTaskComplete)
+
+	if got := mockNotif.callCount(); got != 1 {
+		t.Errorf("expected 1 notification, got %d", got)
+	}
+}
+
+func TestHandler_NotifyTranscript_StatusCooldown(t *testing.T) {
+	cfg := config.DefaultConfig()
+	info := cfg.Statuses["task_complete"]
+	info.CooldownSeconds = 60
+	cfg.Statuses["task_complete"] = info
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+
+	transcriptPath, sessionID := renameTranscript(t, createTempTranscript(t, buildTranscriptWithTools([]string{"Write", "Edit"}, 300)))
+	handler.NotifyTranscript(transcriptPath, analyzer.StatusTaskComplete)
+	// Past the dedup window, only the cooldown applies
+	if err := handler.dedupMgr.ReleaseLock(sessionID, "Stop"); err != nil {
+		t.Fatal(err)
+	}
+	handler.NotifyTranscript(transcriptPath, analyzer.StatusTaskComplete)
+
+	if got := mockNotif.callCount(); got != 1 {
+		t.Errorf("expected 1 notification within the cooldown, got %d", got)
+	}
+}
@@ -0,0 +1,162 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/errorhandler"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/webhook"
+)
+
+// socketReadTimeout bounds how long a client may take to send its hook message
+const socketReadTimeout = 5 * time.Second
+
+// staleSocketDialTimeout bounds the check whether an existing socket is still served
+const staleSocketDialTimeout = time.Second
+
+// socketResponse is written back to the client after a hook is handled
+type socketResponse struct {
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ServeUnixSocket listens on a Unix domain socket and handles one hook JSON
+// message per connection. The hook event is taken from the message's
+// hook_event_name field. Blocks until SIGINT/SIGTERM is received.
+func ServeUnixSocket(socketPath string, handler *Handler) error {
+	if err := removeStaleSocket(socketPath); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	errorhandler.SafeGo(func() {
+		<-sigCh
+		logging.Info("Shutting down socket server")
+		listener.Close()
+	})
+
+	if addr := handler.cfg.Metrics.PrometheusAddr; addr != "" {
+		server := webhook.NewPrometheusServer(addr, handler.webhookSvc.GetMetrics)
+		errorhandler.SafeGo(func() {
+			logging.Info("Serving Prometheus metrics on http://%s/metrics", addr)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logging.Error("Prometheus metrics server failed: %v", err)
+			}
+		})
+		defer server.Close()
+	}
+
+	logging.Info("Listening for hooks on %s", socketPath)
+	return serveListener(listener, handler)
+}
+
+// removeStaleSocket removes a socket file left behind by a previous run. A socket
+// another daemon still listens on is an error, and the file is only removed when
+// connecting to it is refused. Regular files are left alone so a wrong path can't
+// delete user data.
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, staleSocketDialTimeout)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("another daemon is already listening on %s", socketPath)
+	}
+	if !isConnRefused(err) {
+		return fmt.Errorf("failed to check existing socket %s: %w", socketPath, err)
+	}
+
+	if err := os.Remove(socketPath); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
+
+// serveListener accepts connections until the listener is closed
+func serveListener(listener net.Listener, handler *Handler) error {
+	handler.keepAlive = true
+	defer func() {
+		if err := handler.Close(); err != nil {
+			logging.Warn("Failed to close hook handler: %v", err)
+		}
+	}()
+
+	// Hooks are handled one at a time, like separate hook processes would be
+	// serialized by the dedup locks
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		wg.Add(1)
+		errorhandler.SafeGo(func() {
+			defer wg.Done()
+			defer conn.Close()
+			handleSocketConn(conn, handler, &mu)
+		})
+	}
+}
+
+// handleSocketConn reads one hook message from conn, dispatches it and writes the response
+func handleSocketConn(conn net.Conn, handler *Handler, mu *sync.Mutex) {
+	_ = conn.SetReadDeadline(time.Now().Add(socketReadTimeout))
+
+	resp := socketResponse{OK: true}
+	if err := dispatchSocketMessage(conn, handler, mu); err != nil {
+		logging.Error("Socket hook failed: %v", err)
+		resp = socketResponse{Error: err.Error()}
+	}
+
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logging.Warn("Failed to write socket response: %v", err)
+	}
+}
+
+// dispatchSocketMessage decodes a single hook message and passes it to HandleHook
+func dispatchSocketMessage(conn net.Conn, handler *Handler, mu *sync.Mutex) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(conn).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to read hook data: %w", err)
+	}
+
+	var hookData HookData
+	if err := json.Unmarshal(raw, &hookData); err != nil {
+		return fmt.Errorf("failed to parse hook data: %w", err)
+	}
+	if hookData.HookEventName == "" {
+		return fmt.Errorf("hook_event_name is required")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return handler.HandleHook(hookData.HookEventName, bytes.NewReader(raw))
+}
@@ -0,0 +1,265 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// startTestSocketServer serves handler on a temporary Unix socket until the test ends
+func startTestSocketServer(t *testing.T, handler *Handler) string {
+	t.Helper()
+
+	// Keep the path short: Unix socket paths are limited to ~104 bytes
+	dir, err := os.MkdirTemp("", "cn-sock")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "hooks.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- serveListener(listener, handler) }()
+
+	t.Cleanup(func() {
+		listener.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("serveListener returned error: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Error("serveListener did not stop after listener was closed")
+		}
+	})
+
+	return socketPath
+}
+
+// sendSocketMessage sends one message over the socket and decodes the response
+func sendSocketMessage(t *testing.T, socketPath, message string) socketResponse {
+	t.Helper()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprint(conn, message); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	var resp socketResponse
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	return resp
+}
+
+func TestServeUnixSocket_DispatchesHook(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question": {Title: "Question"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	socketPath := startTestSocketServer(t, handler)
+
+	resp := sendSocketMessage(t, socketPath, `{"session_id":"socket-session-1","hook_event_name":"Notification"}`)
+
+	if !resp.OK || resp.Error != "" {
+		t.Fatalf("expected ok response, got %+v", resp)
+	}
+	if !mockNotif.wasCalled() {
+		t.Fatal("expected notification to be sent")
+	}
+	if call := mockNotif.lastCall(); call.status != analyzer.StatusQuestion {
+		t.Errorf("expected question status, got %s", call.status)
+	}
+}
+
+func TestServeListener_ClosesWebhooksOnShutdown(t *testing.T) {
+	handler, _, mockWH := newTestHandler(t, &config.Config{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- serveListener(listener, handler) }()
+
+	listener.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("serveListener returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serveListener did not stop after listener was closed")
+	}
+
+	mockWH.mu.Lock()
+	defer mockWH.mu.Unlock()
+	if mockWH.closes != 1 {
+		t.Errorf("expected the webhook sender to be closed once, got %d", mockWH.closes)
+	}
+}
+
+func TestServeUnixSocket_FocusFromClientEnv(t *testing.T) {
+	t.Setenv("TMUX_PANE", "%99")
+
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"question": {Title: "Question"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		message  string
+		wantPane string
+	}{
+		{"env sent with the hook", `{"session_id":"socket-focus-1","hook_event_name":"Notification","env":{"TMUX_PANE":"%3"}}`, "%3"},
+		// The daemon's own $TMUX_PANE is where it was started, not where Claude runs
+		{"no env", `{"session_id":"socket-focus-2","hook_event_name":"Notification"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockNotif, _ := newTestHandler(t, cfg)
+			socketPath := startTestSocketServer(t, handler)
+
+			if resp := sendSocketMessage(t, socketPath, tt.message); !resp.OK {
+				t.Fatalf("expected ok response, got %+v", resp)
+			}
+			if !mockNotif.wasCalled() {
+				t.Fatal("expected notification to be sent")
+			}
+			if got := mockNotif.lastCall().focus.TmuxPane; got != tt.wantPane {
+				t.Errorf("focus.TmuxPane = %q, want %q", got, tt.wantPane)
+			}
+		})
+	}
+}
+
+func TestServeUnixSocket_MultipleConnections(t *testing.T) {
+	cfg := &config.Config{
+		Notifications: config.NotificationsConfig{
+			Desktop: config.DesktopConfig{Enabled: true},
+		},
+		Statuses: map[string]config.StatusInfo{
+			"plan_ready": {Title: "Plan Ready"},
+		},
+	}
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	socketPath := startTestSocketServer(t, handler)
+
+	for i := 0; i < 3; i++ {
+		msg := fmt.Sprintf(`{"session_id":"socket-multi-%d","hook_event_name":"PreToolUse","tool_name":"ExitPlanMode"}`, i)
+		if resp := sendSocketMessage(t, socketPath, msg); !resp.OK {
+			t.Fatalf("message %d: expected ok response, got %+v", i, resp)
+		}
+	}
+
+	if mockNotif.callCount() != 3 {
+		t.Errorf("expected 3 notifications, got %d", mockNotif.callCount())
+	}
+}
+
+func TestServeUnixSocket_Errors(t *testing.T) {
+	cfg := config.DefaultConfig()
+	handler, mockNotif, _ := newTestHandler(t, cfg)
+	socketPath := startTestSocketServer(t, handler)
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"invalid JSON", `{not json}`},
+		{"missing hook event", `{"session_id":"socket-session-2"}`},
+		{"unknown hook event", `{"session_id":"socket-session-3","hook_event_name":"Bogus"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := sendSocketMessage(t, socketPath, tt.message)
+			if resp.OK || resp.Error == "" {
+				t.Errorf("expected error response, got %+v", resp)
+			}
+		})
+	}
+
+	if mockNotif.wasCalled() {
+		t.Error("expected no notifications for invalid messages")
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cn-sock")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "stale.sock")
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	// Leave the socket file behind, as a crashed daemon would
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	if err := removeStaleSocket(socketPath); err != nil {
+		t.Fatalf("removeStaleSocket failed: %v", err)
+	}
+	if _, err := os.Lstat(socketPath); !os.IsNotExist(err) {
+		t.Error("expected stale socket to be removed")
+	}
+
+	// A socket a running daemon listens on must not be removed
+	livePath := filepath.Join(dir, "live.sock")
+	live, err := net.Listen("unix", livePath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer live.Close()
+	if err := removeStaleSocket(livePath); err == nil {
+		t.Error("expected error for a socket that is still served")
+	}
+	if _, err := os.Lstat(livePath); err != nil {
+		t.Error("expected live socket to be left alone")
+	}
+
+	// Regular files must not be removed
+	regularPath := filepath.Join(dir, "not-a-socket")
+	if err := os.WriteFile(regularPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := removeStaleSocket(regularPath); err != nil {
+		t.Fatalf("removeStaleSocket failed: %v", err)
+	}
+	if _, err := os.Stat(regularPath); err != nil {
+		t.Error("expected regular file to be left alone")
+	}
+}
@@ -1,20 +1,150 @@
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Level is a log severity; messages below a destination's level are dropped
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level name as written in log lines
+func (lv Level) String() string {
+	switch lv {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(lv))
+	}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN"
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	default:
+		return LevelDebug, fmt.Errorf("unknown log level: %q (must be one of: debug, info, warn, error)", name)
+	}
+}
+
+// Format is how lines are written to the log file
+type Format int
+
+const (
+	FormatText Format = iota // "[2006-01-02 15:04:05] [INFO] prefix: message"
+	FormatJSON               // one JSON object per line, for log collectors like Loki or ELK
+)
+
+// ParseFormat parses a format name ("text" or "json")
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("unknown log format: %q (must be one of: text, json)", name)
+	}
+}
+
+// jsonLine is a log line in FormatJSON
+type jsonLine struct {
+	TS     string `json:"ts"`
+	Level  string `json:"level"`
+	PID    int    `json:"pid"`
+	Prefix string `json:"prefix,omitempty"`
+	Msg    string `json:"msg"`
+}
+
+// FormatEnvVar names the environment variable that sets the initial format of new
+// loggers' files (text or json). Unset means text.
+const FormatEnvVar = "CLAUDE_NOTIF_LOG_FORMAT"
+
+// LevelEnvVar names the environment variable that sets the initial level of new loggers
+// (debug, info, warn or error). Unset means debug, so everything is logged.
+const LevelEnvVar = "CLAUDE_NOTIF_LOG_LEVEL"
+
+// Rotation defaults used by InitLogger
+const (
+	DefaultMaxBytes   int64 = 5 * 1024 * 1024 // rotate once the log exceeds 5 MB
+	DefaultMaxBackups       = 3               // keep <path>.1 to <path>.3
+)
+
 // Logger provides structured logging to a file
 type Logger struct {
 	file          *os.File
+	path          string
 	mu            sync.Mutex
 	prefix        string
 	consoleOutput bool // Enable output to console (stderr/stdout)
+	fileLevel     Level
+	consoleLevel  Level
+	format        Format // of the log file; console output is always text
+	stdout        io.Writer
+	stderr        io.Writer
+
+	// maxBytes rotates the log to <path>.1 once it exceeds this size (0 = never rotate)
+	maxBytes int64
+	// maxBackups is how many rotated files (<path>.1 … <path>.N) are kept (minimum 1)
+	maxBackups int
+}
+
+// Option configures a Logger
+type Option func(*Logger)
+
+// WithFileLevel sets the minimum level written to the log file
+func WithFileLevel(level Level) Option {
+	return func(l *Logger) {
+		l.fileLevel = level
+	}
+}
+
+// WithConsoleLevel sets the minimum level written to the console when console output is enabled
+func WithConsoleLevel(level Level) Option {
+	return func(l *Logger) {
+		l.consoleLevel = level
+	}
+}
+
+// WithMaxFileSizeMB enables rotation once the log file exceeds sizeMB megabytes
+func WithMaxFileSizeMB(sizeMB int) Option {
+	return func(l *Logger) {
+		l.maxBytes = int64(sizeMB) * 1024 * 1024
+	}
+}
+
+// WithRotation rotates the log once it exceeds maxBytes, keeping maxBackups rotated files
+func WithRotation(maxBytes int64, maxBackups int) Option {
+	return func(l *Logger) {
+		l.maxBytes = maxBytes
+		l.maxBackups = maxBackups
+	}
 }
 
 var (
@@ -31,21 +161,116 @@ func InitLogger(pluginRoot string) (*Logger, error) {
 			pluginRoot = "."
 		}
 		logPath := filepath.Join(pluginRoot, "notification-debug.log")
-		defaultLogger, err = NewLogger(logPath)
+		defaultLogger, err = NewLoggerWithRotation(logPath, DefaultMaxBytes, DefaultMaxBackups)
 	})
 	return defaultLogger, err
 }
 
 // NewLogger creates a new logger that writes to the specified file
-func NewLogger(path string) (*Logger, error) {
+func NewLogger(path string, opts ...Option) (*Logger, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	level, levelErr := levelFromEnv()
+	format, formatErr := formatFromEnv()
+	l := &Logger{
+		file:         f,
+		path:         path,
+		fileLevel:    level,
+		consoleLevel: level,
+		format:       format,
+		stdout:       os.Stdout,
+		stderr:       os.Stderr,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if levelErr != nil {
+		l.Warn("Ignoring %s: %v", LevelEnvVar, levelErr)
+	}
+	if formatErr != nil {
+		l.Warn("Ignoring %s: %v", FormatEnvVar, formatErr)
+	}
+
+	return l, nil
+}
+
+// levelFromEnv returns the level set by LevelEnvVar, or LevelDebug if it is unset or invalid
+func levelFromEnv() (Level, error) {
+	name := os.Getenv(LevelEnvVar)
+	if name == "" {
+		return LevelDebug, nil
+	}
+	return ParseLevel(name)
+}
+
+// formatFromEnv returns the format set by FormatEnvVar, or FormatText if it is unset or invalid
+func formatFromEnv() (Format, error) {
+	name := os.Getenv(FormatEnvVar)
+	if name == "" {
+		return FormatText, nil
+	}
+	return ParseFormat(name)
+}
+
+// NewLoggerWithRotation creates a logger that rotates the file to <path>.1, <path>.2, …
+// once it exceeds maxBytes, keeping at most maxBackups rotated files
+func NewLoggerWithRotation(path string, maxBytes int64, maxBackups int, opts ...Option) (*Logger, error) {
+	return NewLogger(path, append([]Option{WithRotation(maxBytes, maxBackups)}, opts...)...)
+}
+
+// openLogFile opens the log file for appending, creating it if needed
+func openLogFile(path string) (*os.File, error) {
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
+	return f, nil
+}
+
+// checkRotate rotates the log file to <path>.1 if it exceeds maxBytes, shifting older
+// backups up by one. Backups beyond maxBackups are discarded.
+// Must be called with l.mu held.
+func (l *Logger) checkRotate() {
+	if l.maxBytes <= 0 || l.file == nil {
+		return
+	}
+
+	info, err := l.file.Stat()
+	if err != nil || info.Size() < l.maxBytes {
+		return
+	}
+
+	// Close before renaming (required on Windows)
+	_ = l.file.Close()
+
+	backups := max(l.maxBackups, 1)
+	// Discard the oldest backup, and any left over from a larger maxBackups
+	for n := backups; ; n++ {
+		if err := os.Remove(backupPath(l.path, n)); err != nil && n > backups {
+			break
+		}
+	}
+	for n := backups - 1; n >= 1; n-- {
+		_ = os.Rename(backupPath(l.path, n), backupPath(l.path, n+1))
+	}
+	_ = os.Rename(l.path, backupPath(l.path, 1))
 
-	return &Logger{
-		file: f,
-	}, nil
+	f, err := openLogFile(l.path)
+	if err != nil {
+		// Keep logging to stderr rather than panicking on a nil file
+		fmt.Fprintf(os.Stderr, "[claude-notifications] log rotation failed: %v\n", err)
+		l.file = nil
+		return
+	}
+	l.file = f
+}
+
+// backupPath returns the path of the nth rotated log file
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
 }
 
 // SetPrefix sets a prefix for all log messages
@@ -69,32 +294,73 @@ func (l *Logger) DisableConsoleOutput() {
 	l.consoleOutput = false
 }
 
-// log writes a formatted log message with timestamp
-func (l *Logger) log(level, format string, args ...interface{}) {
+// SetLevel sets the minimum level written to both the log file and the console
+func (l *Logger) SetLevel(level Level) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.fileLevel = level
+	l.consoleLevel = level
+}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	message := fmt.Sprintf(format, args...)
+// SetFormat sets how lines are written to the log file
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
+// SetFileLevel sets the minimum level written to the log file
+func (l *Logger) SetFileLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fileLevel = level
+}
+
+// SetConsoleLevel sets the minimum level written to the console
+func (l *Logger) SetConsoleLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.consoleLevel = level
+}
+
+// log writes a formatted log message with timestamp to each destination whose level allows it
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	var logLine string
-	if l.prefix != "" {
-		logLine = fmt.Sprintf("[%s] [%s] %s: %s\n", timestamp, level, l.prefix, message)
-	} else {
-		logLine = fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+	toFile := level >= l.fileLevel
+	toConsole := l.consoleOutput && level >= l.consoleLevel
+	if !toFile && !toConsole {
+		return
 	}
 
+	now := time.Now()
+	timestamp := now.Format("2006-01-02 15:04:05")
+	message := fmt.Sprintf(format, args...)
+
 	// Write to file
-	_, _ = l.file.WriteString(logLine)
+	if toFile {
+		var logLine string
+		if l.format == FormatJSON {
+			logLine = l.jsonLine(now, level, message)
+		} else if l.prefix != "" {
+			logLine = fmt.Sprintf("[%s] [%s] %s: %s\n", timestamp, level, l.prefix, message)
+		} else {
+			logLine = fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+		}
+
+		l.checkRotate()
+		if l.file != nil {
+			_, _ = l.file.WriteString(logLine)
+		}
+	}
 
 	// Write to console if enabled
-	if l.consoleOutput {
+	if toConsole {
 		// Use stderr for errors and warnings, stdout for info and debug
-		var consoleOutput io.Writer
-		if level == "ERROR" || level == "WARN" {
-			consoleOutput = os.Stderr
-		} else {
-			consoleOutput = os.Stdout
+		consoleOutput := l.stdout
+		if level >= LevelWarn {
+			consoleOutput = l.stderr
 		}
 
 		// Add plugin prefix to console output for clarity
@@ -108,24 +374,38 @@ func (l *Logger) log(level, format string, args ...interface{}) {
 	}
 }
 
+// jsonLine formats a FormatJSON log line. The message is already formatted; fields
+// aren't split out of it.
+func (l *Logger) jsonLine(now time.Time, level Level, message string) string {
+	// Marshalling strings and an int can't fail
+	data, _ := json.Marshal(jsonLine{
+		TS:     now.Format(time.RFC3339Nano),
+		Level:  level.String(),
+		PID:    os.Getpid(),
+		Prefix: l.prefix,
+		Msg:    message,
+	})
+	return string(data) + "\n"
+}
+
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	l.log("DEBUG", format, args...)
+	l.log(LevelDebug, format, args...)
 }
 
 // Info logs an info message
 func (l *Logger) Info(format string, args ...interface{}) {
-	l.log("INFO", format, args...)
+	l.log(LevelInfo, format, args...)
 }
 
 // Warn logs a warning message
 func (l *Logger) Warn(format string, args ...interface{}) {
-	l.log("WARN", format, args...)
+	l.log(LevelWarn, format, args...)
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.log("ERROR", format, args...)
+	l.log(LevelError, format, args...)
 }
 
 // Close closes the log file
@@ -195,6 +475,34 @@ func DisableConsoleOutput() {
 	}
 }
 
+// SetLevel sets the minimum file and console log level for the default logger
+func SetLevel(level Level) {
+	if defaultLogger != nil {
+		defaultLogger.SetLevel(level)
+	}
+}
+
+// SetFormat sets the log file format of the default logger
+func SetFormat(format Format) {
+	if defaultLogger != nil {
+		defaultLogger.SetFormat(format)
+	}
+}
+
+// SetFileLevel sets the minimum file log level for the default logger
+func SetFileLevel(level Level) {
+	if defaultLogger != nil {
+		defaultLogger.SetFileLevel(level)
+	}
+}
+
+// SetConsoleLevel sets the minimum console log level for the default logger
+func SetConsoleLevel(level Level) {
+	if defaultLogger != nil {
+		defaultLogger.SetConsoleLevel(level)
+	}
+}
+
 // Close closes the default logger
 func Close() error {
 	if defaultLogger != nil {
@@ -1,6 +1,9 @@
 package logging
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -257,6 +260,122 @@ func TestInitLogger(t *testing.T) {
 	}
 }
 
+func TestInitLogger_DefaultRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	defaultLogger = nil
+	once = sync.Once{}
+
+	logger, err := InitLogger(tmpDir)
+	if err != nil {
+		t.Fatalf("InitLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	if logger.maxBytes != DefaultMaxBytes {
+		t.Errorf("InitLogger() maxBytes = %d, want %d", logger.maxBytes, DefaultMaxBytes)
+	}
+	if logger.maxBackups != DefaultMaxBackups {
+		t.Errorf("InitLogger() maxBackups = %d, want %d", logger.maxBackups, DefaultMaxBackups)
+	}
+}
+
+func TestLogger_Rotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "rotate.log")
+
+	// Stale second backup from an older run must be discarded
+	if err := os.WriteFile(logPath+".2", []byte("ancient"), 0644); err != nil {
+		t.Fatalf("failed to write .2 backup: %v", err)
+	}
+
+	logger, err := NewLogger(logPath, WithMaxFileSizeMB(1))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	// Push the file past 1 MB, then write again to trigger rotation
+	logger.Info("first %s", strings.Repeat("x", 1024*1024))
+	logger.Info("second message")
+
+	backup, err := os.ReadFile(logPath + ".1")
+	if err != nil {
+		t.Fatalf("expected rotated backup: %v", err)
+	}
+	if !strings.Contains(string(backup), "first") {
+		t.Error("rotated backup should contain the first message")
+	}
+
+	current, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if strings.Contains(string(current), "first") || !strings.Contains(string(current), "second message") {
+		t.Errorf("fresh log file should contain only the second message, got %d bytes", len(current))
+	}
+
+	if _, err := os.Stat(logPath + ".2"); !os.IsNotExist(err) {
+		t.Error(".2 backup should be discarded on rotation")
+	}
+}
+
+func TestNewLoggerWithRotation_KeepsMaxBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "backups.log")
+
+	logger, err := NewLoggerWithRotation(logPath, 100, 2)
+	if err != nil {
+		t.Fatalf("NewLoggerWithRotation() error = %v", err)
+	}
+	defer logger.Close()
+
+	// Every message exceeds 100 bytes, so each write after the first rotates
+	for i := 1; i <= 4; i++ {
+		logger.Info("message %d %s", i, strings.Repeat("x", 100))
+	}
+
+	for n, want := range map[int]string{1: "message 3", 2: "message 2"} {
+		backup, err := os.ReadFile(fmt.Sprintf("%s.%d", logPath, n))
+		if err != nil {
+			t.Fatalf("expected rotated backup .%d: %v", n, err)
+		}
+		if !strings.Contains(string(backup), want) {
+			t.Errorf("backup .%d should contain %q, got:\n%s", n, want, backup)
+		}
+	}
+
+	current, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(current), "message 4") {
+		t.Errorf("log file should contain the latest message, got:\n%s", current)
+	}
+
+	if _, err := os.Stat(logPath + ".3"); !os.IsNotExist(err) {
+		t.Error("backups beyond maxBackups should be discarded")
+	}
+}
+
+func TestLogger_NoRotationWhenDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "norotate.log")
+
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.Info("first %s", strings.Repeat("x", 1024*1024))
+	logger.Info("second message")
+
+	if _, err := os.Stat(logPath + ".1"); !os.IsNotExist(err) {
+		t.Error("log should not rotate when maxBytes is 0")
+	}
+}
+
 func TestInitLogger_EmptyPath(t *testing.T) {
 	// Reset defaultLogger for this test
 	defaultLogger = nil
@@ -516,3 +635,263 @@ func TestLogger_ConsoleOutput_InfoToStdout(t *testing.T) {
 		t.Error("Log should contain [DEBUG]")
 	}
 }
+
+func TestLogger_SeparateFileAndConsoleLevels(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "levels.log")
+
+	logger, err := NewLogger(logPath, WithFileLevel(LevelDebug), WithConsoleLevel(LevelWarn))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	var stdout, stderr bytes.Buffer
+	logger.stdout = &stdout
+	logger.stderr = &stderr
+	logger.EnableConsoleOutput()
+
+	logger.Debug("debug details")
+	logger.Info("info details")
+	logger.Warn("something odd")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"[DEBUG] debug details", "[INFO] info details", "[WARN] something odd"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log file should contain %q, got:\n%s", want, content)
+		}
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("DEBUG/INFO should not reach the console, got: %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "[WARN] something odd") {
+		t.Errorf("WARN should reach the console, got: %q", stderr.String())
+	}
+}
+
+func TestLogger_FileLevelFiltersFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "file-level.log")
+
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+	logger.EnableConsoleOutput()
+	logger.SetFileLevel(LevelError)
+
+	logger.Info("console only")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), "console only") {
+		t.Errorf("INFO should not reach the file at ERROR level, got:\n%s", content)
+	}
+	if !strings.Contains(stdout.String(), "[INFO] console only") {
+		t.Errorf("INFO should reach the console, got: %q", stdout.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{" warn ", LevelWarn, false},
+		{"warning", LevelWarn, false},
+		{"Error", LevelError, false},
+		{"verbose", LevelDebug, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLogger_SetLevelDropsDebug(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "set-level.log")
+
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	var stdout bytes.Buffer
+	logger.stdout = &stdout
+	logger.EnableConsoleOutput()
+	logger.SetLevel(LevelInfo)
+
+	logger.Debug("debug spam")
+	logger.Info("info kept")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), "debug spam") {
+		t.Errorf("DEBUG should not reach the file at INFO level, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "[INFO] info kept") {
+		t.Errorf("INFO should reach the file, got:\n%s", content)
+	}
+	if strings.Contains(stdout.String(), "debug spam") {
+		t.Errorf("DEBUG should not reach the console at INFO level, got: %q", stdout.String())
+	}
+}
+
+func TestNewLogger_LevelFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       string
+		wantDebug bool
+		wantWarn  bool // a warning about an invalid value
+	}{
+		{"unset logs debug", "", true, false},
+		{"info drops debug", "info", false, false},
+		{"invalid falls back to debug", "verbose", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(LevelEnvVar, tt.env)
+			logPath := filepath.Join(t.TempDir(), "env-level.log")
+
+			logger, err := NewLogger(logPath)
+			if err != nil {
+				t.Fatalf("NewLogger() error = %v", err)
+			}
+			logger.Debug("debug line")
+			logger.Info("info line")
+			logger.Close()
+
+			content, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatalf("Failed to read log file: %v", err)
+			}
+			if got := strings.Contains(string(content), "[DEBUG] debug line"); got != tt.wantDebug {
+				t.Errorf("DEBUG line logged = %v, want %v, got:\n%s", got, tt.wantDebug, content)
+			}
+			if !strings.Contains(string(content), "[INFO] info line") {
+				t.Errorf("INFO line should be logged, got:\n%s", content)
+			}
+			if got := strings.Contains(string(content), "Ignoring "+LevelEnvVar); got != tt.wantWarn {
+				t.Errorf("invalid level warning = %v, want %v, got:\n%s", got, tt.wantWarn, content)
+			}
+		})
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "json.log")
+
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	logger.SetFormat(FormatJSON)
+	logger.SetPrefix("PID:42")
+	logger.Warn("webhook failed: %q", "timeout")
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %d:\n%s", len(lines), content)
+	}
+
+	var entry struct {
+		TS     string `json:"ts"`
+		Level  string `json:"level"`
+		PID    int    `json:"pid"`
+		Prefix string `json:"prefix"`
+		Msg    string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, lines[0])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry.TS); err != nil {
+		t.Errorf("ts = %q, want RFC 3339: %v", entry.TS, err)
+	}
+	if entry.Level != "WARN" {
+		t.Errorf("level = %q, want WARN", entry.Level)
+	}
+	if entry.PID != os.Getpid() {
+		t.Errorf("pid = %d, want %d", entry.PID, os.Getpid())
+	}
+	if entry.Prefix != "PID:42" {
+		t.Errorf("prefix = %q, want PID:42", entry.Prefix)
+	}
+	if entry.Msg != `webhook failed: "timeout"` {
+		t.Errorf("msg = %q, want the formatted message", entry.Msg)
+	}
+}
+
+func TestNewLogger_FormatFromEnv(t *testing.T) {
+	t.Setenv(FormatEnvVar, "json")
+	logPath := filepath.Join(t.TempDir(), "env-format.log")
+
+	logger, err := NewLogger(logPath)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	logger.Info("hello")
+	logger.Close()
+
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !json.Valid(bytes.TrimSpace(content)) {
+		t.Errorf("expected a JSON line, got:\n%s", content)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"text", FormatText, false},
+		{" JSON ", FormatJSON, false},
+		{"xml", FormatText, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseFormat(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
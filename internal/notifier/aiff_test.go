@@ -468,7 +468,7 @@ func TestDecodeAudio_EmptyPath(t *testing.T) {
 func TestDecodeAudio_SupportedExtensions(t *testing.T) {
 	// Test that all supported extensions are recognized
 	// (actual decoding will fail without valid audio data, but we test extension detection)
-	extensions := []string{".mp3", ".wav", ".flac", ".ogg", ".aiff", ".aif"}
+	extensions := []string{".mp3", ".wav", ".flac", ".ogg", ".aiff", ".aif", ".opus", ".m4a", ".aac"}
 
 	for _, ext := range extensions {
 		// Create temp file
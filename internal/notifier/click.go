@@ -0,0 +1,250 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// FocusContext records where Claude is running, captured from the environment at hook
+// time so that clicking the notification later can jump back to that pane
+type FocusContext struct {
+	TmuxPane      string // $TMUX_PANE, e.g. "%3"
+	ScreenSession string // $STY
+	ScreenWindow  string // $WINDOW, the screen window number
+	KittyWindow   string // $KITTY_WINDOW_ID
+	WeztermPane   string // $WEZTERM_PANE
+	ITermSession  string // session ID from $ITERM_SESSION_ID ("w0t0p0:<id>")
+	TermProgram   string // $TERM_PROGRAM, used to raise the terminal app on macOS
+	WindowID      string // $WINDOWID, used to raise the terminal window on X11
+	DisplayServer string // platform.LinuxDisplayServer(), "" if not detected
+	Compositor    string // platform.Compositor(), used to raise the terminal window on Wayland
+}
+
+// captureFocusContext reads the focus context from the environment via getenv
+func captureFocusContext(getenv func(string) string) FocusContext {
+	iTermSession := getenv("ITERM_SESSION_ID")
+	if _, id, found := strings.Cut(iTermSession, ":"); found {
+		iTermSession = id
+	}
+	return FocusContext{
+		TmuxPane:      getenv("TMUX_PANE"),
+		ScreenSession: getenv("STY"),
+		ScreenWindow:  getenv("WINDOW"),
+		KittyWindow:   getenv("KITTY_WINDOW_ID"),
+		WeztermPane:   getenv("WEZTERM_PANE"),
+		ITermSession:  iTermSession,
+		TermProgram:   getenv("TERM_PROGRAM"),
+		WindowID:      getenv("WINDOWID"),
+	}
+}
+
+// FocusContextFromEnv returns the focus context described by getenv, with the Linux display
+// stack of this machine. Long-lived processes use it with the environment a hook was sent from.
+func FocusContextFromEnv(getenv func(string) string) FocusContext {
+	fc := captureFocusContext(getenv)
+	if platform.IsLinux() {
+		fc.DisplayServer = platform.LinuxDisplayServer()
+		fc.Compositor = platform.Compositor()
+	}
+	return fc
+}
+
+// currentFocusContext returns the focus context of this process
+func currentFocusContext() FocusContext {
+	return FocusContextFromEnv(os.Getenv)
+}
+
+// command returns the shell command that focuses this context on goos, or "" if nothing
+// can be focused. The multiplexer pane is selected first, then the terminal is raised.
+func (fc FocusContext) command(goos string) string {
+	var commands []string
+
+	// Multiplexer inside the terminal
+	switch {
+	case fc.TmuxPane != "":
+		pane := shellQuote(fc.TmuxPane)
+		commands = append(commands, fmt.Sprintf("tmux select-window -t %s && tmux select-pane -t %s", pane, pane))
+	case fc.ScreenSession != "" && fc.ScreenWindow != "":
+		commands = append(commands, fmt.Sprintf("screen -S %s -X select %s", shellQuote(fc.ScreenSession), shellQuote(fc.ScreenWindow)))
+	}
+
+	// Terminal tab or pane
+	switch {
+	case fc.KittyWindow != "":
+		commands = append(commands, "kitty @ focus-window --match "+shellQuote("id:"+fc.KittyWindow))
+	case fc.WeztermPane != "":
+		commands = append(commands, "wezterm cli activate-pane --pane-id "+shellQuote(fc.WeztermPane))
+	case fc.ITermSession != "" && goos == "darwin":
+		return strings.Join(append(commands, "osascript -e "+shellQuote(iTermFocusScript(fc.ITermSession))), "; ")
+	}
+
+	if len(commands) == 0 {
+		return ""
+	}
+
+	// Terminal window
+	switch goos {
+	case "darwin":
+		if app := macTerminalApp(fc.TermProgram); app != "" {
+			script := fmt.Sprintf(`tell application "%s" to activate`, app)
+			commands = append(commands, "osascript -e "+shellQuote(script))
+		}
+	case "linux":
+		if raise := fc.linuxRaiseCommand(); raise != "" {
+			commands = append(commands, raise)
+		}
+	}
+	return strings.Join(commands, "; ")
+}
+
+// linuxRaiseCommand returns the shell command that raises the terminal window on Linux:
+// hyprctl or swaymsg on those compositors, xdotool on X11, or "" if there is no way to
+func (fc FocusContext) linuxRaiseCommand() string {
+	switch fc.Compositor {
+	case platform.CompositorHyprland, platform.CompositorSway:
+		appID := linuxAppID(fc)
+		if appID == "" {
+			return ""
+		}
+		if fc.Compositor == platform.CompositorHyprland {
+			return "hyprctl dispatch focuswindow " + shellQuote("class:^("+regexp.QuoteMeta(appID)+")$")
+		}
+		return "swaymsg " + shellQuote(fmt.Sprintf(`[app_id="%s"] focus`, appID))
+	}
+
+	// xdotool can't raise native Wayland windows
+	if fc.WindowID != "" && (fc.DisplayServer == "" || fc.DisplayServer == platform.DisplayX11) {
+		return "xdotool windowactivate " + shellQuote(fc.WindowID)
+	}
+	return ""
+}
+
+// linuxAppID returns the Wayland app ID (X11 class) of the terminal running Claude, or "" if unknown
+func linuxAppID(fc FocusContext) string {
+	switch {
+	case fc.KittyWindow != "":
+		return "kitty"
+	case fc.WeztermPane != "":
+		return "org.wezfurlong.wezterm"
+	}
+	switch fc.TermProgram {
+	case "ghostty":
+		return "com.mitchellh.ghostty"
+	case "vscode":
+		return "code"
+	default:
+		return ""
+	}
+}
+
+// iTermFocusScript returns the AppleScript that selects the iTerm2 session with id
+func iTermFocusScript(id string) string {
+	return fmt.Sprintf(`tell application "iTerm2"
+	repeat with w in windows
+		repeat with t in tabs of w
+			repeat with s in sessions of t
+				if unique id of s is "%s" then
+					select w
+					select t
+					select s
+					activate
+					return
+				end if
+			end repeat
+		end repeat
+	end repeat
+end tell`, strings.ReplaceAll(id, `"`, ""))
+}
+
+// shellQuote returns s as a single-quoted POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// clickNotifier shows a desktop notification that runs a shell command when clicked.
+// beeep has no click callback, so clickToFocus goes through one of these when available.
+type clickNotifier interface {
+	Notify(title, message, appIcon, onClick string) error
+}
+
+// findClickNotifier returns the click notifier installed for goos, or nil (overridable in tests)
+var findClickNotifier = detectClickNotifier
+
+// detectClickNotifier looks for terminal-notifier on macOS and notify-send on Linux
+func detectClickNotifier(goos string) clickNotifier {
+	switch goos {
+	case "darwin":
+		if path, err := exec.LookPath("terminal-notifier"); err == nil {
+			return terminalNotifier{path: path}
+		}
+	case "linux":
+		if path, err := exec.LookPath("notify-send"); err == nil {
+			return notifySend{path: path}
+		}
+	}
+	return nil
+}
+
+// terminalNotifier shows notifications with terminal-notifier, which runs -execute on click
+type terminalNotifier struct {
+	path string
+}
+
+// Notify shows the notification; terminal-notifier returns as soon as it is displayed
+func (tn terminalNotifier) Notify(title, message, appIcon, onClick string) error {
+	return exec.Command(tn.path, tn.args(title, message, appIcon, onClick)...).Run()
+}
+
+// args returns the terminal-notifier arguments
+func (tn terminalNotifier) args(title, message, appIcon, onClick string) []string {
+	args := []string{"-title", title, "-message", message, "-execute", onClick}
+	if appIcon != "" {
+		args = append(args, "-appIcon", appIcon)
+	}
+	return args
+}
+
+// onClickEnv passes the focus command to the notify-send wrapper script
+const onClickEnv = "CLAUDE_NOTIFICATIONS_ON_CLICK"
+
+// notifySendScript runs notify-send with its arguments and the focus command when the
+// notification's default action is chosen
+const notifySendScript = `action=$("$@") && [ "$action" = default ] && eval "$` + onClickEnv + `"`
+
+// notifySend shows notifications with notify-send --action (libnotify 0.7.9+)
+type notifySend struct {
+	path string
+}
+
+// Notify starts notify-send in the background, where it waits for the click after the
+// hook process has exited
+func (ns notifySend) Notify(title, message, appIcon, onClick string) error {
+	cmd := ns.command(title, message, appIcon, onClick)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// command returns the detached sh wrapper that runs notify-send and then onClick
+func (ns notifySend) command(title, message, appIcon, onClick string) *exec.Cmd {
+	args := append([]string{"-c", notifySendScript, "sh", ns.path}, ns.args(title, message, appIcon)...)
+	cmd := exec.Command("sh", args...)
+	cmd.Env = append(os.Environ(), onClickEnv+"="+onClick)
+	platform.DetachProcess(cmd)
+	return cmd
+}
+
+// args returns the notify-send arguments
+func (ns notifySend) args(title, message, appIcon string) []string {
+	args := []string{"--wait", "--action=default=Focus"}
+	if appIcon != "" {
+		args = append(args, "--icon="+appIcon)
+	}
+	return append(args, "--", title, message)
+}
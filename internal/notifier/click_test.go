@@ -0,0 +1,281 @@
+package notifier
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+func TestCaptureFocusContext(t *testing.T) {
+	env := map[string]string{
+		"TMUX_PANE":        "%3",
+		"KITTY_WINDOW_ID":  "7",
+		"ITERM_SESSION_ID": "w0t1p0:6B4F2C1E-1234",
+		"TERM_PROGRAM":     "iTerm.app",
+	}
+	got := captureFocusContext(func(key string) string { return env[key] })
+
+	want := FocusContext{TmuxPane: "%3", KittyWindow: "7", ITermSession: "6B4F2C1E-1234", TermProgram: "iTerm.app"}
+	if got != want {
+		t.Errorf("captureFocusContext() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFocusContextCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		fc   FocusContext
+		goos string
+		want string
+	}{
+		{"nothing to focus", FocusContext{TermProgram: "iTerm.app", WindowID: "42"}, "darwin", ""},
+		{
+			"tmux on Linux",
+			FocusContext{TmuxPane: "%3", WindowID: "42"},
+			"linux",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'; xdotool windowactivate '42'",
+		},
+		{
+			"tmux on macOS",
+			FocusContext{TmuxPane: "%3", TermProgram: "ghostty"},
+			"darwin",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'; osascript -e 'tell application \"Ghostty\" to activate'",
+		},
+		{
+			"tmux in an unknown macOS terminal",
+			FocusContext{TmuxPane: "%3", TermProgram: "Hyper"},
+			"darwin",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'",
+		},
+		{"screen needs a window", FocusContext{ScreenSession: "123.pts-0"}, "linux", ""},
+		{"screen", FocusContext{ScreenSession: "123.pts-0", ScreenWindow: "2"}, "linux", "screen -S '123.pts-0' -X select '2'"},
+		{"kitty", FocusContext{KittyWindow: "7"}, "linux", "kitty @ focus-window --match 'id:7'"},
+		{"wezterm", FocusContext{WeztermPane: "5"}, "linux", "wezterm cli activate-pane --pane-id '5'"},
+		{"iTerm2 ignored off macOS", FocusContext{ITermSession: "abc"}, "linux", ""},
+		{
+			"tmux on X11",
+			FocusContext{TmuxPane: "%3", WindowID: "42", DisplayServer: platform.DisplayX11},
+			"linux",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'; xdotool windowactivate '42'",
+		},
+		{
+			"tmux on GNOME Wayland",
+			FocusContext{TmuxPane: "%3", WindowID: "42", DisplayServer: platform.DisplayWayland, Compositor: platform.CompositorGNOME},
+			"linux",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'",
+		},
+		{
+			"kitty on Hyprland",
+			FocusContext{KittyWindow: "7", DisplayServer: platform.DisplayWayland, Compositor: platform.CompositorHyprland},
+			"linux",
+			"kitty @ focus-window --match 'id:7'; hyprctl dispatch focuswindow 'class:^(kitty)$'",
+		},
+		{
+			"wezterm on Hyprland",
+			FocusContext{WeztermPane: "5", DisplayServer: platform.DisplayWayland, Compositor: platform.CompositorHyprland},
+			"linux",
+			`wezterm cli activate-pane --pane-id '5'; hyprctl dispatch focuswindow 'class:^(org\.wezfurlong\.wezterm)$'`,
+		},
+		{
+			"tmux in ghostty on Sway",
+			FocusContext{TmuxPane: "%3", TermProgram: "ghostty", DisplayServer: platform.DisplayWayland, Compositor: platform.CompositorSway},
+			"linux",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'; swaymsg '[app_id=\"com.mitchellh.ghostty\"] focus'",
+		},
+		{
+			"unknown terminal on Sway",
+			FocusContext{TmuxPane: "%3", WindowID: "42", DisplayServer: platform.DisplayWayland, Compositor: platform.CompositorSway},
+			"linux",
+			"tmux select-window -t '%3' && tmux select-pane -t '%3'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fc.command(tt.goos); got != tt.want {
+				t.Errorf("command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFocusContextCommandITerm(t *testing.T) {
+	got := FocusContext{TmuxPane: "%1", ITermSession: "6B4F-1234", TermProgram: "iTerm.app"}.command("darwin")
+
+	if !strings.HasPrefix(got, "tmux select-window -t '%1' && tmux select-pane -t '%1'; osascript -e ") {
+		t.Errorf("command() = %q, want tmux then osascript", got)
+	}
+	if !strings.Contains(got, `unique id of s is "6B4F-1234"`) {
+		t.Errorf("command() = %q, want the iTerm2 session selected", got)
+	}
+	if strings.Count(got, "osascript") != 1 {
+		t.Errorf("command() = %q, want the iTerm2 script to raise the app itself", got)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"%3":        "'%3'",
+		"it's":      `'it'\''s'`,
+		"$(reboot)": "'$(reboot)'",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestClickNotifierArgs(t *testing.T) {
+	got := terminalNotifier{}.args("Question", "Which file?", "/icon.png", "tmux select-pane -t '%3'")
+	want := []string{"-title", "Question", "-message", "Which file?", "-execute", "tmux select-pane -t '%3'", "-appIcon", "/icon.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("terminalNotifier args = %q, want %q", got, want)
+	}
+
+	got = notifySend{}.args("Question", "-Which file?", "")
+	want = []string{"--wait", "--action=default=Focus", "--", "Question", "-Which file?"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("notifySend args = %q, want %q", got, want)
+	}
+}
+
+func TestNotifySendRunsFocusOnClick(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("notify-send wrapper needs a POSIX shell")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "focused")
+
+	tests := []struct {
+		name      string
+		action    string
+		wantFocus bool
+	}{
+		{"clicked", "default", true},
+		{"dismissed", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(marker)
+			fake := filepath.Join(dir, "notify-send-"+tt.name)
+			if err := os.WriteFile(fake, []byte("#!/bin/sh\necho '"+tt.action+"'\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := (notifySend{path: fake}).Notify("Question", "Which file?", "", "touch "+shellQuote(marker)); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+
+			// notify-send runs in the background; give it time to act on the click
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				if _, err := os.Stat(marker); err == nil {
+					break
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			if !tt.wantFocus {
+				time.Sleep(200 * time.Millisecond)
+			}
+			_, err := os.Stat(marker)
+			if focused := err == nil; focused != tt.wantFocus {
+				t.Errorf("focus command ran = %v, want %v", focused, tt.wantFocus)
+			}
+		})
+	}
+}
+
+// fakeClickNotifier records click notifications
+type fakeClickNotifier struct {
+	onClick string
+	calls   int
+	err     error
+}
+
+func (f *fakeClickNotifier) Notify(title, message, appIcon, onClick string) error {
+	f.calls++
+	f.onClick = onClick
+	return f.err
+}
+
+func TestSendDesktopClickToFocus(t *testing.T) {
+	originalNotify := notify
+	originalFind := findClickNotifier
+	defer func() {
+		notify = originalNotify
+		findClickNotifier = originalFind
+	}()
+
+	tmux := FocusContext{TmuxPane: "%3"}
+
+	tests := []struct {
+		name         string
+		clickToFocus bool
+		focus        FocusContext
+		clickErr     error
+		noNotifier   bool
+		wantClick    bool
+		wantBeeep    bool
+	}{
+		{"disabled", false, tmux, nil, false, false, true},
+		{"enabled", true, tmux, nil, false, true, false},
+		{"nothing to focus", true, FocusContext{}, nil, false, false, true},
+		{"no click notifier installed", true, tmux, nil, true, false, true},
+		{"click notifier fails", true, tmux, errors.New("boom"), false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			beeepCalls := 0
+			notify = func(title, message string, icon any) error {
+				beeepCalls++
+				return nil
+			}
+			fake := &fakeClickNotifier{err: tt.clickErr}
+			findClickNotifier = func(goos string) clickNotifier {
+				if tt.noNotifier {
+					return nil
+				}
+				return fake
+			}
+
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.Sound = false
+			cfg.Notifications.Desktop.ClickToFocus = tt.clickToFocus
+			n := New(cfg)
+			defer n.Close()
+			// The notifier's own pane must not be used when a focus is passed
+			n.focus = FocusContext{TmuxPane: "%0"}
+
+			if err := n.SendDesktopWithFocus(analyzer.StatusTaskComplete, "Done", tt.focus); err != nil {
+				t.Fatalf("SendDesktopWithFocus() error = %v", err)
+			}
+
+			if got := fake.calls > 0; got != tt.wantClick {
+				t.Errorf("click notifier used = %v, want %v", got, tt.wantClick)
+			}
+			if tt.wantClick && !strings.HasPrefix(fake.onClick, "tmux select-window -t '%3'") {
+				t.Errorf("onClick = %q, want the tmux pane selected", fake.onClick)
+			}
+			if got := beeepCalls > 0; got != tt.wantBeeep {
+				t.Errorf("beeep used = %v, want %v", got, tt.wantBeeep)
+			}
+		})
+	}
+}
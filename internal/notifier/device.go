@@ -0,0 +1,129 @@
+package notifier
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// alsaDevicePrefix marks a desktop.soundDevice that names an ALSA card instead of a
+// PulseAudio/PipeWire sink, e.g. "alsa:PCH" or "alsa:1"
+const alsaDevicePrefix = "alsa:"
+
+// ErrSoundDeviceUnsupported is returned by ListSoundDevices off Linux
+var ErrSoundDeviceUnsupported = errors.New("sound device selection is only supported on Linux")
+
+// SoundDevice is an audio output that desktop.soundDevice can select
+type SoundDevice struct {
+	Name        string `json:"name"`    // value for desktop.soundDevice
+	Backend     string `json:"backend"` // "pulseaudio" (also PipeWire) or "alsa"
+	Description string `json:"description"`
+}
+
+// soundDeviceEnv returns the environment that routes this process's audio to device.
+// oto (under beep/speaker) always opens ALSA's default PCM, so the device is chosen
+// the way ALSA and PulseAudio clients pick theirs: ALSA_CARD for an ALSA card, and
+// PULSE_SINK (PIPEWIRE_NODE for pipewire-alsa) for a sink behind the default PCM.
+func soundDeviceEnv(device string) map[string]string {
+	if card, ok := strings.CutPrefix(device, alsaDevicePrefix); ok {
+		return map[string]string{"ALSA_CARD": card}
+	}
+	return map[string]string{"PULSE_SINK": device, "PIPEWIRE_NODE": device}
+}
+
+// applySoundDevice routes sounds to desktop.soundDevice; it must run before the speaker
+// is initialized, since ALSA reads the environment when the device is opened
+func applySoundDevice(device, goos string) {
+	if device == "" {
+		return
+	}
+	if goos != "linux" {
+		logging.Warn("desktop.soundDevice is not supported on %s, using the default output", goos)
+		return
+	}
+	for key, value := range soundDeviceEnv(device) {
+		if err := os.Setenv(key, value); err != nil {
+			logging.Warn("Failed to select sound device %s: %v", device, err)
+			return
+		}
+	}
+	logging.Debug("Sound device selected: %s", device)
+}
+
+// ListSoundDevices returns the PulseAudio/PipeWire sinks and ALSA cards that
+// desktop.soundDevice can name. Either source may be missing; it is an error only if
+// neither can be read.
+func ListSoundDevices() ([]SoundDevice, error) {
+	if runtime.GOOS != "linux" {
+		return nil, ErrSoundDeviceUnsupported
+	}
+
+	var devices []SoundDevice
+	var errs []error
+	if out, err := exec.Command("pactl", "list", "short", "sinks").Output(); err == nil {
+		devices = append(devices, parsePactlSinks(string(out))...)
+	} else {
+		errs = append(errs, fmt.Errorf("pactl: %w", err))
+	}
+	if cards, err := os.ReadFile("/proc/asound/cards"); err == nil {
+		devices = append(devices, parseALSACards(string(cards))...)
+	} else {
+		errs = append(errs, fmt.Errorf("ALSA: %w", err))
+	}
+
+	if len(errs) == 2 {
+		return nil, errors.Join(errs...)
+	}
+	return devices, nil
+}
+
+// parsePactlSinks parses `pactl list short sinks`: index, name, module, sample spec, state
+func parsePactlSinks(output string) []SoundDevice {
+	var devices []SoundDevice
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 2 || fields[1] == "" {
+			continue
+		}
+		device := SoundDevice{Name: fields[1], Backend: "pulseaudio"}
+		if len(fields) >= 4 {
+			device.Description = fields[3]
+		}
+		if len(fields) >= 5 {
+			device.Description = strings.TrimSpace(device.Description + " " + strings.ToLower(fields[4]))
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// parseALSACards parses /proc/asound/cards, where each card is a line like
+// " 0 [PCH            ]: HDA-Intel - HDA Intel PCH" followed by an indented detail line
+func parseALSACards(content string) []SoundDevice {
+	var devices []SoundDevice
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		open, closing := strings.Index(line, "["), strings.Index(line, "]:")
+		if open < 0 || closing < open {
+			continue
+		}
+		id := strings.TrimSpace(line[open+1 : closing])
+		if id == "" {
+			continue
+		}
+		description := strings.TrimSpace(line[closing+2:])
+		if _, name, found := strings.Cut(description, " - "); found {
+			description = strings.TrimSpace(name)
+		}
+		devices = append(devices, SoundDevice{Name: alsaDevicePrefix + id, Backend: "alsa", Description: description})
+	}
+	return devices
+}
@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSoundDeviceEnv(t *testing.T) {
+	tests := []struct {
+		device string
+		want   map[string]string
+	}{
+		{"alsa:PCH", map[string]string{"ALSA_CARD": "PCH"}},
+		{"alsa:1", map[string]string{"ALSA_CARD": "1"}},
+		{
+			"alsa_output.usb-Headset-00.analog-stereo",
+			map[string]string{
+				"PULSE_SINK":    "alsa_output.usb-Headset-00.analog-stereo",
+				"PIPEWIRE_NODE": "alsa_output.usb-Headset-00.analog-stereo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.device, func(t *testing.T) {
+			if got := soundDeviceEnv(tt.device); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("soundDeviceEnv(%q) = %v, want %v", tt.device, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySoundDevice(t *testing.T) {
+	t.Setenv("PULSE_SINK", "")
+	t.Setenv("PIPEWIRE_NODE", "")
+
+	applySoundDevice("hdmi-sink", "darwin")
+	if got := os.Getenv("PULSE_SINK"); got != "" {
+		t.Errorf("PULSE_SINK = %q on macOS, want it untouched", got)
+	}
+
+	applySoundDevice("hdmi-sink", "linux")
+	if got := os.Getenv("PULSE_SINK"); got != "hdmi-sink" {
+		t.Errorf("PULSE_SINK = %q, want %q", got, "hdmi-sink")
+	}
+}
+
+func TestParsePactlSinks(t *testing.T) {
+	output := "0\talsa_output.pci-0000_00_1f.3.hdmi-stereo\tPipeWire\ts32le 2ch 48000Hz\tSUSPENDED\n" +
+		"1\talsa_output.usb-Headset-00.analog-stereo\tPipeWire\ts16le 2ch 48000Hz\tRUNNING\n" +
+		"\n"
+
+	want := []SoundDevice{
+		{Name: "alsa_output.pci-0000_00_1f.3.hdmi-stereo", Backend: "pulseaudio", Description: "s32le 2ch 48000Hz suspended"},
+		{Name: "alsa_output.usb-Headset-00.analog-stereo", Backend: "pulseaudio", Description: "s16le 2ch 48000Hz running"},
+	}
+	if got := parsePactlSinks(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePactlSinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseALSACards(t *testing.T) {
+	content := ` 0 [PCH            ]: HDA-Intel - HDA Intel PCH
+                      HDA Intel PCH at 0xf7f10000 irq 33
+ 1 [Headset        ]: USB-Audio - USB Headset
+                      Logitech USB Headset at usb-0000:00:14.0-2, full speed
+`
+
+	want := []SoundDevice{
+		{Name: "alsa:PCH", Backend: "alsa", Description: "HDA Intel PCH"},
+		{Name: "alsa:Headset", Backend: "alsa", Description: "USB Headset"},
+	}
+	if got := parseALSACards(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseALSACards() = %+v, want %+v", got, want)
+	}
+
+	if got := parseALSACards("--- no soundcards ---\n"); got != nil {
+		t.Errorf("parseALSACards(no cards) = %+v, want none", got)
+	}
+}
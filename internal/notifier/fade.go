@@ -0,0 +1,67 @@
+package notifier
+
+import (
+	"sync"
+
+	"github.com/gopxl/beep"
+)
+
+// fadeOutStreamer passes samples through until a fade is started, then ramps the
+// gain linearly down to zero over fadeSamples samples and ends the stream
+type fadeOutStreamer struct {
+	streamer    beep.Streamer
+	fadeSamples int
+
+	mu        sync.Mutex
+	fading    bool
+	remaining int // samples left in the fade, guarded by mu
+}
+
+// newFadeOutStreamer wraps streamer with a fade-out lasting fadeSamples samples
+func newFadeOutStreamer(streamer beep.Streamer, fadeSamples int) *fadeOutStreamer {
+	if fadeSamples < 1 {
+		fadeSamples = 1
+	}
+	return &fadeOutStreamer{streamer: streamer, fadeSamples: fadeSamples}
+}
+
+// StartFade begins the fade-out; calling it again has no effect
+func (f *fadeOutStreamer) StartFade() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.fading {
+		f.fading = true
+		f.remaining = f.fadeSamples
+	}
+}
+
+func (f *fadeOutStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fading {
+		if f.remaining <= 0 {
+			return 0, false
+		}
+		if len(samples) > f.remaining {
+			samples = samples[:f.remaining]
+		}
+	}
+
+	n, ok = f.streamer.Stream(samples)
+	if !f.fading {
+		return n, ok
+	}
+
+	for i := 0; i < n; i++ {
+		gain := float64(f.remaining-i) / float64(f.fadeSamples)
+		samples[i][0] *= gain
+		samples[i][1] *= gain
+	}
+	f.remaining -= n
+	return n, ok
+}
+
+func (f *fadeOutStreamer) Err() error {
+	return f.streamer.Err()
+}
@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"math"
+	"testing"
+)
+
+// constStreamer produces n samples of value 1.0 on both channels
+type constStreamer struct {
+	n int
+}
+
+func (s *constStreamer) Stream(samples [][2]float64) (int, bool) {
+	if s.n <= 0 {
+		return 0, false
+	}
+	count := len(samples)
+	if count > s.n {
+		count = s.n
+	}
+	for i := 0; i < count; i++ {
+		samples[i] = [2]float64{1, 1}
+	}
+	s.n -= count
+	return count, true
+}
+
+func (s *constStreamer) Err() error { return nil }
+
+func TestFadeOutStreamerPassesThroughUntilFade(t *testing.T) {
+	fade := newFadeOutStreamer(&constStreamer{n: 100}, 4)
+
+	buf := make([][2]float64, 10)
+	n, ok := fade.Stream(buf)
+	if n != 10 || !ok {
+		t.Fatalf("Stream() = (%d, %v), want (10, true)", n, ok)
+	}
+	for i, s := range buf {
+		if s != [2]float64{1, 1} {
+			t.Fatalf("sample %d = %v, want unchanged", i, s)
+		}
+	}
+}
+
+func TestFadeOutStreamerRampsDownAndStops(t *testing.T) {
+	fade := newFadeOutStreamer(&constStreamer{n: 100}, 4)
+	fade.StartFade()
+
+	buf := make([][2]float64, 10)
+	n, ok := fade.Stream(buf)
+	if n != 4 || !ok {
+		t.Fatalf("Stream() = (%d, %v), want (4, true)", n, ok)
+	}
+
+	want := []float64{1, 0.75, 0.5, 0.25}
+	for i, w := range want {
+		if math.Abs(buf[i][0]-w) > 1e-9 || math.Abs(buf[i][1]-w) > 1e-9 {
+			t.Errorf("sample %d = %v, want gain %.2f", i, buf[i], w)
+		}
+	}
+
+	if n, ok := fade.Stream(buf); n != 0 || ok {
+		t.Errorf("Stream() after fade = (%d, %v), want (0, false)", n, ok)
+	}
+}
+
+func TestFadeOutStreamerStartFadeIsIdempotent(t *testing.T) {
+	fade := newFadeOutStreamer(&constStreamer{n: 100}, 4)
+	fade.StartFade()
+
+	buf := make([][2]float64, 2)
+	fade.Stream(buf)
+	fade.StartFade()
+
+	n, _ := fade.Stream(make([][2]float64, 10))
+	if n != 2 {
+		t.Errorf("Stream() after second StartFade returned %d samples, want 2", n)
+	}
+}
@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// focusTerminal raises the terminal window running Claude (overridable in tests)
+var focusTerminal = raiseTerminal
+
+// focusAction returns the action that raises the terminal in fc for this status,
+// or nil if the status is not configured with autoFocus
+func focusAction(statusInfo config.StatusInfo, fc FocusContext) func() error {
+	if !statusInfo.AutoFocus {
+		return nil
+	}
+	return func() error { return focusTerminal(fc) }
+}
+
+// raiseTerminal brings the terminal application running fc to the foreground
+func raiseTerminal(fc FocusContext) error {
+	switch {
+	case platform.IsMacOS():
+		app := macTerminalApp(fc.TermProgram)
+		if app == "" {
+			return fmt.Errorf("unknown terminal (TERM_PROGRAM=%q), not raising a different app", fc.TermProgram)
+		}
+		script := fmt.Sprintf(`tell application "%s" to activate`, app)
+		return exec.Command("osascript", "-e", script).Run()
+	case platform.IsLinux():
+		raise := fc.linuxRaiseCommand()
+		if raise == "" {
+			return fmt.Errorf("cannot locate terminal window (WINDOWID not set on X11, or unsupported Wayland compositor)")
+		}
+		return exec.Command("sh", "-c", raise).Run()
+	default:
+		return fmt.Errorf("auto-focus is not supported on %s", platform.OS())
+	}
+}
+
+// macTerminalApp maps $TERM_PROGRAM to the macOS application name, or "" for a terminal
+// it doesn't know, which is left alone rather than activating some other app
+func macTerminalApp(termProgram string) string {
+	switch termProgram {
+	case "Apple_Terminal":
+		return "Terminal"
+	case "iTerm.app":
+		return "iTerm"
+	case "vscode":
+		return "Visual Studio Code"
+	case "WarpTerminal":
+		return "Warp"
+	case "ghostty":
+		return "Ghostty"
+	default:
+		return ""
+	}
+}
@@ -1,9 +1,12 @@
 package notifier
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,25 +36,233 @@ type Notifier struct {
 	speakerInited bool
 	mu            sync.Mutex
 	wg            sync.WaitGroup
+	lastSoundAt   time.Time // guarded by mu, for the sound cooldown
+
+	// closing is cancelled by Shutdown to fade out sounds that are still playing
+	closing     context.Context
+	stopPlaying context.CancelFunc
+
+	// soundMarkerPath shares the last sound time between hook processes ("" = in-memory only)
+	soundMarkerPath string
+
+	// now returns the current time for quiet hours checks (overridable in tests)
+	now func() time.Time
+
+	// appName returns the app name each notification is shown under (overridable in tests)
+	appName func() string
+
+	// focus is where Claude is running when SendDesktop is used, captured from this
+	// process's environment (see SendDesktopWithFocus for long-lived processes)
+	focus FocusContext
+
+	// headless is what this environment can't present; logged once via headlessLogged
+	headless       platform.Headless
+	headlessLogged sync.Once
+
+	// soundResolver picks the sound file for each notification (nil = StaticSound)
+	soundResolver SoundResolver
+}
+
+// SoundResolver returns the sound file to play for a status, or "" for no sound.
+// It lets embedders choose sounds dynamically, e.g. by time of day or at random.
+type SoundResolver func(status analyzer.Status, cfg *config.Config) string
+
+// StaticSound is the default SoundResolver: the sound configured for the status
+func StaticSound(status analyzer.Status, cfg *config.Config) string {
+	statusInfo, _ := cfg.GetStatusInfo(string(status))
+	return statusInfo.Sound
+}
+
+// SetSoundResolver replaces how sound files are chosen (nil restores StaticSound).
+// The attention sound, volume, quiet hours and desktop.sound still apply.
+func (n *Notifier) SetSoundResolver(resolver SoundResolver) {
+	n.soundResolver = resolver
+}
+
+// resolveSound returns the sound file to play for status
+func (n *Notifier) resolveSound(status analyzer.Status) string {
+	if n.soundResolver != nil {
+		return n.soundResolver(status, n.cfg)
+	}
+	return StaticSound(status, n.cfg)
 }
 
 // New creates a new notifier
 func New(cfg *config.Config) *Notifier {
+	closing, stopPlaying := context.WithCancel(context.Background())
 	return &Notifier{
-		cfg: cfg,
+		cfg:             cfg,
+		now:             time.Now,
+		appName:         uniqueAppName,
+		closing:         closing,
+		stopPlaying:     stopPlaying,
+		soundMarkerPath: filepath.Join(platform.TempDir(), "claude-notifications-last-sound"),
+		focus:           currentFocusContext(),
+		headless:        detectHeadless(),
 	}
 }
 
+// detectHeadless inspects the environment when a notifier is created (overridable in tests)
+var detectHeadless = platform.DetectHeadless
+
+// headlessConditions returns what this environment can't present, or nothing when
+// desktop.forceEnabled is set
+func (n *Notifier) headlessConditions() platform.Headless {
+	if n.cfg != nil && n.cfg.Notifications.Desktop.ForceEnabled {
+		return platform.Headless{}
+	}
+	return n.headless
+}
+
+// logHeadless logs once per notifier why desktop notifications or sounds are skipped,
+// instead of failing on every attempt to reach D-Bus or the speaker
+func (n *Notifier) logHeadless() {
+	n.headlessLogged.Do(func() {
+		logging.Info("Headless environment detected (%s), skipping what it can't present (set desktop.forceEnabled to try anyway)", n.headless)
+	})
+}
+
+// playbackContext returns a context for one sound playback, cancelled when Close is called
+func (n *Notifier) playbackContext() (context.Context, context.CancelFunc) {
+	parent := n.closing
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithCancel(parent)
+}
+
+// notify is the function used to display desktop notifications (overridable in tests)
+var notify = beeep.Notify
+
+// uniqueAppName gives each notification its own group ID based on the current time,
+// so the OS doesn't group or replace earlier notifications
+func uniqueAppName() string {
+	return fmt.Sprintf("claude-notif-%d", time.Now().UnixNano())
+}
+
+// desktopAudio is what to play after a desktop notification is shown
+type desktopAudio struct {
+	soundPath     string // empty = no sound
+	attentionPath string // chime played right before soundPath; empty = none
+	volume        float64
+	speech        string // text to speak; empty = TTS disabled
+}
+
+// sounds returns the sound files to play in order
+func (a desktopAudio) sounds() []string {
+	if a.attentionPath == "" {
+		return []string{a.soundPath}
+	}
+	return []string{a.attentionPath, a.soundPath}
+}
+
+// interactiveStatuses wait for the user, so their sound is preceded by the attentionSound
+var interactiveStatuses = map[analyzer.Status]bool{
+	analyzer.StatusQuestion:   true,
+	analyzer.StatusPermission: true,
+	analyzer.StatusPlanReady:  true,
+}
+
 // SendDesktop sends a desktop notification using beeep (cross-platform)
+// Sound playback and speech are asynchronous unless desktop.blockingSound is set; use
+// Close() to wait for them to finish. Playback errors are logged, not returned.
 func (n *Notifier) SendDesktop(status analyzer.Status, message string) error {
+	return n.SendDesktopWithFocus(status, message, n.focus)
+}
+
+// SendDesktopWithFocus is SendDesktop for a notification about Claude running in focus,
+// which clickToFocus and autoFocus jump back to. Long-lived processes (the hook daemon,
+// watch) use it because their own environment says nothing about where Claude runs.
+func (n *Notifier) SendDesktopWithFocus(status analyzer.Status, message string, focus FocusContext) error {
+	audio, err := n.showNotification(status, message, focus)
+	if err != nil || (audio.soundPath == "" && audio.speech == "") {
+		return err
+	}
+
+	ctx, cancel := n.playbackContext()
+	play := func() {
+		defer cancel()
+		if audio.soundPath != "" {
+			if err := n.playNotificationSounds(ctx, audio); err != nil {
+				logging.Error("Sound playback failed: %v", err)
+			}
+		}
+		n.speak(audio.speech)
+	}
+
+	if n.cfg.Notifications.Desktop.BlockingSound {
+		play()
+		return nil
+	}
+
+	n.wg.Add(1)
+	// Use SafeGo to protect against panics in sound playback goroutine
+	errorhandler.SafeGo(func() {
+		defer n.wg.Done()
+		play()
+	})
+
+	return nil
+}
+
+// SendDesktopSync sends a desktop notification and waits for sound playback to finish
+// Unlike SendDesktop, sound playback errors are returned to the caller (useful for test harnesses)
+func (n *Notifier) SendDesktopSync(status analyzer.Status, message string) error {
+	audio, err := n.showNotification(status, message, n.focus)
+	if err != nil {
+		return err
+	}
+
+	if audio.soundPath != "" {
+		ctx, cancel := n.playbackContext()
+		defer cancel()
+		if err := n.playNotificationSounds(ctx, audio); err != nil {
+			return err
+		}
+	}
+	n.speak(audio.speech)
+	return nil
+}
+
+// SendNotice shows a plain desktop notification about the plugin itself (e.g. a
+// configuration problem), without sound, speech or a status
+func (n *Notifier) SendNotice(title, message string) error {
 	if !n.cfg.IsDesktopEnabled() {
-		logging.Debug("Desktop notifications disabled, skipping")
+		return nil
+	}
+	if n.headlessConditions().NoDesktop() {
+		n.logHeadless()
 		return nil
 	}
 
+	appIcon := n.cfg.Notifications.Desktop.AppIcon
+	if appIcon != "" && !platform.FileExists(appIcon) {
+		appIcon = ""
+	}
+	return notify(title, message, appIcon)
+}
+
+// showNotification displays the desktop notification and returns the sound and speech to play
+// Returns empty audio if notifications are disabled or neither sound nor TTS is configured
+func (n *Notifier) showNotification(status analyzer.Status, message string, focus FocusContext) (desktopAudio, error) {
+	if !n.cfg.IsDesktopEnabled() {
+		logging.Debug("Desktop notifications disabled, skipping")
+		return desktopAudio{}, nil
+	}
+	if n.headlessConditions().NoDesktop() {
+		n.logHeadless()
+		return desktopAudio{}, nil
+	}
+
 	statusInfo, exists := n.cfg.GetStatusInfo(string(status))
 	if !exists {
-		return fmt.Errorf("unknown status: %s", status)
+		return desktopAudio{}, fmt.Errorf("unknown status: %s", status)
+	}
+
+	quietHours := n.inQuietHours()
+	if quietHours && n.cfg.Notifications.Desktop.QuietHours.MuteNotifications {
+		logging.Debug("Quiet hours: desktop notification muted")
+		return desktopAudio{}, nil
 	}
 
 	// Extract session name from message (format: "[session-name] actual message")
@@ -71,34 +282,184 @@ func (n *Notifier) SendDesktop(status analyzer.Status, message string) error {
 	}
 
 	// Set unique AppName to prevent notification grouping/replacement
-	// Each notification gets a unique group ID based on timestamp
+	appName := uniqueAppName
+	if n.appName != nil {
+		appName = n.appName
+	}
 	originalAppName := beeep.AppName
-	beeep.AppName = fmt.Sprintf("claude-notif-%d", time.Now().UnixNano())
+	beeep.AppName = appName()
 	defer func() {
 		beeep.AppName = originalAppName
 	}()
 
-	// Send notification using beeep with proper title and clean message
-	if err := beeep.Notify(title, cleanMessage, appIcon); err != nil {
+	body := combineTitleAndBody(n.cfg.Notifications.Desktop.TitleInBody, title, cleanMessage)
+
+	// Send notification with proper title and clean message
+	if err := n.deliver(title, body, appIcon, focus); err != nil {
 		logging.Error("Failed to send desktop notification: %v", err)
-		return err
+		return desktopAudio{}, err
 	}
 
-	logging.Debug("Desktop notification sent via beeep: title=%s", title)
+	// Raise the terminal for interactive statuses (e.g. questions) configured with autoFocus
+	if raise := focusAction(statusInfo, focus); raise != nil {
+		if err := raise(); err != nil {
+			logging.Warn("Failed to focus terminal: %v", err)
+		}
+	}
 
+	if quietHours {
+		logging.Debug("Quiet hours: skipping sound and speech")
+		return desktopAudio{}, nil
+	}
+
+	var audio desktopAudio
 	// Play sound if enabled (sequential playback handled by speaker mixer)
-	if n.cfg.Notifications.Desktop.Sound && statusInfo.Sound != "" {
-		n.wg.Add(1)
-		// Use SafeGo to protect against panics in sound playback goroutine
-		errorhandler.SafeGo(func() {
-			defer n.wg.Done()
-			n.playSound(statusInfo.Sound)
-		})
+	if n.cfg.Notifications.Desktop.Sound {
+		audio.soundPath = n.resolveSound(status)
+	}
+	if audio.soundPath != "" {
+		audio.volume = n.resolveVolume(statusInfo)
+		if interactiveStatuses[status] {
+			audio.attentionPath = n.cfg.Notifications.Desktop.AttentionSound
+		}
+	}
+	if n.cfg.Notifications.Desktop.TTS {
+		audio.speech = cleanMessage
 	}
 
+	return audio, nil
+}
+
+// deliver shows the notification. With clickToFocus it goes through a click notifier so that
+// clicking it focuses Claude's pane in focus; otherwise, or if none is installed, through beeep.
+func (n *Notifier) deliver(title, body, appIcon string, focus FocusContext) error {
+	if n.cfg.Notifications.Desktop.ClickToFocus {
+		if onClick := focus.command(runtime.GOOS); onClick != "" {
+			if cn := findClickNotifier(runtime.GOOS); cn != nil {
+				err := cn.Notify(title, body, appIcon, onClick)
+				if err == nil {
+					logging.Debug("Desktop notification sent with click-to-focus: title=%s", title)
+					return nil
+				}
+				logging.Warn("Click-to-focus notification failed, falling back to beeep: %v", err)
+			} else {
+				logging.Debug("clickToFocus: no terminal-notifier/notify-send found, using beeep")
+			}
+		}
+	}
+
+	if err := notify(title, body, appIcon); err != nil {
+		return err
+	}
+	logging.Debug("Desktop notification sent via beeep: title=%s", title)
 	return nil
 }
 
+// claimSoundCooldown records a sound as playing now, unless one played within
+// the configured cooldown, in which case it returns false. Each hook runs in its
+// own process, so the last sound time is also kept in a shared marker file.
+func (n *Notifier) claimSoundCooldown() bool {
+	cooldownMs := 0
+	if n.cfg != nil {
+		cooldownMs = n.cfg.Notifications.Desktop.SoundCooldownMs
+	}
+	if cooldownMs <= 0 {
+		return true
+	}
+
+	now := time.Now
+	if n.now != nil {
+		now = n.now
+	}
+	current := now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	last := n.lastSoundAt
+	if shared := readSoundMarker(n.soundMarkerPath); shared.After(last) {
+		last = shared
+	}
+	if !last.IsZero() && current.Sub(last) < time.Duration(cooldownMs)*time.Millisecond {
+		return false
+	}
+
+	n.lastSoundAt = current
+	writeSoundMarker(n.soundMarkerPath, current)
+	return true
+}
+
+// readSoundMarker returns the time stored in the shared sound marker, or zero if unavailable
+func readSoundMarker(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}
+	}
+	nanos, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// writeSoundMarker stores t in the shared sound marker
+func writeSoundMarker(path string, t time.Time) {
+	if path == "" {
+		return
+	}
+	if err := os.WriteFile(path, []byte(strconv.FormatInt(t.UnixNano(), 10)), 0600); err != nil {
+		logging.Debug("Failed to write sound cooldown marker: %v", err)
+	}
+}
+
+// inQuietHours reports whether desktop quiet hours are configured and active now
+func (n *Notifier) inQuietHours() bool {
+	now := time.Now
+	if n.now != nil {
+		now = n.now
+	}
+	return n.cfg.Notifications.Desktop.QuietHours.Contains(now())
+}
+
+// combineTitleAndBody repeats the title in the body according to the titleInBody mode
+// ("prefix" or "suffix"), so platforms that only display the body still show the status
+func combineTitleAndBody(mode, title, body string) string {
+	if body == "" {
+		return body
+	}
+	switch mode {
+	case "prefix":
+		return title + ": " + body
+	case "suffix":
+		return body + " (" + title + ")"
+	default:
+		return body
+	}
+}
+
+// resolveVolume returns the per-status volume if configured, otherwise the global desktop volume,
+// clamped to [0, 1]
+func (n *Notifier) resolveVolume(statusInfo config.StatusInfo) float64 {
+	if statusInfo.Volume != nil {
+		return clampVolume(*statusInfo.Volume)
+	}
+	return clampVolume(n.cfg.Notifications.Desktop.Volume)
+}
+
+// clampVolume limits volume to the 0.0-1.0 range supported by volumeToGain
+func clampVolume(volume float64) float64 {
+	if volume < 0 {
+		return 0
+	}
+	if volume > 1 {
+		return 1
+	}
+	return volume
+}
+
 // initSpeaker initializes the speaker once with sync.Once
 func (n *Notifier) initSpeaker() error {
 	// Check if already initialized
@@ -112,6 +473,10 @@ func (n *Notifier) initSpeaker() error {
 	var initErr error
 
 	n.speakerInit.Do(func() {
+		if n.cfg != nil {
+			applySoundDevice(n.cfg.Notifications.Desktop.SoundDevice, runtime.GOOS)
+		}
+
 		// Initialize speaker with standard sample rate (44100 Hz) and buffer size (4096 samples)
 		// Buffer size of 4096 samples = ~93ms latency at 44100 Hz
 		sampleRate := beep.SampleRate(44100)
@@ -132,9 +497,28 @@ func (n *Notifier) initSpeaker() error {
 	return initErr
 }
 
+// AudioExtensions lists the sound file extensions DecodeAudio accepts
+var AudioExtensions = []string{".mp3", ".wav", ".flac", ".ogg", ".aiff", ".aif", ".opus", ".m4a", ".aac"}
+
+// IsAudioFile reports whether path has a supported sound file extension
+func IsAudioFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, supported := range AudioExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
 // decodeAudio decodes an audio file and returns a streamer and format
-// Supports: MP3, WAV, FLAC, AIFF, Vorbis (OGG)
 func (n *Notifier) decodeAudio(soundPath string) (beep.StreamSeekCloser, beep.Format, error) {
+	return DecodeAudio(soundPath)
+}
+
+// DecodeAudio decodes an audio file and returns a streamer and format
+// Supports: MP3, WAV, FLAC, AIFF, Vorbis (OGG), and Opus/M4A/AAC via an external decoder
+func DecodeAudio(soundPath string) (beep.StreamSeekCloser, beep.Format, error) {
 	f, err := os.Open(soundPath)
 	if err != nil {
 		return nil, beep.Format{}, fmt.Errorf("failed to open audio file: %w", err)
@@ -209,6 +593,15 @@ func (n *Notifier) decodeAudio(soundPath string) (beep.StreamSeekCloser, beep.Fo
 
 		return streamer, format, nil
 
+	case ".opus", ".m4a", ".aac":
+		// No pure-Go decoder for these formats, convert to WAV with an external tool
+		f.Close()
+		streamer, format, err := DecodeWithExternalTool(soundPath)
+		if err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to decode %s: %w", strings.ToUpper(ext[1:]), err)
+		}
+		return streamer, format, nil
+
 	default:
 		f.Close()
 		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", ext)
@@ -300,58 +693,151 @@ func (s *aiffStreamer) Close() error {
 	return nil
 }
 
+// PlaySound plays a sound file at the given volume (clamped to [0, 1]) and waits for it to finish
+func (n *Notifier) PlaySound(soundPath string, volume float64) error {
+	ctx, cancel := n.playbackContext()
+	defer cancel()
+	return n.playSound(ctx, soundPath, clampVolume(volume))
+}
+
 // playSound plays a sound file using gopxl/beep (cross-platform) with volume control
-func (n *Notifier) playSound(soundPath string) {
-	if !platform.FileExists(soundPath) {
-		logging.Warn("Sound file not found: %s", soundPath)
-		return
+// Blocks until playback completes or times out. When ctx is cancelled or playback times
+// out, the sound fades out over the configured fadeOutMs (or keeps playing to the end if
+// no fade is set)
+func (n *Notifier) playSound(ctx context.Context, soundPath string, volume float64) error {
+	return n.playSounds(ctx, []string{soundPath}, volume)
+}
+
+// playNotificationSounds plays a notification's sounds unless another notification played
+// one within desktop.soundCooldownMs. The cooldown is claimed only once the files are found,
+// so a misconfigured sound doesn't silence the notifications that follow it.
+func (n *Notifier) playNotificationSounds(ctx context.Context, audio desktopAudio) error {
+	soundPaths := audio.sounds()
+	if n.headlessConditions().NoSound() {
+		n.logHeadless()
+		return nil
+	}
+	if err := checkSoundFiles(soundPaths); err != nil {
+		return err
+	}
+	if !n.claimSoundCooldown() {
+		logging.Debug("Sound cooldown active, skipping: %s", audio.soundPath)
+		return nil
+	}
+	return n.playSounds(ctx, soundPaths, audio.volume)
+}
+
+// checkSoundFiles returns an error for the first sound file that doesn't exist
+func checkSoundFiles(soundPaths []string) error {
+	for _, path := range soundPaths {
+		if !platform.FileExists(path) {
+			return fmt.Errorf("sound file not found: %s", path)
+		}
+	}
+	return nil
+}
+
+// maxAttentionDuration cuts off an attention chime so a long file can't hold up the status sound
+const maxAttentionDuration = 1500 * time.Millisecond
+
+// speakerPlay starts playing a streamer on the speaker mixer (overridable in tests)
+var speakerPlay = speaker.Play
+
+// playSounds plays sound files one after another as a single sequence, like playSound.
+// Every sound but the last is an attention chime and is cut off after maxAttentionDuration.
+func (n *Notifier) playSounds(ctx context.Context, soundPaths []string, volume float64) error {
+	soundPath := soundPaths[len(soundPaths)-1]
+	if n.headlessConditions().NoSound() {
+		n.logHeadless()
+		return nil
+	}
+	if err := checkSoundFiles(soundPaths); err != nil {
+		return err
 	}
 
 	// Initialize speaker once
 	if err := n.initSpeaker(); err != nil {
-		logging.Error("Failed to initialize speaker: %v", err)
-		return
+		return fmt.Errorf("failed to initialize speaker: %w", err)
 	}
 
-	// Decode audio file
-	streamer, format, err := n.decodeAudio(soundPath)
-	if err != nil {
-		logging.Error("Failed to decode audio %s: %v", soundPath, err)
-		return
-	}
-	defer streamer.Close()
+	sampleRate := beep.SampleRate(44100)
+	sequence := make([]beep.Streamer, 0, len(soundPaths))
+	for i, path := range soundPaths {
+		// Decode audio file
+		streamer, format, err := n.decodeAudio(path)
+		if err != nil {
+			return fmt.Errorf("failed to decode audio %s: %w", path, err)
+		}
+		defer streamer.Close()
 
-	// Resample if needed (convert to speaker's sample rate: 44100 Hz)
-	resampled := beep.Resample(4, format.SampleRate, beep.SampleRate(44100), streamer)
+		// Resample if needed (convert to speaker's sample rate: 44100 Hz)
+		var resampled beep.Streamer = beep.Resample(4, format.SampleRate, sampleRate, streamer)
+		if i < len(soundPaths)-1 {
+			resampled = beep.Take(sampleRate.N(maxAttentionDuration), resampled)
+		}
+		sequence = append(sequence, resampled)
+	}
 
-	// Apply volume control from config
-	volume := n.cfg.Notifications.Desktop.Volume
-	var gainStreamer beep.Streamer = resampled
+	// Apply volume control
+	var gainStreamer beep.Streamer = beep.Seq(sequence...)
 	if volume < 1.0 {
 		gainStreamer = &effects.Gain{
-			Streamer: resampled,
+			Streamer: gainStreamer,
 			Gain:     volumeToGain(volume),
 		}
 		logging.Debug("Applying volume control: %.0f%%", volume*100)
 	}
 
+	// Wrap in a fade-out so Shutdown() or the timeout can end the sound smoothly
+	var fade *fadeOutStreamer
+	if fadeMs := n.fadeOutMs(); fadeMs > 0 {
+		fade = newFadeOutStreamer(gainStreamer, sampleRate.N(time.Duration(fadeMs)*time.Millisecond))
+		gainStreamer = fade
+	}
+
 	// Create done channel to wait for playback completion
-	done := make(chan bool)
+	done := make(chan bool, 1)
 
 	// Play sound with callback when finished
-	speaker.Play(beep.Seq(gainStreamer, beep.Callback(func() {
+	speakerPlay(beep.Seq(gainStreamer, beep.Callback(func() {
 		done <- true
 	})))
 
 	// Wait for playback to complete with timeout
-	select {
-	case <-done:
-		logging.Debug("Sound played successfully: %s (volume: %.0f%%)", soundPath, volume*100)
-	case <-time.After(30 * time.Second):
-		logging.Warn("Sound playback timed out: %s", soundPath)
+	timeout := time.After(30 * time.Second)
+	stopping := ctx.Done()
+	for {
+		select {
+		case <-done:
+			logging.Debug("Sound played successfully: %s (volume: %.0f%%)", soundPath, volume*100)
+			return nil
+		case <-stopping:
+			stopping = nil
+			if fade != nil {
+				logging.Debug("Fading out sound: %s", soundPath)
+				fade.StartFade()
+			}
+		case <-timeout:
+			if fade != nil {
+				fade.StartFade()
+				select {
+				case <-done:
+				case <-time.After(time.Duration(n.fadeOutMs())*time.Millisecond + time.Second):
+				}
+			}
+			return fmt.Errorf("sound playback timed out: %s", soundPath)
+		}
 	}
 }
 
+// fadeOutMs returns the configured fade-out length in milliseconds (0 = no fade)
+func (n *Notifier) fadeOutMs() int {
+	if n.cfg == nil {
+		return 0
+	}
+	return n.cfg.Notifications.Desktop.FadeOutMs
+}
+
 // volumeToGain converts linear volume (0.0-1.0) to gain value for effects.Gain
 // effects.Gain formula: output = input * (1 + Gain)
 // Examples: volume 1.0 → Gain 0.0 (100%), volume 0.3 → Gain -0.7 (30%), volume 0.5 → Gain -0.5 (50%)
@@ -359,20 +845,34 @@ func volumeToGain(volume float64) float64 {
 	return volume - 1.0
 }
 
-// Close waits for all sounds to finish playing and cleans up resources
+// Close waits for all sounds to finish playing and cleans up resources. It is for callers
+// that exist to deliver a notification (send-test, a throttle flush, a replay); a process
+// exiting after other work, like a hook, uses Shutdown so its sounds fade out instead.
 func (n *Notifier) Close() error {
-	// Wait for all sounds to finish
 	n.wg.Wait()
+	n.closeSpeaker()
+	return nil
+}
+
+// Shutdown is Close for a process that is exiting (a hook, or a watcher stopped by a signal):
+// sounds still playing are faded out over fadeOutMs instead of played to the end
+func (n *Notifier) Shutdown() error {
+	if n.stopPlaying != nil {
+		n.stopPlaying()
+	}
+	n.wg.Wait()
+	n.closeSpeaker()
+	return nil
+}
 
-	// Close speaker if it was initialized
+// closeSpeaker closes the speaker if it was initialized
+func (n *Notifier) closeSpeaker() {
 	n.mu.Lock()
+	defer n.mu.Unlock()
 	if n.speakerInited {
 		speaker.Close()
 		logging.Debug("Speaker closed")
 	}
-	n.mu.Unlock()
-
-	return nil
 }
 
 // extractSessionName extracts session name from message with format "[session-name] message"
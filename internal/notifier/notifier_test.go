@@ -1,14 +1,25 @@
 package notifier
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gen2brain/beeep"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
 )
 
+// TestMain runs the tests as if on a desktop, so CI and containers don't skip what they check
+func TestMain(m *testing.M) {
+	detectHeadless = func() platform.Headless { return platform.Headless{} }
+	os.Exit(m.Run())
+}
+
 func TestExtractSessionName(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -141,3 +152,437 @@ func TestVolumeToGain(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveVolume(t *testing.T) {
+	questionVolume := 0.8
+	taskVolume := 0.4
+	loudVolume := 1.5
+	negativeVolume := -0.2
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Volume = 0.6
+	n := New(cfg)
+
+	tests := []struct {
+		name       string
+		statusInfo config.StatusInfo
+		expected   float64
+	}{
+		{"per-status volume (question)", config.StatusInfo{Volume: &questionVolume}, 0.8},
+		{"per-status volume (task_complete)", config.StatusInfo{Volume: &taskVolume}, 0.4},
+		{"nil falls back to global volume", config.StatusInfo{}, 0.6},
+		{"above 1 is clamped", config.StatusInfo{Volume: &loudVolume}, 1.0},
+		{"negative is clamped", config.StatusInfo{Volume: &negativeVolume}, 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := n.resolveVolume(tt.statusInfo)
+			if result != tt.expected {
+				t.Errorf("resolveVolume() = %.2f, want %.2f", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSendDesktopSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping sound playback test in short mode")
+	}
+
+	// Stub out the visual notification so the test doesn't depend on a notification daemon
+	originalNotify := notify
+	notify = func(title, message string, icon any) error { return nil }
+	defer func() { notify = originalNotify }()
+
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	t.Run("returns after playback finishes", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Notifications.Desktop.Volume = 0.3
+		cfg.Statuses["task_complete"] = config.StatusInfo{
+			Title: "Task Complete",
+			Sound: filepath.Join(soundsDir, "task-complete.mp3"),
+		}
+		n := New(cfg)
+		defer n.Close()
+
+		start := time.Now()
+		if err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] Done"); err != nil {
+			t.Fatalf("SendDesktopSync() error = %v", err)
+		}
+
+		// The call must block for the duration of the sound
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("SendDesktopSync() returned after %v, expected it to wait for playback", elapsed)
+		}
+	})
+
+	t.Run("errors on missing sound file", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Statuses["task_complete"] = config.StatusInfo{
+			Title: "Task Complete",
+			Sound: filepath.Join(t.TempDir(), "missing.mp3"),
+		}
+		n := New(cfg)
+		defer n.Close()
+
+		err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] Done")
+		if err == nil {
+			t.Fatal("SendDesktopSync() expected error for missing sound file, got nil")
+		}
+		if !strings.Contains(err.Error(), "sound file not found") {
+			t.Errorf("SendDesktopSync() error = %v, want sound file not found", err)
+		}
+	})
+}
+
+func TestFocusAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusInfo config.StatusInfo
+		wantAction bool
+	}{
+		{"autoFocus enabled", config.StatusInfo{Title: "Question", AutoFocus: true}, true},
+		{"autoFocus disabled", config.StatusInfo{Title: "Task Complete"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := focusAction(tt.statusInfo, FocusContext{}) != nil; got != tt.wantAction {
+				t.Errorf("focusAction() attached = %v, want %v", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestSendDesktopAutoFocusOnlyForConfiguredStatuses(t *testing.T) {
+	originalNotify := notify
+	originalFocus := focusTerminal
+	defer func() {
+		notify = originalNotify
+		focusTerminal = originalFocus
+	}()
+
+	notify = func(title, message string, icon any) error { return nil }
+	focused := 0
+	focusTerminal = func(FocusContext) error {
+		focused++
+		return nil
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Sound = false
+	question := cfg.Statuses["question"]
+	question.AutoFocus = true
+	cfg.Statuses["question"] = question
+	n := New(cfg)
+	defer n.Close()
+
+	statuses := []struct {
+		status    analyzer.Status
+		wantFocus bool
+	}{
+		{analyzer.StatusQuestion, true},
+		{analyzer.StatusPermission, false}, // off by default
+		{analyzer.StatusTaskComplete, false},
+		{analyzer.StatusPlanReady, false},
+		{analyzer.StatusReviewComplete, false},
+	}
+
+	for _, tt := range statuses {
+		t.Run(string(tt.status), func(t *testing.T) {
+			focused = 0
+			if err := n.SendDesktop(tt.status, "[bold-cat] message"); err != nil {
+				t.Fatalf("SendDesktop() error = %v", err)
+			}
+			if got := focused > 0; got != tt.wantFocus {
+				t.Errorf("terminal focused = %v, want %v", got, tt.wantFocus)
+			}
+		})
+	}
+}
+
+func TestMacTerminalApp(t *testing.T) {
+	tests := map[string]string{
+		"iTerm.app":      "iTerm",
+		"Apple_Terminal": "Terminal",
+		"vscode":         "Visual Studio Code",
+		"Hyper":          "",
+		"":               "",
+	}
+
+	for termProgram, expected := range tests {
+		if got := macTerminalApp(termProgram); got != expected {
+			t.Errorf("macTerminalApp(%q) = %q, want %q", termProgram, got, expected)
+		}
+	}
+}
+
+func TestSendDesktopAppName(t *testing.T) {
+	originalNotify := notify
+	defer func() { notify = originalNotify }()
+	originalAppName := beeep.AppName
+
+	var gotAppName string
+	notify = func(title, message string, icon any) error {
+		gotAppName = beeep.AppName
+		return nil
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.Sound = false
+	n := New(cfg)
+	defer n.Close()
+	n.appName = func() string { return "claude-notif-test" }
+
+	if err := n.SendDesktop(analyzer.StatusTaskComplete, "Created 3 files"); err != nil {
+		t.Fatalf("SendDesktop() error = %v", err)
+	}
+	if gotAppName != "claude-notif-test" {
+		t.Errorf("app name = %q, want %q", gotAppName, "claude-notif-test")
+	}
+	if beeep.AppName != originalAppName {
+		t.Errorf("app name not restored after notification: %q", beeep.AppName)
+	}
+}
+
+func TestSendNotice(t *testing.T) {
+	originalNotify := notify
+	defer func() { notify = originalNotify }()
+
+	var gotTitle, gotBody string
+	notify = func(title, message string, icon any) error {
+		gotTitle, gotBody = title, message
+		return nil
+	}
+
+	cfg := config.DefaultConfig()
+	n := New(cfg)
+	defer n.Close()
+
+	if err := n.SendNotice("Claude Notifications", "Temp dir is not writable"); err != nil {
+		t.Fatalf("SendNotice() error = %v", err)
+	}
+	if gotTitle != "Claude Notifications" || gotBody != "Temp dir is not writable" {
+		t.Errorf("SendNotice() showed %q / %q", gotTitle, gotBody)
+	}
+
+	gotTitle = ""
+	cfg.Notifications.Desktop.Enabled = false
+	if err := n.SendNotice("Claude Notifications", "ignored"); err != nil || gotTitle != "" {
+		t.Errorf("SendNotice() should do nothing with desktop notifications disabled")
+	}
+}
+
+func TestUniqueAppName(t *testing.T) {
+	name := uniqueAppName()
+	if !strings.HasPrefix(name, "claude-notif-") {
+		t.Errorf("uniqueAppName() = %q, want claude-notif- prefix", name)
+	}
+}
+
+func TestSendDesktopTitleInBody(t *testing.T) {
+	originalNotify := notify
+	defer func() { notify = originalNotify }()
+
+	var gotTitle, gotBody string
+	notify = func(title, message string, icon any) error {
+		gotTitle, gotBody = title, message
+		return nil
+	}
+
+	tests := []struct {
+		mode     string
+		wantBody string
+	}{
+		{"", "Created 3 files"},
+		{"prefix", "✅ Task Completed [bold-cat]: Created 3 files"},
+		{"suffix", "Created 3 files (✅ Task Completed [bold-cat])"},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.Sound = false
+			cfg.Notifications.Desktop.TitleInBody = tt.mode
+			n := New(cfg)
+			defer n.Close()
+
+			if err := n.SendDesktop(analyzer.StatusTaskComplete, "[bold-cat] Created 3 files"); err != nil {
+				t.Fatalf("SendDesktop() error = %v", err)
+			}
+			if gotTitle != "✅ Task Completed [bold-cat]" {
+				t.Errorf("title = %q, want %q", gotTitle, "✅ Task Completed [bold-cat]")
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("body = %q, want %q", gotBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSendDesktopQuietHours(t *testing.T) {
+	originalNotify := notify
+	defer func() { notify = originalNotify }()
+
+	shown := 0
+	notify = func(title, message string, icon any) error {
+		shown++
+		return nil
+	}
+
+	night := time.Date(2024, time.March, 1, 23, 30, 0, 0, time.Local)
+	afternoon := time.Date(2024, time.March, 1, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		mute      bool
+		wantShown bool
+		wantSound bool
+	}{
+		{"outside quiet hours", afternoon, false, true, true},
+		{"quiet hours skip sound", night, false, true, false},
+		{"quiet hours mute notification", night, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.QuietHours = &config.QuietHoursConfig{
+				Start:             "22:00",
+				End:               "08:00",
+				MuteNotifications: tt.mute,
+			}
+			// A missing sound file makes attempted playback observable as an error
+			cfg.Statuses["task_complete"] = config.StatusInfo{
+				Title: "Task Complete",
+				Sound: filepath.Join(t.TempDir(), "missing.mp3"),
+			}
+			n := New(cfg)
+			n.now = func() time.Time { return tt.now }
+			defer n.Close()
+
+			shown = 0
+			err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] Done")
+
+			if got := shown > 0; got != tt.wantShown {
+				t.Errorf("notification shown = %v, want %v", got, tt.wantShown)
+			}
+			if got := err != nil && strings.Contains(err.Error(), "sound file not found"); got != tt.wantSound {
+				t.Errorf("sound attempted = %v (err: %v), want %v", got, err, tt.wantSound)
+			}
+		})
+	}
+}
+
+func TestPlaySoundCooldown(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		cooldownMs int
+		second     time.Duration
+		wantSecond bool
+	}{
+		{"second sound within cooldown is skipped", 1000, 500 * time.Millisecond, false},
+		{"second sound after cooldown plays", 1000, 1500 * time.Millisecond, true},
+		{"disabled cooldown plays every sound", 0, 10 * time.Millisecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.SoundCooldownMs = tt.cooldownMs
+			cfg.Statuses["task_complete"] = config.StatusInfo{
+				Title: "Task Complete",
+				Sound: filepath.Join(soundsDir, "task-complete.mp3"),
+			}
+			n, log := newStubbedNotifier(t, cfg)
+			n.soundMarkerPath = filepath.Join(t.TempDir(), "last-sound")
+
+			current := start
+			n.now = func() time.Time { return current }
+
+			if err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] first"); err != nil {
+				t.Fatalf("first SendDesktopSync() error = %v", err)
+			}
+
+			current = start.Add(tt.second)
+			if err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] second"); err != nil {
+				t.Fatalf("second SendDesktopSync() error = %v", err)
+			}
+			if got := log.played.Load() == 2; got != tt.wantSecond {
+				t.Errorf("second sound played = %v, want %v", got, tt.wantSecond)
+			}
+		})
+	}
+
+	t.Run("missing sound file does not claim the cooldown", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Notifications.Desktop.SoundCooldownMs = 1000
+		cfg.Statuses["question"] = config.StatusInfo{Title: "Question", Sound: filepath.Join(t.TempDir(), "missing.mp3")}
+		cfg.Statuses["task_complete"] = config.StatusInfo{Title: "Task Complete", Sound: filepath.Join(soundsDir, "task-complete.mp3")}
+		n, log := newStubbedNotifier(t, cfg)
+		n.soundMarkerPath = filepath.Join(t.TempDir(), "last-sound")
+		n.now = func() time.Time { return start }
+
+		if err := n.SendDesktopSync(analyzer.StatusQuestion, "[bold-cat] question"); err == nil {
+			t.Fatal("missing sound file should fail")
+		}
+		if err := n.SendDesktopSync(analyzer.StatusTaskComplete, "[bold-cat] done"); err != nil {
+			t.Fatalf("SendDesktopSync() error = %v", err)
+		}
+		if got := log.played.Load(); got != 1 {
+			t.Errorf("played %d sounds, want 1", got)
+		}
+	})
+
+	t.Run("PlaySound ignores the cooldown", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Notifications.Desktop.SoundCooldownMs = 1000
+		n, log := newStubbedNotifier(t, cfg)
+		n.soundMarkerPath = filepath.Join(t.TempDir(), "last-sound")
+		n.now = func() time.Time { return start }
+
+		for i := 0; i < 2; i++ {
+			if err := n.PlaySound(filepath.Join(soundsDir, "task-complete.mp3"), 1.0); err != nil {
+				t.Fatalf("PlaySound() error = %v", err)
+			}
+		}
+		if got := log.played.Load(); got != 2 {
+			t.Errorf("played %d sounds, want 2", got)
+		}
+	})
+}
+
+func TestPlaySoundCooldownSharedAcrossNotifiers(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Desktop.SoundCooldownMs = 1000
+	markerPath := filepath.Join(t.TempDir(), "last-sound")
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	// Separate hook processes each create their own Notifier
+	first := New(cfg)
+	first.soundMarkerPath = markerPath
+	first.now = func() time.Time { return now }
+	if !first.claimSoundCooldown() {
+		t.Fatal("first notifier should play its sound")
+	}
+
+	second := New(cfg)
+	second.soundMarkerPath = markerPath
+	second.now = func() time.Time { return now.Add(200 * time.Millisecond) }
+	if second.claimSoundCooldown() {
+		t.Error("second notifier should respect the cooldown from the shared marker")
+	}
+}
@@ -1,10 +1,17 @@
 package notifier
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gopxl/beep"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/platform"
 )
@@ -47,7 +54,7 @@ func TestPlaySoundWithBuiltInFiles(t *testing.T) {
 			// Test that playSound doesn't crash
 			// We can't really test that audio is actually playing without human verification
 			// But we can test that the function completes without error
-			n.playSound(soundPath)
+			_ = n.playSound(context.Background(), soundPath, cfg.Notifications.Desktop.Volume)
 
 			// If we get here, playSound completed (either successfully or with logged error)
 			// This is good enough for automated testing
@@ -292,3 +299,257 @@ func findSoundsDirectory() string {
 
 	return ""
 }
+
+// TestAttentionSoundSequencedBeforeQuestion checks that the attention chime and the status
+// sound are played as one sequence, for interactive statuses only
+func TestAttentionSoundSequencedBeforeQuestion(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	// playedSamples sends a notification for status and returns the number of samples played
+	playedSamples := func(status analyzer.Status, attentionSound string) int64 {
+		t.Helper()
+		cfg := config.DefaultConfig()
+		cfg.Notifications.Desktop.AttentionSound = attentionSound
+		cfg.Statuses[string(status)] = config.StatusInfo{Title: "Status", Sound: filepath.Join(soundsDir, "question.mp3")}
+		n, log := newStubbedNotifier(t, cfg)
+
+		if err := n.SendDesktopSync(status, "[bold-cat] message"); err != nil {
+			t.Fatalf("SendDesktopSync() error = %v", err)
+		}
+		if got := log.played.Load(); got != 1 {
+			t.Fatalf("played %d streams, want one sequence", got)
+		}
+		return log.samples.Load()
+	}
+
+	chime := filepath.Join(soundsDir, "task-complete.mp3")
+	alone := playedSamples(analyzer.StatusQuestion, "")
+	withChime := playedSamples(analyzer.StatusQuestion, chime)
+
+	chimeSamples := withChime - alone
+	if chimeSamples <= 0 {
+		t.Fatalf("question with attentionSound played %d samples, want more than the %d without it", withChime, alone)
+	}
+	if limit := int64(beep.SampleRate(44100).N(maxAttentionDuration)); chimeSamples > limit {
+		t.Errorf("attention chime played %d samples, want at most %d", chimeSamples, limit)
+	}
+
+	if got := playedSamples(analyzer.StatusTaskComplete, chime); got != alone {
+		t.Errorf("task_complete played %d samples, want %d (no attention chime)", got, alone)
+	}
+}
+
+// playLog records what a Notifier from newStubbedNotifier showed and played
+type playLog struct {
+	// pace, when set, streams sounds on another goroutine and sleeps this long between
+	// chunks, like a real speaker; otherwise they are drained before speakerPlay returns
+	pace time.Duration
+
+	notified atomic.Int64 // desktop notifications shown
+	played   atomic.Int64 // sounds started
+	samples  atomic.Int64 // samples streamed
+}
+
+// newStubbedNotifier returns a Notifier for cfg whose notifications and sounds are recorded
+// in the returned playLog instead of reaching the desktop and the speaker. The stubs are
+// restored and the notifier closed when the test ends.
+func newStubbedNotifier(t *testing.T, cfg *config.Config) (*Notifier, *playLog) {
+	t.Helper()
+	log := &playLog{}
+
+	originalNotify, originalPlay := notify, speakerPlay
+	t.Cleanup(func() { notify, speakerPlay = originalNotify, originalPlay })
+	notify = func(title, message string, icon any) error {
+		log.notified.Add(1)
+		return nil
+	}
+	speakerPlay = func(streamers ...beep.Streamer) {
+		log.played.Add(1)
+		if log.pace > 0 {
+			go log.stream(streamers)
+			return
+		}
+		log.stream(streamers)
+	}
+
+	n := New(cfg)
+	n.speakerInited = true
+	n.soundMarkerPath = ""
+	t.Cleanup(func() { n.Close() })
+	return n, log
+}
+
+// stream drains streamers the way the speaker would
+func (l *playLog) stream(streamers []beep.Streamer) {
+	samples := make([][2]float64, 512)
+	for _, s := range streamers {
+		for {
+			n, ok := s.Stream(samples)
+			l.samples.Add(int64(n))
+			if !ok {
+				break
+			}
+			if l.pace > 0 {
+				time.Sleep(l.pace)
+			}
+		}
+	}
+}
+
+func TestSendDesktopHeadless(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	tests := []struct {
+		name         string
+		headless     platform.Headless
+		forceEnabled bool
+		wantNotify   bool
+		wantSound    bool
+	}{
+		{"desktop", platform.Headless{}, false, true, true},
+		{"CI", platform.Headless{CI: "GITHUB_ACTIONS"}, false, false, false},
+		{"no display", platform.Headless{NoDisplay: true}, false, false, false},
+		{"no audio device", platform.Headless{NoAudio: true}, false, true, false},
+		{"forced", platform.Headless{NoDisplay: true, NoAudio: true}, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.ForceEnabled = tt.forceEnabled
+			cfg.Statuses[string(analyzer.StatusTaskComplete)] = config.StatusInfo{Title: "Done", Sound: filepath.Join(soundsDir, "task-complete.mp3")}
+			n, log := newStubbedNotifier(t, cfg)
+			n.headless = tt.headless
+
+			if err := n.SendDesktopSync(analyzer.StatusTaskComplete, "Done"); err != nil {
+				t.Fatalf("SendDesktopSync() error = %v", err)
+			}
+			if got := log.notified.Load() > 0; got != tt.wantNotify {
+				t.Errorf("notification shown = %v, want %v", got, tt.wantNotify)
+			}
+			if got := log.played.Load() > 0; got != tt.wantSound {
+				t.Errorf("sound played = %v, want %v", got, tt.wantSound)
+			}
+		})
+	}
+}
+
+func TestSendDesktopBlockingSound(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	tests := []struct {
+		name         string
+		blocking     bool
+		wantFinished bool // most of the sound played by the time SendDesktop returns
+	}{
+		{"non-blocking", false, false},
+		{"blocking", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.BlockingSound = tt.blocking
+			cfg.Statuses[string(analyzer.StatusTaskComplete)] = config.StatusInfo{Title: "Done", Sound: filepath.Join(soundsDir, "task-complete.mp3")}
+			n, log := newStubbedNotifier(t, cfg)
+			log.pace = time.Millisecond // finish playback only after a while, like the speaker
+
+			if err := n.SendDesktop(analyzer.StatusTaskComplete, "Done"); err != nil {
+				t.Fatalf("SendDesktop() error = %v", err)
+			}
+			atReturn := log.samples.Load()
+
+			n.Close()
+			full := log.samples.Load()
+			if full == 0 {
+				t.Fatal("nothing played by Close")
+			}
+			if got := atReturn*2 > full; got != tt.wantFinished {
+				t.Errorf("playback finished when SendDesktop returned = %v (%d of %d samples), want %v", got, atReturn, full, tt.wantFinished)
+			}
+		})
+	}
+}
+
+func TestCloseFinishesSoundShutdownFadesIt(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	// played returns how many samples were streamed when the notifier was closed by stop
+	played := func(stop func(*Notifier) error) int64 {
+		cfg := config.DefaultConfig()
+		cfg.Notifications.Desktop.FadeOutMs = 10
+		cfg.Statuses[string(analyzer.StatusTaskComplete)] = config.StatusInfo{Title: "Done", Sound: filepath.Join(soundsDir, "task-complete.mp3")}
+		n, log := newStubbedNotifier(t, cfg)
+		log.pace = time.Millisecond
+
+		if err := n.SendDesktop(analyzer.StatusTaskComplete, "Done"); err != nil {
+			t.Fatalf("SendDesktop() error = %v", err)
+		}
+		if err := stop(n); err != nil {
+			t.Fatalf("closing the notifier: %v", err)
+		}
+		return log.samples.Load()
+	}
+
+	full := played((*Notifier).Close)
+	faded := played((*Notifier).Shutdown)
+	if faded >= full/2 {
+		t.Errorf("Shutdown streamed %d samples, want far fewer than the %d Close let play", faded, full)
+	}
+}
+
+func TestSendDesktopSoundResolver(t *testing.T) {
+	soundsDir := findSoundsDirectory()
+	if soundsDir == "" {
+		t.Skip("Sounds directory not found")
+	}
+
+	// Missing files fail playback with an error naming them, which shows the file chosen
+	configuredSound := filepath.Join(t.TempDir(), "configured.mp3")
+	daySound := filepath.Join(soundsDir, "task-complete.mp3")
+	nightSound := filepath.Join(t.TempDir(), "night.mp3")
+
+	tests := []struct {
+		name       string
+		resolver   SoundResolver
+		wantPlayed bool
+		wantErr    string
+	}{
+		{"static sound", nil, false, configuredSound},
+		{"day sound", func(analyzer.Status, *config.Config) string { return daySound }, true, ""},
+		{"night sound", func(analyzer.Status, *config.Config) string { return nightSound }, false, nightSound},
+		{"no sound", func(analyzer.Status, *config.Config) string { return "" }, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Statuses[string(analyzer.StatusTaskComplete)] = config.StatusInfo{Title: "Done", Sound: configuredSound}
+			n, log := newStubbedNotifier(t, cfg)
+			n.SetSoundResolver(tt.resolver)
+
+			err := n.SendDesktopSync(analyzer.StatusTaskComplete, "Done")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("SendDesktopSync() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("SendDesktopSync() error = %v, want it to name %s", err, tt.wantErr)
+			}
+			if got := log.played.Load() > 0; got != tt.wantPlayed {
+				t.Errorf("sound played = %v, want %v", got, tt.wantPlayed)
+			}
+		})
+	}
+}
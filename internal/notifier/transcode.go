@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/wav"
+)
+
+// transcodeTimeout bounds how long an external decoder may take to convert a sound
+const transcodeTimeout = 10 * time.Second
+
+// externalDecoder is a command-line tool that converts an audio file to 16-bit PCM WAV
+type externalDecoder struct {
+	name string
+	args func(in, out string) []string
+}
+
+// externalDecoders are tried in order for formats without a pure-Go beep decoder
+// (Opus, M4A/AAC). afconvert ships with macOS; ffmpeg covers Linux and Windows.
+var externalDecoders = []externalDecoder{
+	{
+		name: "ffmpeg",
+		args: func(in, out string) []string {
+			return []string{"-nostdin", "-v", "error", "-y", "-i", in, "-acodec", "pcm_s16le", out}
+		},
+	},
+	{
+		name: "afconvert",
+		args: func(in, out string) []string {
+			return []string{"-f", "WAVE", "-d", "LEI16", in, out}
+		},
+	},
+}
+
+// DecodeWithExternalTool converts soundPath to WAV with the first available external
+// decoder and returns a streamer over the in-memory result
+func DecodeWithExternalTool(soundPath string) (beep.StreamSeekCloser, beep.Format, error) {
+	decoder, path, ok := findExternalDecoder()
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("no decoder found for %s files (install ffmpeg)", filepath.Ext(soundPath))
+	}
+
+	tmpDir, err := os.MkdirTemp("", "claude-notifications-sound-")
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	outPath := filepath.Join(tmpDir, "sound.wav")
+
+	ctx, cancel := context.WithTimeout(context.Background(), transcodeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, decoder.args(soundPath, outPath)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, beep.Format{}, fmt.Errorf("%s failed: %w: %s", decoder.name, err, strings.TrimSpace(string(output)))
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to read %s output: %w", decoder.name, err)
+	}
+
+	streamer, format, err := wav.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to decode %s output: %w", decoder.name, err)
+	}
+	return streamer, format, nil
+}
+
+// findExternalDecoder returns the first external decoder found in PATH
+func findExternalDecoder() (externalDecoder, string, bool) {
+	for _, decoder := range externalDecoders {
+		if path, err := exec.LookPath(decoder.name); err == nil {
+			return decoder, path, true
+		}
+	}
+	return externalDecoder{}, "", false
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gopxl/beep"
+	"github.com/gopxl/beep/generators"
+	"github.com/gopxl/beep/wav"
+)
+
+// withExternalDecoders replaces the external decoder list for the duration of a test
+func withExternalDecoders(t *testing.T, decoders []externalDecoder) {
+	t.Helper()
+	original := externalDecoders
+	externalDecoders = decoders
+	t.Cleanup(func() { externalDecoders = original })
+}
+
+// writeTestWAV writes a short silent WAV file and returns its path
+func writeTestWAV(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fixture.wav")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create WAV fixture: %v", err)
+	}
+	defer f.Close()
+
+	format := beep.Format{SampleRate: 44100, NumChannels: 2, Precision: 2}
+	if err := wav.Encode(f, beep.Take(441, generators.Silence(-1)), format); err != nil {
+		t.Fatalf("Failed to encode WAV fixture: %v", err)
+	}
+	return path
+}
+
+func TestDecodeAudio_TranscodedFormats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses cp as a stand-in decoder")
+	}
+
+	fixture := writeTestWAV(t)
+	// Stand-in for ffmpeg: ignore the input and copy a known WAV to the output path
+	withExternalDecoders(t, []externalDecoder{
+		{name: "missing-decoder-for-test"},
+		{name: "cp", args: func(in, out string) []string { return []string{fixture, out} }},
+	})
+
+	n := &Notifier{cfg: nil}
+	for _, ext := range []string{".opus", ".m4a", ".aac"} {
+		t.Run(ext, func(t *testing.T) {
+			soundPath := filepath.Join(t.TempDir(), "sound"+ext)
+			if err := os.WriteFile(soundPath, []byte("encoded audio"), 0644); err != nil {
+				t.Fatalf("Failed to write sound file: %v", err)
+			}
+
+			streamer, format, err := n.decodeAudio(soundPath)
+			if err != nil {
+				t.Fatalf("decodeAudio(%s) failed: %v", ext, err)
+			}
+			defer streamer.Close()
+
+			if format.SampleRate != 44100 || format.NumChannels != 2 {
+				t.Errorf("Unexpected format: %+v", format)
+			}
+			if streamer.Len() != 441 {
+				t.Errorf("Expected 441 samples, got %d", streamer.Len())
+			}
+		})
+	}
+}
+
+func TestDecodeAudio_NoExternalDecoder(t *testing.T) {
+	withExternalDecoders(t, []externalDecoder{{name: "missing-decoder-for-test"}})
+
+	soundPath := filepath.Join(t.TempDir(), "sound.opus")
+	if err := os.WriteFile(soundPath, []byte("encoded audio"), 0644); err != nil {
+		t.Fatalf("Failed to write sound file: %v", err)
+	}
+
+	n := &Notifier{cfg: nil}
+	_, _, err := n.decodeAudio(soundPath)
+	if err == nil {
+		t.Fatal("decodeAudio() should fail without an external decoder, got nil")
+	}
+	if !strings.Contains(err.Error(), "install ffmpeg") {
+		t.Errorf("Expected hint to install ffmpeg, got: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// defaultSpeechRate is the typical default speaking rate in words per minute,
+// used to map ttsRate onto engines that take a relative rate
+const defaultSpeechRate = 175
+
+// speechTimeout bounds how long a TTS engine may take, so a hung engine can't hold up the
+// process; a summary of the maximum length is spoken well within it (overridable in tests)
+var speechTimeout = 30 * time.Second
+
+// ttsEngine is a command-line text-to-speech tool
+type ttsEngine struct {
+	name string
+	args func(voice string, rate int, text string) []string
+}
+
+// ttsEngines lists the engines tried in order for each OS
+var ttsEngines = map[string][]ttsEngine{
+	"darwin": {
+		{
+			name: "say",
+			args: func(voice string, rate int, text string) []string {
+				var args []string
+				if voice != "" {
+					args = append(args, "-v", voice)
+				}
+				if rate > 0 {
+					args = append(args, "-r", fmt.Sprintf("%d", rate))
+				}
+				return append(args, "--", text)
+			},
+		},
+	},
+	"linux": {
+		{
+			name: "espeak",
+			args: func(voice string, rate int, text string) []string {
+				var args []string
+				if voice != "" {
+					args = append(args, "-v", voice)
+				}
+				if rate > 0 {
+					args = append(args, "-s", fmt.Sprintf("%d", rate))
+				}
+				return append(args, "--", text)
+			},
+		},
+		{
+			// spd-say takes a rate from -100 to 100; -w waits until speech finishes
+			name: "spd-say",
+			args: func(voice string, rate int, text string) []string {
+				args := []string{"-w"}
+				if voice != "" {
+					args = append(args, "-y", voice)
+				}
+				if rate > 0 {
+					args = append(args, "-r", fmt.Sprintf("%d", relativeRate(rate, 100)))
+				}
+				return append(args, "--", text)
+			},
+		},
+	},
+	"windows": {
+		{
+			// System.Speech takes a rate from -10 to 10
+			name: "powershell",
+			args: func(voice string, rate int, text string) []string {
+				script := "Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer;"
+				if voice != "" {
+					script += fmt.Sprintf(" $s.SelectVoice(%s);", powershellQuote(voice))
+				}
+				if rate > 0 {
+					script += fmt.Sprintf(" $s.Rate = %d;", relativeRate(rate, 10))
+				}
+				script += fmt.Sprintf(" $s.Speak(%s)", powershellQuote(text))
+				return []string{"-NoProfile", "-NonInteractive", "-Command", script}
+			},
+		},
+	},
+}
+
+// speak reads text aloud with the first available TTS engine.
+// Does nothing if no engine is installed.
+func (n *Notifier) speak(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	engine, path, ok := findTTSEngine(runtime.GOOS)
+	if !ok {
+		logging.Debug("No text-to-speech engine found, skipping speech")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), speechTimeout)
+	defer cancel()
+
+	desktop := n.cfg.Notifications.Desktop
+	cmd := exec.CommandContext(ctx, path, engine.args(desktop.TTSVoice, desktop.TTSRate, text)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		logging.Warn("%s failed: %v: %s", engine.name, err, strings.TrimSpace(string(output)))
+	}
+}
+
+// findTTSEngine returns the first TTS engine for goos found in PATH
+func findTTSEngine(goos string) (ttsEngine, string, bool) {
+	for _, engine := range ttsEngines[goos] {
+		if path, err := exec.LookPath(engine.name); err == nil {
+			return engine, path, true
+		}
+	}
+	return ttsEngine{}, "", false
+}
+
+// relativeRate maps words per minute onto a -limit..limit scale centred on the default rate
+func relativeRate(wordsPerMinute, limit int) int {
+	rate := (wordsPerMinute - defaultSpeechRate) * limit / defaultSpeechRate
+	if rate > limit {
+		return limit
+	}
+	if rate < -limit {
+		return -limit
+	}
+	return rate
+}
+
+// powershellQuote returns s as a single-quoted PowerShell string literal
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
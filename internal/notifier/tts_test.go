@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestTTSEngineArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		goos   string
+		engine int
+		voice  string
+		rate   int
+		want   []string
+	}{
+		{"say defaults", "darwin", 0, "", 0, []string{"--", "hello"}},
+		{"say voice and rate", "darwin", 0, "Samantha", 200, []string{"-v", "Samantha", "-r", "200", "--", "hello"}},
+		{"espeak voice and rate", "linux", 0, "en-us", 150, []string{"-v", "en-us", "-s", "150", "--", "hello"}},
+		{"spd-say relative rate", "linux", 1, "", 350, []string{"-w", "-r", "100", "--", "hello"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ttsEngines[tt.goos][tt.engine].args(tt.voice, tt.rate, "hello")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTTSEngineArgsPowerShellQuotesText(t *testing.T) {
+	args := ttsEngines["windows"][0].args("", 0, "it's done")
+	script := args[len(args)-1]
+	want := "Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; $s.Speak('it''s done')"
+	if script != want {
+		t.Errorf("script = %q, want %q", script, want)
+	}
+}
+
+func TestRelativeRate(t *testing.T) {
+	tests := []struct {
+		wordsPerMinute int
+		limit          int
+		want           int
+	}{
+		{defaultSpeechRate, 10, 0},
+		{350, 10, 10},
+		{1000, 100, 100},
+		{1, 100, -99},
+		{87, 10, -5},
+	}
+
+	for _, tt := range tests {
+		if got := relativeRate(tt.wordsPerMinute, tt.limit); got != tt.want {
+			t.Errorf("relativeRate(%d, %d) = %d, want %d", tt.wordsPerMinute, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestSpeakTimesOut(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("uses a shell script as the espeak engine")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nexec sleep 10\n"
+	if err := os.WriteFile(filepath.Join(dir, "espeak"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake engine: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	original := speechTimeout
+	speechTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { speechTimeout = original })
+
+	n := New(config.DefaultConfig())
+	start := time.Now()
+	n.speak("hello")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("speak() took %v, want it stopped after the timeout", elapsed)
+	}
+}
+
+func TestFindTTSEngineMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if _, _, ok := findTTSEngine("linux"); ok {
+		t.Error("findTTSEngine() found an engine with an empty PATH")
+	}
+	if _, _, ok := findTTSEngine("plan9"); ok {
+		t.Error("findTTSEngine() found an engine for an unsupported OS")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// DetachProcess makes cmd start in its own process group, so that a Ctrl+C or SIGHUP
+// sent to the caller's group (e.g. the terminal closing) doesn't kill it
+func DetachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// DetachProcess makes cmd start in its own process group without a console, so that
+// Ctrl+C in the caller's console or the console closing doesn't kill it
+func DetachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+	}
+}
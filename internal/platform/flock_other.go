@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package platform
+
+import "os"
+
+// LockFile reports that advisory locks are unavailable on this platform
+func LockFile(*os.File) error {
+	return ErrFileLockUnsupported
+}
+
+// UnlockFile is a no-op where LockFile is unsupported
+func UnlockFile(*os.File) error {
+	return nil
+}
@@ -1,7 +1,9 @@
 package platform
 
 import (
+	"errors"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -29,6 +31,19 @@ func TempDir() string {
 	return strings.TrimSuffix(tempDir, string(os.PathSeparator))
 }
 
+// SanitizeFileName replaces everything but ASCII letters, digits, '-' and '_' with '_',
+// so that an ID taken from hook input (e.g. a session ID) can't escape the temp dir
+func SanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
 // FileMTime returns the modification time of a file as Unix timestamp
 // Returns 0 if the file doesn't exist or on error
 func FileMTime(path string) int64 {
@@ -60,20 +75,25 @@ func FileExists(path string) bool {
 	return err == nil
 }
 
-// CleanupOldFiles removes files older than maxAge seconds matching a pattern
+// CleanupOldFiles removes files older than maxAge seconds matching a pattern.
+// Every file is attempted; files that could not be removed are reported together.
 func CleanupOldFiles(dir, pattern string, maxAge int64) error {
 	matches, err := filepath.Glob(filepath.Join(dir, pattern))
 	if err != nil {
 		return err
 	}
 
+	var errs []error
 	for _, path := range matches {
 		age := FileAge(path)
 		if age >= 0 && age > maxAge {
-			_ = os.Remove(path) // Ignore errors
+			// Another process may have removed it first
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // AtomicCreateFile creates a file atomically using O_EXCL flag
@@ -90,6 +110,11 @@ func AtomicCreateFile(path string) (bool, error) {
 	return true, nil
 }
 
+// ErrFileLockUnsupported is returned by LockFile where advisory file locks don't exist.
+// LockFile can also fail where they exist but the filesystem doesn't support them
+// (e.g. some network filesystems).
+var ErrFileLockUnsupported = errors.New("advisory file locks are not supported")
+
 // NormalizePath normalizes a file path (removes double slashes, etc.)
 func NormalizePath(path string) string {
 	return filepath.Clean(path)
@@ -114,3 +139,193 @@ func IsMacOS() bool {
 func IsLinux() bool {
 	return runtime.GOOS == "linux"
 }
+
+// IsRemoteSession returns true if running in an SSH session with no desktop to show
+// notifications on. On Linux a forwarded X11 or Wayland display counts as a desktop.
+func IsRemoteSession() bool {
+	return isRemoteSession(os.Getenv, runtime.GOOS)
+}
+
+// isRemoteSession is IsRemoteSession with the environment and OS injected (for tests)
+func isRemoteSession(getenv func(string) string, goos string) bool {
+	if getenv("SSH_CONNECTION") == "" && getenv("SSH_TTY") == "" {
+		return false
+	}
+	if goos == "linux" && (getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != "") {
+		return false
+	}
+	return true
+}
+
+// ciEnvVars are set by common CI services; "CI" covers most of the others too
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "TF_BUILD", "CIRCLECI", "TRAVIS"}
+
+// Headless describes why this session can't show desktop notifications or play sounds
+type Headless struct {
+	CI        string // the CI environment variable that is set, "" if not in CI
+	NoDisplay bool   // Linux without an X11 or Wayland display
+	NoAudio   bool   // Linux without a sound device or PulseAudio/PipeWire server
+}
+
+// DetectHeadless inspects the environment for CI, a display and an audio device
+func DetectHeadless() Headless {
+	return detectHeadless(os.Getenv, runtime.GOOS, FileExists)
+}
+
+// IsHeadless returns true in CI, or when there is no display or audio device
+func IsHeadless() bool {
+	return DetectHeadless().Any()
+}
+
+// detectHeadless is DetectHeadless with the environment, OS and file check injected (for tests)
+func detectHeadless(getenv func(string) string, goos string, exists func(string) bool) Headless {
+	h := Headless{CI: ciEnvVar(getenv)}
+	if goos == "linux" {
+		h.NoDisplay = !hasDisplay(getenv, exists)
+		h.NoAudio = !hasAudio(getenv, exists)
+	}
+	return h
+}
+
+// hasDisplay reports whether an X11 or Wayland display is available. A tmux or screen
+// server keeps the environment it was started with, so inside one a missing $DISPLAY
+// falls back to looking for the local display sockets.
+func hasDisplay(getenv func(string) string, exists func(string) bool) bool {
+	if getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != "" {
+		return true
+	}
+	if getenv("TMUX") == "" && getenv("STY") == "" {
+		return false
+	}
+	if exists("/tmp/.X11-unix/X0") {
+		return true
+	}
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+	return runtimeDir != "" && exists(path.Join(runtimeDir, "wayland-0"))
+}
+
+// hasAudio reports whether a sound device or a PulseAudio or PipeWire server is available
+func hasAudio(getenv func(string) string, exists func(string) bool) bool {
+	if exists("/dev/snd") || getenv("PULSE_SERVER") != "" {
+		return true
+	}
+	runtimeDir := getenv("XDG_RUNTIME_DIR")
+	return runtimeDir != "" && (exists(path.Join(runtimeDir, "pulse", "native")) || exists(path.Join(runtimeDir, "pipewire-0")))
+}
+
+// ciEnvVar returns the CI environment variable that is set, or ""
+func ciEnvVar(getenv func(string) string) string {
+	for _, name := range ciEnvVars {
+		if value := getenv(name); value != "" && value != "false" && value != "0" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Any reports whether any headless condition was detected
+func (h Headless) Any() bool {
+	return h.CI != "" || h.NoDisplay || h.NoAudio
+}
+
+// NoDesktop reports whether desktop notifications can't be shown
+func (h Headless) NoDesktop() bool {
+	return h.CI != "" || h.NoDisplay
+}
+
+// NoSound reports whether sounds can't be played
+func (h Headless) NoSound() bool {
+	return h.CI != "" || h.NoAudio
+}
+
+// String describes the detected conditions, e.g. "CI (GITHUB_ACTIONS), no display"
+func (h Headless) String() string {
+	var reasons []string
+	if h.CI != "" {
+		reasons = append(reasons, "CI ("+h.CI+")")
+	}
+	if h.NoDisplay {
+		reasons = append(reasons, "no display")
+	}
+	if h.NoAudio {
+		reasons = append(reasons, "no audio device")
+	}
+	if len(reasons) == 0 {
+		return "not headless"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Linux display servers returned by LinuxDisplayServer
+const (
+	DisplayWayland = "wayland"
+	DisplayX11     = "x11"
+	DisplayNone    = "none"
+)
+
+// LinuxDisplayServer returns the display server of the local Linux desktop: wayland, x11,
+// or none off Linux, in SSH sessions and in CI. XWayland sets $DISPLAY too, so Wayland wins.
+func LinuxDisplayServer() string {
+	return linuxDisplayServer(os.Getenv, runtime.GOOS)
+}
+
+// linuxDisplayServer is LinuxDisplayServer with the environment and OS injected (for tests)
+func linuxDisplayServer(getenv func(string) string, goos string) string {
+	if goos != "linux" || getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "" || ciEnvVar(getenv) != "" {
+		return DisplayNone
+	}
+	sessionType := getenv("XDG_SESSION_TYPE")
+	switch {
+	case getenv("WAYLAND_DISPLAY") != "" || sessionType == DisplayWayland:
+		return DisplayWayland
+	case getenv("DISPLAY") != "" || sessionType == DisplayX11:
+		return DisplayX11
+	default:
+		return DisplayNone
+	}
+}
+
+// Compositors returned by Compositor
+const (
+	CompositorHyprland = "hyprland"
+	CompositorSway     = "sway"
+	CompositorGNOME    = "gnome"
+	CompositorKDE      = "kde"
+)
+
+// Compositor returns the Linux compositor or desktop running the session, or "" if it is
+// unknown or there is no display (see LinuxDisplayServer)
+func Compositor() string {
+	return compositor(os.Getenv, runtime.GOOS)
+}
+
+// compositor is Compositor with the environment and OS injected (for tests)
+func compositor(getenv func(string) string, goos string) string {
+	if linuxDisplayServer(getenv, goos) == DisplayNone {
+		return ""
+	}
+	switch {
+	case getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		return CompositorHyprland
+	case getenv("SWAYSOCK") != "":
+		return CompositorSway
+	}
+
+	// $XDG_CURRENT_DESKTOP is a colon-separated list, e.g. "ubuntu:GNOME"
+	for _, desktop := range strings.Split(strings.ToLower(getenv("XDG_CURRENT_DESKTOP")), ":") {
+		switch desktop {
+		case "hyprland":
+			return CompositorHyprland
+		case "sway":
+			return CompositorSway
+		case "gnome":
+			return CompositorGNOME
+		case "kde":
+			return CompositorKDE
+		}
+	}
+	if getenv("KDE_FULL_SESSION") != "" {
+		return CompositorKDE
+	}
+	return ""
+}
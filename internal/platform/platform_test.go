@@ -23,6 +23,13 @@ func TestTempDir(t *testing.T) {
 	assert.NotEqual(t, "/", tempDir[len(tempDir)-1:])
 }
 
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "73b5e210-ec1a-4294-96e4-c2aecb2e1063", SanitizeFileName("73b5e210-ec1a-4294-96e4-c2aecb2e1063"))
+	assert.Equal(t, "______etc_passwd", SanitizeFileName("../../etc/passwd"))
+	assert.Equal(t, "a_b_c_d", SanitizeFileName(`a\b c:d`))
+	assert.Equal(t, "Stop", SanitizeFileName("Stop"))
+}
+
 func TestFileExists(t *testing.T) {
 	// Create temp file
 	tmpFile := filepath.Join(t.TempDir(), "test.txt")
@@ -110,6 +117,27 @@ func TestCleanupOldFiles(t *testing.T) {
 	assert.True(t, FileExists(newFile))
 }
 
+func TestCleanupOldFiles_RemoveError(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A non-empty directory can't be removed, even by root
+	stuck := filepath.Join(tmpDir, "stuck.txt")
+	require.NoError(t, os.MkdirAll(filepath.Join(stuck, "child"), 0755))
+	oldFile := filepath.Join(tmpDir, "old.txt")
+	require.NoError(t, os.WriteFile(oldFile, []byte("old"), 0644))
+
+	oldTime := time.Now().Add(-2 * time.Minute)
+	require.NoError(t, os.Chtimes(stuck, oldTime, oldTime))
+	require.NoError(t, os.Chtimes(oldFile, oldTime, oldTime))
+
+	err := CleanupOldFiles(tmpDir, "*.txt", 60)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck.txt")
+
+	// Other files are still cleaned up
+	assert.False(t, FileExists(oldFile))
+}
+
 func TestNormalizePath(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -184,3 +212,182 @@ func TestAtomicCreateFile_PermissionDenied(t *testing.T) {
 	assert.False(t, created)
 	assert.Error(t, err, "Creating file in read-only directory should fail")
 }
+
+func TestIsRemoteSession(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		goos string
+		want bool
+	}{
+		{"local", map[string]string{}, "linux", false},
+		{"local with display", map[string]string{"DISPLAY": ":0"}, "linux", false},
+		{"ssh connection", map[string]string{"SSH_CONNECTION": "10.0.0.2 52110 10.0.0.5 22"}, "linux", true},
+		{"ssh tty", map[string]string{"SSH_TTY": "/dev/pts/1"}, "linux", true},
+		{"ssh with X11 forwarding", map[string]string{"SSH_TTY": "/dev/pts/1", "DISPLAY": "localhost:10.0"}, "linux", false},
+		{"ssh with wayland", map[string]string{"SSH_TTY": "/dev/pts/1", "WAYLAND_DISPLAY": "wayland-0"}, "linux", false},
+		{"ssh on macOS", map[string]string{"SSH_CONNECTION": "10.0.0.2 52110 10.0.0.5 22", "DISPLAY": ":0"}, "darwin", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, isRemoteSession(getenv, tt.goos))
+		})
+	}
+}
+
+func TestDetectHeadless(t *testing.T) {
+	desktop := map[string]string{"DISPLAY": ":0"}
+	snd := []string{"/dev/snd"}
+	tests := []struct {
+		name     string
+		env      map[string]string
+		goos     string
+		files    []string
+		want     Headless
+		wantText string
+	}{
+		{"linux desktop", desktop, "linux", snd, Headless{}, "not headless"},
+		{"wayland desktop", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, "linux", snd, Headless{}, "not headless"},
+		{"no display", map[string]string{}, "linux", snd, Headless{NoDisplay: true}, "no display"},
+		{"no sound device", desktop, "linux", nil, Headless{NoAudio: true}, "no audio device"},
+		{"pulseaudio server", map[string]string{"DISPLAY": ":0", "PULSE_SERVER": "unix:/run/pulse"}, "linux", nil, Headless{}, "not headless"},
+		{
+			"pulseaudio socket",
+			map[string]string{"DISPLAY": ":0", "XDG_RUNTIME_DIR": "/run/user/1000"},
+			"linux",
+			[]string{"/run/user/1000/pulse/native"},
+			Headless{},
+			"not headless",
+		},
+		{
+			"pipewire socket",
+			map[string]string{"DISPLAY": ":0", "XDG_RUNTIME_DIR": "/run/user/1000"},
+			"linux",
+			[]string{"/run/user/1000/pipewire-0"},
+			Headless{},
+			"not headless",
+		},
+		{"tmux without DISPLAY on X11", map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}, "linux", []string{"/dev/snd", "/tmp/.X11-unix/X0"}, Headless{}, "not headless"},
+		{
+			"tmux without WAYLAND_DISPLAY on Wayland",
+			map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0", "XDG_RUNTIME_DIR": "/run/user/1000"},
+			"linux",
+			[]string{"/dev/snd", "/run/user/1000/wayland-0"},
+			Headless{},
+			"not headless",
+		},
+		{"tmux on a server", map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}, "linux", snd, Headless{NoDisplay: true}, "no display"},
+		{"X11 socket outside a multiplexer", map[string]string{}, "linux", []string{"/dev/snd", "/tmp/.X11-unix/X0"}, Headless{NoDisplay: true}, "no display"},
+		{
+			"container",
+			map[string]string{},
+			"linux",
+			nil,
+			Headless{NoDisplay: true, NoAudio: true},
+			"no display, no audio device",
+		},
+		{"CI", map[string]string{"DISPLAY": ":0", "CI": "true"}, "linux", snd, Headless{CI: "CI"}, "CI (CI)"},
+		{"CI disabled", map[string]string{"DISPLAY": ":0", "CI": "false"}, "linux", snd, Headless{}, "not headless"},
+		{"GitHub Actions on macOS", map[string]string{"GITHUB_ACTIONS": "true"}, "darwin", nil, Headless{CI: "GITHUB_ACTIONS"}, "CI (GITHUB_ACTIONS)"},
+		{"macOS", map[string]string{}, "darwin", nil, Headless{}, "not headless"},
+		{"Windows", map[string]string{}, "windows", nil, Headless{}, "not headless"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			exists := func(path string) bool {
+				for _, file := range tt.files {
+					if file == path {
+						return true
+					}
+				}
+				return false
+			}
+			got := detectHeadless(getenv, tt.goos, exists)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantText, got.String())
+			assert.Equal(t, tt.want != Headless{}, got.Any())
+		})
+	}
+}
+
+func TestHeadlessNoDesktopNoSound(t *testing.T) {
+	tests := []struct {
+		name          string
+		h             Headless
+		wantNoDesktop bool
+		wantNoSound   bool
+	}{
+		{"none", Headless{}, false, false},
+		{"CI", Headless{CI: "CI"}, true, true},
+		{"no display", Headless{NoDisplay: true}, true, false},
+		{"no audio", Headless{NoAudio: true}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantNoDesktop, tt.h.NoDesktop())
+			assert.Equal(t, tt.wantNoSound, tt.h.NoSound())
+		})
+	}
+}
+
+func TestLinuxDisplayServer(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		goos string
+		want string
+	}{
+		{"wayland", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, "linux", DisplayWayland},
+		{"xwayland", map[string]string{"WAYLAND_DISPLAY": "wayland-1", "DISPLAY": ":0"}, "linux", DisplayWayland},
+		{"x11", map[string]string{"DISPLAY": ":0"}, "linux", DisplayX11},
+		{"session type wayland", map[string]string{"XDG_SESSION_TYPE": "wayland"}, "linux", DisplayWayland},
+		{"session type x11", map[string]string{"XDG_SESSION_TYPE": "x11"}, "linux", DisplayX11},
+		{"tty", map[string]string{"XDG_SESSION_TYPE": "tty"}, "linux", DisplayNone},
+		{"ssh", map[string]string{"SSH_TTY": "/dev/pts/1", "DISPLAY": "localhost:10.0"}, "linux", DisplayNone},
+		{"CI", map[string]string{"CI": "true", "DISPLAY": ":99"}, "linux", DisplayNone},
+		{"macOS", map[string]string{"DISPLAY": ":0"}, "darwin", DisplayNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, linuxDisplayServer(getenv, tt.goos))
+		})
+	}
+}
+
+func TestCompositor(t *testing.T) {
+	wayland := func(env map[string]string) map[string]string {
+		env["WAYLAND_DISPLAY"] = "wayland-1"
+		return env
+	}
+	tests := []struct {
+		name string
+		env  map[string]string
+		goos string
+		want string
+	}{
+		{"hyprland", wayland(map[string]string{"HYPRLAND_INSTANCE_SIGNATURE": "v0.41_1718000000"}), "linux", CompositorHyprland},
+		{"sway", wayland(map[string]string{"SWAYSOCK": "/run/user/1000/sway-ipc.sock"}), "linux", CompositorSway},
+		{"gnome on ubuntu", wayland(map[string]string{"XDG_CURRENT_DESKTOP": "ubuntu:GNOME"}), "linux", CompositorGNOME},
+		{"kde", wayland(map[string]string{"XDG_CURRENT_DESKTOP": "KDE"}), "linux", CompositorKDE},
+		{"kde full session on x11", map[string]string{"DISPLAY": ":0", "KDE_FULL_SESSION": "true"}, "linux", CompositorKDE},
+		{"hyprland by desktop name", wayland(map[string]string{"XDG_CURRENT_DESKTOP": "Hyprland"}), "linux", CompositorHyprland},
+		{"unknown", wayland(map[string]string{"XDG_CURRENT_DESKTOP": "XFCE"}), "linux", ""},
+		{"ssh", wayland(map[string]string{"SSH_CONNECTION": "10.0.0.2 52110 10.0.0.5 22", "SWAYSOCK": "/tmp/sway.sock"}), "linux", ""},
+		{"no display", map[string]string{"XDG_CURRENT_DESKTOP": "GNOME"}, "linux", ""},
+		{"macOS", wayland(map[string]string{"XDG_CURRENT_DESKTOP": "GNOME"}), "darwin", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, compositor(getenv, tt.goos))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is a weekly time window such as "09:00-18:00 Mon-Fri". Either part may be
+// omitted: "09:00-18:00" applies every day, "Sat,Sun" all day on those days.
+type Schedule struct {
+	start, end int // minutes since midnight; start == end means all day
+	days       [7]bool
+}
+
+// dayNames maps accepted weekday spellings to time.Weekday
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// New returns a schedule from start to end, in minutes since midnight, on the given days.
+// No days means every day; start == end means all day.
+func New(start, end int, days ...time.Weekday) *Schedule {
+	s := &Schedule{start: start, end: end}
+	if len(days) == 0 {
+		s.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	for _, day := range days {
+		s.days[day] = true
+	}
+	return s
+}
+
+// Parse parses a schedule: an optional "HH:MM-HH:MM" time range followed by optional
+// days, given as a comma-separated list of days and day ranges, e.g. "Mon-Fri" or
+// "Mon,Wed,Fri-Sun". Time ranges may cross midnight (e.g. "22:00-02:00").
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid schedule %q (expected e.g. \"09:00-18:00 Mon-Fri\")", spec)
+	}
+
+	s := &Schedule{}
+	if strings.Contains(fields[0], ":") {
+		if err := s.parseTimes(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		s.days = [7]bool{true, true, true, true, true, true, true}
+		return s, nil
+	}
+	if len(fields) > 1 {
+		return nil, fmt.Errorf("invalid schedule %q (expected e.g. \"09:00-18:00 Mon-Fri\")", spec)
+	}
+	if err := s.parseDays(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return s, nil
+}
+
+// parseTimes parses "HH:MM-HH:MM"
+func (s *Schedule) parseTimes(value string) error {
+	from, to, found := strings.Cut(value, "-")
+	if !found {
+		return fmt.Errorf("invalid time range %q (expected HH:MM-HH:MM)", value)
+	}
+	var err error
+	if s.start, err = ParseClock(from); err != nil {
+		return err
+	}
+	if s.end, err = ParseClock(to); err != nil {
+		return err
+	}
+	if s.start == s.end {
+		return fmt.Errorf("empty time range %q", value)
+	}
+	return nil
+}
+
+// ParseClock parses "HH:MM" into minutes since midnight
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseDays parses a comma-separated list of days and day ranges; ranges may wrap
+// around the week (e.g. "Fri-Mon")
+func (s *Schedule) parseDays(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := ParseWeekday(from)
+		if err != nil {
+			return err
+		}
+		last := first
+		if isRange {
+			if last, err = ParseWeekday(to); err != nil {
+				return err
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			s.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// ParseWeekday parses a weekday name like "Mon" or "monday"
+func ParseWeekday(value string) (time.Weekday, error) {
+	day, ok := dayNames[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		return 0, fmt.Errorf("invalid weekday %q", value)
+	}
+	return day, nil
+}
+
+// Contains reports whether t falls within the schedule. For time ranges crossing
+// midnight, the day is the one the range started on.
+func (s *Schedule) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case s.start == s.end:
+		// All day
+	case s.start < s.end:
+		if minute < s.start || minute >= s.end {
+			return false
+		}
+	case minute >= s.start:
+		// Evening part, the range started today
+	case minute < s.end:
+		// Morning part, the range started yesterday
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return s.days[day]
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns 2025-01-06 (a Monday) plus days at hh:mm
+func at(days, hh, mm int) time.Time {
+	return time.Date(2025, 1, 6+days, hh, mm, 0, 0, time.Local)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"9-18",
+		"09:00",
+		"09:00-25:00",
+		"09:00-09:00",
+		"09:00-18:00 Mon-Fri extra",
+		"09:00-18:00 Someday",
+		"Mon Fri",
+		"Mon-",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Parse(%q) expected error", spec)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		time time.Time
+		want bool
+	}{
+		{"work hours on a weekday", "09:00-18:00 Mon-Fri", at(2, 10, 30), true},
+		{"start is inclusive", "09:00-18:00 Mon-Fri", at(0, 9, 0), true},
+		{"end is exclusive", "09:00-18:00 Mon-Fri", at(0, 18, 0), false},
+		{"before work hours", "09:00-18:00 Mon-Fri", at(0, 8, 59), false},
+		{"weekend", "09:00-18:00 Mon-Fri", at(5, 10, 0), false},
+		{"no days means every day", "09:00-18:00", at(6, 10, 0), true},
+		{"days only means all day", "Sat,Sun", at(5, 23, 59), true},
+		{"days only on another day", "Sat,Sun", at(4, 12, 0), false},
+		{"case insensitive", "09:00-18:00 monday", at(0, 12, 0), true},
+		{"day list with range", "Mon,Wed-Thu", at(3, 0, 0), true},
+		{"day list skips", "Mon,Wed-Thu", at(1, 12, 0), false},
+		{"range wraps the week", "Fri-Mon", at(6, 12, 0), true},
+		{"overnight evening", "22:00-02:00 Fri", at(4, 23, 0), true},
+		{"overnight morning counts the start day", "22:00-02:00 Fri", at(5, 1, 0), true},
+		{"overnight morning of another start day", "22:00-02:00 Fri", at(4, 1, 0), false},
+		{"overnight gap", "22:00-02:00", at(0, 12, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Contains(tt.time); got != tt.want {
+				t.Errorf("Parse(%q).Contains(%s) = %v, want %v", tt.spec, tt.time.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *Schedule
+		time time.Time
+		want bool
+	}{
+		{"no days means every day", New(22*60, 8*60), at(6, 23, 0), true},
+		{"overnight morning counts the start day", New(22*60, 8*60, time.Friday), at(5, 3, 0), true},
+		{"overnight morning of another start day", New(22*60, 8*60, time.Saturday), at(5, 3, 0), false},
+		{"start equal to end is all day", New(0, 0, time.Monday), at(0, 12, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Contains(tt.time); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package state
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/platform"
@@ -18,9 +24,29 @@ type SessionState struct {
 	LastTaskCompleteTime   int64  `json:"last_task_complete_ts,omitempty"`
 	LastNotificationTime   int64  `json:"last_notification_ts,omitempty"`
 	LastNotificationStatus string `json:"last_notification_status,omitempty"`
-	CWD                    string `json:"cwd"`
+	// LastNotificationHash identifies the status and message of the last notification
+	// (empty if the message is unknown), to suppress consecutive identical ones
+	LastNotificationHash string `json:"last_notification_hash,omitempty"`
+	// LastNotificationHookTime is when a Notification hook last notified (to suppress the Stop that follows)
+	LastNotificationHookTime int64 `json:"last_notification_hook_ts,omitempty"`
+	// LastStatusTimes tracks the last notification timestamp per status (for per-status cooldown)
+	LastStatusTimes map[string]int64 `json:"last_status_ts,omitempty"`
+	CWD             string           `json:"cwd"`
 }
 
+// SessionSummary describes one session known from its state file
+type SessionSummary struct {
+	SessionID            string
+	LastStatus           string // status of the last notification ("" if none was sent)
+	LastNotificationTime int64  // Unix timestamp of the last notification (0 if none was sent)
+	CWD                  string
+}
+
+// FirstSeenMaxAge is how long (seconds) a session's first-seen marker is kept after the
+// session's last hook event. It outlives the short-lived state files so a session that sat
+// idle doesn't look newly started.
+const FirstSeenMaxAge = 24 * 60 * 60
+
 // Manager manages session state
 type Manager struct {
 	tempDir string
@@ -35,7 +61,12 @@ func NewManager() *Manager {
 
 // getStatePath returns the path to the state file for a session
 func (m *Manager) getStatePath(sessionID string) string {
-	return filepath.Join(m.tempDir, fmt.Sprintf("claude-session-state-%s.json", sessionID))
+	return filepath.Join(m.tempDir, fmt.Sprintf("claude-session-state-%s.json", platform.SanitizeFileName(sessionID)))
+}
+
+// getFirstSeenPath returns the path to the marker that records when a session was first seen
+func (m *Manager) getFirstSeenPath(sessionID string) string {
+	return filepath.Join(m.tempDir, fmt.Sprintf("claude-session-first-seen-%s", platform.SanitizeFileName(sessionID)))
 }
 
 // Load loads session state from disk
@@ -75,6 +106,48 @@ func (m *Manager) Save(state *SessionState) error {
 	return nil
 }
 
+// ListActiveSessions returns the sessions whose state was written in the last maxAgeSeconds
+// (all sessions if maxAgeSeconds <= 0), most recently notified first. Unreadable state files are skipped.
+func (m *Manager) ListActiveSessions(maxAgeSeconds int64) ([]SessionSummary, error) {
+	matches, err := filepath.Glob(filepath.Join(m.tempDir, "claude-session-state-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state files: %w", err)
+	}
+
+	var sessions []SessionSummary
+	for _, path := range matches {
+		if maxAgeSeconds > 0 {
+			if age := platform.FileAge(path); age < 0 || age > maxAgeSeconds {
+				continue
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var state SessionState
+		if err := json.Unmarshal(data, &state); err != nil || state.SessionID == "" {
+			continue
+		}
+
+		sessions = append(sessions, SessionSummary{
+			SessionID:            state.SessionID,
+			LastStatus:           state.LastNotificationStatus,
+			LastNotificationTime: state.LastNotificationTime,
+			CWD:                  state.CWD,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].LastNotificationTime != sessions[j].LastNotificationTime {
+			return sessions[i].LastNotificationTime > sessions[j].LastNotificationTime
+		}
+		return sessions[i].SessionID < sessions[j].SessionID
+	})
+	return sessions, nil
+}
+
 // Delete deletes session state
 func (m *Manager) Delete(sessionID string) error {
 	path := m.getStatePath(sessionID)
@@ -164,12 +237,89 @@ func (m *Manager) UpdateState(sessionID string, status analyzer.Status, toolName
 }
 
 // Cleanup cleans up old state files (older than maxAge seconds)
+// and first-seen markers older than FirstSeenMaxAge
 func (m *Manager) Cleanup(maxAge int64) error {
-	return platform.CleanupOldFiles(m.tempDir, "claude-session-state-*.json", maxAge)
+	if err := platform.CleanupOldFiles(m.tempDir, "claude-session-state-*.json", maxAge); err != nil {
+		return err
+	}
+	return platform.CleanupOldFiles(m.tempDir, "claude-session-first-seen-*", FirstSeenMaxAge)
+}
+
+// MarkSeen records the current time as the session's first observed event if none
+// is recorded yet, and returns the first-seen Unix timestamp. The marker holds that
+// timestamp and its mtime is refreshed on every call, so Cleanup removes markers of
+// sessions inactive for FirstSeenMaxAge rather than of sessions that started that long ago.
+func (m *Manager) MarkSeen(sessionID string) (int64, error) {
+	path := m.getFirstSeenPath(sessionID)
+	now := platform.CurrentTimestamp()
+	created, err := platform.AtomicCreateFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record first seen time: %w", err)
+	}
+	if created {
+		if err := writeFirstSeen(path, now); err != nil {
+			return 0, err
+		}
+		return now, nil
+	}
+
+	firstSeen, ok := readFirstSeen(path)
+	if !ok {
+		// Markers written before the timestamp was stored in them use the mtime
+		firstSeen = platform.FileMTime(path)
+		if err := writeFirstSeen(path, firstSeen); err != nil {
+			return firstSeen, err
+		}
+	}
+	touched := time.Unix(now, 0)
+	if err := os.Chtimes(path, touched, touched); err != nil {
+		return firstSeen, fmt.Errorf("failed to refresh first seen marker: %w", err)
+	}
+	return firstSeen, nil
+}
+
+// readFirstSeen returns the timestamp stored in a first-seen marker
+func readFirstSeen(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return ts, err == nil
+}
+
+// writeFirstSeen stores ts in a first-seen marker
+func writeFirstSeen(path string, ts int64) error {
+	if err := os.WriteFile(path, []byte(strconv.FormatInt(ts, 10)), 0644); err != nil {
+		return fmt.Errorf("failed to record first seen time: %w", err)
+	}
+	return nil
+}
+
+// ShouldSuppressStartup marks the session as seen and checks if a notification should be
+// suppressed due to being within the grace period after the session's first observed event
+func (m *Manager) ShouldSuppressStartup(sessionID string, graceSeconds int) (bool, error) {
+	if graceSeconds <= 0 {
+		return false, nil
+	}
+
+	firstSeen, err := m.MarkSeen(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	elapsed := platform.CurrentTimestamp() - firstSeen
+	return elapsed < int64(graceSeconds), nil
 }
 
 // UpdateLastNotification updates the last notification timestamp and status
 func (m *Manager) UpdateLastNotification(sessionID string, status analyzer.Status) error {
+	return m.UpdateLastNotificationMessage(sessionID, status, "")
+}
+
+// UpdateLastNotificationMessage updates the last notification timestamp and status, and
+// remembers the message for ShouldSuppressIdentical ("" = unknown)
+func (m *Manager) UpdateLastNotificationMessage(sessionID string, status analyzer.Status, message string) error {
 	state, err := m.Load(sessionID)
 	if err != nil {
 		return err
@@ -181,12 +331,88 @@ func (m *Manager) UpdateLastNotification(sessionID string, status analyzer.Statu
 		}
 	}
 
-	state.LastNotificationTime = platform.CurrentTimestamp()
+	now := platform.CurrentTimestamp()
+	state.LastNotificationTime = now
 	state.LastNotificationStatus = string(status)
+	if state.LastStatusTimes == nil {
+		state.LastStatusTimes = make(map[string]int64)
+	}
+	state.LastStatusTimes[string(status)] = now
+	state.LastNotificationHash = ""
+	if message != "" {
+		state.LastNotificationHash = notificationHash(status, message)
+	}
 
 	return m.Save(state)
 }
 
+// notificationHash identifies a notification by its status and message
+func notificationHash(status analyzer.Status, message string) string {
+	sum := sha256.Sum256([]byte(string(status) + "\x00" + message))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ShouldSuppressIdentical checks if a notification repeats the session's last one, with
+// the same status and message, within windowSeconds
+func (m *Manager) ShouldSuppressIdentical(sessionID string, status analyzer.Status, message string, windowSeconds int) (bool, error) {
+	if windowSeconds <= 0 {
+		return false, nil
+	}
+
+	state, err := m.Load(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if state == nil || state.LastNotificationHash == "" {
+		return false, nil
+	}
+	if state.LastNotificationHash != notificationHash(status, message) {
+		return false, nil
+	}
+
+	elapsed := platform.CurrentTimestamp() - state.LastNotificationTime
+	return elapsed < int64(windowSeconds), nil
+}
+
+// UpdateNotificationHook records that a Notification hook notified for the session
+func (m *Manager) UpdateNotificationHook(sessionID string) error {
+	state, err := m.Load(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		state = &SessionState{
+			SessionID: sessionID,
+		}
+	}
+
+	state.LastNotificationHookTime = platform.CurrentTimestamp()
+
+	return m.Save(state)
+}
+
+// ShouldSuppressStopAfterNotification checks if a Stop notification should be suppressed
+// because a Notification hook already notified for the same session within the window
+func (m *Manager) ShouldSuppressStopAfterNotification(sessionID string, windowSeconds int) (bool, error) {
+	if windowSeconds <= 0 {
+		return false, nil
+	}
+
+	state, err := m.Load(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if state == nil || state.LastNotificationHookTime == 0 {
+		return false, nil
+	}
+
+	elapsed := platform.CurrentTimestamp() - state.LastNotificationHookTime
+	return elapsed < int64(windowSeconds), nil
+}
+
 // ShouldSuppressQuestionAfterAnyNotification checks if a question notification should be suppressed
 // due to being within the cooldown window after ANY notification
 func (m *Manager) ShouldSuppressQuestionAfterAnyNotification(sessionID string, cooldownSeconds int) (bool, error) {
@@ -214,3 +440,31 @@ func (m *Manager) ShouldSuppressQuestionAfterAnyNotification(sessionID string, c
 
 	return shouldSuppress, nil
 }
+
+// ShouldSuppressStatus checks if a notification for the given status should be suppressed
+// due to being within the per-status cooldown window after the last notification of the same status
+func (m *Manager) ShouldSuppressStatus(sessionID string, status analyzer.Status, cooldownSeconds int) (bool, error) {
+	if cooldownSeconds <= 0 {
+		return false, nil
+	}
+
+	state, err := m.Load(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if state == nil {
+		return false, nil
+	}
+
+	lastTime := state.LastStatusTimes[string(status)]
+	if lastTime == 0 {
+		return false, nil
+	}
+
+	// Check if we're within the cooldown window
+	now := platform.CurrentTimestamp()
+	elapsed := now - lastTime
+
+	return elapsed < int64(cooldownSeconds), nil
+}
@@ -377,6 +377,142 @@ func TestManager_ShouldSuppressAfterAny_OutsideCooldown(t *testing.T) {
 	assert.False(t, suppress)
 }
 
+// === ShouldSuppressStopAfterNotification Tests ===
+
+func TestManager_ShouldSuppressStopAfterNotification_NoState(t *testing.T) {
+	mgr := NewManager()
+
+	suppress, err := mgr.ShouldSuppressStopAfterNotification("non-existent", 5)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStopAfterNotification_WithinWindow(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-stop-within"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateNotificationHook(sessionID)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStopAfterNotification(sessionID, 5)
+	require.NoError(t, err)
+	assert.True(t, suppress)
+}
+
+func TestManager_ShouldSuppressStopAfterNotification_OutsideWindow(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-stop-outside"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	state := &SessionState{
+		SessionID:                sessionID,
+		LastNotificationHookTime: platform.CurrentTimestamp() - 6,
+	}
+	err := mgr.Save(state)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStopAfterNotification(sessionID, 5)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStopAfterNotification_OtherNotification(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-stop-other"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	// A notification from another hook (e.g. a previous Stop) doesn't count
+	err := mgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStopAfterNotification(sessionID, 5)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStopAfterNotification_Disabled(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-stop-disabled"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateNotificationHook(sessionID)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStopAfterNotification(sessionID, 0)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+// === ShouldSuppressStatus Tests ===
+
+func TestManager_ShouldSuppressStatus_NoState(t *testing.T) {
+	mgr := NewManager()
+
+	suppress, err := mgr.ShouldSuppressStatus("non-existent", analyzer.StatusTaskComplete, 30)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStatus_WithinCooldown(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-status-within"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStatus(sessionID, analyzer.StatusTaskComplete, 30)
+	require.NoError(t, err)
+	assert.True(t, suppress)
+}
+
+func TestManager_ShouldSuppressStatus_OutsideCooldown(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-status-outside"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	state := &SessionState{
+		SessionID: sessionID,
+		LastStatusTimes: map[string]int64{
+			string(analyzer.StatusPlanReady): platform.CurrentTimestamp() - 61,
+		},
+	}
+	err := mgr.Save(state)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStatus(sessionID, analyzer.StatusPlanReady, 60)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStatus_DifferentStatus(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-status-different"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete)
+	require.NoError(t, err)
+
+	// Cooldown is tracked per status: a recent task_complete must not suppress plan_ready
+	suppress, err := mgr.ShouldSuppressStatus(sessionID, analyzer.StatusPlanReady, 60)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStatus_ZeroCooldown(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-status-zero"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete)
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressStatus(sessionID, analyzer.StatusTaskComplete, 0)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
 // === UpdateState Tests ===
 
 func TestManager_UpdateState_TaskComplete(t *testing.T) {
@@ -447,6 +583,120 @@ func TestManager_UpdateState_QuestionWithoutTool(t *testing.T) {
 	assert.Nil(t, state)
 }
 
+// === Startup Grace Tests ===
+
+func TestManager_ShouldSuppressStartup_FirstEvent(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+
+	// The first observed event starts the grace period, so it is inside it
+	suppress, err := mgr.ShouldSuppressStartup("session-new", 10)
+	require.NoError(t, err)
+	assert.True(t, suppress)
+
+	suppress, err = mgr.ShouldSuppressStartup("session-new", 10)
+	require.NoError(t, err)
+	assert.True(t, suppress, "events within the grace period should be suppressed")
+}
+
+func TestManager_ShouldSuppressStartup_OutsideGrace(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+	sessionID := "session-started"
+
+	_, err := mgr.MarkSeen(sessionID)
+	require.NoError(t, err)
+	require.NoError(t, writeFirstSeen(mgr.getFirstSeenPath(sessionID), time.Now().Unix()-11))
+
+	suppress, err := mgr.ShouldSuppressStartup(sessionID, 10)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressStartup_KeepsFirstSeen(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+	sessionID := "session-first-seen"
+
+	first, err := mgr.MarkSeen(sessionID)
+	require.NoError(t, err)
+	require.NoError(t, writeFirstSeen(mgr.getFirstSeenPath(sessionID), first-100))
+
+	// Later events must not move the first-seen time
+	again, err := mgr.MarkSeen(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, first-100, again)
+}
+
+func TestManager_MarkSeen_LegacyMarker(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+	sessionID := "session-legacy"
+
+	// Older versions left the marker empty, with the first-seen time as its mtime
+	path := mgr.getFirstSeenPath(sessionID)
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	firstSeen := time.Now().Add(-100 * time.Second).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(path, firstSeen, firstSeen))
+
+	for i := 0; i < 2; i++ {
+		got, err := mgr.MarkSeen(sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, firstSeen.Unix(), got, "call %d", i+1)
+	}
+}
+
+func TestManager_Cleanup_KeepsActiveLongSession(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+	sessionID := "session-long"
+
+	_, err := mgr.MarkSeen(sessionID)
+	require.NoError(t, err)
+	path := mgr.getFirstSeenPath(sessionID)
+	started := time.Now().Unix() - FirstSeenMaxAge - 3600
+	require.NoError(t, writeFirstSeen(path, started))
+	old := time.Unix(started, 0)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	// A hook event of the still-active session refreshes the marker
+	got, err := mgr.MarkSeen(sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, started, got)
+
+	require.NoError(t, mgr.Cleanup(60))
+	assert.True(t, platform.FileExists(path), "an active session's marker must outlive FirstSeenMaxAge")
+
+	suppress, err := mgr.ShouldSuppressStartup(sessionID, 10)
+	require.NoError(t, err)
+	assert.False(t, suppress, "a long-running session must not re-enter the startup grace period")
+}
+
+func TestManager_ShouldSuppressStartup_Disabled(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+
+	suppress, err := mgr.ShouldSuppressStartup("session-disabled", 0)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+	assert.False(t, platform.FileExists(mgr.getFirstSeenPath("session-disabled")),
+		"no marker should be written when the grace period is disabled")
+}
+
+func TestManager_Cleanup_KeepsRecentFirstSeen(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+
+	_, err := mgr.MarkSeen("session-idle")
+	require.NoError(t, err)
+	_, err = mgr.MarkSeen("session-stale")
+	require.NoError(t, err)
+
+	// Idle for longer than the state TTL, but still the same session
+	idle := time.Now().Add(-120 * time.Second)
+	require.NoError(t, os.Chtimes(mgr.getFirstSeenPath("session-idle"), idle, idle))
+	stale := time.Now().Add(-(FirstSeenMaxAge + 60) * time.Second)
+	require.NoError(t, os.Chtimes(mgr.getFirstSeenPath("session-stale"), stale, stale))
+
+	require.NoError(t, mgr.Cleanup(60))
+
+	assert.True(t, platform.FileExists(mgr.getFirstSeenPath("session-idle")))
+	assert.False(t, platform.FileExists(mgr.getFirstSeenPath("session-stale")))
+}
+
 // === Cleanup Tests ===
 
 func TestManager_Cleanup_OldFiles(t *testing.T) {
@@ -553,6 +803,47 @@ func TestManager_StateFilePath(t *testing.T) {
 	assert.Equal(t, expectedFilename, filepath.Base(path))
 }
 
+func TestManager_ListActiveSessions(t *testing.T) {
+	tempDir := t.TempDir()
+	mgr := &Manager{tempDir: tempDir}
+
+	require.NoError(t, mgr.Save(&SessionState{
+		SessionID: "older", LastNotificationStatus: "question", LastNotificationTime: 100, CWD: "/work/api",
+	}))
+	require.NoError(t, mgr.Save(&SessionState{
+		SessionID: "newer", LastNotificationStatus: "task_complete", LastNotificationTime: 200, CWD: "/work/web",
+	}))
+	require.NoError(t, mgr.Save(&SessionState{SessionID: "silent", CWD: "/work/cli"}))
+	require.NoError(t, mgr.Save(&SessionState{SessionID: "stale", LastNotificationTime: 300}))
+
+	// Stale and unreadable state files are left out
+	oldTime := time.Now().Add(-10 * time.Minute)
+	require.NoError(t, os.Chtimes(mgr.getStatePath("stale"), oldTime, oldTime))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "claude-session-state-broken.json"), []byte("{"), 0644))
+
+	sessions, err := mgr.ListActiveSessions(60)
+	require.NoError(t, err)
+	assert.Equal(t, []SessionSummary{
+		{SessionID: "newer", LastStatus: "task_complete", LastNotificationTime: 200, CWD: "/work/web"},
+		{SessionID: "older", LastStatus: "question", LastNotificationTime: 100, CWD: "/work/api"},
+		{SessionID: "silent", CWD: "/work/cli"},
+	}, sessions)
+
+	// No age limit includes the stale session
+	sessions, err = mgr.ListActiveSessions(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 4)
+	assert.Equal(t, "stale", sessions[0].SessionID)
+}
+
+func TestManager_ListActiveSessions_Empty(t *testing.T) {
+	mgr := &Manager{tempDir: t.TempDir()}
+
+	sessions, err := mgr.ListActiveSessions(60)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
 func TestLoad_InvalidJSON(t *testing.T) {
 	mgr := NewManager()
 	sessionID := "test-invalid-json"
@@ -600,3 +891,66 @@ func TestDelete_PermissionDenied(t *testing.T) {
 	// Restore permissions for cleanup
 	_ = os.Chmod(testTempDir, 0755)
 }
+
+// === ShouldSuppressIdentical Tests ===
+
+func TestManager_ShouldSuppressIdentical(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-identical"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateLastNotificationMessage(sessionID, analyzer.StatusTaskComplete, "Created a file")
+	require.NoError(t, err)
+
+	suppress, err := mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusTaskComplete, "Created a file", 30)
+	require.NoError(t, err)
+	assert.True(t, suppress, "identical status and message within the window")
+
+	suppress, err = mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusTaskComplete, "Edited a file", 30)
+	require.NoError(t, err)
+	assert.False(t, suppress, "different message")
+
+	suppress, err = mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusReviewComplete, "Created a file", 30)
+	require.NoError(t, err)
+	assert.False(t, suppress, "different status")
+
+	suppress, err = mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusTaskComplete, "Created a file", 0)
+	require.NoError(t, err)
+	assert.False(t, suppress, "disabled window")
+}
+
+func TestManager_ShouldSuppressIdentical_OutsideWindow(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-identical-outside"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	err := mgr.UpdateLastNotificationMessage(sessionID, analyzer.StatusTaskComplete, "Created a file")
+	require.NoError(t, err)
+
+	state, err := mgr.Load(sessionID)
+	require.NoError(t, err)
+	state.LastNotificationTime = platform.CurrentTimestamp() - 61
+	require.NoError(t, mgr.Save(state))
+
+	suppress, err := mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusTaskComplete, "Created a file", 60)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
+
+func TestManager_ShouldSuppressIdentical_UnknownMessage(t *testing.T) {
+	mgr := NewManager()
+	sessionID := "test-suppress-identical-unknown"
+	defer func() { _ = mgr.Delete(sessionID) }()
+
+	require.NoError(t, mgr.UpdateLastNotificationMessage(sessionID, analyzer.StatusTaskComplete, "Created a file"))
+	// A notification recorded without its message replaces the remembered one
+	require.NoError(t, mgr.UpdateLastNotification(sessionID, analyzer.StatusTaskComplete))
+
+	suppress, err := mgr.ShouldSuppressIdentical(sessionID, analyzer.StatusTaskComplete, "Created a file", 60)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+
+	suppress, err = mgr.ShouldSuppressIdentical("non-existent", analyzer.StatusTaskComplete, "Created a file", 60)
+	require.NoError(t, err)
+	assert.False(t, suppress)
+}
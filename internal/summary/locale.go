@@ -0,0 +1,303 @@
+package summary
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// DefaultLocale is used when notifications.locale is unset or not in the catalog
+const DefaultLocale = "en"
+
+// Message keys of the generated phrases
+const (
+	msgCreated             = "created"              // Created %s (files)
+	msgEdited              = "edited"               // Edited %s (files or notebooks)
+	msgRan                 = "ran"                  // Ran %s (commands)
+	msgStopped             = "stopped"              // Stopped %s (processes)
+	msgReviewed            = "reviewed"             // Reviewed %s (files)
+	msgCompletedOperations = "completed_operations" // Completed task with %s (operations)
+	msgTookSeconds         = "took_s"
+	msgTookMinutes         = "took_m"
+	msgTookMinutesSeconds  = "took_m_s"
+	msgTookHours           = "took_h"
+	msgTookHoursMinutes    = "took_h_m"
+	msgAllowCommand        = "allow_command" // Allow <tool>: <command>
+	msgAllowFile           = "allow_file"    // Allow <tool> on <file>
+	msgAllowTool           = "allow_tool"    // Allow <tool>?
+
+	msgQuestionFallback     = "question_fallback"
+	msgPlanFallback         = "plan_fallback"
+	msgReviewFallback       = "review_fallback"
+	msgTaskFallback         = "task_fallback"
+	msgSessionLimitFallback = "session_limit_fallback"
+	msgLimitWarningFallback = "limit_warning_fallback"
+	msgAPIErrorFallback     = "api_error_fallback"
+	msgPermissionFallback   = "permission_fallback"
+	msgErrorFallback        = "error_fallback"
+	msgNotificationFallback = "notification_fallback" // status without a configured title
+
+	msgTestsPassed    = "tests_passed"     // All %s passed (tests or packages)
+	msgOneTestPassed  = "one_test_passed"  // %s passed (1 test or package)
+	msgTestsFailed    = "tests_failed"     // %s failed (tests or packages)
+	msgLongTaskPrefix = "long_task_prefix" // before task summaries of long responses
+	msgRevertedPrefix = "reverted_prefix"  // before task summaries where Claude undid its edits
+	msgOverCount      = "over_count"       // over %d %s (a large count, rounded down)
+)
+
+// Noun keys of the counted things
+const (
+	nounFile         = "file"
+	nounCommand      = "command"
+	nounNotebook     = "notebook"
+	nounSlashCommand = "slash_command"
+	nounProcess      = "process"
+	nounOperation    = "operation"
+	nounTest         = "test"
+	nounPackage      = "package" // go test without -v only reports packages
+)
+
+// catalog holds the phrases of one locale
+type catalog struct {
+	messages map[string]string   // fmt templates by message key
+	nouns    map[string][]string // noun forms by noun key, indexed by plural
+	plural   func(n int) int     // index of the noun form to use for n
+}
+
+// catalogs are the supported locales. Keys missing from a locale fall back to English.
+var catalogs = map[string]*catalog{
+	"en": {
+		messages: map[string]string{
+			msgCreated:              "Created %s",
+			msgEdited:               "Edited %s",
+			msgRan:                  "Ran %s",
+			msgStopped:              "Stopped %s",
+			msgReviewed:             "Reviewed %s",
+			msgCompletedOperations:  "Completed task with %s",
+			msgTookSeconds:          "Took %ds",
+			msgTookMinutes:          "Took %dm",
+			msgTookMinutesSeconds:   "Took %dm %ds",
+			msgTookHours:            "Took %dh",
+			msgTookHoursMinutes:     "Took %dh %dm",
+			msgAllowCommand:         "Allow %s: %s",
+			msgAllowFile:            "Allow %s on %s",
+			msgAllowTool:            "Allow %s?",
+			msgQuestionFallback:     "Claude needs your input to continue",
+			msgPlanFallback:         "Plan is ready for review",
+			msgReviewFallback:       "Code review completed",
+			msgTaskFallback:         "Task completed successfully",
+			msgSessionLimitFallback: "Session limit reached. Please start a new conversation.",
+			msgLimitWarningFallback: "Approaching usage limit",
+			msgAPIErrorFallback:     "Please run /login",
+			msgPermissionFallback:   "Claude needs your permission to continue",
+			msgErrorFallback:        "Last command failed",
+			msgNotificationFallback: "Claude Code notification",
+			msgTestsPassed:          "All %s passed",
+			msgOneTestPassed:        "%s passed",
+			msgTestsFailed:          "%s failed",
+			msgLongTaskPrefix:       LongTaskPrefix,
+			msgRevertedPrefix:       RevertedPrefix,
+			msgOverCount:            "over %d %s",
+		},
+		nouns: map[string][]string{
+			nounFile:         {"file", "files"},
+			nounCommand:      {"command", "commands"},
+			nounNotebook:     {"notebook", "notebooks"},
+			nounSlashCommand: {"slash command", "slash commands"},
+			nounProcess:      {"process", "processes"},
+			nounOperation:    {"operation", "operations"},
+			nounTest:         {"test", "tests"},
+			nounPackage:      {"package", "packages"},
+		},
+		plural: oneOther,
+	},
+	"de": {
+		messages: map[string]string{
+			msgCreated:              "Erstellt: %s",
+			msgEdited:               "Bearbeitet: %s",
+			msgRan:                  "Ausgeführt: %s",
+			msgStopped:              "Gestoppt: %s",
+			msgReviewed:             "Geprüft: %s",
+			msgCompletedOperations:  "Aufgabe erledigt: %s",
+			msgTookSeconds:          "Dauer: %d s",
+			msgTookMinutes:          "Dauer: %d min",
+			msgTookMinutesSeconds:   "Dauer: %d min %d s",
+			msgTookHours:            "Dauer: %d h",
+			msgTookHoursMinutes:     "Dauer: %d h %d min",
+			msgAllowCommand:         "%s erlauben: %s",
+			msgAllowFile:            "%s für %s erlauben",
+			msgAllowTool:            "%s erlauben?",
+			msgQuestionFallback:     "Claude braucht deine Eingabe, um fortzufahren",
+			msgPlanFallback:         "Der Plan ist bereit zur Prüfung",
+			msgReviewFallback:       "Code-Review abgeschlossen",
+			msgTaskFallback:         "Aufgabe erfolgreich abgeschlossen",
+			msgSessionLimitFallback: "Sitzungslimit erreicht. Bitte starte eine neue Unterhaltung.",
+			msgLimitWarningFallback: "Nutzungslimit fast erreicht",
+			msgAPIErrorFallback:     "Bitte /login ausführen",
+			msgPermissionFallback:   "Claude braucht deine Erlaubnis, um fortzufahren",
+			msgErrorFallback:        "Letzter Befehl fehlgeschlagen",
+			msgNotificationFallback: "Claude-Code-Benachrichtigung",
+			msgTestsPassed:          "Alle %s bestanden",
+			msgOneTestPassed:        "%s bestanden",
+			msgTestsFailed:          "%s fehlgeschlagen",
+			msgLongTaskPrefix:       "⏱ Lange Aufgabe: ",
+			msgRevertedPrefix:       "⚠️ Teilweise rückgängig gemacht: ",
+			msgOverCount:            "über %d %s",
+		},
+		nouns: map[string][]string{
+			nounFile:         {"Datei", "Dateien"},
+			nounCommand:      {"Befehl", "Befehle"},
+			nounNotebook:     {"Notebook", "Notebooks"},
+			nounSlashCommand: {"Slash-Befehl", "Slash-Befehle"},
+			nounProcess:      {"Prozess", "Prozesse"},
+			nounOperation:    {"Vorgang", "Vorgänge"},
+			nounTest:         {"Test", "Tests"},
+			nounPackage:      {"Paket", "Pakete"},
+		},
+		plural: oneOther,
+	},
+	"ru": {
+		messages: map[string]string{
+			msgCreated:              "Создано: %s",
+			msgEdited:               "Изменено: %s",
+			msgRan:                  "Выполнено: %s",
+			msgStopped:              "Остановлено: %s",
+			msgReviewed:             "Просмотрено: %s",
+			msgCompletedOperations:  "Задача выполнена: %s",
+			msgTookSeconds:          "Заняло %d с",
+			msgTookMinutes:          "Заняло %d мин",
+			msgTookMinutesSeconds:   "Заняло %d мин %d с",
+			msgTookHours:            "Заняло %d ч",
+			msgTookHoursMinutes:     "Заняло %d ч %d мин",
+			msgAllowCommand:         "Разрешить %s: %s",
+			msgAllowFile:            "Разрешить %s для %s",
+			msgAllowTool:            "Разрешить %s?",
+			msgQuestionFallback:     "Claude ждёт вашего ответа",
+			msgPlanFallback:         "План готов к проверке",
+			msgReviewFallback:       "Ревью кода завершено",
+			msgTaskFallback:         "Задача успешно выполнена",
+			msgSessionLimitFallback: "Достигнут лимит сессии. Начните новый диалог.",
+			msgLimitWarningFallback: "Лимит использования почти исчерпан",
+			msgAPIErrorFallback:     "Выполните /login",
+			msgPermissionFallback:   "Claude нужно ваше разрешение, чтобы продолжить",
+			msgErrorFallback:        "Последняя команда завершилась с ошибкой",
+			msgNotificationFallback: "Уведомление Claude Code",
+			msgTestsPassed:          "Пройдено: все %s",
+			msgOneTestPassed:        "Пройдено: %s",
+			msgTestsFailed:          "Не пройдено: %s",
+			msgLongTaskPrefix:       "⏱ Долгая задача: ",
+			msgRevertedPrefix:       "⚠️ Частично (правки отменены): ",
+			msgOverCount:            "более %d %s",
+		},
+		nouns: map[string][]string{
+			nounFile:         {"файл", "файла", "файлов"},
+			nounCommand:      {"команда", "команды", "команд"},
+			nounNotebook:     {"блокнот", "блокнота", "блокнотов"},
+			nounSlashCommand: {"slash-команда", "slash-команды", "slash-команд"},
+			nounProcess:      {"процесс", "процесса", "процессов"},
+			nounOperation:    {"операция", "операции", "операций"},
+			nounTest:         {"тест", "теста", "тестов"},
+			nounPackage:      {"пакет", "пакета", "пакетов"},
+		},
+		plural: slavicPlural,
+	},
+}
+
+// HasLongTaskPrefix reports whether message contains the long-task prefix of any locale.
+// The prefix may follow the session name, and throttled messages merge several summaries.
+func HasLongTaskPrefix(message string) bool {
+	for _, c := range catalogs {
+		if prefix := c.messages[msgLongTaskPrefix]; prefix != "" && strings.Contains(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// oneOther is the plural rule of English and German: 1 file, 2 files
+func oneOther(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// slavicPlural is the plural rule of Russian: 1 файл, 2 файла, 5 файлов, 21 файл
+func slavicPlural(n int) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// localizer formats generated phrases in one locale, falling back to English per key
+type localizer struct {
+	c *catalog
+}
+
+// newLocalizer returns the localizer for locale ("ru", "de-DE", "ru_RU"), or English if
+// the locale is not in the catalog
+func newLocalizer(locale string) localizer {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if c, ok := catalogs[locale]; ok {
+		return localizer{c: c}
+	}
+	// Fall back from a regional variant to its language
+	if lang, _, found := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-"); found {
+		if c, ok := catalogs[lang]; ok {
+			return localizer{c: c}
+		}
+	}
+	return localizer{c: catalogs[DefaultLocale]}
+}
+
+// localizerFor returns the localizer for notifications.locale
+func localizerFor(cfg *config.Config) localizer {
+	if cfg == nil {
+		return newLocalizer(DefaultLocale)
+	}
+	return newLocalizer(cfg.Notifications.Locale)
+}
+
+// text formats the phrase for key with args
+func (l localizer) text(key string, args ...interface{}) string {
+	template, ok := l.c.messages[key]
+	if !ok {
+		template = catalogs[DefaultLocale].messages[key]
+	}
+	return fmt.Sprintf(template, args...)
+}
+
+// noun returns the form of noun that goes with n
+func (l localizer) noun(noun string, n int) string {
+	c := l.c
+	forms, ok := c.nouns[noun]
+	if !ok {
+		c = catalogs[DefaultLocale]
+		forms = c.nouns[noun]
+	}
+	i := 0
+	if c.plural != nil {
+		i = c.plural(n)
+	}
+	if i >= len(forms) {
+		i = len(forms) - 1
+	}
+	return forms[i]
+}
+
+// count formats n with the matching form of noun, rounding large counts down to their
+// leading digit ("over 200 files" for 237)
+func (l localizer) count(n int, noun string) string {
+	if n >= LargeCountThreshold {
+		if rounded := roundCount(n); rounded < n {
+			return l.text(msgOverCount, rounded, l.noun(noun, rounded))
+		}
+	}
+	return fmt.Sprintf("%d %s", n, l.noun(noun, n))
+}
@@ -0,0 +1,153 @@
+package summary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+func TestLocalizerCount(t *testing.T) {
+	tests := []struct {
+		locale string
+		n      int
+		want   string
+	}{
+		{"en", 1, "1 file"},
+		{"en", 2, "2 files"},
+		{"de", 1, "1 Datei"},
+		{"de", 4, "4 Dateien"},
+		{"ru", 1, "1 файл"},
+		{"ru", 2, "2 файла"},
+		{"ru", 4, "4 файла"},
+		{"ru", 5, "5 файлов"},
+		{"ru", 11, "11 файлов"},
+		{"ru", 14, "14 файлов"},
+		{"ru", 21, "21 файл"},
+		{"ru", 22, "22 файла"},
+		{"ru", 250, "более 200 файлов"},
+		{"de", 1234, "über 1000 Dateien"},
+	}
+
+	for _, tt := range tests {
+		if got := newLocalizer(tt.locale).count(tt.n, nounFile); got != tt.want {
+			t.Errorf("%s: count(%d) = %q, want %q", tt.locale, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBuildActionsStringLocalized(t *testing.T) {
+	toolCounts := map[string]int{"Write": 1, "Edit": 3, "Bash": 5}
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "Created 1 file. Edited 3 files. Ran 5 commands. Took 2m"},
+		{"de", "Erstellt: 1 Datei. Bearbeitet: 3 Dateien. Ausgeführt: 5 Befehle. Dauer: 2 min"},
+		{"ru", "Создано: 1 файл. Изменено: 3 файла. Выполнено: 5 команд. Заняло 2 мин"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			l := newLocalizer(tt.locale)
+			if got := buildActionsString(l, toolCounts, nil, formatDuration(l, config.DurationFormatWords, 2*time.Minute)); got != tt.want {
+				t.Errorf("buildActionsString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLocalizer(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", "Task completed successfully"},
+		{"ru", "Задача успешно выполнена"},
+		{"RU", "Задача успешно выполнена"},
+		{"ru-RU", "Задача успешно выполнена"},
+		{"de_DE", "Aufgabe erfolgreich abgeschlossen"},
+		{"fr", "Task completed successfully"},
+	}
+
+	for _, tt := range tests {
+		if got := newLocalizer(tt.locale).text(msgTaskFallback); got != tt.want {
+			t.Errorf("newLocalizer(%q) task fallback = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizerFallsBackPerKey(t *testing.T) {
+	catalogs["xx"] = &catalog{
+		messages: map[string]string{msgCreated: "Made %s"},
+		nouns:    map[string][]string{nounFile: {"thing", "things"}},
+		plural:   oneOther,
+	}
+	defer delete(catalogs, "xx")
+
+	l := newLocalizer("xx")
+	got := buildActionsString(l, map[string]int{"Write": 2, "Bash": 1}, nil, "")
+	if want := "Made 2 things. Ran 1 command"; got != want {
+		t.Errorf("buildActionsString() = %q, want %q", got, want)
+	}
+}
+
+func TestLocalizedFallbackMessages(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Locale = "ru"
+
+	if got := generateSessionLimitSummary(nil, cfg); got != "Достигнут лимит сессии. Начните новый диалог." {
+		t.Errorf("session limit summary = %q", got)
+	}
+	if got := generatePermissionSummary(nil, cfg); got != "Claude нужно ваше разрешение, чтобы продолжить" {
+		t.Errorf("permission summary = %q", got)
+	}
+
+	// A configured defaultFallbackMessage still wins
+	info := cfg.Statuses["api_error"]
+	info.DefaultFallbackMessage = "Log in again"
+	cfg.Statuses["api_error"] = info
+	if got := generateAPIErrorSummary(nil, cfg); got != "Log in again" {
+		t.Errorf("api error summary = %q, want the configured fallback", got)
+	}
+}
+
+func TestLocalizedTestOutcome(t *testing.T) {
+	tests := []struct {
+		locale   string
+		run      testRunResult
+		expected string
+	}{
+		{"en", testRunResult{passed: 42, unit: nounTest}, "All 42 tests passed"},
+		{"de", testRunResult{passed: 42, unit: nounTest}, "Alle 42 Tests bestanden"},
+		{"de", testRunResult{passed: 1, unit: nounPackage}, "1 Paket bestanden"},
+		{"ru", testRunResult{passed: 42, unit: nounTest}, "Пройдено: все 42 теста"},
+		{"ru", testRunResult{passed: 40, failed: 5, unit: nounTest}, "Не пройдено: 5 тестов"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTestOutcome(newLocalizer(tt.locale), tt.run); got != tt.expected {
+			t.Errorf("formatTestOutcome(%s, %+v) = %q, want %q", tt.locale, tt.run, got, tt.expected)
+		}
+	}
+}
+
+func TestLocalizedPrefixesAndDefaultMessage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notifications.Locale = "de"
+
+	if got := GetDefaultMessage("no_such_status", cfg); got != "Claude-Code-Benachrichtigung" {
+		t.Errorf("GetDefaultMessage() = %q, want the German fallback", got)
+	}
+
+	for locale := range catalogs {
+		prefix := newLocalizer(locale).text(msgLongTaskPrefix)
+		if !HasLongTaskPrefix("[bold-cat] " + prefix + "Migrated the database") {
+			t.Errorf("HasLongTaskPrefix() = false for the %s prefix %q", locale, prefix)
+		}
+	}
+	if HasLongTaskPrefix("[bold-cat] Migrated the database") {
+		t.Error("HasLongTaskPrefix() = true without a prefix")
+	}
+}
@@ -2,9 +2,12 @@ package summary
 
 import (
 	"fmt"
+	"html"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
@@ -12,17 +15,34 @@ import (
 )
 
 const (
-	// Message window sizes for different notification types
-	// These determine how many recent assistant messages to analyze
+	// Default message window sizes for different notification types
+	// These determine how many recent assistant messages to analyze (see notifications.summaryWindows)
 	QuestionMessagesWindow = 8 // Based on bash version, good balance for question detection
 	ReviewMessagesWindow   = 5 // Smaller window for focused review summaries
 	TaskMessagesWindow     = 5 // Smaller window for task completion summaries
+
+	// Limits that keep the actions string short for very busy sessions
+	MaxActionPhrases    = 3   // Max tool phrases in the actions string (duration not counted)
+	LargeCountThreshold = 100 // Counts at or above this are rounded down: "over 200 files" for 237
+	MaxTimelineSteps    = 8   // Max tools in the tool timeline; older steps are elided
+	MaxListedFiles      = 3   // Max file names listed per action with notifications.summaryShowFiles; more fall back to a count
+
+	// DefaultMaxSummaryLength caps summaries when desktop.maxSummaryLength is unset
+	DefaultMaxSummaryLength = 150
+
+	// RevertedPrefix marks task summaries where Claude undid its own edits (English;
+	// other locales have their own in the catalog)
+	RevertedPrefix = "⚠️ Partial work (reverted): "
+
+	// LongTaskPrefix marks task summaries whose response took longer than
+	// notifications.longTaskThresholdSeconds (English; see HasLongTaskPrefix for all locales)
+	LongTaskPrefix = "⏱ Long task: "
 )
 
 var (
 	// Regex patterns for markdown cleanup
 	headerPattern     = regexp.MustCompile(`^#+\s*`)
-	bulletPattern     = regexp.MustCompile(`^[-*•]\s*`)
+	bulletPattern     = regexp.MustCompile(`^[-*•]\s+`)
 	backtickPattern   = regexp.MustCompile("`")
 	multiSpacePattern = regexp.MustCompile(`\s+`)
 	emojiPattern      = regexp.MustCompile(`^[\p{So}\p{Sk}]+\s*`)
@@ -35,6 +55,19 @@ var (
 	italicPattern        = regexp.MustCompile(`(\*|_)([^*_]+)(\*|_)`)    // *text* or _text_
 	strikethroughPattern = regexp.MustCompile(`~~(.+?)~~`)               // ~~text~~
 	blockquotePattern    = regexp.MustCompile(`^>\s*`)                   // > quote
+
+	// Ordered list numbers (1. item, up to three digits so "2024. was" is kept) and
+	// table separator rows (|---|:---:| or ---|---)
+	orderedListPattern = regexp.MustCompile(`^\d{1,3}\.\s+`)
+	tableRulePattern   = regexp.MustCompile(`^\|?(\s*:?-+:?\s*\|)+\s*(:?-+:?\s*)?$`)
+
+	// Bold italic (***text*** or ___text___), and horizontal rules and setext underlines
+	// on their own line (---, ===, ***, ___)
+	boldItalicPattern     = regexp.MustCompile(`(\*\*\*|___)(.+?)(\*\*\*|___)`)
+	horizontalRulePattern = regexp.MustCompile(`(?m)^[ \t]*(?:(?:-[ \t]*){3,}|(?:=[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+
+	// ANSI escape sequences: CSI (colors, cursor movement), OSC (titles, hyperlinks) and two-byte escapes
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
 )
 
 // getRecentAssistantMessages safely extracts recent assistant messages from current response
@@ -59,29 +92,103 @@ func getRecentAssistantMessages(messages []jsonl.Message, limit int) []jsonl.Mes
 
 // GenerateFromTranscript generates a status-specific summary from transcript
 func GenerateFromTranscript(transcriptPath string, status analyzer.Status, cfg *config.Config) string {
-	messages, err := jsonl.ParseFile(transcriptPath)
+	messages, err := jsonl.TailMessages(transcriptPath, analyzer.TranscriptTailLines)
 	if err != nil {
-		return GetDefaultMessage(status, cfg)
+		return GenerateSimple(status, cfg)
 	}
+	return GenerateFromMessages(messages, status, cfg)
+}
 
+// GenerateFromMessages generates a status-specific summary from the tail of a transcript
+func GenerateFromMessages(messages []jsonl.Message, status analyzer.Status, cfg *config.Config) string {
 	if len(messages) == 0 {
-		return GetDefaultMessage(status, cfg)
+		return GenerateSimple(status, cfg)
+	}
+
+	if summary := generateForStatus(messages, status, cfg); summary != "" {
+		return summary
+	}
+	return GenerateSimple(status, cfg)
+}
+
+// questionWindow returns how many recent assistant messages the question summary reads
+func questionWindow(cfg *config.Config) int {
+	if w := summaryWindows(cfg); w.Question > 0 {
+		return w.Question
+	}
+	return QuestionMessagesWindow
+}
+
+// reviewWindow returns how many recent assistant messages the review summary reads
+func reviewWindow(cfg *config.Config) int {
+	if w := summaryWindows(cfg); w.Review > 0 {
+		return w.Review
+	}
+	return ReviewMessagesWindow
+}
+
+// taskWindow returns how many recent assistant messages the task and error summaries read
+func taskWindow(cfg *config.Config) int {
+	if w := summaryWindows(cfg); w.Task > 0 {
+		return w.Task
+	}
+	return TaskMessagesWindow
+}
+
+// maxSummaryLength returns the configured summary length limit, or the default if unset
+func maxSummaryLength(cfg *config.Config) int {
+	if cfg != nil && cfg.Notifications.Desktop.MaxSummaryLength > 0 {
+		return cfg.Notifications.Desktop.MaxSummaryLength
+	}
+	return DefaultMaxSummaryLength
+}
+
+// summaryWindows returns the configured summary windows, or zero values if unset
+func summaryWindows(cfg *config.Config) config.SummaryWindowsConfig {
+	if cfg == nil || cfg.Notifications.SummaryWindows == nil {
+		return config.SummaryWindowsConfig{}
 	}
+	return *cfg.Notifications.SummaryWindows
+}
 
-	// Use status-specific generators
+// generateForStatus runs the status-specific summary generator
+func generateForStatus(messages []jsonl.Message, status analyzer.Status, cfg *config.Config) string {
 	switch status {
 	case analyzer.StatusQuestion:
 		return generateQuestionSummary(messages, cfg)
+	case analyzer.StatusPermission:
+		return generatePermissionSummary(messages, cfg)
 	case analyzer.StatusPlanReady:
 		return generatePlanSummary(messages, cfg)
 	case analyzer.StatusReviewComplete:
 		return generateReviewSummary(messages, cfg)
 	case analyzer.StatusTaskComplete:
-		return generateTaskSummary(messages, cfg)
+		summary := generateTaskSummary(messages, cfg)
+		l := localizerFor(cfg)
+		if detectRevertPattern(messages) {
+			summary = truncateText(l.text(msgRevertedPrefix)+summary, maxSummaryLength(cfg))
+		}
+		if isLongTask(messages, cfg) {
+			summary = truncateText(l.text(msgLongTaskPrefix)+summary, maxSummaryLength(cfg))
+		}
+		if cfg.Notifications.IncludeToolTimeline {
+			summary = appendWithinLimit(summary, buildToolTimeline(messages), maxSummaryLength(cfg))
+		}
+		return summary
 	case analyzer.StatusSessionLimitReached:
 		return generateSessionLimitSummary(messages, cfg)
+	case analyzer.StatusLimitWarning:
+		return generateLimitWarningSummary(messages, cfg)
 	case analyzer.StatusAPIError:
 		return generateAPIErrorSummary(messages, cfg)
+	case analyzer.StatusError:
+		return generateErrorSummary(messages, cfg)
+	case analyzer.StatusUnknown:
+		return generateUnknownSummary(messages, cfg)
+	case analyzer.StatusSubagentComplete:
+		// The transcript belongs to the parent session, so summarizing it would
+		// describe the whole task rather than the subagent's part
+		return GenerateSimple(status, cfg)
 	default:
 		return generateTaskSummary(messages, cfg)
 	}
@@ -94,11 +201,11 @@ func generateQuestionSummary(messages []jsonl.Message, cfg *config.Config) strin
 	question, isRecent := extractAskUserQuestion(messages)
 	if question != "" && isRecent {
 		cleaned := CleanMarkdown(question)
-		return truncateText(cleaned, 150)
+		return truncateText(cleaned, maxSummaryLength(cfg))
 	}
 
 	// 2) Get recent messages from current response using helper
-	recentMessages := getRecentAssistantMessages(messages, QuestionMessagesWindow)
+	recentMessages := getRecentAssistantMessages(messages, questionWindow(cfg))
 	texts := jsonl.ExtractTextFromMessages(recentMessages)
 
 	// Strategy A: Find texts with "?" and prioritize short ones
@@ -118,7 +225,7 @@ func generateQuestionSummary(messages []jsonl.Message, cfg *config.Config) strin
 			}
 		}
 		cleaned := CleanMarkdown(shortestQuestion)
-		return truncateText(cleaned, 150)
+		return truncateText(cleaned, maxSummaryLength(cfg))
 	}
 
 	// Strategy B: No "?" found, take first sentence from last assistant message
@@ -126,14 +233,14 @@ func generateQuestionSummary(messages []jsonl.Message, cfg *config.Config) strin
 		lastText := texts[len(texts)-1]
 		cleaned := CleanMarkdown(lastText)
 		// Extract first sentence
-		firstSentence := extractFirstSentence(cleaned)
+		firstSentence := extractFirstSentence(cleaned, maxSummaryLength(cfg))
 		if len(firstSentence) > 10 {
-			return truncateText(firstSentence, 150)
+			return truncateText(firstSentence, maxSummaryLength(cfg))
 		}
 	}
 
 	// 3) Final fallback: generic prompt
-	return "Claude needs your input to continue"
+	return fallbackMessage(analyzer.StatusQuestion, cfg, localizerFor(cfg).text(msgQuestionFallback))
 }
 
 // generatePlanSummary generates summary for plan_ready status
@@ -155,11 +262,11 @@ func generatePlanSummary(messages []jsonl.Message, cfg *config.Config) string {
 		}
 
 		if firstLine != "" {
-			return truncateText(firstLine, 150)
+			return truncateText(firstLine, maxSummaryLength(cfg))
 		}
 	}
 
-	return "Plan is ready for review"
+	return fallbackMessage(analyzer.StatusPlanReady, cfg, localizerFor(cfg).text(msgPlanFallback))
 }
 
 // generateReviewSummary generates summary for review_complete status
@@ -171,7 +278,7 @@ func generateReviewSummary(messages []jsonl.Message, cfg *config.Config) string
 	// contains current response. See generateQuestionSummary for reference implementation.
 
 	// Look for review-related messages
-	recentMessages := jsonl.GetLastAssistantMessages(messages, ReviewMessagesWindow)
+	recentMessages := jsonl.GetLastAssistantMessages(messages, reviewWindow(cfg))
 	texts := jsonl.ExtractTextFromMessages(recentMessages)
 	combined := strings.Join(texts, " ")
 
@@ -183,7 +290,7 @@ func generateReviewSummary(messages []jsonl.Message, cfg *config.Config) string
 			for _, text := range texts {
 				if strings.Contains(strings.ToLower(text), keyword) {
 					cleaned := CleanMarkdown(text)
-					return truncateText(cleaned, 150)
+					return truncateText(cleaned, maxSummaryLength(cfg))
 				}
 			}
 		}
@@ -198,15 +305,12 @@ func generateReviewSummary(messages []jsonl.Message, cfg *config.Config) string
 		}
 	}
 
+	l := localizerFor(cfg)
 	if readCount > 0 {
-		noun := "file"
-		if readCount != 1 {
-			noun = "files"
-		}
-		return fmt.Sprintf("Reviewed %d %s", readCount, noun)
+		return l.text(msgReviewed, l.count(readCount, nounFile))
 	}
 
-	return "Code review completed"
+	return fallbackMessage(analyzer.StatusReviewComplete, cfg, l.text(msgReviewFallback))
 }
 
 // generateTaskSummary generates summary for task_complete status
@@ -218,9 +322,9 @@ func generateTaskSummary(messages []jsonl.Message, cfg *config.Config) string {
 	// contains current response. See generateQuestionSummary for reference implementation.
 
 	// Get recent assistant messages
-	recentMessages := jsonl.GetLastAssistantMessages(messages, TaskMessagesWindow)
+	recentMessages := jsonl.GetLastAssistantMessages(messages, taskWindow(cfg))
 	if len(recentMessages) == 0 {
-		return GetDefaultMessage(analyzer.StatusTaskComplete, cfg)
+		return GenerateSimple(analyzer.StatusTaskComplete, cfg)
 	}
 
 	// Extract last assistant message text
@@ -231,32 +335,47 @@ func generateTaskSummary(messages []jsonl.Message, cfg *config.Config) string {
 	}
 
 	// Calculate duration and count tools
-	duration := calculateDuration(messages)
-	toolCounts := countToolsByType(messages)
+	l := localizerFor(cfg)
+	duration := calculateDuration(l, durationFormat(cfg), messages)
+	toolCounts := CountToolsByType(messages)
+	var files map[string][]string
+	if cfg != nil && cfg.Notifications.SummaryShowFiles {
+		files = changedFiles(messages)
+	}
 
 	// Build actions string
-	actions := buildActionsString(toolCounts, duration)
+	actions := buildActionsString(l, toolCounts, files, duration)
+
+	// Lead with the result of a final test run ("All 42 tests passed")
+	if outcome := testOutcome(l, messages); outcome != "" {
+		if actions != "" {
+			actions = outcome + ". " + actions
+		} else {
+			actions = outcome
+		}
+	}
 
 	// If we have both message and actions, combine them
 	if lastMessage != "" {
 		// Clean markdown first
 		cleaned := CleanMarkdown(lastMessage)
 
-		// If message is short (< 150 chars), use it as-is
+		// If message fits the summary limit, use it as-is
 		// Otherwise extract first sentence(s)
+		maxLen := maxSummaryLength(cfg)
 		var messageText string
-		if len(cleaned) < 150 {
+		if utf8.RuneCountInString(cleaned) < maxLen {
 			messageText = cleaned
 		} else {
-			messageText = extractFirstSentence(cleaned)
+			messageText = extractFirstSentence(cleaned, maxLen)
 		}
 
 		if actions != "" {
 			// Combine message with actions
 			combined := messageText + ". " + actions
-			return truncateText(combined, 150)
+			return truncateText(combined, maxLen)
 		}
-		return truncateText(messageText, 150)
+		return truncateText(messageText, maxLen)
 	}
 
 	// Fallback: just actions or generic message
@@ -270,22 +389,84 @@ func generateTaskSummary(messages []jsonl.Message, cfg *config.Config) string {
 		toolCount += count
 	}
 	if toolCount > 0 {
-		return fmt.Sprintf("Completed task with %d operations", toolCount)
+		return l.text(msgCompletedOperations, l.count(toolCount, nounOperation))
 	}
 
-	return "Task completed successfully"
+	return fallbackMessage(analyzer.StatusTaskComplete, cfg, l.text(msgTaskFallback))
 }
 
 // generateSessionLimitSummary generates summary for session_limit_reached status
 func generateSessionLimitSummary(messages []jsonl.Message, cfg *config.Config) string {
 	// Simple message for session limit
-	return "Session limit reached. Please start a new conversation."
+	return fallbackMessage(analyzer.StatusSessionLimitReached, cfg, localizerFor(cfg).text(msgSessionLimitFallback))
+}
+
+// generateLimitWarningSummary generates summary for limit_warning status
+// Uses the warning text itself so the user sees the actual usage/reset details
+func generateLimitWarningSummary(messages []jsonl.Message, cfg *config.Config) string {
+	recentMessages := jsonl.GetLastAssistantMessages(messages, 3)
+	texts := jsonl.ExtractTextFromMessages(recentMessages)
+	keywords := analyzer.LimitWarningKeywords(cfg)
+	for i := len(texts) - 1; i >= 0; i-- {
+		if analyzer.IsLimitWarningText(texts[i], keywords) {
+			return truncateText(CleanMarkdown(texts[i]), maxSummaryLength(cfg))
+		}
+	}
+
+	return fallbackMessage(analyzer.StatusLimitWarning, cfg, localizerFor(cfg).text(msgLimitWarningFallback))
 }
 
 // generateAPIErrorSummary generates summary for api_error status
 func generateAPIErrorSummary(messages []jsonl.Message, cfg *config.Config) string {
 	// Simple message for API authentication error
-	return "Please run /login"
+	return fallbackMessage(analyzer.StatusAPIError, cfg, localizerFor(cfg).text(msgAPIErrorFallback))
+}
+
+// generatePermissionSummary generates summary for permission status
+// Names the tool (and command or file) that is waiting for approval
+func generatePermissionSummary(messages []jsonl.Message, cfg *config.Config) string {
+	l := localizerFor(cfg)
+	pending := jsonl.FindPendingToolUse(messages)
+	if pending == nil || pending.Name == "" {
+		return fallbackMessage(analyzer.StatusPermission, cfg, l.text(msgPermissionFallback))
+	}
+
+	if command := inputString(pending.Input, "command"); command != "" {
+		return truncateText(l.text(msgAllowCommand, pending.Name, command), maxSummaryLength(cfg))
+	}
+	if path := inputString(pending.Input, "file_path"); path != "" {
+		return truncateText(l.text(msgAllowFile, pending.Name, filepath.Base(path)), maxSummaryLength(cfg))
+	}
+	return l.text(msgAllowTool, pending.Name)
+}
+
+// generateErrorSummary generates summary for error status
+// Uses Claude's report of the failure when there is one
+func generateErrorSummary(messages []jsonl.Message, cfg *config.Config) string {
+	recentMessages := getRecentAssistantMessages(messages, taskWindow(cfg))
+	texts := jsonl.ExtractTextFromMessages(recentMessages)
+	if len(texts) > 0 {
+		lastText := texts[len(texts)-1]
+		if analyzer.IsErrorText(lastText, analyzer.ErrorKeywords(cfg)) {
+			return truncateText(CleanMarkdown(lastText), maxSummaryLength(cfg))
+		}
+	}
+
+	return fallbackMessage(analyzer.StatusError, cfg, localizerFor(cfg).text(msgErrorFallback))
+}
+
+// generateUnknownSummary generates summary for a Stop event the analyzer couldn't classify
+// There are no tools to describe, so Claude's last reply in the current response is used
+func generateUnknownSummary(messages []jsonl.Message, cfg *config.Config) string {
+	userTS := jsonl.GetLastUserTimestamp(messages)
+	texts := jsonl.ExtractTextFromMessages(jsonl.FilterMessagesAfterTimestamp(messages, userTS))
+	for i := len(texts) - 1; i >= 0; i-- {
+		if cleaned := CleanMarkdown(texts[i]); cleaned != "" {
+			return truncateText(cleaned, maxSummaryLength(cfg))
+		}
+	}
+
+	return GenerateSimple(analyzer.StatusUnknown, cfg)
 }
 
 // extractAskUserQuestion extracts the last AskUserQuestion with recency check
@@ -354,35 +535,76 @@ func extractExitPlanModePlan(messages []jsonl.Message) string {
 }
 
 // calculateDuration calculates duration between last user and last assistant messages
-func calculateDuration(messages []jsonl.Message) string {
+func calculateDuration(l localizer, format string, messages []jsonl.Message) string {
+	duration, ok := ResponseDuration(messages)
+	if !ok {
+		return ""
+	}
+
+	return formatDuration(l, format, duration)
+}
+
+// ResponseDuration returns the time from the last user message to the last assistant message
+func ResponseDuration(messages []jsonl.Message) (time.Duration, bool) {
 	userTS := jsonl.GetLastUserTimestamp(messages)
 	assistantTS := jsonl.GetLastAssistantTimestamp(messages)
 
 	if userTS == "" || assistantTS == "" {
-		return ""
+		return 0, false
 	}
 
 	userTime, err1 := time.Parse(time.RFC3339, userTS)
 	assistantTime, err2 := time.Parse(time.RFC3339, assistantTS)
 
 	if err1 != nil || err2 != nil {
-		return ""
+		return 0, false
 	}
 
 	duration := assistantTime.Sub(userTime)
 	if duration < 0 {
-		return ""
+		return 0, false
 	}
 
-	return formatDuration(duration)
+	return duration, true
 }
 
-// formatDuration formats duration into human-readable string
-func formatDuration(d time.Duration) string {
+// isLongTask reports whether the response took longer than notifications.longTaskThresholdSeconds
+func isLongTask(messages []jsonl.Message, cfg *config.Config) bool {
+	threshold := cfg.Notifications.LongTaskThresholdSeconds
+	if threshold <= 0 {
+		return false
+	}
+	duration, ok := ResponseDuration(messages)
+	return ok && duration > time.Duration(threshold)*time.Second
+}
+
+// durationFormat returns notifications.durationFormat, or "words" if unset
+func durationFormat(cfg *config.Config) string {
+	if cfg == nil || cfg.Notifications.DurationFormat == "" {
+		return config.DurationFormatWords
+	}
+	return cfg.Notifications.DurationFormat
+}
+
+// formatDuration formats duration in the given durationFormat: "Took 1m 30s" (words),
+// "1:30" (clock), "PT1M30S" (iso) or "" (none)
+func formatDuration(l localizer, format string, d time.Duration) string {
 	seconds := int(d.Seconds())
 
+	switch format {
+	case config.DurationFormatNone:
+		return ""
+	case config.DurationFormatClock:
+		if seconds < 3600 {
+			return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+		}
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	case config.DurationFormatISO:
+		return isoDuration(seconds)
+	}
+
 	if seconds < 60 {
-		return fmt.Sprintf("Took %ds", seconds)
+		return l.text(msgTookSeconds, seconds)
 	}
 
 	minutes := seconds / 60
@@ -390,23 +612,78 @@ func formatDuration(d time.Duration) string {
 
 	if minutes < 60 {
 		if secs > 0 {
-			return fmt.Sprintf("Took %dm %ds", minutes, secs)
+			return l.text(msgTookMinutesSeconds, minutes, secs)
 		}
-		return fmt.Sprintf("Took %dm", minutes)
+		return l.text(msgTookMinutes, minutes)
 	}
 
 	hours := minutes / 60
 	mins := minutes % 60
 
 	if mins > 0 {
-		return fmt.Sprintf("Took %dh %dm", hours, mins)
+		return l.text(msgTookHoursMinutes, hours, mins)
+	}
+	return l.text(msgTookHours, hours)
+}
+
+// isoDuration formats seconds as an ISO 8601 duration, e.g. "PT1H2M" or "PT45S"
+func isoDuration(seconds int) string {
+	hours, minutes, secs := seconds/3600, seconds/60%60, seconds%60
+
+	var b strings.Builder
+	b.WriteString("PT")
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
 	}
-	return fmt.Sprintf("Took %dh", hours)
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if secs > 0 || (hours == 0 && minutes == 0) {
+		fmt.Fprintf(&b, "%dS", secs)
+	}
+	return b.String()
 }
 
-// countToolsByType counts tools since last user message
-func countToolsByType(messages []jsonl.Message) map[string]int {
+// CountToolsByType counts tools since last user message
+func CountToolsByType(messages []jsonl.Message) map[string]int {
 	counts := make(map[string]int)
+	for _, name := range toolsSinceLastUser(messages) {
+		counts[name]++
+	}
+	return counts
+}
+
+// changedFiles returns the base names of the files each Write and Edit tool touched
+// since the last user message, keyed by tool name, in order of first use
+func changedFiles(messages []jsonl.Message) map[string][]string {
+	files := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, tool := range toolUsesSinceLastUser(messages) {
+		if tool.Name != "Write" && tool.Name != "Edit" {
+			continue
+		}
+		path := inputString(tool.Input, "file_path")
+		if path == "" || seen[tool.Name+"\x00"+path] {
+			continue
+		}
+		seen[tool.Name+"\x00"+path] = true
+		files[tool.Name] = append(files[tool.Name], filepath.Base(path))
+	}
+	return files
+}
+
+// toolsSinceLastUser returns the names of tools used since last user message, in order
+func toolsSinceLastUser(messages []jsonl.Message) []string {
+	var names []string
+	for _, tool := range toolUsesSinceLastUser(messages) {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+// toolUsesSinceLastUser returns the tool_use contents since last user message, in order
+func toolUsesSinceLastUser(messages []jsonl.Message) []jsonl.Content {
+	var tools []jsonl.Content
 
 	// Find last user timestamp
 	userTS := jsonl.GetLastUserTimestamp(messages)
@@ -417,7 +694,7 @@ func countToolsByType(messages []jsonl.Message) map[string]int {
 		}
 	}
 
-	// Count tools after user message
+	// Collect tools after user message
 	for _, msg := range messages {
 		if msg.Type != "assistant" {
 			continue
@@ -434,43 +711,133 @@ func countToolsByType(messages []jsonl.Message) map[string]int {
 
 		for _, content := range msg.Message.Content {
 			if content.Type == "tool_use" {
-				counts[content.Name]++
+				tools = append(tools, content)
 			}
 		}
 	}
 
-	return counts
+	return tools
+}
+
+// detectRevertPattern reports whether Claude changed a file, ran a command (e.g. tests)
+// and then edited the same file back. An Edit only counts as a revert when it swaps
+// back the old_string/new_string of an earlier Edit to that file, so an ordinary
+// follow-up fix after a failing test run is not reported
+func detectRevertPattern(messages []jsonl.Message) bool {
+	// Edits per file that happened before the most recent Bash run
+	type edit struct{ oldText, newText string }
+	pending := make(map[string][]edit)
+	tested := make(map[string][]edit)
+
+	for _, tool := range toolUsesSinceLastUser(messages) {
+		switch tool.Name {
+		case "Bash":
+			for path, edits := range pending {
+				tested[path] = append(tested[path], edits...)
+			}
+			pending = make(map[string][]edit)
+		case "Edit":
+			path := inputString(tool.Input, "file_path")
+			oldText := inputString(tool.Input, "old_string")
+			newText := inputString(tool.Input, "new_string")
+			if path == "" || oldText == newText {
+				continue
+			}
+			for _, earlier := range tested[path] {
+				if earlier.oldText == newText && earlier.newText == oldText {
+					return true
+				}
+			}
+			pending[path] = append(pending[path], edit{oldText, newText})
+		}
+	}
+
+	return false
+}
+
+// inputString returns a string field from a tool input, or "" if missing
+func inputString(input map[string]interface{}, key string) string {
+	value, _ := input[key].(string)
+	return value
+}
+
+// buildToolTimeline builds a compact tool sequence since last user message, e.g. "Read→Edit→Bash"
+// Consecutive repeats are collapsed and only the last MaxTimelineSteps tools are kept
+func buildToolTimeline(messages []jsonl.Message) string {
+	var steps []string
+	for _, name := range toolsSinceLastUser(messages) {
+		if len(steps) == 0 || steps[len(steps)-1] != name {
+			steps = append(steps, name)
+		}
+	}
+
+	if len(steps) > MaxTimelineSteps {
+		steps = append([]string{"…"}, steps[len(steps)-MaxTimelineSteps:]...)
+	}
+	return strings.Join(steps, "→")
+}
+
+// appendWithinLimit appends suffix to text, shortening text so the result fits in maxLen
+func appendWithinLimit(text, suffix string, maxLen int) string {
+	if suffix == "" {
+		return text
+	}
+	if text == "" {
+		return truncateText(suffix, maxLen)
+	}
+
+	separator := ". "
+	budget := maxLen - utf8.RuneCountInString(suffix) - len(separator)
+	if budget <= 0 {
+		return truncateText(text, maxLen)
+	}
+
+	text = truncateText(text, budget)
+	if strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?") {
+		separator = " "
+	}
+	return text + separator + suffix
 }
 
 // buildActionsString builds actions summary with tool counts and duration
-func buildActionsString(toolCounts map[string]int, duration string) string {
+// files optionally lists the changed files per tool (see changedFiles); up to MaxListedFiles
+// names are shown instead of the count
+func buildActionsString(l localizer, toolCounts map[string]int, files map[string][]string, duration string) string {
 	var parts []string
 
 	// Write
 	if count := toolCounts["Write"]; count > 0 {
-		noun := "file"
-		if count != 1 {
-			noun = "files"
-		}
-		parts = append(parts, fmt.Sprintf("Created %d %s", count, noun))
+		parts = append(parts, l.text(msgCreated, formatFiles(l, files["Write"], count)))
 	}
 
 	// Edit
 	if count := toolCounts["Edit"]; count > 0 {
-		noun := "file"
-		if count != 1 {
-			noun = "files"
-		}
-		parts = append(parts, fmt.Sprintf("Edited %d %s", count, noun))
+		parts = append(parts, l.text(msgEdited, formatFiles(l, files["Edit"], count)))
 	}
 
 	// Bash
 	if count := toolCounts["Bash"]; count > 0 {
-		noun := "command"
-		if count != 1 {
-			noun = "commands"
-		}
-		parts = append(parts, fmt.Sprintf("Ran %d %s", count, noun))
+		parts = append(parts, l.text(msgRan, l.count(count, nounCommand)))
+	}
+
+	// NotebookEdit
+	if count := toolCounts["NotebookEdit"]; count > 0 {
+		parts = append(parts, l.text(msgEdited, l.count(count, nounNotebook)))
+	}
+
+	// SlashCommand
+	if count := toolCounts["SlashCommand"]; count > 0 {
+		parts = append(parts, l.text(msgRan, l.count(count, nounSlashCommand)))
+	}
+
+	// KillShell
+	if count := toolCounts["KillShell"]; count > 0 {
+		parts = append(parts, l.text(msgStopped, l.count(count, nounProcess)))
+	}
+
+	// Cap the number of phrases
+	if len(parts) > MaxActionPhrases {
+		parts = parts[:MaxActionPhrases]
 	}
 
 	// Add duration at the end
@@ -485,13 +852,32 @@ func buildActionsString(toolCounts map[string]int, duration string) string {
 	return strings.Join(parts, ". ")
 }
 
+// formatFiles lists up to MaxListedFiles file names ("auth.go, config.go"), or falls back
+// to the file count when there are none or too many
+func formatFiles(l localizer, names []string, count int) string {
+	if len(names) == 0 || len(names) > MaxListedFiles {
+		return l.count(count, nounFile)
+	}
+	return strings.Join(names, ", ")
+}
+
+// roundCount rounds n down to its leading digit: 237 to 200, 1234 to 1000
+func roundCount(n int) int {
+	unit := 1
+	for n/unit >= 10 {
+		unit *= 10
+	}
+	return n / unit * unit
+}
+
 // Helper functions
 
-func extractFirstSentence(text string) string {
+// extractFirstSentence returns the first sentence of text, adding the second if the first is very short.
+// Sentences are kept within maxLen where possible; text without punctuation is cut at maxLen.
+func extractFirstSentence(text string, maxLen int) string {
 	// Find first sentence (ending with . ! or ?)
 	// If first sentence is too short (< 20 chars), try to include second sentence too
 	const minSentenceLength = 20
-	const maxLength = 200
 
 	var sentences []string
 	var currentStart int
@@ -505,18 +891,18 @@ func extractFirstSentence(text string) string {
 				currentStart = i + 1
 
 				// Calculate total length so far
-				totalLength := len(strings.Join(sentences, " "))
+				totalLength := utf8.RuneCountInString(strings.Join(sentences, " "))
 
 				// If we have at least one sentence and either:
 				// 1. Total length >= minSentenceLength, OR
-				// 2. Total length >= maxLength
+				// 2. Total length >= maxLen
 				// Then return what we have
-				if len(sentences) == 1 && totalLength < minSentenceLength && totalLength < maxLength {
+				if len(sentences) == 1 && totalLength < minSentenceLength && totalLength < maxLen {
 					// First sentence too short, continue to get second
 					continue
 				}
 
-				if totalLength >= maxLength {
+				if totalLength >= maxLen {
 					// Too long, return what we had before last sentence
 					if len(sentences) > 1 {
 						return strings.Join(sentences[:len(sentences)-1], " ")
@@ -534,27 +920,78 @@ func extractFirstSentence(text string) string {
 		return strings.Join(sentences, " ")
 	}
 
-	// Return first 100 chars if no punctuation found
-	if len(text) > 100 {
-		return text[:100]
+	// Return first maxLen chars if no punctuation found
+	return runePrefix(text, maxLen)
+}
+
+// runePrefix returns the first n characters (runes) of text, never splitting a
+// multi-byte UTF-8 character
+func runePrefix(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	count := 0
+	for i := range text {
+		if count == n {
+			return text[:i]
+		}
+		count++
 	}
 	return text
 }
 
+// DefaultEllipsis marks text cut at a word boundary by Truncate
+const DefaultEllipsis = "..."
+
+// TruncateOptions controls how Truncate shortens text
+type TruncateOptions struct {
+	// Ellipsis is appended when text is cut at a word boundary ("" = DefaultEllipsis)
+	Ellipsis string
+	// PreferSentences cuts after the first complete sentence that fits, when there is one
+	// past the first third of maxLen, before falling back to a word boundary
+	PreferSentences bool
+}
+
+// truncateText shortens text to at most maxLen characters (runes), preferring a sentence
+// boundary, then a word boundary followed by "..."
 func truncateText(text string, maxLen int) string {
-	if len(text) <= maxLen {
+	return Truncate(text, maxLen, TruncateOptions{PreferSentences: true})
+}
+
+// Truncate shortens text to at most maxLen characters (runes). Text is cut at a sentence
+// boundary if opts.PreferSentences is set and one fits, otherwise at a word boundary
+// followed by the ellipsis; the result including the ellipsis stays within maxLen.
+func Truncate(text string, maxLen int, opts TruncateOptions) string {
+	if utf8.RuneCountInString(text) <= maxLen {
 		return text
 	}
 
-	// Step 1: Try to find sentence boundary (., !, ?) within maxLen
-	// Look for the last sentence-ending punctuation in the allowed range
-	searchText := text[:maxLen]
+	if opts.PreferSentences {
+		if end := sentenceBoundary(runePrefix(text, maxLen), maxLen); end >= 0 {
+			// Found a sentence boundary, truncate there (including the punctuation)
+			return strings.TrimSpace(text[:end+1])
+		}
+	}
 
-	// Check for sentence enders: ". ", "! ", "? " (followed by space or newline)
-	// Also check for end of string within maxLen
-	lastSentenceEnd := -1
+	// No sentence boundary found, try word boundary
+	ellipsis := opts.Ellipsis
+	if ellipsis == "" {
+		ellipsis = DefaultEllipsis
+	}
+	truncated := runePrefix(text, maxLen-utf8.RuneCountInString(ellipsis))
+	lastSpace := strings.LastIndex(truncated, " ")
+	if lastSpace >= 0 && utf8.RuneCountInString(truncated[:lastSpace]) > maxLen/2 {
+		truncated = truncated[:lastSpace]
+	}
+
+	return truncated + ellipsis
+}
 
-	// Try sentence endings with space/newline after
+// sentenceBoundary returns the byte offset of the punctuation ending the first sentence
+// in searchText that is longer than a third of maxLen, or -1 if there is none.
+// Enders are ASCII, so slicing at the offset is safe.
+func sentenceBoundary(searchText string, maxLen int) int {
+	// Check for sentence enders: ". ", "! ", "? " (followed by space or newline)
 	// Find the FIRST suitable sentence ending (to avoid partial next sentences)
 	sentenceEnders := []string{". ", "! ", "? ", ".\n", "!\n", "?\n"}
 	for _, ender := range sentenceEnders {
@@ -566,51 +1003,76 @@ func truncateText(text string, maxLen int) string {
 			}
 			actualPos := idx + pos
 			// Check if this position is suitable: not too early
-			if actualPos > maxLen/3 {
-				// Found a suitable sentence ending
-				// Only use it if we haven't found one yet, or this is a better one
-				// (we want the FIRST suitable one, not the last)
-				if lastSentenceEnd < 0 {
-					lastSentenceEnd = actualPos
-					break // Stop searching for this ender
-				}
+			if utf8.RuneCountInString(searchText[:actualPos]) > maxLen/3 {
+				return actualPos
 			}
 			idx = actualPos + 1
 		}
-		if lastSentenceEnd >= 0 {
-			break // Found a suitable sentence, no need to check other enders
-		}
 	}
 
 	// Also try sentence ending at the very end of searchText (no space after)
-	if lastSentenceEnd < 0 && len(searchText) > 0 {
+	if len(searchText) > 0 {
 		lastChar := searchText[len(searchText)-1]
 		if lastChar == '.' || lastChar == '!' || lastChar == '?' {
-			lastSentenceEnd = len(searchText) - 1
+			return len(searchText) - 1
 		}
 	}
 
-	if lastSentenceEnd >= 0 {
-		// Found a sentence boundary, truncate there (including the punctuation)
-		return strings.TrimSpace(text[:lastSentenceEnd+1])
+	return -1
+}
+
+// StripANSI removes ANSI escape sequences (e.g. terminal colors) from text
+func StripANSI(text string) string {
+	if !strings.Contains(text, "\x1b") {
+		return text
 	}
+	return ansiPattern.ReplaceAllString(text, "")
+}
 
-	// Step 2: No sentence boundary found, try word boundary
-	truncated := text[:maxLen-3]
-	lastSpace := strings.LastIndex(truncated, " ")
-	if lastSpace > maxLen/2 {
-		truncated = truncated[:lastSpace]
+// tableRowText joins the non-empty cells of a markdown table row with spaces
+func tableRowText(row string) string {
+	var cells []string
+	for _, cell := range strings.Split(strings.Trim(row, "|"), "|") {
+		if cell = strings.TrimSpace(cell); cell != "" {
+			cells = append(cells, cell)
+		}
 	}
+	return strings.Join(cells, " ")
+}
 
-	return truncated + "..."
+// findTableRows marks the lines that belong to a table: the header row above each separator
+// row and the rows with a "|" below it. Rows need no border pipes, as in "a | b".
+func findTableRows(lines []string) map[int]bool {
+	rows := make(map[int]bool)
+	for i, line := range lines {
+		if !tableRulePattern.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		if i > 0 && strings.Contains(lines[i-1], "|") {
+			rows[i-1] = true
+		}
+		for j := i + 1; j < len(lines) && strings.Contains(lines[j], "|"); j++ {
+			rows[j] = true
+		}
+	}
+	return rows
 }
 
+// maxEmphasisDepth bounds how many layers of nested emphasis CleanMarkdown unwraps
+const maxEmphasisDepth = 3
+
 // CleanMarkdown cleans markdown formatting from text
 // Removes all markdown syntax while preserving the actual text content
 func CleanMarkdown(text string) string {
+	// Step 0: Remove ANSI escape codes leaked from tool output
+	text = StripANSI(text)
+
 	// Step 1: Remove code blocks first (they can contain markdown-like syntax)
 	text = codeBlockPattern.ReplaceAllString(text, "")
 
+	// Step 1b: Remove horizontal rules before their * and _ are taken for emphasis
+	text = horizontalRulePattern.ReplaceAllString(text, "")
+
 	// Step 2: Convert images to alt text (must be before links since images are ![](url))
 	text = imagePattern.ReplaceAllString(text, "$1")
 
@@ -620,26 +1082,40 @@ func CleanMarkdown(text string) string {
 	// Step 4: Remove strikethrough
 	text = strikethroughPattern.ReplaceAllString(text, "$1")
 
-	// Step 5: Remove bold (both ** and __)
-	text = boldPattern.ReplaceAllString(text, "$2")
-
-	// Step 6: Remove italic (both * and _)
-	// Need to be careful with edge cases
-	text = italicPattern.ReplaceAllString(text, "$2")
+	// Steps 5-6: Remove bold italic (*** and ___), bold (** and __) and italic (* and _),
+	// repeated so nested emphasis like _*text*_ or __*text*__ is unwrapped layer by layer
+	for i := 0; i < maxEmphasisDepth; i++ {
+		before := text
+		text = boldItalicPattern.ReplaceAllString(text, "$2")
+		text = boldPattern.ReplaceAllString(text, "$2")
+		text = italicPattern.ReplaceAllString(text, "$2")
+		if text == before {
+			break
+		}
+	}
 
 	// Step 7: Remove backticks (inline code)
 	text = backtickPattern.ReplaceAllString(text, "")
 
 	// Step 8: Process line by line for line-based patterns
 	lines := strings.Split(text, "\n")
+	tableRows := findTableRows(lines)
 	var cleaned []string
 
-	for _, line := range lines {
+	for i, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
+		// Drop table separator rows and join the cells of other rows (| a | b | -> a b)
+		if tableRulePattern.MatchString(line) {
+			continue
+		}
+		if tableRows[i] || strings.HasPrefix(line, "|") {
+			line = tableRowText(line)
+		}
+
 		// Remove headers (# text)
 		line = headerPattern.ReplaceAllString(line, "")
 
@@ -649,6 +1125,9 @@ func CleanMarkdown(text string) string {
 		// Remove bullet points (- text, * text, • text)
 		line = bulletPattern.ReplaceAllString(line, "")
 
+		// Remove ordered list numbers (1. text)
+		line = orderedListPattern.ReplaceAllString(line, "")
+
 		// Trim again
 		line = strings.TrimSpace(line)
 		if line != "" {
@@ -656,8 +1135,9 @@ func CleanMarkdown(text string) string {
 		}
 	}
 
-	// Step 9: Join lines and normalize whitespace
-	result := strings.Join(cleaned, " ")
+	// Step 9: Join lines, decode HTML entities echoed with code (&amp;, &lt;, &#x27;)
+	// and normalize whitespace. Decoding last keeps an escaped &#42; from reading as emphasis.
+	result := html.UnescapeString(strings.Join(cleaned, " "))
 	result = multiSpacePattern.ReplaceAllString(result, " ")
 
 	return strings.TrimSpace(result)
@@ -666,8 +1146,11 @@ func CleanMarkdown(text string) string {
 // GetDefaultMessage returns a default message for a status
 func GetDefaultMessage(status analyzer.Status, cfg *config.Config) string {
 	statusInfo, exists := cfg.GetStatusInfo(string(status))
+	if !exists && status == analyzer.StatusUnknown {
+		statusInfo, exists = config.DefaultUnknownStatus(), true
+	}
 	if !exists {
-		return "Claude Code notification"
+		return localizerFor(cfg).text(msgNotificationFallback)
 	}
 
 	// Remove emoji from title for message
@@ -678,6 +1161,15 @@ func GetDefaultMessage(status analyzer.Status, cfg *config.Config) string {
 }
 
 // GenerateSimple generates a simple message based on status
+// Uses the status's defaultFallbackMessage if configured
 func GenerateSimple(status analyzer.Status, cfg *config.Config) string {
-	return GetDefaultMessage(status, cfg)
+	return fallbackMessage(status, cfg, GetDefaultMessage(status, cfg))
+}
+
+// fallbackMessage returns the status's configured defaultFallbackMessage, or def if none is set
+func fallbackMessage(status analyzer.Status, cfg *config.Config, def string) string {
+	if statusInfo, exists := cfg.GetStatusInfo(string(status)); exists && statusInfo.DefaultFallbackMessage != "" {
+		return statusInfo.DefaultFallbackMessage
+	}
+	return def
 }
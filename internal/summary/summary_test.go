@@ -7,12 +7,29 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/pkg/jsonl"
 )
 
+func TestBuildActionsString_HighCountsStayConcise(t *testing.T) {
+	toolCounts := map[string]int{"Write": 999, "Edit": 99999, "Bash": 12345}
+
+	result := buildActionsString(newLocalizer(DefaultLocale), toolCounts, nil, "Took 10h")
+
+	if phrases := strings.Count(result, ". ") + 1; phrases > MaxActionPhrases+1 {
+		t.Errorf("buildActionsString() has %d phrases, want at most %d: %s", phrases, MaxActionPhrases+1, result)
+	}
+	if len(result) > 90 {
+		t.Errorf("buildActionsString() too long (%d chars): %s", len(result), result)
+	}
+	if !strings.Contains(result, "Edited over 90000 files") {
+		t.Errorf("buildActionsString() should collapse large counts: %s", result)
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		duration time.Duration
@@ -28,7 +45,7 @@ func TestFormatDuration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.expected, func(t *testing.T) {
-			result := formatDuration(tt.duration)
+			result := formatDuration(newLocalizer(DefaultLocale), config.DurationFormatWords, tt.duration)
 			if result != tt.expected {
 				t.Errorf("formatDuration(%v) = %s, want %s", tt.duration, result, tt.expected)
 			}
@@ -36,6 +53,70 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatDurationFormats(t *testing.T) {
+	tests := []struct {
+		format   string
+		duration time.Duration
+		expected string
+	}{
+		{config.DurationFormatWords, 90 * time.Second, "Took 1m 30s"},
+		{config.DurationFormatClock, 5 * time.Second, "0:05"},
+		{config.DurationFormatClock, 90 * time.Second, "1:30"},
+		{config.DurationFormatClock, 3600 * time.Second, "1:00:00"},
+		{config.DurationFormatClock, 3725 * time.Second, "1:02:05"},
+		{config.DurationFormatISO, 0, "PT0S"},
+		{config.DurationFormatISO, 45 * time.Second, "PT45S"},
+		{config.DurationFormatISO, 90 * time.Second, "PT1M30S"},
+		{config.DurationFormatISO, 3600 * time.Second, "PT1H"},
+		{config.DurationFormatISO, 3725 * time.Second, "PT1H2M5S"},
+		{config.DurationFormatNone, 90 * time.Second, ""},
+		{config.DurationFormatNone, 3725 * time.Second, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.duration.String(), func(t *testing.T) {
+			result := formatDuration(newLocalizer(DefaultLocale), tt.format, tt.duration)
+			if result != tt.expected {
+				t.Errorf("formatDuration(%s, %v) = %q, want %q", tt.format, tt.duration, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateTaskSummary_DurationFormat(t *testing.T) {
+	messages := []jsonl.Message{
+		{Type: "user", Timestamp: "2025-01-01T10:00:00Z", Message: jsonl.MessageContent{Role: "user", ContentString: "Run it"}},
+		{
+			Type:      "assistant",
+			Timestamp: "2025-01-01T10:01:30Z",
+			Message: jsonl.MessageContent{
+				Role:    "assistant",
+				Content: []jsonl.Content{{Type: "tool_use", Name: "Bash"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", "Ran 1 command. Took 1m 30s"},
+		{config.DurationFormatClock, "Ran 1 command. 1:30"},
+		{config.DurationFormatISO, "Ran 1 command. PT1M30S"},
+		{config.DurationFormatNone, "Ran 1 command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.DurationFormat = tt.format
+			if result := generateTaskSummary(messages, cfg); result != tt.expected {
+				t.Errorf("generateTaskSummary() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestBuildActionsString(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -67,11 +148,59 @@ func TestBuildActionsString(t *testing.T) {
 			duration:   "",
 			expected:   "",
 		},
+		{
+			name:       "Large edit count collapses",
+			toolCounts: map[string]int{"Edit": 237},
+			duration:   "",
+			expected:   "Edited over 200 files",
+		},
+		{
+			name:       "Just below threshold keeps exact count",
+			toolCounts: map[string]int{"Bash": 99},
+			duration:   "",
+			expected:   "Ran 99 commands",
+		},
+		{
+			name:       "High counts across all tools",
+			toolCounts: map[string]int{"Write": 150, "Edit": 1200, "Bash": 430, "Read": 5000},
+			duration:   "Took 3h",
+			expected:   "Created over 100 files. Edited over 1000 files. Ran over 400 commands. Took 3h",
+		},
+		{
+			name:       "Round large count stays exact",
+			toolCounts: map[string]int{"Edit": 300},
+			duration:   "",
+			expected:   "Edited 300 files",
+		},
+		{
+			name:       "Slash command only",
+			toolCounts: map[string]int{"SlashCommand": 1},
+			duration:   "Took 5s",
+			expected:   "Ran 1 slash command. Took 5s",
+		},
+		{
+			name:       "Stopped processes",
+			toolCounts: map[string]int{"KillShell": 2},
+			duration:   "",
+			expected:   "Stopped 2 processes",
+		},
+		{
+			name:       "Notebook and bash",
+			toolCounts: map[string]int{"NotebookEdit": 1, "Bash": 3},
+			duration:   "",
+			expected:   "Ran 3 commands. Edited 1 notebook",
+		},
+		{
+			name:       "Extra tools stay within phrase cap",
+			toolCounts: map[string]int{"Write": 1, "Edit": 1, "Bash": 1, "SlashCommand": 1, "KillShell": 1},
+			duration:   "",
+			expected:   "Created 1 file. Edited 1 file. Ran 1 command",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildActionsString(tt.toolCounts, tt.duration)
+			result := buildActionsString(newLocalizer(DefaultLocale), tt.toolCounts, nil, tt.duration)
 			if result != tt.expected {
 				t.Errorf("buildActionsString() = %s, want %s", result, tt.expected)
 			}
@@ -79,6 +208,29 @@ func TestBuildActionsString(t *testing.T) {
 	}
 }
 
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text", "All tests passed", "All tests passed"},
+		{"colors", "\x1b[32mok\x1b[0m  pkg \x1b[1;31mFAIL\x1b[0m", "ok  pkg FAIL"},
+		{"256 and truecolor", "\x1b[38;5;208mwarn\x1b[39m \x1b[38;2;255;0;0mred\x1b[m", "warn red"},
+		{"cursor and erase", "\x1b[2K\x1b[1Gprogress 100%", "progress 100%"},
+		{"osc hyperlink", "see \x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\ here", "see docs here"},
+		{"osc title with bell", "\x1b]0;build\x07done", "done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripANSI(tt.input); got != tt.expected {
+				t.Errorf("StripANSI(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCleanMarkdown(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -135,6 +287,11 @@ func TestCleanMarkdown(t *testing.T) {
 			input:    "Some text\n```python\nprint('hello')\n```\nMore text",
 			expected: "Some text More text",
 		},
+		{
+			name:     "ANSI colors",
+			input:    "**Done**: \x1b[32mall tests passed\x1b[0m",
+			expected: "Done: all tests passed",
+		},
 		{
 			name:     "Inline code",
 			input:    "`code` and text",
@@ -155,6 +312,76 @@ func TestCleanMarkdown(t *testing.T) {
 			input:    "Multiple    spaces",
 			expected: "Multiple spaces",
 		},
+		{
+			name:     "Table",
+			input:    "Results:\n| Test | Status |\n|------|:------:|\n| auth | pass |\n| db | fail |",
+			expected: "Results: Test Status auth pass db fail",
+		},
+		{
+			name:     "Table without border pipes",
+			input:    "a | b\n---|---\nc | d\nThat's all",
+			expected: "a b c d That's all",
+		},
+		{
+			name:     "Pipe outside a table is kept",
+			input:    "Ran ls | wc -l",
+			expected: "Ran ls | wc -l",
+		},
+		{
+			name:     "Numbered list",
+			input:    "Steps:\n1. Build the binary\n2. Run the tests\n10. Ship it",
+			expected: "Steps: Build the binary Run the tests Ship it",
+		},
+		{
+			name:     "Year is not a list",
+			input:    "2024. Was a good year",
+			expected: "2024. Was a good year",
+		},
+		{
+			name:     "Dash without a space is not a bullet",
+			input:    "-5 degrees and *args",
+			expected: "-5 degrees and *args",
+		},
+		{
+			name:     "Decimal number is not a list",
+			input:    "2.5 seconds faster",
+			expected: "2.5 seconds faster",
+		},
+		{
+			name:     "HTML entities",
+			input:    "Use `a &amp;&amp; b` with &lt;T&gt; and &quot;quotes&quot;",
+			expected: "Use a && b with <T> and \"quotes\"",
+		},
+		{
+			name:     "Numeric HTML entities",
+			input:    "It&#x27;s &#42;not&#42; emphasis",
+			expected: "It's *not* emphasis",
+		},
+		{
+			name:     "Bold italic",
+			input:    "This is ***very important*** and ___so is this___",
+			expected: "This is very important and so is this",
+		},
+		{
+			name:     "Italic inside bold",
+			input:    "__*Fixed*__ the **_flaky_ test**",
+			expected: "Fixed the flaky test",
+		},
+		{
+			name:     "Nested italic markers",
+			input:    "_*all done*_",
+			expected: "all done",
+		},
+		{
+			name:     "Horizontal rules",
+			input:    "Summary\n---\nTests pass\n***\nDocs updated\n* * *\nDone",
+			expected: "Summary Tests pass Docs updated Done",
+		},
+		{
+			name:     "Setext header underline",
+			input:    "Results\n=======\nAll green",
+			expected: "Results All green",
+		},
 	}
 
 	for _, tt := range tests {
@@ -210,13 +437,40 @@ func TestTruncateText(t *testing.T) {
 			maxLen:   50,
 			expected: strings.Repeat("a", 47) + "...",
 		},
+		{
+			name:     "Emoji at boundary",
+			text:     strings.Repeat("🎉", 60),
+			maxLen:   50,
+			expected: strings.Repeat("🎉", 47) + "...",
+		},
+		{
+			name:     "CJK at boundary",
+			text:     strings.Repeat("漢字", 40),
+			maxLen:   30,
+			expected: strings.Repeat("漢字", 13) + "漢...",
+		},
+		{
+			name:     "Cyrillic sentence boundary",
+			text:     "Исправлены все тесты в проекте. Добавлена документация по API.",
+			maxLen:   40,
+			expected: "Исправлены все тесты в проекте.",
+		},
+		{
+			name:     "Emoji words truncated at word",
+			text:     "Готово 🎉 все тесты проходят ✅ и сборка зелёная 🚀 отлично",
+			maxLen:   30,
+			expected: "Готово 🎉 все тесты...",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := truncateText(tt.text, tt.maxLen)
-			if len(result) > tt.maxLen {
-				t.Errorf("truncateText() returned text longer than maxLen: %d > %d", len(result), tt.maxLen)
+			if n := utf8.RuneCountInString(result); n > tt.maxLen {
+				t.Errorf("truncateText() returned text longer than maxLen: %d > %d", n, tt.maxLen)
+			}
+			if !utf8.ValidString(result) {
+				t.Errorf("truncateText() split a UTF-8 character: %q", result)
 			}
 			if result != tt.expected {
 				t.Errorf("truncateText() = %q, want %q", result, tt.expected)
@@ -225,6 +479,71 @@ func TestTruncateText(t *testing.T) {
 	}
 }
 
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxLen   int
+		opts     TruncateOptions
+		expected string
+	}{
+		{
+			name:     "Short text unchanged",
+			text:     "Done.",
+			maxLen:   30,
+			opts:     TruncateOptions{Ellipsis: "…"},
+			expected: "Done.",
+		},
+		{
+			name:     "Sentence preferred",
+			text:     "Build passed. Deploying the new version to production now",
+			maxLen:   30,
+			opts:     TruncateOptions{PreferSentences: true},
+			expected: "Build passed.",
+		},
+		{
+			name:     "Word boundary without sentence preference",
+			text:     "Build passed. Deploying the new version to production now",
+			maxLen:   30,
+			opts:     TruncateOptions{},
+			expected: "Build passed. Deploying...",
+		},
+		{
+			name:     "Sentence preferred falls back to word boundary",
+			text:     "This is a long text that should be truncated at word boundary",
+			maxLen:   30,
+			opts:     TruncateOptions{PreferSentences: true, Ellipsis: "…"},
+			expected: "This is a long text that…",
+		},
+		{
+			name:     "Custom ellipsis",
+			text:     "This is a long text that should be truncated at word boundary",
+			maxLen:   30,
+			opts:     TruncateOptions{Ellipsis: "…"},
+			expected: "This is a long text that…",
+		},
+		{
+			name:     "Custom ellipsis counts toward maxLen",
+			text:     strings.Repeat("a", 50),
+			maxLen:   20,
+			opts:     TruncateOptions{Ellipsis: " [more]"},
+			expected: strings.Repeat("a", 13) + " [more]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Truncate(tt.text, tt.maxLen, tt.opts)
+			if n := utf8.RuneCountInString(result); n > tt.maxLen {
+				t.Errorf("Truncate() returned text longer than maxLen: %d > %d", n, tt.maxLen)
+			}
+			if result != tt.expected {
+				t.Errorf("Truncate() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestExtractFirstSentence(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -258,8 +577,18 @@ func TestExtractFirstSentence(t *testing.T) {
 		},
 		{
 			name:     "No punctuation",
-			text:     strings.Repeat("a", 150),
-			expected: strings.Repeat("a", 100),
+			text:     strings.Repeat("a", 200),
+			expected: strings.Repeat("a", DefaultMaxSummaryLength),
+		},
+		{
+			name:     "No punctuation - CJK",
+			text:     strings.Repeat("漢", 200),
+			expected: strings.Repeat("漢", DefaultMaxSummaryLength),
+		},
+		{
+			name:     "No punctuation - emoji",
+			text:     strings.Repeat("🚀", 200),
+			expected: strings.Repeat("🚀", DefaultMaxSummaryLength),
 		},
 		{
 			name:     "Single short sentence",
@@ -270,7 +599,7 @@ func TestExtractFirstSentence(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := extractFirstSentence(tt.text)
+			result := extractFirstSentence(tt.text, DefaultMaxSummaryLength)
 			if result != tt.expected {
 				t.Errorf("extractFirstSentence() = %q, want %q", result, tt.expected)
 			}
@@ -413,7 +742,7 @@ func TestCountToolsByType(t *testing.T) {
 		},
 	}
 
-	counts := countToolsByType(messages)
+	counts := CountToolsByType(messages)
 
 	if counts["Write"] != 2 {
 		t.Errorf("Write count = %d, want 2", counts["Write"])
@@ -426,6 +755,324 @@ func TestCountToolsByType(t *testing.T) {
 	}
 }
 
+// toolMessages builds a user message followed by an assistant message using the given tools
+func toolMessages(tools ...string) []jsonl.Message {
+	var content []jsonl.Content
+	for _, tool := range tools {
+		content = append(content, jsonl.Content{Type: "tool_use", Name: tool})
+	}
+	return []jsonl.Message{
+		{Type: "assistant", Timestamp: "2025-01-01T11:59:00Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "tool_use", Name: "Grep"}}, // previous turn, not in timeline
+		}},
+		{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: "Do something"}},
+		}},
+		{Type: "assistant", Timestamp: "2025-01-01T12:00:10Z", Message: jsonl.MessageContent{Content: content}},
+	}
+}
+
+func TestBuildToolTimeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		tools    []string
+		expected string
+	}{
+		{"no tools", nil, ""},
+		{"single tool", []string{"Bash"}, "Bash"},
+		{"repeats collapsed", []string{"Read", "Read", "Edit", "Edit", "Edit", "Bash", "Write", "Write"}, "Read→Edit→Bash→Write"},
+		{"non-consecutive repeats kept", []string{"Edit", "Bash", "Edit", "Bash"}, "Edit→Bash→Edit→Bash"},
+		{
+			"long timeline keeps last steps",
+			[]string{"Read", "Edit", "Bash", "Edit", "Bash", "Edit", "Bash", "Edit", "Bash", "Write"},
+			"…→Bash→Edit→Bash→Edit→Bash→Edit→Bash→Write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildToolTimeline(toolMessages(tt.tools...)); got != tt.expected {
+				t.Errorf("buildToolTimeline() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildActionsString_Files(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolCounts map[string]int
+		files      map[string][]string
+		expected   string
+	}{
+		{
+			name:       "Lists few files",
+			toolCounts: map[string]int{"Edit": 3, "Write": 1},
+			files:      map[string][]string{"Edit": {"auth.go", "config.go"}, "Write": {"auth_test.go"}},
+			expected:   "Created auth_test.go. Edited auth.go, config.go",
+		},
+		{
+			name:       "Falls back to count for many files",
+			toolCounts: map[string]int{"Edit": 4},
+			files:      map[string][]string{"Edit": {"a.go", "b.go", "c.go", "d.go"}},
+			expected:   "Edited 4 files",
+		},
+		{
+			name:       "Falls back to count without file paths",
+			toolCounts: map[string]int{"Write": 2},
+			files:      map[string][]string{},
+			expected:   "Created 2 files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := buildActionsString(newLocalizer(DefaultLocale), tt.toolCounts, tt.files, ""); result != tt.expected {
+				t.Errorf("buildActionsString() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateFromTranscript_SummaryShowFiles(t *testing.T) {
+	edit := func(path string) jsonl.Content {
+		return jsonl.Content{Type: "tool_use", Name: "Edit", Input: map[string]interface{}{"file_path": path}}
+	}
+	messages := toolMessages()
+	messages[2].Message.Content = []jsonl.Content{
+		edit("/repo/internal/auth.go"),
+		edit("/repo/internal/config.go"),
+		edit("/repo/internal/auth.go"), // same file again
+		{Type: "text", Text: "Fixed the login bug."},
+	}
+	transcriptPath := t.TempDir() + "/files.jsonl"
+	writeTranscript(t, transcriptPath, messages)
+
+	cfg := config.DefaultConfig()
+	if result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg); !strings.Contains(result, "Edited 3 files") {
+		t.Errorf("expected file counts by default, got: %s", result)
+	}
+
+	cfg.Notifications.SummaryShowFiles = true
+	if result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg); !strings.Contains(result, "Edited auth.go, config.go") {
+		t.Errorf("expected changed file names, got: %s", result)
+	}
+}
+
+func TestAppendWithinLimit(t *testing.T) {
+	if got := appendWithinLimit("Done", "Read→Edit", 150); got != "Done. Read→Edit" {
+		t.Errorf("appendWithinLimit() = %q", got)
+	}
+	if got := appendWithinLimit("All done.", "Read→Edit", 150); got != "All done. Read→Edit" {
+		t.Errorf("appendWithinLimit() = %q", got)
+	}
+	if got := appendWithinLimit("Done", "", 150); got != "Done" {
+		t.Errorf("appendWithinLimit() with empty suffix = %q", got)
+	}
+
+	long := strings.Repeat("word ", 60)
+	got := appendWithinLimit(long, "Read→Edit→Bash", 150)
+	if n := utf8.RuneCountInString(got); n > 150 {
+		t.Errorf("appendWithinLimit() result too long: %d chars", n)
+	}
+	if !strings.HasSuffix(got, "Read→Edit→Bash") {
+		t.Errorf("appendWithinLimit() should keep the timeline: %q", got)
+	}
+}
+
+func TestGenerateFromTranscript_ToolTimeline(t *testing.T) {
+	transcriptPath := t.TempDir() + "/timeline.jsonl"
+	messages := toolMessages("Read", "Read", "Edit", "Bash", "Bash")
+	messages[2].Message.Content = append(messages[2].Message.Content, jsonl.Content{Type: "text", Text: "Fixed the bug."})
+	writeTranscript(t, transcriptPath, messages)
+
+	cfg := config.DefaultConfig()
+	if result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg); strings.Contains(result, "→") {
+		t.Errorf("timeline should be off by default, got: %s", result)
+	}
+
+	cfg.Notifications.IncludeToolTimeline = true
+	result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg)
+	if !strings.HasPrefix(result, "Fixed the bug.") || !strings.HasSuffix(result, " Read→Edit→Bash") {
+		t.Errorf("expected summary followed by timeline, got: %s", result)
+	}
+}
+
+func TestGenerateForStatus_LongTask(t *testing.T) {
+	// The response below took 10s
+	messages := toolMessages("Edit")
+	messages[2].Message.Content = append(messages[2].Message.Content, jsonl.Content{Type: "text", Text: "Fixed the bug."})
+
+	tests := []struct {
+		name      string
+		threshold int
+		status    analyzer.Status
+		expected  bool
+	}{
+		{"above threshold", 5, analyzer.StatusTaskComplete, true},
+		{"below threshold", 30, analyzer.StatusTaskComplete, false},
+		{"at threshold", 10, analyzer.StatusTaskComplete, false},
+		{"disabled", 0, analyzer.StatusTaskComplete, false},
+		{"other status", 5, analyzer.StatusReviewComplete, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.LongTaskThresholdSeconds = tt.threshold
+
+			result := generateForStatus(messages, tt.status, cfg)
+			if got := strings.HasPrefix(result, LongTaskPrefix+"Fixed the bug."); got != tt.expected {
+				t.Errorf("long task prefix = %v, want %v: %q", got, tt.expected, result)
+			}
+		})
+	}
+}
+
+// editTool builds an Edit tool_use on path replacing oldText with newText
+func editTool(path, oldText, newText string) jsonl.Content {
+	return jsonl.Content{Type: "tool_use", Name: "Edit", Input: map[string]interface{}{
+		"file_path": path, "old_string": oldText, "new_string": newText,
+	}}
+}
+
+func TestDetectRevertPattern(t *testing.T) {
+	bash := jsonl.Content{Type: "tool_use", Name: "Bash", Input: map[string]interface{}{"command": "go test ./..."}}
+
+	tests := []struct {
+		name     string
+		tools    []jsonl.Content
+		expected bool
+	}{
+		{"edit, test, undo", []jsonl.Content{editTool("a.go", "x", "y"), bash, editTool("a.go", "y", "x")}, true},
+		{"edit, test, follow-up fix", []jsonl.Content{editTool("a.go", "x", "y"), bash, editTool("a.go", "y", "z")}, false},
+		{"undo without test run", []jsonl.Content{editTool("a.go", "x", "y"), editTool("a.go", "y", "x")}, false},
+		{"undo on a different file", []jsonl.Content{editTool("a.go", "x", "y"), bash, editTool("b.go", "y", "x")}, false},
+		{"no file path", []jsonl.Content{editTool("", "x", "y"), bash, editTool("", "y", "x")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := toolMessages()
+			messages[2].Message.Content = tt.tools
+			if got := detectRevertPattern(messages); got != tt.expected {
+				t.Errorf("detectRevertPattern() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateFromTranscript_RevertedPrefix(t *testing.T) {
+	transcriptPath := t.TempDir() + "/reverted.jsonl"
+	messages := toolMessages()
+	messages[2].Message.Content = []jsonl.Content{
+		editTool("main.go", "return nil", "return err"),
+		{Type: "tool_use", Name: "Bash"},
+		editTool("main.go", "return err", "return nil"),
+		{Type: "text", Text: "Tests failed, so I restored the original code."},
+	}
+	writeTranscript(t, transcriptPath, messages)
+
+	result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, config.DefaultConfig())
+	if !strings.HasPrefix(result, RevertedPrefix+"Tests failed") {
+		t.Errorf("expected reverted prefix, got: %s", result)
+	}
+}
+
+func TestGeneratePermissionSummary(t *testing.T) {
+	cfg := config.DefaultConfig()
+	pending := func(input map[string]interface{}) []jsonl.Message {
+		messages := toolMessages()
+		messages[2].Message.Content = []jsonl.Content{{Type: "tool_use", ID: "toolu_1", Name: "Bash", Input: input}}
+		return messages
+	}
+
+	tests := []struct {
+		name     string
+		messages []jsonl.Message
+		expected string
+	}{
+		{"command", pending(map[string]interface{}{"command": "rm -rf dist"}), "Allow Bash: rm -rf dist"},
+		{"file", pending(map[string]interface{}{"file_path": "/repo/main.go"}), "Allow Bash on main.go"},
+		{"no input", pending(nil), "Allow Bash?"},
+		{"nothing pending", toolMessages("Bash"), "Claude needs your permission to continue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generatePermissionSummary(tt.messages, cfg); got != tt.expected {
+				t.Errorf("generatePermissionSummary() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMaxSummaryLength(t *testing.T) {
+	messages := []jsonl.Message{
+		{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: "Explain the build"}},
+		}},
+		{Type: "assistant", Timestamp: "2025-01-01T12:00:05Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: strings.Repeat("The build compiles every package and runs the linters ", 10)}},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		maxLen int
+		want   int
+	}{
+		{"default", 0, DefaultMaxSummaryLength},
+		{"shorter", 40, 40},
+		{"longer", 300, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Notifications.Desktop.MaxSummaryLength = tt.maxLen
+
+			result := generateUnknownSummary(messages, cfg)
+			if len(result) > tt.want || len(result) < tt.want-20 {
+				t.Errorf("generateUnknownSummary() length = %d, want close to %d: %q", len(result), tt.want, result)
+			}
+		})
+	}
+}
+
+func TestGenerateFromTranscript_MaxSummaryLength(t *testing.T) {
+	text := "Refactored the payment service to use the new retry client. " +
+		"Updated every caller and added tests for the timeout and backoff paths. " +
+		"All packages build and the full test suite passes."
+	transcriptPath := t.TempDir() + "/long.jsonl"
+	writeTranscript(t, transcriptPath, []jsonl.Message{
+		{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: "Refactor the payment service"}},
+		}},
+		{Type: "assistant", Timestamp: "2025-01-01T12:00:01Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: text}},
+		}},
+	})
+
+	cfg := config.DefaultConfig()
+	short := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg)
+	if n := utf8.RuneCountInString(short); n > DefaultMaxSummaryLength || strings.Contains(short, "test suite passes") {
+		t.Errorf("default summary should be cut to %d chars, got %d: %q", DefaultMaxSummaryLength, n, short)
+	}
+
+	cfg.Notifications.Desktop.MaxSummaryLength = 300
+	long := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg)
+	if !strings.HasPrefix(long, text) {
+		t.Errorf("summary should not be truncated with a 300 char limit, got: %q", long)
+	}
+
+	// Fallback messages are not summaries and ignore the limit
+	cfg.Notifications.Desktop.MaxSummaryLength = 20
+	if got := GenerateSimple(analyzer.StatusTaskComplete, cfg); got != GetDefaultMessage(analyzer.StatusTaskComplete, cfg) {
+		t.Errorf("GenerateSimple() = %q, want the default message", got)
+	}
+}
+
 func TestGetDefaultMessage(t *testing.T) {
 	cfg := config.DefaultConfig()
 
@@ -988,6 +1635,164 @@ func TestGenerateFromTranscript_APIError(t *testing.T) {
 	}
 }
 
+func TestGenerateFromTranscript_LimitWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	transcriptPath := tmpDir + "/limit_warning.jsonl"
+
+	messages := []jsonl.Message{
+		{
+			Type:      "assistant",
+			Timestamp: time.Now().Format(time.RFC3339),
+			Message: jsonl.MessageContent{
+				Content: []jsonl.Content{
+					{Type: "text", Text: "Approaching usage limit · resets at 5pm"},
+				},
+			},
+		},
+	}
+
+	writeTranscript(t, transcriptPath, messages)
+
+	cfg := config.DefaultConfig()
+	result := GenerateFromTranscript(transcriptPath, analyzer.StatusLimitWarning, cfg)
+
+	if !strings.Contains(result, "Approaching usage limit") || !strings.Contains(result, "resets at 5pm") {
+		t.Errorf("Limit warning summary should contain the warning text, got: %s", result)
+	}
+}
+
+func TestGenerateFromTranscript_SubagentComplete(t *testing.T) {
+	tmpDir := t.TempDir()
+	transcriptPath := tmpDir + "/subagent.jsonl"
+
+	messages := []jsonl.Message{
+		{
+			Type:      "assistant",
+			Timestamp: time.Now().Format(time.RFC3339),
+			Message: jsonl.MessageContent{
+				Content: []jsonl.Content{
+					{Type: "tool_use", Name: "Write"},
+					{Type: "text", Text: "Refactored the whole payment module."},
+				},
+			},
+		},
+	}
+
+	writeTranscript(t, transcriptPath, messages)
+
+	cfg := config.DefaultConfig()
+	result := GenerateFromTranscript(transcriptPath, analyzer.StatusSubagentComplete, cfg)
+
+	if result != "Subagent Completed" {
+		t.Errorf("Subagent summary should use the default status message, got: %s", result)
+	}
+}
+
+func TestDefaultFallbackMessage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	for _, status := range []string{"task_complete", "review_complete", "question", "plan_ready"} {
+		info := cfg.Statuses[status]
+		info.DefaultFallbackMessage = "Custom fallback for " + status
+		cfg.Statuses[status] = info
+	}
+
+	// Transcript with no assistant text or tools: every generator falls through to its fallback
+	edge := []jsonl.Message{
+		{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: "Hello"}},
+		}},
+		{Type: "assistant", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "thinking"}},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		generate func([]jsonl.Message, *config.Config) string
+		status   string
+	}{
+		{"task", generateTaskSummary, "task_complete"},
+		{"review", generateReviewSummary, "review_complete"},
+		{"question", generateQuestionSummary, "question"},
+		{"plan", generatePlanSummary, "plan_ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := tt.generate(edge, cfg), "Custom fallback for "+tt.status; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+
+	t.Run("empty transcript", func(t *testing.T) {
+		transcriptPath := t.TempDir() + "/empty.jsonl"
+		writeTranscript(t, transcriptPath, nil)
+
+		if got := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, cfg); got != "Custom fallback for task_complete" {
+			t.Errorf("GenerateFromTranscript() = %q, want configured fallback", got)
+		}
+	})
+
+	t.Run("built-in message when not configured", func(t *testing.T) {
+		if got := generateReviewSummary(edge, config.DefaultConfig()); got != "Code review completed" {
+			t.Errorf("generateReviewSummary() = %q, want built-in fallback", got)
+		}
+		if got := GenerateSimple(analyzer.StatusTaskComplete, config.DefaultConfig()); got != "Task Completed" {
+			t.Errorf("GenerateSimple() = %q, want title-based default", got)
+		}
+	})
+}
+
+func TestGenerateErrorSummary(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	withText := toolMessages("Bash")
+	withText[2].Message.Content = append(withText[2].Message.Content,
+		jsonl.Content{Type: "text", Text: "The build **failed** with 3 errors."})
+	if result := generateErrorSummary(withText, cfg); result != "The build failed with 3 errors." {
+		t.Errorf("generateErrorSummary() = %q, want the failure report", result)
+	}
+
+	if result := generateErrorSummary(toolMessages("Bash"), cfg); result != "Last command failed" {
+		t.Errorf("generateErrorSummary() = %q, want fallback", result)
+	}
+}
+
+func TestGenerateUnknownSummary(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	t.Run("uses last reply of current response", func(t *testing.T) {
+		messages := []jsonl.Message{
+			{Type: "assistant", Timestamp: "2025-01-01T11:59:00Z", Message: jsonl.MessageContent{
+				Content: []jsonl.Content{{Type: "text", Text: "Old reply from a previous turn."}},
+			}},
+			{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+				Content: []jsonl.Content{{Type: "text", Text: "What does this flag do?"}},
+			}},
+			{Type: "assistant", Timestamp: "2025-01-01T12:00:05Z", Message: jsonl.MessageContent{
+				Content: []jsonl.Content{{Type: "text", Text: "It enables **verbose** logging."}},
+			}},
+		}
+
+		if result := generateUnknownSummary(messages, cfg); result != "It enables verbose logging." {
+			t.Errorf("generateUnknownSummary() = %q, want last reply", result)
+		}
+	})
+
+	t.Run("falls back to unclassified title", func(t *testing.T) {
+		messages := []jsonl.Message{
+			{Type: "user", Timestamp: "2025-01-01T12:00:00Z", Message: jsonl.MessageContent{
+				Content: []jsonl.Content{{Type: "text", Text: "Hello"}},
+			}},
+		}
+
+		if result := generateUnknownSummary(messages, cfg); result != "Claude Code finished (unclassified)" {
+			t.Errorf("generateUnknownSummary() = %q, want unclassified default", result)
+		}
+	})
+}
+
 func TestCalculateDuration(t *testing.T) {
 	now := time.Now()
 	userTime := now.Add(-120 * time.Second)
@@ -1009,7 +1814,7 @@ func TestCalculateDuration(t *testing.T) {
 		},
 	}
 
-	duration := calculateDuration(messages)
+	duration := calculateDuration(newLocalizer(DefaultLocale), config.DurationFormatWords, messages)
 	// Should be "Took 2m" for 120 seconds
 	if !strings.Contains(duration, "Took") || !strings.Contains(duration, "2m") {
 		t.Errorf("calculateDuration() = %q, want 'Took 2m'", duration)
@@ -1499,3 +2304,69 @@ func TestGenerateQuestionSummary_VeryShortText(t *testing.T) {
 		t.Logf("Result: %q (should use fallback for short text)", result)
 	}
 }
+
+// bashRun returns a transcript whose last Bash command printed output
+func bashRun(output string) []jsonl.Message {
+	messages := toolMessages()
+	messages[2].Message.Content = []jsonl.Content{
+		{Type: "tool_use", ID: "toolu_1", Name: "Bash", Input: map[string]interface{}{"command": "make test"}},
+	}
+	return append(messages,
+		jsonl.Message{Type: "user", Timestamp: "2025-01-01T12:00:20Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "tool_result", ToolUseID: "toolu_1", Output: jsonl.ToolOutput(output)}},
+		}},
+		jsonl.Message{Type: "assistant", Timestamp: "2025-01-01T12:00:30Z", Message: jsonl.MessageContent{
+			Content: []jsonl.Content{{Type: "text", Text: "Ran the suite"}},
+		}},
+	)
+}
+
+func TestTestOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"go verbose pass", "=== RUN   TestA\n--- PASS: TestA (0.00s)\n=== RUN   TestB\n--- PASS: TestB (0.00s)\nPASS\nok  \tpkg\t0.01s", "All 2 tests passed"},
+		{"go verbose fail", "--- PASS: TestA (0.00s)\n--- FAIL: TestB (0.00s)\nFAIL\nFAIL\tpkg\t0.01s", "1 test failed"},
+		{"go packages pass", "ok  \tgithub.com/a/b\t0.01s\nok  \tgithub.com/a/c\t(cached)\n?   \tgithub.com/a/d\t[no test files]", "All 2 packages passed"},
+		{"go packages fail", "ok  \tgithub.com/a/b\t0.01s\nFAIL\tgithub.com/a/c\t0.02s\nFAIL", "1 package failed"},
+		{"pytest pass", "collected 42 items\n\n========== 42 passed in 1.23s ==========", "All 42 tests passed"},
+		{"pytest fail", "========= 3 failed, 39 passed, 1 skipped in 2.50s =========", "3 tests failed"},
+		{"jest fail", "Test Suites: 1 failed, 4 passed, 5 total\nTests:       3 failed, 39 passed, 42 total", "3 tests failed"},
+		{"vitest pass", " Test Files  5 passed (5)\n      Tests  42 passed (42)", "All 42 tests passed"},
+		{"mocha", "  40 passing (2s)\n  2 failing", "2 tests failed"},
+		{"cargo", "test result: ok. 10 passed; 0 failed; 0 ignored\ntest result: ok. 5 passed; 0 failed; 0 ignored", "All 15 tests passed"},
+		{"colored output", "\x1b[32m\x1b[1m========== 42 passed in 1.23s ==========\x1b[0m", "All 42 tests passed"},
+		{"single test", "========== 1 passed in 0.01s ==========", "1 test passed"},
+		{"not a test run", "total 8\ndrwxr-xr-x  2 user user 4096 main.go", ""},
+		{"empty output", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testOutcome(newLocalizer("en"), bashRun(tt.output)); got != tt.expected {
+				t.Errorf("testOutcome() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTestOutcome_OnlyLastBash(t *testing.T) {
+	messages := bashRun("========== 42 passed in 1.23s ==========")
+	messages[2].Message.Content = append(messages[2].Message.Content,
+		jsonl.Content{Type: "tool_use", ID: "toolu_2", Name: "Bash", Input: map[string]interface{}{"command": "git status"}})
+	if got := testOutcome(newLocalizer("en"), messages); got != "" {
+		t.Errorf("expected no outcome when the last command was not a test run, got %q", got)
+	}
+}
+
+func TestGenerateFromTranscript_TestOutcome(t *testing.T) {
+	transcriptPath := t.TempDir() + "/tests.jsonl"
+	writeTranscript(t, transcriptPath, bashRun("Tests:       3 failed, 39 passed, 42 total"))
+
+	result := GenerateFromTranscript(transcriptPath, analyzer.StatusTaskComplete, config.DefaultConfig())
+	if !strings.Contains(result, "Ran the suite. 3 tests failed. Ran 1 command") {
+		t.Errorf("expected test outcome ahead of actions, got: %s", result)
+	}
+}
@@ -0,0 +1,166 @@
+package summary
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// testRunResult holds the counts parsed from a test runner's summary
+type testRunResult struct {
+	passed, failed int
+	unit           string // nounTest or nounPackage (go test without -v only reports packages)
+}
+
+var (
+	// cargo test: "test result: FAILED. 40 passed; 2 failed; 0 ignored; ..."
+	cargoResultPattern = regexp.MustCompile(`test result: (?:ok|FAILED)\. (\d+) passed; (\d+) failed`)
+	// pytest: "==== 2 failed, 40 passed in 1.23s ===="
+	pytestSummaryPattern = regexp.MustCompile(`(?m)^=+ (.*\b(?:passed|failed)\b.*) in [\d.]+s\b.*=+\s*$`)
+	// jest: "Tests:       2 failed, 40 passed, 42 total"; vitest: "Tests  2 failed | 40 passed (42)"
+	jestSummaryPattern = regexp.MustCompile(`(?m)^\s*Tests:?\s+(.*\d+ (?:passed|failed).*)$`)
+	// mocha: "40 passing (2s)" / "2 failing"
+	mochaPassingPattern = regexp.MustCompile(`(?m)^\s*(\d+) passing\b`)
+	mochaFailingPattern = regexp.MustCompile(`(?m)^\s*(\d+) failing\b`)
+	// go test -v: one line per test
+	goTestPassPattern = regexp.MustCompile(`(?m)^\s*--- PASS: `)
+	goTestFailPattern = regexp.MustCompile(`(?m)^\s*--- FAIL: `)
+	// go test: one line per package
+	goPackageOKPattern   = regexp.MustCompile(`(?m)^ok[ \t]+\S+`)
+	goPackageFailPattern = regexp.MustCompile(`(?m)^FAIL[ \t]+\S+`)
+
+	passedCountPattern = regexp.MustCompile(`(\d+) passed`)
+	failedCountPattern = regexp.MustCompile(`(\d+) failed`)
+)
+
+// testRunParsers recognise common test runner summaries, most specific first
+var testRunParsers = []func(output string) (testRunResult, bool){
+	parseCargoTestOutput,
+	parsePytestOutput,
+	parseJestOutput,
+	parseMochaOutput,
+	parseGoTestOutput,
+}
+
+// testOutcome reports the result of the last Bash command since the last user
+// message when its output looks like a test run, e.g. "All 42 tests passed" or
+// "3 tests failed". Returns "" otherwise.
+func testOutcome(l localizer, messages []jsonl.Message) string {
+	var lastBash *jsonl.Content
+	for _, tool := range toolUsesSinceLastUser(messages) {
+		if tool.Name == "Bash" {
+			tool := tool
+			lastBash = &tool
+		}
+	}
+	if lastBash == nil {
+		return ""
+	}
+
+	result := jsonl.FindToolResult(messages, lastBash.ID)
+	if result == nil || result.Output == "" {
+		return ""
+	}
+
+	// Test runners color their output when they think they're on a terminal
+	output := StripANSI(string(result.Output))
+	for _, parse := range testRunParsers {
+		if run, ok := parse(output); ok {
+			return formatTestOutcome(l, run)
+		}
+	}
+	return ""
+}
+
+// formatTestOutcome turns counts into a short phrase; "" if nothing ran
+func formatTestOutcome(l localizer, run testRunResult) string {
+	switch {
+	case run.failed > 0:
+		return l.text(msgTestsFailed, l.count(run.failed, run.unit))
+	case run.passed == 1:
+		return l.text(msgOneTestPassed, l.count(1, run.unit))
+	case run.passed > 1:
+		return l.text(msgTestsPassed, l.count(run.passed, run.unit))
+	}
+	return ""
+}
+
+func parseCargoTestOutput(output string) (testRunResult, bool) {
+	matches := cargoResultPattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return testRunResult{}, false
+	}
+	// One summary per test binary
+	run := testRunResult{unit: nounTest}
+	for _, m := range matches {
+		run.passed += atoi(m[1])
+		run.failed += atoi(m[2])
+	}
+	return run, true
+}
+
+func parsePytestOutput(output string) (testRunResult, bool) {
+	return parseSummaryLine(pytestSummaryPattern, output)
+}
+
+func parseJestOutput(output string) (testRunResult, bool) {
+	return parseSummaryLine(jestSummaryPattern, output)
+}
+
+// parseSummaryLine reads "N passed" / "N failed" from the last line matching pattern
+func parseSummaryLine(pattern *regexp.Regexp, output string) (testRunResult, bool) {
+	matches := pattern.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return testRunResult{}, false
+	}
+	line := matches[len(matches)-1][1]
+	return testRunResult{
+		passed: firstCount(passedCountPattern, line),
+		failed: firstCount(failedCountPattern, line),
+		unit:   nounTest,
+	}, true
+}
+
+func parseMochaOutput(output string) (testRunResult, bool) {
+	passing := mochaPassingPattern.FindStringSubmatch(output)
+	if passing == nil {
+		return testRunResult{}, false
+	}
+	return testRunResult{
+		passed: atoi(passing[1]),
+		failed: firstCount(mochaFailingPattern, output),
+		unit:   nounTest,
+	}, true
+}
+
+func parseGoTestOutput(output string) (testRunResult, bool) {
+	// Verbose output lists every test
+	passed := len(goTestPassPattern.FindAllStringIndex(output, -1))
+	failed := len(goTestFailPattern.FindAllStringIndex(output, -1))
+	if passed+failed > 0 {
+		return testRunResult{passed: passed, failed: failed, unit: nounTest}, true
+	}
+
+	// Otherwise count packages
+	passed = len(goPackageOKPattern.FindAllStringIndex(output, -1))
+	failed = len(goPackageFailPattern.FindAllStringIndex(output, -1))
+	if passed+failed > 0 {
+		return testRunResult{passed: passed, failed: failed, unit: nounPackage}, true
+	}
+	return testRunResult{}, false
+}
+
+// firstCount returns the number captured by the first match of pattern, or 0
+func firstCount(pattern *regexp.Regexp, text string) int {
+	if m := pattern.FindStringSubmatch(text); m != nil {
+		return atoi(m[1])
+	}
+	return 0
+}
+
+// atoi converts a regexp-captured digit string, which can't fail to parse
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,241 @@
+package throttle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+// staleLeaderGrace is how long past the window a leader marker is trusted
+// before it is assumed to belong to a crashed process
+const staleLeaderGrace = 30 * time.Second
+
+// Entry is a notification buffered while a throttle window is open
+type Entry struct {
+	Status    analyzer.Status `json:"status"`
+	Message   string          `json:"message"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// Window buffers notifications for one session so bursts can be sent as a
+// single combined notification. Hooks run as separate processes, so the buffer
+// lives in a file: the first Add in a window becomes the leader, waits for the
+// window to close and flushes everything added in the meantime.
+type Window struct {
+	path     string
+	duration time.Duration
+}
+
+// NewWindow creates a throttle window for sessionID in the system temp directory
+func NewWindow(sessionID string, duration time.Duration) *Window {
+	path := filepath.Join(platform.TempDir(), fmt.Sprintf("claude-notifications-throttle-%s.jsonl", platform.SanitizeFileName(sessionID)))
+	return newWindowAt(path, duration)
+}
+
+func newWindowAt(path string, duration time.Duration) *Window {
+	return &Window{
+		path:     path,
+		duration: duration,
+	}
+}
+
+// Duration returns the window length
+func (w *Window) Duration() time.Duration {
+	return w.duration
+}
+
+// Add buffers a notification. Returns true if the caller opened the window and
+// is responsible for calling Wait to flush it.
+func (w *Window) Add(status analyzer.Status, message string) (bool, error) {
+	line, err := json.Marshal(Entry{Status: status, Message: message, Timestamp: time.Now().Unix()})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal throttle entry: %w", err)
+	}
+
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, fmt.Errorf("failed to open throttle buffer: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	f.Close()
+	if err != nil {
+		return false, fmt.Errorf("failed to write throttle buffer: %w", err)
+	}
+
+	return w.claimLeader()
+}
+
+// Wait blocks until the window closes, then returns and clears all buffered entries.
+// The window closes a duration after the leader opened it, so handing the flush to
+// another process doesn't extend it.
+func (w *Window) Wait() ([]Entry, error) {
+	remaining := w.duration
+	if info, err := os.Stat(w.leaderPath()); err == nil {
+		remaining -= time.Since(info.ModTime())
+	}
+	if remaining > 0 {
+		timer := time.NewTimer(remaining)
+		defer timer.Stop()
+		<-timer.C
+	}
+
+	return w.collect()
+}
+
+// leaderPath returns the marker created by the hook that opened the window
+func (w *Window) leaderPath() string {
+	return w.path + ".leader"
+}
+
+// claimLeader atomically creates the leader marker, replacing a stale one
+func (w *Window) claimLeader() (bool, error) {
+	leaderPath := w.leaderPath()
+
+	created, err := platform.AtomicCreateFile(leaderPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to create throttle leader marker: %w", err)
+	}
+	if created {
+		return true, nil
+	}
+
+	maxAge := int64((w.duration + staleLeaderGrace).Seconds())
+	if age := platform.FileAge(leaderPath); age >= 0 && age > maxAge {
+		logging.Warn("Removing stale throttle leader marker: %s", leaderPath)
+		_ = os.Remove(leaderPath)
+		return platform.AtomicCreateFile(leaderPath)
+	}
+
+	return false, nil
+}
+
+// collect closes the window and reads every entry buffered during it.
+// The leader marker is removed before the buffer is taken over, so an Add racing
+// with collect either lands in this batch or opens the next window.
+func (w *Window) collect() ([]Entry, error) {
+	_ = os.Remove(w.leaderPath())
+
+	takenPath := fmt.Sprintf("%s.%d.flush", w.path, time.Now().UnixNano())
+	if err := os.Rename(w.path, takenPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to take over throttle buffer: %w", err)
+	}
+	defer os.Remove(takenPath)
+
+	data, err := os.ReadFile(takenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read throttle buffer: %w", err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			logging.Warn("Skipping corrupted throttle entry: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// statusPriority orders statuses from most to least important. The merged
+// notification takes the most important status in the batch.
+var statusPriority = []analyzer.Status{
+	analyzer.StatusAPIError,
+	analyzer.StatusSessionLimitReached,
+	analyzer.StatusError,
+	analyzer.StatusPermission,
+	analyzer.StatusQuestion,
+	analyzer.StatusPlanReady,
+	analyzer.StatusLimitWarning,
+	analyzer.StatusReviewComplete,
+	analyzer.StatusTaskComplete,
+	analyzer.StatusSubagentComplete,
+	analyzer.StatusLongRunningCommand,
+}
+
+// statusLabels are the singular/plural phrases used in merged messages
+var statusLabels = map[analyzer.Status][2]string{
+	analyzer.StatusTaskComplete:        {"task completed", "tasks completed"},
+	analyzer.StatusReviewComplete:      {"review completed", "reviews completed"},
+	analyzer.StatusQuestion:            {"question", "questions"},
+	analyzer.StatusPermission:          {"permission request", "permission requests"},
+	analyzer.StatusPlanReady:           {"plan ready", "plans ready"},
+	analyzer.StatusSessionLimitReached: {"session limit reached", "session limits reached"},
+	analyzer.StatusLimitWarning:        {"usage limit warning", "usage limit warnings"},
+	analyzer.StatusSubagentComplete:    {"subagent completed", "subagents completed"},
+	analyzer.StatusAPIError:            {"API error", "API errors"},
+	analyzer.StatusError:               {"task failed", "tasks failed"},
+	analyzer.StatusLongRunningCommand:  {"command started", "commands started"},
+}
+
+// Merge combines buffered entries into one notification.
+// A single entry is returned unchanged; several become e.g. "3 tasks completed in the last 10s".
+func Merge(entries []Entry, window time.Duration) (analyzer.Status, string) {
+	if len(entries) == 0 {
+		return analyzer.StatusUnknown, ""
+	}
+	if len(entries) == 1 {
+		return entries[0].Status, entries[0].Message
+	}
+
+	counts := make(map[analyzer.Status]int)
+	var order []analyzer.Status
+	for _, entry := range entries {
+		if counts[entry.Status] == 0 {
+			order = append(order, entry.Status)
+		}
+		counts[entry.Status]++
+	}
+
+	// Known statuses first in priority order, then any others in arrival order
+	var sorted []analyzer.Status
+	for _, status := range statusPriority {
+		if counts[status] > 0 {
+			sorted = append(sorted, status)
+		}
+	}
+	for _, status := range order {
+		if _, known := statusLabels[status]; !known {
+			sorted = append(sorted, status)
+		}
+	}
+
+	parts := make([]string, 0, len(sorted))
+	for _, status := range sorted {
+		parts = append(parts, formatCount(status, counts[status]))
+	}
+
+	return sorted[0], fmt.Sprintf("%s in the last %ds", strings.Join(parts, ", "), int(window.Seconds()))
+}
+
+// formatCount renders "3 tasks completed" for a status and count
+func formatCount(status analyzer.Status, count int) string {
+	labels, ok := statusLabels[status]
+	if !ok {
+		labels = [2]string{string(status) + " notification", string(status) + " notifications"}
+	}
+	if count == 1 {
+		return fmt.Sprintf("1 %s", labels[0])
+	}
+	return fmt.Sprintf("%d %s", count, labels[1])
+}
@@ -0,0 +1,186 @@
+package throttle
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+func newTestWindow(t *testing.T, duration time.Duration) *Window {
+	t.Helper()
+	return newWindowAt(filepath.Join(t.TempDir(), "throttle.jsonl"), duration)
+}
+
+func TestWindowFirstAddIsLeader(t *testing.T) {
+	w := newTestWindow(t, 50*time.Millisecond)
+
+	leader, err := w.Add(analyzer.StatusTaskComplete, "first")
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	for i := 0; i < 2; i++ {
+		leader, err = w.Add(analyzer.StatusTaskComplete, "more")
+		require.NoError(t, err)
+		assert.False(t, leader, "only the first add should open the window")
+	}
+
+	entries, err := w.Wait()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "first", entries[0].Message)
+
+	// The window is closed: buffer and leader marker are gone
+	_, err = os.Stat(w.path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(w.path + ".leader")
+	assert.True(t, os.IsNotExist(err))
+
+	// The next add opens a new window
+	leader, err = w.Add(analyzer.StatusQuestion, "next")
+	require.NoError(t, err)
+	assert.True(t, leader)
+}
+
+func TestWindowWaitCountsFromOpening(t *testing.T) {
+	w := newTestWindow(t, time.Hour)
+
+	leader, err := w.Add(analyzer.StatusTaskComplete, "first")
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	// Opened an hour ago: already closed for the process that flushes it
+	opened := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(w.leaderPath(), opened, opened))
+
+	done := make(chan []Entry, 1)
+	go func() {
+		entries, err := w.Wait()
+		assert.NoError(t, err)
+		done <- entries
+	}()
+
+	select {
+	case entries := <-done:
+		assert.Len(t, entries, 1)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait() should return at once for a window that has closed")
+	}
+}
+
+func TestNewWindowSanitizesSessionID(t *testing.T) {
+	w := NewWindow("../../etc/passwd", time.Second)
+	assert.Equal(t, platform.TempDir(), filepath.Dir(w.path))
+	assert.NotContains(t, filepath.Base(w.path), "..")
+}
+
+func TestWindowConcurrentAdds(t *testing.T) {
+	w := newTestWindow(t, 200*time.Millisecond)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	leaders := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			leader, err := w.Add(analyzer.StatusTaskComplete, "done")
+			assert.NoError(t, err)
+			if leader {
+				mu.Lock()
+				leaders++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, leaders)
+
+	entries, err := w.Wait()
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestWindowReplacesStaleLeader(t *testing.T) {
+	w := newTestWindow(t, time.Second)
+
+	// Leader marker left behind by a crashed process
+	leaderPath := w.path + ".leader"
+	require.NoError(t, os.WriteFile(leaderPath, nil, 0600))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(leaderPath, old, old))
+
+	leader, err := w.Add(analyzer.StatusTaskComplete, "done")
+	require.NoError(t, err)
+	assert.True(t, leader)
+}
+
+func TestWindowWaitWithoutEntries(t *testing.T) {
+	w := newTestWindow(t, 10*time.Millisecond)
+
+	entries, err := w.Wait()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMerge(t *testing.T) {
+	window := 10 * time.Second
+
+	tests := []struct {
+		name        string
+		entries     []Entry
+		wantStatus  analyzer.Status
+		wantMessage string
+	}{
+		{
+			name:        "single entry is unchanged",
+			entries:     []Entry{{Status: analyzer.StatusTaskComplete, Message: "Created 3 files"}},
+			wantStatus:  analyzer.StatusTaskComplete,
+			wantMessage: "Created 3 files",
+		},
+		{
+			name: "same status is counted",
+			entries: []Entry{
+				{Status: analyzer.StatusTaskComplete, Message: "a"},
+				{Status: analyzer.StatusTaskComplete, Message: "b"},
+				{Status: analyzer.StatusTaskComplete, Message: "c"},
+			},
+			wantStatus:  analyzer.StatusTaskComplete,
+			wantMessage: "3 tasks completed in the last 10s",
+		},
+		{
+			name: "mixed statuses take the most important one",
+			entries: []Entry{
+				{Status: analyzer.StatusTaskComplete, Message: "a"},
+				{Status: analyzer.StatusTaskComplete, Message: "b"},
+				{Status: analyzer.StatusQuestion, Message: "c"},
+			},
+			wantStatus:  analyzer.StatusQuestion,
+			wantMessage: "1 question, 2 tasks completed in the last 10s",
+		},
+		{
+			name: "unknown statuses are listed last",
+			entries: []Entry{
+				{Status: analyzer.Status("custom"), Message: "a"},
+				{Status: analyzer.StatusPlanReady, Message: "b"},
+			},
+			wantStatus:  analyzer.StatusPlanReady,
+			wantMessage: "1 plan ready, 1 custom notification in the last 10s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, message := Merge(tt.entries, window)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/hooks"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// DefaultPollInterval is how often the transcript is checked when no interval is given
+const DefaultPollInterval = time.Second
+
+// Watcher sends notifications for a transcript file without Claude Code hooks, by
+// polling it and analyzing it like the Stop hook would
+type Watcher struct {
+	pollInterval time.Duration
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+// New creates a watcher that polls every pollInterval (0 = DefaultPollInterval)
+func New(pollInterval time.Duration) *Watcher {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Watcher{
+		pollInterval: pollInterval,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Stop makes Watch return
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// Watch polls transcriptPath until Stop is called and sends a notification through handler
+// whenever its status changes, or a new request ends in the same status. The status the
+// transcript has when watching starts is not notified, and a request is only analyzed once
+// Claude finished its turn, so a response still being written doesn't fire early.
+func (w *Watcher) Watch(transcriptPath string, handler *hooks.Handler) error {
+	return w.watch(transcriptPath, handler.Config(), handler.NotifyTranscript)
+}
+
+// fileStamp identifies a version of the transcript. The size catches writes within the
+// same second, which FileMTime can't tell apart.
+type fileStamp struct {
+	mtime int64
+	size  int64
+}
+
+// stampFile returns the current stamp of path
+func stampFile(path string) fileStamp {
+	stamp := fileStamp{mtime: platform.FileMTime(path)}
+	if info, err := os.Stat(path); err == nil {
+		stamp.size = info.Size()
+	}
+	return stamp
+}
+
+// transcriptState is what a notification is sent for: the status of the latest request
+type transcriptState struct {
+	status  analyzer.Status
+	request string // timestamp of the last user message
+}
+
+// analyze returns the current state of the transcript, and whether Claude finished its
+// turn. The status is only determined for a finished turn.
+func analyze(transcriptPath string, cfg *config.Config) (transcriptState, bool, error) {
+	messages, err := jsonl.TailMessages(transcriptPath, analyzer.TranscriptTailLines)
+	if err != nil {
+		return transcriptState{}, false, err
+	}
+	if !turnFinished(messages) {
+		return transcriptState{}, false, nil
+	}
+	status := analyzer.AnalyzeMessages(messages, cfg)
+	return transcriptState{status: status, request: jsonl.GetLastUserTimestamp(messages)}, true, nil
+}
+
+// turnFinished reports whether Claude is waiting for the user: its last response ended
+// the turn with no tool call left running, or it is asking through a question or plan tool
+func turnFinished(messages []jsonl.Message) bool {
+	if pending := jsonl.FindPendingToolUse(messages); pending != nil {
+		return pending.Name == "ExitPlanMode" || contains(analyzer.QuestionTools, pending.Name)
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Type {
+		case "assistant":
+			return messages[i].Message.StopReason == "end_turn"
+		case "user":
+			// A request or tool result Claude hasn't answered yet
+			return false
+		}
+	}
+	return false
+}
+
+// contains reports whether list contains name
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// watch is Watch with the notification function injected (for tests)
+func (w *Watcher) watch(transcriptPath string, cfg *config.Config, notify func(string, analyzer.Status)) error {
+	if !platform.FileExists(transcriptPath) {
+		return fmt.Errorf("transcript not found: %s", transcriptPath)
+	}
+
+	last := stampFile(transcriptPath)
+	// A turn still in progress is notified once it finishes
+	lastState, _, err := analyze(transcriptPath, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to analyze transcript: %w", err)
+	}
+	logging.Debug("Watching %s (status %s, every %s)", transcriptPath, lastState.status, w.pollInterval)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	settled := true
+	for {
+		select {
+		case <-w.stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		current := stampFile(transcriptPath)
+		if current != last {
+			last = current
+			settled = false
+			continue
+		}
+		if settled {
+			continue
+		}
+		settled = true
+
+		state, finished, err := analyze(transcriptPath, cfg)
+		if err != nil {
+			logging.Warn("Failed to analyze transcript: %v", err)
+			continue
+		}
+		if !finished || state == lastState {
+			continue
+		}
+		logging.Debug("Transcript status changed: %s → %s", lastState.status, state.status)
+		lastState = state
+
+		// Like the Stop hook, an unclassified turn is only notified with notifyOnUnknown
+		if state.status == analyzer.StatusUnknown && !cfg.Notifications.NotifyOnUnknown {
+			continue
+		}
+		notify(transcriptPath, state.status)
+	}
+}
@@ -0,0 +1,194 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/pkg/jsonl"
+)
+
+// userLine returns a transcript line with a user request sent at second
+func userLine(second int, text string) string {
+	return fmt.Sprintf(`{"type":"user","timestamp":"2025-01-01T12:00:%02dZ","message":{"role":"user","content":%q}}`, second, text)
+}
+
+// toolLine returns a transcript line with an assistant response at second calling tool
+func toolLine(second int, tool string) string {
+	return fmt.Sprintf(`{"type":"assistant","timestamp":"2025-01-01T12:00:%02dZ","message":{"role":"assistant","content":[`+
+		`{"type":"tool_use","id":"toolu_%02d","name":%q,"input":{"file_path":"/test/file.go"}}],"stop_reason":"tool_use"}}`,
+		second, second, tool)
+}
+
+// resultLine returns a transcript line with the result of the tool called at toolSecond
+func resultLine(second, toolSecond int) string {
+	return fmt.Sprintf(`{"type":"user","timestamp":"2025-01-01T12:00:%02dZ","message":{"role":"user","content":[`+
+		`{"type":"tool_result","tool_use_id":"toolu_%02d","content":"ok"}]}}`,
+		second, toolSecond)
+}
+
+// endLine returns a transcript line with an assistant response at second that ends the turn
+func endLine(second int, text string) string {
+	return fmt.Sprintf(`{"type":"assistant","timestamp":"2025-01-01T12:00:%02dZ","message":{"role":"assistant","content":[`+
+		`{"type":"text","text":%q}],"stop_reason":"end_turn"}}`,
+		second, text)
+}
+
+// appendLines appends lines to the transcript at path
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWatchNotifiesOnStatusChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-1.jsonl")
+	appendLines(t, path, userLine(0, "Add a file"), toolLine(1, "Write"), resultLine(2, 1), endLine(3, "Created the file."))
+
+	notified := make(chan analyzer.Status, 10)
+	w := New(10 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.watch(path, config.DefaultConfig(), func(transcriptPath string, status analyzer.Status) {
+			if transcriptPath != path {
+				t.Errorf("notified for %q, want %q", transcriptPath, path)
+			}
+			notified <- status
+		})
+	}()
+
+	expect := func(want analyzer.Status) {
+		t.Helper()
+		select {
+		case got := <-notified:
+			if got != want {
+				t.Errorf("notified %s, want %s", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no notification, want %s", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case got := <-notified:
+			t.Errorf("unexpected notification %s", got)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// The status found at startup is not notified
+	expectNone()
+
+	appendLines(t, path, userLine(10, "Which database?"), toolLine(11, "AskUserQuestion"))
+	expect(analyzer.StatusQuestion)
+
+	// A new request that ends in the same status fires again
+	appendLines(t, path, userLine(12, "And now?"), toolLine(13, "AskUserQuestion"))
+	expect(analyzer.StatusQuestion)
+
+	// A request still in progress is not notified
+	appendLines(t, path, userLine(20, "Postgres 16"))
+	expectNone()
+
+	// Neither is a tool call, although the transcript already looks like a finished task
+	appendLines(t, path, toolLine(21, "Edit"))
+	expectNone()
+	appendLines(t, path, resultLine(22, 21))
+	expectNone()
+
+	appendLines(t, path, endLine(23, "Switched to Postgres."))
+	expect(analyzer.StatusTaskComplete)
+
+	w.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watch() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch() did not return after Stop")
+	}
+}
+
+func TestWatchNotifiesTurnInProgressAtStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session-1.jsonl")
+	appendLines(t, path, userLine(0, "Add a file"), toolLine(1, "Write"))
+
+	notified := make(chan analyzer.Status, 10)
+	w := New(10 * time.Millisecond)
+	defer w.Stop()
+	go func() {
+		_ = w.watch(path, config.DefaultConfig(), func(_ string, status analyzer.Status) {
+			notified <- status
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	appendLines(t, path, resultLine(2, 1), endLine(3, "Created the file."))
+
+	select {
+	case got := <-notified:
+		if got != analyzer.StatusTaskComplete {
+			t.Errorf("notified %s, want %s", got, analyzer.StatusTaskComplete)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification for the turn that was in progress at start")
+	}
+}
+
+func TestTurnFinished(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  bool
+	}{
+		{"empty", nil, false},
+		{"request without response", []string{userLine(0, "Hi")}, false},
+		{"response ends the turn", []string{userLine(0, "Hi"), endLine(1, "Hello")}, true},
+		{"tool running", []string{userLine(0, "Fix it"), toolLine(1, "Bash")}, false},
+		{"tool result not answered", []string{userLine(0, "Fix it"), toolLine(1, "Bash"), resultLine(2, 1)}, false},
+		{"tool done and turn ended", []string{userLine(0, "Fix it"), toolLine(1, "Bash"), resultLine(2, 1), endLine(3, "Fixed")}, true},
+		{"question waits for the user", []string{userLine(0, "Fix it"), toolLine(1, "AskUserQuestion")}, true},
+		{"plan waits for the user", []string{userLine(0, "Plan it"), toolLine(1, "ExitPlanMode")}, true},
+		{"response without stop reason", []string{userLine(0, "Hi"), `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Hel"}]}}`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := jsonl.Parse(strings.NewReader(strings.Join(tt.lines, "\n")))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := turnFinished(messages); got != tt.want {
+				t.Errorf("turnFinished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchMissingTranscript(t *testing.T) {
+	w := New(0)
+	err := w.watch(filepath.Join(t.TempDir(), "missing.jsonl"), config.DefaultConfig(), func(string, analyzer.Status) {
+		t.Error("unexpected notification")
+	})
+	if err == nil {
+		t.Fatal("watch() expected error for a missing transcript")
+	}
+	if w.pollInterval != DefaultPollInterval {
+		t.Errorf("pollInterval = %s, want %s", w.pollInterval, DefaultPollInterval)
+	}
+}
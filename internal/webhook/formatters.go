@@ -2,10 +2,13 @@ package webhook
 
 import (
 	"fmt"
+	"html"
+	"sort"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/summary"
 )
 
 // Formatter interface for different webhook formats
@@ -14,51 +17,91 @@ type Formatter interface {
 }
 
 // SlackFormatter formats messages for Slack
-type SlackFormatter struct{}
+type SlackFormatter struct {
+	Host              string            // shown in the footer when set, e.g. "build-box (linux)"
+	EscalateLongTasks bool              // use the long-task color for long-task summaries
+	Fields            map[string]string // extra context shown as attachment fields
+}
 
 func (f *SlackFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
 	color := getColorForStatus(status)
+	if f.EscalateLongTasks && isLongTask(message) {
+		color = longTaskColor
+	}
+
+	footer := fmt.Sprintf("Session: %s | Claude Notifications", sessionID)
+	if f.Host != "" {
+		footer = fmt.Sprintf("Session: %s | %s | Claude Notifications", sessionID, f.Host)
+	}
+
+	attachment := map[string]interface{}{
+		"color":       color,
+		"title":       statusInfo.Title,
+		"text":        message,
+		"footer":      footer,
+		"footer_icon": "https://claude.ai/favicon.ico",
+		"ts":          time.Now().Unix(),
+		"mrkdwn_in":   []string{"text"},
+	}
+	if len(f.Fields) > 0 {
+		var fields []map[string]interface{}
+		for _, key := range sortedKeys(f.Fields) {
+			fields = append(fields, map[string]interface{}{"title": key, "value": f.Fields[key], "short": true})
+		}
+		attachment["fields"] = fields
+	}
 
 	return map[string]interface{}{
-		"attachments": []map[string]interface{}{
-			{
-				"color":       color,
-				"title":       statusInfo.Title,
-				"text":        message,
-				"footer":      fmt.Sprintf("Session: %s | Claude Notifications", sessionID),
-				"footer_icon": "https://claude.ai/favicon.ico",
-				"ts":          time.Now().Unix(),
-				"mrkdwn_in":   []string{"text"},
-			},
-		},
+		"attachments": []map[string]interface{}{attachment},
 	}, nil
 }
 
 // DiscordFormatter formats messages for Discord with embeds
-type DiscordFormatter struct{}
+type DiscordFormatter struct {
+	Host              string            // shown in the footer when set, e.g. "build-box (linux)"
+	EscalateLongTasks bool              // use the long-task color for long-task summaries
+	Fields            map[string]string // extra context shown as inline embed fields
+}
 
 func (f *DiscordFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
 	colorInt := getDiscordColorInt(status)
+	if f.EscalateLongTasks && isLongTask(message) {
+		colorInt = longTaskColorInt
+	}
+
+	footer := fmt.Sprintf("Session: %s", sessionID)
+	if f.Host != "" {
+		footer = fmt.Sprintf("Session: %s | %s", sessionID, f.Host)
+	}
+
+	embed := map[string]interface{}{
+		"title":       statusInfo.Title,
+		"description": message,
+		"color":       colorInt,
+		"footer": map[string]interface{}{
+			"text": footer,
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if len(f.Fields) > 0 {
+		var fields []map[string]interface{}
+		for _, key := range sortedKeys(f.Fields) {
+			fields = append(fields, map[string]interface{}{"name": key, "value": f.Fields[key], "inline": true})
+		}
+		embed["fields"] = fields
+	}
 
 	return map[string]interface{}{
 		"username": "Claude Code",
-		"embeds": []map[string]interface{}{
-			{
-				"title":       statusInfo.Title,
-				"description": message,
-				"color":       colorInt,
-				"footer": map[string]interface{}{
-					"text": fmt.Sprintf("Session: %s", sessionID),
-				},
-				"timestamp": time.Now().Format(time.RFC3339),
-			},
-		},
+		"embeds":   []map[string]interface{}{embed},
 	}, nil
 }
 
 // TelegramFormatter formats messages for Telegram with HTML
 type TelegramFormatter struct {
-	ChatID string
+	ChatID       string
+	MessageField string            // payload field for the message, e.g. "caption" for attachments (empty = "text")
+	Fields       map[string]string // extra context appended as "key: value" lines
 }
 
 func (f *TelegramFormatter) Format(status analyzer.Status, message, sessionID string, statusInfo config.StatusInfo) (interface{}, error) {
@@ -66,14 +109,43 @@ func (f *TelegramFormatter) Format(status analyzer.Status, message, sessionID st
 	emoji := getEmojiForStatus(status)
 	text := fmt.Sprintf("<b>%s %s</b>\n\n%s\n\n<i>Session: %s</i>",
 		emoji, statusInfo.Title, message, sessionID)
+	for _, key := range sortedKeys(f.Fields) {
+		text += fmt.Sprintf("\n<i>%s: %s</i>", html.EscapeString(key), html.EscapeString(f.Fields[key]))
+	}
+
+	field := f.MessageField
+	if field == "" {
+		field = "text"
+	}
 
 	return map[string]interface{}{
 		"chat_id":    f.ChatID,
-		"text":       text,
+		field:        text,
 		"parse_mode": "HTML",
 	}, nil
 }
 
+// sortedKeys returns the keys of fields in alphabetical order, for a stable field order
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Colors of escalated long-task notifications (orange)
+const (
+	longTaskColor    = "#fd7e14"
+	longTaskColorInt = 0xfd7e14
+)
+
+// isLongTask reports whether message carries the long-task prefix of any locale
+func isLongTask(message string) bool {
+	return summary.HasLongTaskPrefix(message)
+}
+
 // getColorForStatus returns color hex code for status (Slack)
 func getColorForStatus(status analyzer.Status) string {
 	switch status {
@@ -83,8 +155,20 @@ func getColorForStatus(status analyzer.Status) string {
 		return "#17a2b8" // Teal
 	case analyzer.StatusQuestion:
 		return "#ffc107" // Yellow/Orange
+	case analyzer.StatusPermission:
+		return "#e83e8c" // Pink
 	case analyzer.StatusPlanReady:
 		return "#007bff" // Blue
+	case analyzer.StatusLimitWarning:
+		return "#fd7e14" // Orange
+	case analyzer.StatusSubagentComplete:
+		return "#6f42c1" // Purple
+	case analyzer.StatusTest:
+		return "#20c997" // Mint
+	case analyzer.StatusError:
+		return "#dc3545" // Red
+	case analyzer.StatusLongRunningCommand:
+		return "#343a40" // Dark gray
 	default:
 		return "#6c757d" // Gray
 	}
@@ -99,8 +183,20 @@ func getDiscordColorInt(status analyzer.Status) int {
 		return 0x17a2b8 // Teal
 	case analyzer.StatusQuestion:
 		return 0xffc107 // Yellow
+	case analyzer.StatusPermission:
+		return 0xe83e8c // Pink
 	case analyzer.StatusPlanReady:
 		return 0x007bff // Blue
+	case analyzer.StatusLimitWarning:
+		return 0xfd7e14 // Orange
+	case analyzer.StatusSubagentComplete:
+		return 0x6f42c1 // Purple
+	case analyzer.StatusTest:
+		return 0x20c997 // Mint
+	case analyzer.StatusError:
+		return 0xdc3545 // Red
+	case analyzer.StatusLongRunningCommand:
+		return 0x343a40 // Dark gray
 	default:
 		return 0x6c757d // Gray
 	}
@@ -115,8 +211,20 @@ func getEmojiForStatus(status analyzer.Status) string {
 		return "🔍"
 	case analyzer.StatusQuestion:
 		return "❓"
+	case analyzer.StatusPermission:
+		return "🔐"
 	case analyzer.StatusPlanReady:
 		return "📋"
+	case analyzer.StatusLimitWarning:
+		return "⚠️"
+	case analyzer.StatusSubagentComplete:
+		return "🤖"
+	case analyzer.StatusTest:
+		return "🧪"
+	case analyzer.StatusError:
+		return "❌"
+	case analyzer.StatusLongRunningCommand:
+		return "⏳"
 	default:
 		return "ℹ️"
 	}
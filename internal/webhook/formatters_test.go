@@ -7,6 +7,7 @@ import (
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/summary"
 )
 
 func TestSlackFormatterFormat(t *testing.T) {
@@ -73,6 +74,61 @@ func TestSlackFormatterFormat(t *testing.T) {
 	}
 }
 
+func TestFormattersCustomFields(t *testing.T) {
+	fields := map[string]string{"environment": "staging", "author": "dev@example.com"}
+	statusInfo := config.StatusInfo{Title: "Task Complete"}
+
+	t.Run("slack", func(t *testing.T) {
+		result, err := (&SlackFormatter{Fields: fields}).Format(analyzer.StatusTaskComplete, "Done", "s1", statusInfo)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		attachment := result.(map[string]interface{})["attachments"].([]map[string]interface{})[0]
+		got, ok := attachment["fields"].([]map[string]interface{})
+		if !ok || len(got) != 2 {
+			t.Fatalf("Expected 2 attachment fields, got %v", attachment["fields"])
+		}
+		if got[0]["title"] != "author" || got[0]["value"] != "dev@example.com" || got[1]["title"] != "environment" {
+			t.Errorf("Expected fields sorted by name, got %v", got)
+		}
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		result, err := (&DiscordFormatter{Fields: fields}).Format(analyzer.StatusTaskComplete, "Done", "s1", statusInfo)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		embed := result.(map[string]interface{})["embeds"].([]map[string]interface{})[0]
+		got, ok := embed["fields"].([]map[string]interface{})
+		if !ok || len(got) != 2 {
+			t.Fatalf("Expected 2 embed fields, got %v", embed["fields"])
+		}
+		if got[1]["name"] != "environment" || got[1]["value"] != "staging" || got[1]["inline"] != true {
+			t.Errorf("Unexpected embed field: %v", got[1])
+		}
+	})
+
+	t.Run("telegram", func(t *testing.T) {
+		tgFields := map[string]string{"author": "Dev <dev@example.com>"}
+		result, err := (&TelegramFormatter{ChatID: "1", Fields: tgFields}).Format(analyzer.StatusTaskComplete, "Done", "s1", statusInfo)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		text := result.(map[string]interface{})["text"].(string)
+		if !strings.HasSuffix(text, "\n<i>author: Dev &lt;dev@example.com&gt;</i>") {
+			t.Errorf("Expected escaped field line at the end, got %q", text)
+		}
+	})
+
+	t.Run("no fields", func(t *testing.T) {
+		result, _ := (&SlackFormatter{}).Format(analyzer.StatusTaskComplete, "Done", "s1", statusInfo)
+		attachment := result.(map[string]interface{})["attachments"].([]map[string]interface{})[0]
+		if _, exists := attachment["fields"]; exists {
+			t.Errorf("Expected no fields without custom fields, got %v", attachment["fields"])
+		}
+	})
+}
+
 func TestSlackFormatterColors(t *testing.T) {
 	formatter := &SlackFormatter{}
 	statusInfo := config.StatusInfo{Title: "Test"}
@@ -85,6 +141,10 @@ func TestSlackFormatterColors(t *testing.T) {
 		{analyzer.StatusReviewComplete, "#17a2b8"},
 		{analyzer.StatusQuestion, "#ffc107"},
 		{analyzer.StatusPlanReady, "#007bff"},
+		{analyzer.StatusLimitWarning, "#fd7e14"},
+		{analyzer.StatusSubagentComplete, "#6f42c1"},
+		{analyzer.StatusError, "#dc3545"},
+		{analyzer.StatusPermission, "#e83e8c"},
 	}
 
 	for _, tt := range tests {
@@ -184,6 +244,53 @@ func TestDiscordFormatterFormat(t *testing.T) {
 	}
 }
 
+func TestFormatterHostFooter(t *testing.T) {
+	statusInfo := config.StatusInfo{Title: "Task Complete"}
+
+	slack, _ := (&SlackFormatter{Host: "build-box (linux)"}).Format(analyzer.StatusTaskComplete, "Done", "session-123", statusInfo)
+	footer := slack.(map[string]interface{})["attachments"].([]map[string]interface{})[0]["footer"]
+	if footer != "Session: session-123 | build-box (linux) | Claude Notifications" {
+		t.Errorf("unexpected Slack footer: %v", footer)
+	}
+
+	discord, _ := (&DiscordFormatter{Host: "build-box (linux)"}).Format(analyzer.StatusTaskComplete, "Done", "session-123", statusInfo)
+	embed := discord.(map[string]interface{})["embeds"].([]map[string]interface{})[0]
+	if text := embed["footer"].(map[string]interface{})["text"]; text != "Session: session-123 | build-box (linux)" {
+		t.Errorf("unexpected Discord footer: %v", text)
+	}
+}
+
+func TestFormatterLongTaskEscalation(t *testing.T) {
+	statusInfo := config.StatusInfo{Title: "Task Complete"}
+	longTask := "[bold-cat] " + summary.LongTaskPrefix + "Migrated the database"
+
+	tests := []struct {
+		name        string
+		escalate    bool
+		message     string
+		wantSlack   string
+		wantDiscord int
+	}{
+		{"escalated long task", true, longTask, longTaskColor, longTaskColorInt},
+		{"escalation disabled", false, longTask, "#28a745", 0x28a745},
+		{"regular task", true, "[bold-cat] Migrated the database", "#28a745", 0x28a745},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slack, _ := (&SlackFormatter{EscalateLongTasks: tt.escalate}).Format(analyzer.StatusTaskComplete, tt.message, "session-123", statusInfo)
+			if color := slack.(map[string]interface{})["attachments"].([]map[string]interface{})[0]["color"]; color != tt.wantSlack {
+				t.Errorf("Slack color = %v, want %s", color, tt.wantSlack)
+			}
+
+			discord, _ := (&DiscordFormatter{EscalateLongTasks: tt.escalate}).Format(analyzer.StatusTaskComplete, tt.message, "session-123", statusInfo)
+			if color := discord.(map[string]interface{})["embeds"].([]map[string]interface{})[0]["color"]; color != tt.wantDiscord {
+				t.Errorf("Discord color = %v, want %#x", color, tt.wantDiscord)
+			}
+		})
+	}
+}
+
 func TestDiscordFormatterColors(t *testing.T) {
 	formatter := &DiscordFormatter{}
 	statusInfo := config.StatusInfo{Title: "Test"}
@@ -196,6 +303,10 @@ func TestDiscordFormatterColors(t *testing.T) {
 		{analyzer.StatusReviewComplete, 0x17a2b8},
 		{analyzer.StatusQuestion, 0xffc107},
 		{analyzer.StatusPlanReady, 0x007bff},
+		{analyzer.StatusLimitWarning, 0xfd7e14},
+		{analyzer.StatusSubagentComplete, 0x6f42c1},
+		{analyzer.StatusError, 0xdc3545},
+		{analyzer.StatusPermission, 0xe83e8c},
 	}
 
 	for _, tt := range tests {
@@ -287,6 +398,39 @@ func TestTelegramFormatterFormat(t *testing.T) {
 	}
 }
 
+func TestTelegramFormatterMessageField(t *testing.T) {
+	statusInfo := config.StatusInfo{Title: "Task Complete"}
+
+	tests := []struct {
+		field     string
+		wantField string
+		noField   string
+	}{
+		{"", "text", "caption"},
+		{"text", "text", "caption"},
+		{"caption", "caption", "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantField+"/"+tt.field, func(t *testing.T) {
+			formatter := &TelegramFormatter{ChatID: "123", MessageField: tt.field}
+			result, err := formatter.Format(analyzer.StatusTaskComplete, "Done", "session-1", statusInfo)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			resultMap := result.(map[string]interface{})
+			text, ok := resultMap[tt.wantField].(string)
+			if !ok || !strings.Contains(text, "Done") {
+				t.Errorf("Expected message in %q field, got %v", tt.wantField, resultMap)
+			}
+			if _, exists := resultMap[tt.noField]; exists {
+				t.Errorf("Did not expect %q field, got %v", tt.noField, resultMap)
+			}
+		})
+	}
+}
+
 func TestTelegramFormatterEmojis(t *testing.T) {
 	formatter := &TelegramFormatter{ChatID: "123"}
 	statusInfo := config.StatusInfo{Title: "Test"}
@@ -371,6 +515,10 @@ func TestGetEmojiForStatus(t *testing.T) {
 		{analyzer.StatusReviewComplete, "🔍"},
 		{analyzer.StatusQuestion, "❓"},
 		{analyzer.StatusPlanReady, "📋"},
+		{analyzer.StatusLimitWarning, "⚠️"},
+		{analyzer.StatusSubagentComplete, "🤖"},
+		{analyzer.StatusError, "❌"},
+		{analyzer.StatusPermission, "🔐"},
 		{analyzer.Status("unknown"), "ℹ️"},
 	}
 
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/google/uuid"
+)
+
+const (
+	// HealthCheckMessage is the notification text sent by HealthCheck
+	HealthCheckMessage = "Test notification from claude-notifications"
+
+	// healthCheckSessionID is the session ID reported in health check payloads
+	healthCheckSessionID = "health-check"
+)
+
+// ErrNoWebhooksEnabled is returned by HealthCheck when no endpoint is enabled
+var ErrNoWebhooksEnabled = errors.New("no webhook endpoints are enabled")
+
+// HealthCheck sends a test notification to every enabled endpoint and expects a 2xx response.
+// Requests are sent once, bypassing retries, the circuit breaker, rate limits, the offline
+// queue and metrics. Custom JSON payloads include "test": true so receivers can filter them;
+// other presets carry HealthCheckMessage as their text.
+func (s *Sender) HealthCheck(ctx context.Context) error {
+	var errs []error
+	checked := 0
+	for _, key := range s.endpointKeys {
+		ep := s.endpoints[key]
+		if !ep.cfg.Enabled {
+			continue
+		}
+		checked++
+		if err := s.checkEndpoint(ctx, ep); err != nil {
+			errs = append(errs, fmt.Errorf("%s webhook: %w", endpointName(ep), err))
+		}
+	}
+
+	if checked == 0 {
+		return ErrNoWebhooksEnabled
+	}
+	return errors.Join(errs...)
+}
+
+// checkEndpoint delivers the test notification to one endpoint with a single attempt
+func (s *Sender) checkEndpoint(ctx context.Context, ep *endpoint) error {
+	if ep.cfg.Preset == "imessage" {
+		script, err := s.renderAppleScript(ep.cfg, analyzer.StatusTaskComplete, HealthCheckMessage, healthCheckSessionID)
+		if err != nil {
+			return err
+		}
+		return s.sendIMessage(ctx, script)
+	}
+
+	if err := validateURL(ep.cfg.URL); err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	correlationID := uuid.New().String()
+	payload, contentType, err := s.buildTestPayload(ep, correlationID)
+	if err != nil {
+		return fmt.Errorf("failed to build payload: %w", err)
+	}
+
+	return s.sendHTTPRequest(ctx, ep, uuid.New().String(), payload, contentType, s.statusHeaders(ep, analyzer.StatusTaskComplete, correlationID))
+}
+
+// buildTestPayload builds the endpoint's regular payload for the test notification,
+// marking custom JSON payloads with "test": true
+func (s *Sender) buildTestPayload(ep *endpoint, correlationID string) ([]byte, string, error) {
+	statusInfo, _ := s.cfg.GetStatusInfo(string(analyzer.StatusTaskComplete))
+	preset := statusPreset(ep, statusInfo)
+	_, hasFormatter := ep.formatters[preset]
+	if preset == "shortcuts" || hasFormatter || ep.cfg.Format == "text" {
+		return s.buildPayload(ep, analyzer.StatusTaskComplete, HealthCheckMessage, healthCheckSessionID, correlationID)
+	}
+
+	payload := s.customPayloadFields(analyzer.StatusTaskComplete, HealthCheckMessage, healthCheckSessionID, correlationID, ep.cfg, statusInfo)
+	payload["test"] = true
+
+	data, err := json.Marshal(payload)
+	return data, "application/json", err
+}
+
+// endpointName labels an endpoint in messages by its preset
+func endpointName(ep *endpoint) string {
+	if ep.cfg.Preset == "" {
+		return "custom"
+	}
+	return ep.cfg.Preset
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHealthCheckSuccess(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	if err := sender.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+
+	if body["test"] != true {
+		t.Errorf("expected custom payload to be marked as a test, got %v", body)
+	}
+	if body["message"] != HealthCheckMessage {
+		t.Errorf("message = %v, want %q", body["message"], HealthCheckMessage)
+	}
+	if body["status"] != "task_complete" {
+		t.Errorf("status = %v, want task_complete", body["status"])
+	}
+
+	// Health checks are not real notifications
+	if stats := sender.GetMetrics(); stats.TotalRequests != 0 {
+		t.Errorf("expected health check to skip metrics, got %d requests", stats.TotalRequests)
+	}
+}
+
+func TestHealthCheckPresetUsesFormatter(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Preset = "slack"
+	sender := New(cfg)
+
+	if err := sender.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+	if !strings.Contains(body, "attachments") || !strings.Contains(body, HealthCheckMessage) {
+		t.Errorf("expected Slack payload with the test message, got %s", body)
+	}
+}
+
+func TestHealthCheckFailureReportsStatusAndBody(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	err := sender.HealthCheck(context.Background())
+	if err == nil {
+		t.Fatal("expected error for 503 response")
+	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable || httpErr.Body != "maintenance" {
+		t.Errorf("expected HTTPError with status and body, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected a single attempt without retries, got %d", attempts.Load())
+	}
+}
+
+func TestHealthCheckMultipleEndpoints(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+
+	cfg := newTestConfig(ok.URL)
+	broken := cfg.Notifications.Webhook[0]
+	broken.Preset = "discord"
+	broken.URL = "not a url"
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, broken)
+
+	err := New(cfg).HealthCheck(context.Background())
+	if err == nil {
+		t.Fatal("expected error for the broken endpoint")
+	}
+	if !strings.HasPrefix(err.Error(), "discord webhook: invalid webhook URL") {
+		t.Errorf("expected only the discord endpoint to fail, got %v", err)
+	}
+}
+
+func TestHealthCheckNoEnabledEndpoints(t *testing.T) {
+	cfg := newTestConfig("https://example.com/webhook")
+	cfg.Notifications.Webhook[0].Enabled = false
+
+	if err := New(cfg).HealthCheck(context.Background()); !errors.Is(err, ErrNoWebhooksEnabled) {
+		t.Errorf("expected ErrNoWebhooksEnabled, got %v", err)
+	}
+}
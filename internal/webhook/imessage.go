@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/config"
+)
+
+// defaultAppleScriptTemplate sends "Title: message" to the recipient through Messages
+const defaultAppleScriptTemplate = `tell application "Messages" to send "{{.Title}}: {{.Message}}" to buddy "{{.Recipient}}"`
+
+// osascriptCommand runs AppleScript; overridden in tests
+var osascriptCommand = "osascript"
+
+// appleScriptData holds the values available to an AppleScript template.
+// Every field is escaped for use inside an AppleScript string literal.
+type appleScriptData struct {
+	Title     string
+	Message   string
+	Status    string
+	SessionID string
+	Recipient string
+}
+
+// appleScriptEscaper escapes backslashes and quotes so values can't break out of a string literal
+var appleScriptEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// renderAppleScript fills the endpoint's configured (or default) AppleScript template
+func (s *Sender) renderAppleScript(webhookCfg *config.SingleWebhookConfig, status analyzer.Status, message, sessionID string) (string, error) {
+	statusInfo, _ := s.cfg.GetStatusInfo(string(status))
+
+	text := webhookCfg.AppleScriptTemplate
+	if text == "" {
+		text = defaultAppleScriptTemplate
+	}
+
+	tmpl, err := template.New("imessage").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid appleScriptTemplate: %w", err)
+	}
+
+	var sb strings.Builder
+	err = tmpl.Execute(&sb, appleScriptData{
+		Title:     appleScriptEscaper.Replace(statusInfo.Title),
+		Message:   appleScriptEscaper.Replace(message),
+		Status:    appleScriptEscaper.Replace(string(status)),
+		SessionID: appleScriptEscaper.Replace(sessionID),
+		Recipient: appleScriptEscaper.Replace(webhookCfg.RecipientPhone),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render appleScriptTemplate: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// sendIMessage runs the rendered AppleScript with osascript
+func (s *Sender) sendIMessage(ctx context.Context, script string) error {
+	cmd := exec.CommandContext(ctx, osascriptCommand, "-e", script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("osascript failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func TestRenderAppleScript_Default(t *testing.T) {
+	cfg := newTestConfig("")
+	cfg.Notifications.Webhook[0].Preset = "imessage"
+	cfg.Notifications.Webhook[0].RecipientPhone = "+15555550123"
+	sender := New(cfg)
+
+	script, err := sender.renderAppleScript(&cfg.Notifications.Webhook[0], analyzer.StatusTaskComplete, `Fixed "quoted" path C:\tmp`, "session-123")
+	if err != nil {
+		t.Fatalf("renderAppleScript failed: %v", err)
+	}
+
+	expected := `tell application "Messages" to send "Task Complete: Fixed \"quoted\" path C:\\tmp" to buddy "+15555550123"`
+	if script != expected {
+		t.Errorf("unexpected script:\n got: %s\nwant: %s", script, expected)
+	}
+}
+
+func TestRenderAppleScript_CustomTemplate(t *testing.T) {
+	cfg := newTestConfig("")
+	cfg.Notifications.Webhook[0].Preset = "imessage"
+	cfg.Notifications.Webhook[0].RecipientPhone = "me@example.com"
+	cfg.Notifications.Webhook[0].AppleScriptTemplate = `display notification "{{.Message}}" with title "{{.Status}} {{.SessionID}}"`
+	sender := New(cfg)
+
+	script, err := sender.renderAppleScript(&cfg.Notifications.Webhook[0], analyzer.StatusQuestion, "Need input", "abc")
+	if err != nil {
+		t.Fatalf("renderAppleScript failed: %v", err)
+	}
+	if script != `display notification "Need input" with title "question abc"` {
+		t.Errorf("unexpected script: %s", script)
+	}
+
+	cfg.Notifications.Webhook[0].AppleScriptTemplate = `{{.Broken`
+	if _, err := sender.renderAppleScript(&cfg.Notifications.Webhook[0], analyzer.StatusQuestion, "Need input", "abc"); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestSenderSendIMessage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a stand-in for osascript")
+	}
+
+	// Stand-in for osascript: record the script passed with -e
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "script.txt")
+	fake := filepath.Join(dir, "osascript")
+	content := "#!/bin/sh\nprintf '%s' \"$2\" > \"" + outPath + "\"\n"
+	if err := os.WriteFile(fake, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write fake osascript: %v", err)
+	}
+	original := osascriptCommand
+	osascriptCommand = fake
+	t.Cleanup(func() { osascriptCommand = original })
+
+	cfg := newTestConfig("")
+	cfg.Notifications.Webhook[0].Preset = "imessage"
+	cfg.Notifications.Webhook[0].RecipientPhone = "+15555550123"
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("osascript was not run: %v", err)
+	}
+	if !strings.Contains(string(data), `send "Task Complete: Done" to buddy "+15555550123"`) {
+		t.Errorf("unexpected script: %s", data)
+	}
+	if stats := sender.GetMetrics(); stats.SuccessfulRequests != 1 {
+		t.Errorf("expected 1 successful request, got %d", stats.SuccessfulRequests)
+	}
+}
@@ -1,11 +1,26 @@
 package webhook
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+const (
+	statsFileName = "claude-notifications-metrics.json"
+
+	// latencyWindowSize is the number of recent latencies kept for percentiles
+	latencyWindowSize = 1024
 )
 
 // Metrics tracks webhook statistics
@@ -26,6 +41,14 @@ type Metrics struct {
 	totalLatency atomic.Int64 // in milliseconds
 	requestCount atomic.Int64 // for average calculation
 
+	// Ring buffer of recent latencies (ms) for percentiles
+	latencies  []int64
+	latencyPos int
+	latencyMu  sync.Mutex
+
+	// Latencies (ms) not yet merged into the stats file, oldest first
+	unpersisted []int64
+
 	// Circuit breaker state
 	circuitBreakerState atomic.Int32 // 0=closed, 1=open, 2=half-open
 }
@@ -34,6 +57,7 @@ type Metrics struct {
 func NewMetrics() *Metrics {
 	return &Metrics{
 		statusCounters: make(map[analyzer.Status]*atomic.Int64),
+		latencies:      make([]int64, 0, latencyWindowSize),
 	}
 }
 
@@ -73,6 +97,28 @@ func (m *Metrics) RecordCircuitOpen() {
 func (m *Metrics) recordLatency(latency time.Duration) {
 	m.totalLatency.Add(latency.Milliseconds())
 	m.requestCount.Add(1)
+
+	m.latencyMu.Lock()
+	if len(m.latencies) < latencyWindowSize {
+		m.latencies = append(m.latencies, latency.Milliseconds())
+	} else {
+		m.latencies[m.latencyPos] = latency.Milliseconds()
+	}
+	m.latencyPos = (m.latencyPos + 1) % latencyWindowSize
+	m.unpersisted = append(m.unpersisted, latency.Milliseconds())
+	if len(m.unpersisted) > latencyWindowSize {
+		m.unpersisted = m.unpersisted[len(m.unpersisted)-latencyWindowSize:]
+	}
+	m.latencyMu.Unlock()
+}
+
+// takeUnpersistedLatencies returns the latencies recorded since the last call, oldest first
+func (m *Metrics) takeUnpersistedLatencies() []int64 {
+	m.latencyMu.Lock()
+	defer m.latencyMu.Unlock()
+	latencies := m.unpersisted
+	m.unpersisted = nil
+	return latencies
 }
 
 // incrementStatusCounter increments counter for a specific status
@@ -102,6 +148,12 @@ func (m *Metrics) GetStats() Stats {
 	}
 	m.mu.RUnlock()
 
+	m.latencyMu.Lock()
+	latencies := make([]int64, len(m.latencies))
+	copy(latencies, m.latencies)
+	m.latencyMu.Unlock()
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
 	requestCount := m.requestCount.Load()
 	avgLatency := int64(0)
 	if requestCount > 0 {
@@ -117,6 +169,8 @@ func (m *Metrics) GetStats() Stats {
 		CircuitOpenRequests: m.circuitOpenRequests.Load(),
 		StatusCounts:        statusCounts,
 		AverageLatencyMs:    avgLatency,
+		TotalLatencyMs:      m.totalLatency.Load(),
+		LatenciesMs:         latencies,
 		CircuitBreakerState: CircuitBreakerState(m.circuitBreakerState.Load()),
 	}
 }
@@ -136,6 +190,12 @@ func (m *Metrics) Reset() {
 	m.mu.Lock()
 	m.statusCounters = make(map[analyzer.Status]*atomic.Int64)
 	m.mu.Unlock()
+
+	m.latencyMu.Lock()
+	m.latencies = make([]int64, 0, latencyWindowSize)
+	m.latencyPos = 0
+	m.unpersisted = nil
+	m.latencyMu.Unlock()
 }
 
 // Stats represents a snapshot of metrics
@@ -148,6 +208,8 @@ type Stats struct {
 	CircuitOpenRequests int64
 	StatusCounts        map[analyzer.Status]int64
 	AverageLatencyMs    int64
+	TotalLatencyMs      int64   // sum over successful requests, for merging averages
+	LatenciesMs         []int64 // recent latencies (sorted ascending in GetStats, oldest first in the stats file)
 	CircuitBreakerState CircuitBreakerState
 }
 
@@ -159,6 +221,39 @@ func (s *Stats) SuccessRate() float64 {
 	return float64(s.SuccessfulRequests) / float64(s.TotalRequests) * 100
 }
 
+// P50 returns the median latency in milliseconds over recent requests
+func (s *Stats) P50() int64 {
+	return s.percentile(50)
+}
+
+// P95 returns the 95th percentile latency in milliseconds over recent requests
+func (s *Stats) P95() int64 {
+	return s.percentile(95)
+}
+
+// P99 returns the 99th percentile latency in milliseconds over recent requests
+func (s *Stats) P99() int64 {
+	return s.percentile(99)
+}
+
+// percentile returns the nearest-rank percentile of the latencies
+func (s *Stats) percentile(p int) int64 {
+	n := len(s.LatenciesMs)
+	if n == 0 {
+		return 0
+	}
+	sorted := s.LatenciesMs
+	if !sort.SliceIsSorted(sorted, func(i, j int) bool { return sorted[i] < sorted[j] }) {
+		sorted = append([]int64(nil), s.LatenciesMs...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	}
+	rank := (p*n + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
 // FailureRate returns the failure rate as a percentage
 func (s *Stats) FailureRate() float64 {
 	if s.TotalRequests == 0 {
@@ -166,3 +261,129 @@ func (s *Stats) FailureRate() float64 {
 	}
 	return float64(s.FailedRequests) / float64(s.TotalRequests) * 100
 }
+
+// sub returns what s gained since prev, an earlier snapshot of the same metrics.
+// Latencies are left out, since the ring buffer can't tell which ones are new.
+func (s Stats) sub(prev Stats) Stats {
+	delta := Stats{
+		TotalRequests:       s.TotalRequests - prev.TotalRequests,
+		SuccessfulRequests:  s.SuccessfulRequests - prev.SuccessfulRequests,
+		FailedRequests:      s.FailedRequests - prev.FailedRequests,
+		RetriedRequests:     s.RetriedRequests - prev.RetriedRequests,
+		RateLimitedRequests: s.RateLimitedRequests - prev.RateLimitedRequests,
+		CircuitOpenRequests: s.CircuitOpenRequests - prev.CircuitOpenRequests,
+		TotalLatencyMs:      s.TotalLatencyMs - prev.TotalLatencyMs,
+		StatusCounts:        make(map[analyzer.Status]int64),
+		CircuitBreakerState: s.CircuitBreakerState,
+	}
+	for status, count := range s.StatusCounts {
+		if gained := count - prev.StatusCounts[status]; gained > 0 {
+			delta.StatusCounts[status] = gained
+		}
+	}
+	return delta
+}
+
+// add returns s with the counts of delta added. The circuit breaker state is delta's,
+// as the most recent one, and only the newest latencyWindowSize latencies are kept.
+func (s Stats) add(delta Stats) Stats {
+	// Snapshots written before TotalLatencyMs existed only have the average
+	if s.TotalLatencyMs == 0 {
+		s.TotalLatencyMs = s.AverageLatencyMs * s.SuccessfulRequests
+	}
+	merged := Stats{
+		TotalRequests:       s.TotalRequests + delta.TotalRequests,
+		SuccessfulRequests:  s.SuccessfulRequests + delta.SuccessfulRequests,
+		FailedRequests:      s.FailedRequests + delta.FailedRequests,
+		RetriedRequests:     s.RetriedRequests + delta.RetriedRequests,
+		RateLimitedRequests: s.RateLimitedRequests + delta.RateLimitedRequests,
+		CircuitOpenRequests: s.CircuitOpenRequests + delta.CircuitOpenRequests,
+		TotalLatencyMs:      s.TotalLatencyMs + delta.TotalLatencyMs,
+		StatusCounts:        make(map[analyzer.Status]int64),
+		CircuitBreakerState: delta.CircuitBreakerState,
+	}
+	for status, count := range s.StatusCounts {
+		merged.StatusCounts[status] += count
+	}
+	for status, count := range delta.StatusCounts {
+		merged.StatusCounts[status] += count
+	}
+	if merged.SuccessfulRequests > 0 {
+		merged.AverageLatencyMs = merged.TotalLatencyMs / merged.SuccessfulRequests
+	}
+	merged.LatenciesMs = append(append([]int64(nil), s.LatenciesMs...), delta.LatenciesMs...)
+	if n := len(merged.LatenciesMs); n > latencyWindowSize {
+		merged.LatenciesMs = merged.LatenciesMs[n-latencyWindowSize:]
+	}
+	return merged
+}
+
+// StatsSnapshot is a Stats value persisted to disk
+type StatsSnapshot struct {
+	Stats
+	UpdatedAt time.Time
+}
+
+// DefaultStatsPath returns the metrics snapshot location in the system temp directory
+func DefaultStatsPath() string {
+	return filepath.Join(platform.TempDir(), statsFileName)
+}
+
+// WriteStatsFile atomically writes a metrics snapshot to path
+func WriteStatsFile(path string, stats Stats) error {
+	data, err := json.MarshalIndent(StatsSnapshot{Stats: stats, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace metrics file: %w", err)
+	}
+
+	return nil
+}
+
+// mergeStatsFile adds delta to the snapshot at path. Every hook process merges its own
+// counts, so the file is locked while it is read and rewritten.
+func mergeStatsFile(path string, delta Stats) error {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics lock: %w", err)
+	}
+	defer f.Close()
+	if err := platform.LockFile(f); err != nil {
+		logging.Debug("Advisory lock unavailable, merging metrics without it: %v", err)
+	} else {
+		defer func() { _ = platform.UnlockFile(f) }()
+	}
+
+	merged := Stats{}
+	snapshot, err := ReadStatsFile(path)
+	switch {
+	case err == nil:
+		merged = snapshot.Stats
+	case !errors.Is(err, os.ErrNotExist):
+		logging.Warn("Replacing unreadable metrics file: %v", err)
+	}
+	return WriteStatsFile(path, merged.add(delta))
+}
+
+// ReadStatsFile reads a metrics snapshot written by WriteStatsFile
+func ReadStatsFile(path string) (*StatsSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics file: %w", err)
+	}
+
+	var snapshot StatsSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics file: %w", err)
+	}
+
+	return &snapshot, nil
+}
@@ -1,6 +1,9 @@
 package webhook
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -253,6 +256,79 @@ func TestMetricsReset(t *testing.T) {
 	if len(stats.StatusCounts) != 0 {
 		t.Errorf("Expected empty status counts after reset, got %v", stats.StatusCounts)
 	}
+	if len(stats.LatenciesMs) != 0 || stats.P99() != 0 {
+		t.Errorf("Expected empty latency buffer after reset, got %d samples", len(stats.LatenciesMs))
+	}
+}
+
+func TestMetricsLatencyPercentiles(t *testing.T) {
+	m := NewMetrics()
+
+	// Latencies 1..100ms in shuffled order
+	for i := 0; i < 100; i++ {
+		ms := (i*37)%100 + 1
+		m.RecordSuccess(analyzer.StatusTaskComplete, time.Duration(ms)*time.Millisecond)
+	}
+
+	stats := m.GetStats()
+	if stats.P50() != 50 {
+		t.Errorf("Expected P50 50ms, got %d", stats.P50())
+	}
+	if stats.P95() != 95 {
+		t.Errorf("Expected P95 95ms, got %d", stats.P95())
+	}
+	if stats.P99() != 99 {
+		t.Errorf("Expected P99 99ms, got %d", stats.P99())
+	}
+	if stats.AverageLatencyMs != 50 {
+		t.Errorf("Expected average latency 50ms to be kept, got %d", stats.AverageLatencyMs)
+	}
+}
+
+func TestMetricsLatencyPercentilesTail(t *testing.T) {
+	m := NewMetrics()
+
+	// 98 fast requests and 2 slow ones: the average hides the tail, P99 must not
+	for i := 0; i < 98; i++ {
+		m.RecordSuccess(analyzer.StatusTaskComplete, 10*time.Millisecond)
+	}
+	m.RecordSuccess(analyzer.StatusTaskComplete, 2000*time.Millisecond)
+	m.RecordSuccess(analyzer.StatusTaskComplete, 3000*time.Millisecond)
+
+	stats := m.GetStats()
+	if stats.P50() != 10 || stats.P95() != 10 {
+		t.Errorf("Expected P50/P95 10ms, got %d/%d", stats.P50(), stats.P95())
+	}
+	if stats.P99() != 2000 {
+		t.Errorf("Expected P99 2000ms, got %d", stats.P99())
+	}
+}
+
+func TestMetricsLatencyRingBuffer(t *testing.T) {
+	m := NewMetrics()
+
+	// Fill the window with slow requests, then overwrite it entirely with fast ones
+	for i := 0; i < latencyWindowSize; i++ {
+		m.RecordSuccess(analyzer.StatusTaskComplete, 500*time.Millisecond)
+	}
+	for i := 0; i < latencyWindowSize; i++ {
+		m.RecordSuccess(analyzer.StatusTaskComplete, 5*time.Millisecond)
+	}
+
+	stats := m.GetStats()
+	if len(stats.LatenciesMs) != latencyWindowSize {
+		t.Errorf("Expected %d samples, got %d", latencyWindowSize, len(stats.LatenciesMs))
+	}
+	if stats.P99() != 5 {
+		t.Errorf("Expected old latencies to be evicted (P99 5ms), got %d", stats.P99())
+	}
+}
+
+func TestStatsPercentileEmpty(t *testing.T) {
+	stats := Stats{}
+	if stats.P50() != 0 || stats.P95() != 0 || stats.P99() != 0 {
+		t.Error("Expected zero percentiles with no samples")
+	}
 }
 
 func TestMetricsConcurrency(t *testing.T) {
@@ -341,3 +417,122 @@ func TestMetricsLargeLatencyValues(t *testing.T) {
 		t.Errorf("Expected average latency %d ms, got %d ms", expectedAvg, stats.AverageLatencyMs)
 	}
 }
+
+func TestStatsFileRoundTrip(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest()
+	m.RecordRequest()
+	m.RecordSuccess(analyzer.StatusTaskComplete, 150*time.Millisecond)
+	m.RecordFailure()
+	m.UpdateCircuitBreakerState(StateHalfOpen)
+
+	path := filepath.Join(t.TempDir(), statsFileName)
+	if err := WriteStatsFile(path, m.GetStats()); err != nil {
+		t.Fatalf("WriteStatsFile failed: %v", err)
+	}
+
+	snapshot, err := ReadStatsFile(path)
+	if err != nil {
+		t.Fatalf("ReadStatsFile failed: %v", err)
+	}
+
+	if snapshot.TotalRequests != 2 || snapshot.SuccessfulRequests != 1 || snapshot.FailedRequests != 1 {
+		t.Errorf("Unexpected counters: %+v", snapshot.Stats)
+	}
+	if snapshot.StatusCounts[analyzer.StatusTaskComplete] != 1 {
+		t.Errorf("Expected 1 task_complete, got %d", snapshot.StatusCounts[analyzer.StatusTaskComplete])
+	}
+	if snapshot.AverageLatencyMs != 150 {
+		t.Errorf("Expected 150ms average latency, got %d", snapshot.AverageLatencyMs)
+	}
+	if snapshot.CircuitBreakerState != StateHalfOpen {
+		t.Errorf("Expected half-open circuit state, got %s", snapshot.CircuitBreakerState)
+	}
+	if snapshot.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be set")
+	}
+}
+
+func TestReadStatsFileMissing(t *testing.T) {
+	if _, err := ReadStatsFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing metrics file, got nil")
+	}
+}
+
+func TestSenderPersistsStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	path := filepath.Join(t.TempDir(), statsFileName)
+	sender.SetStatsPath(path)
+
+	if err := sender.Send(analyzer.StatusQuestion, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	snapshot, err := ReadStatsFile(path)
+	if err != nil {
+		t.Fatalf("ReadStatsFile failed: %v", err)
+	}
+	if snapshot.SuccessfulRequests != 1 {
+		t.Errorf("Expected 1 successful request in snapshot, got %d", snapshot.SuccessfulRequests)
+	}
+}
+
+func TestSendersMergeStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), statsFileName)
+
+	// Two senders stand for two hook processes sharing the stats file
+	first := New(newTestConfig(server.URL))
+	first.SetStatsPath(path)
+	second := New(newTestConfig(server.URL))
+	second.SetStatsPath(path)
+
+	for _, send := range []struct {
+		sender *Sender
+		status analyzer.Status
+	}{
+		{first, analyzer.StatusQuestion},
+		{second, analyzer.StatusTaskComplete},
+		{first, analyzer.StatusTaskComplete},
+	} {
+		if err := send.sender.Send(send.status, "Test", "session-123"); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+
+	snapshot, err := ReadStatsFile(path)
+	if err != nil {
+		t.Fatalf("ReadStatsFile failed: %v", err)
+	}
+	if snapshot.TotalRequests != 3 || snapshot.SuccessfulRequests != 3 {
+		t.Errorf("Expected 3 requests from both senders, got %d total, %d successful", snapshot.TotalRequests, snapshot.SuccessfulRequests)
+	}
+	if snapshot.StatusCounts[analyzer.StatusTaskComplete] != 2 || snapshot.StatusCounts[analyzer.StatusQuestion] != 1 {
+		t.Errorf("Unexpected status counts: %v", snapshot.StatusCounts)
+	}
+	if len(snapshot.LatenciesMs) != 3 {
+		t.Errorf("Expected 3 latencies, got %d", len(snapshot.LatenciesMs))
+	}
+}
+
+func TestStatsAddKeepsNewestLatencies(t *testing.T) {
+	old := Stats{SuccessfulRequests: 2, AverageLatencyMs: 100, LatenciesMs: make([]int64, latencyWindowSize)}
+	delta := Stats{SuccessfulRequests: 2, TotalLatencyMs: 600, LatenciesMs: []int64{300, 300}}
+
+	merged := old.add(delta)
+	if merged.AverageLatencyMs != 200 {
+		t.Errorf("Expected average 200ms from a snapshot without TotalLatencyMs, got %d", merged.AverageLatencyMs)
+	}
+	if n := len(merged.LatenciesMs); n != latencyWindowSize || merged.LatenciesMs[n-1] != 300 {
+		t.Errorf("Expected the window to end with the newest latencies, got %d samples", n)
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// WritePrometheus writes current metrics in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	stats := m.GetStats()
+	return stats.WritePrometheus(w)
+}
+
+// WritePrometheus writes the stats snapshot in the Prometheus text exposition format
+func (s *Stats) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeMetric(bw, "claude_webhook_requests_total", "counter", "Total webhook requests attempted.", s.TotalRequests)
+	writeMetric(bw, "claude_webhook_success_total", "counter", "Webhooks delivered successfully.", s.SuccessfulRequests)
+	writeMetric(bw, "claude_webhook_failures_total", "counter", "Webhooks that failed after all retries.", s.FailedRequests)
+	writeMetric(bw, "claude_webhook_latency_ms", "gauge", "Average webhook latency in milliseconds.", s.AverageLatencyMs)
+
+	statuses := make([]string, 0, len(s.StatusCounts))
+	for status := range s.StatusCounts {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+
+	fmt.Fprintln(bw, "# HELP claude_webhook_status_total Webhooks delivered per notification status.")
+	fmt.Fprintln(bw, "# TYPE claude_webhook_status_total counter")
+	for _, status := range statuses {
+		count := s.StatusCounts[analyzer.Status(status)]
+		fmt.Fprintf(bw, "claude_webhook_status_total{status=%q} %d\n", status, count)
+	}
+
+	return bw.Flush()
+}
+
+// writeMetric writes a single unlabeled metric with its HELP and TYPE lines
+func writeMetric(w io.Writer, name, metricType, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+// PrometheusHandler serves the stats returned by getStats on each scrape
+func PrometheusHandler(getStats func() Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats := getStats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := stats.WritePrometheus(w); err != nil {
+			logging.Warn("Failed to write Prometheus metrics: %v", err)
+		}
+	})
+}
+
+// NewPrometheusServer creates an HTTP server exposing metrics at /metrics
+func NewPrometheusServer(addr string, getStats func() Stats) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", PrometheusHandler(getStats))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func TestMetricsWritePrometheus(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest()
+	m.RecordRequest()
+	m.RecordRequest()
+	m.RecordSuccess(analyzer.StatusTaskComplete, 100*time.Millisecond)
+	m.RecordSuccess(analyzer.StatusQuestion, 300*time.Millisecond)
+	m.RecordFailure()
+
+	var buf bytes.Buffer
+	if err := m.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE claude_webhook_requests_total counter",
+		"claude_webhook_requests_total 3",
+		"claude_webhook_success_total 2",
+		"claude_webhook_failures_total 1",
+		"# TYPE claude_webhook_latency_ms gauge",
+		"claude_webhook_latency_ms 200",
+		`claude_webhook_status_total{status="question"} 1`,
+		`claude_webhook_status_total{status="task_complete"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Expected line %q in output:\n%s", line, out)
+		}
+	}
+
+	// Labels are emitted in a stable (sorted) order
+	if strings.Index(out, `status="question"`) > strings.Index(out, `status="task_complete"`) {
+		t.Errorf("Expected status labels sorted:\n%s", out)
+	}
+}
+
+func TestMetricsWritePrometheusEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMetrics().WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "claude_webhook_requests_total 0\n") {
+		t.Errorf("Expected zero counters, got:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "claude_webhook_status_total{") {
+		t.Errorf("Expected no per-status samples, got:\n%s", buf.String())
+	}
+}
+
+func TestPrometheusServer(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest()
+	m.RecordSuccess(analyzer.StatusPlanReady, 50*time.Millisecond)
+
+	server := NewPrometheusServer("127.0.0.1:0", m.GetStats)
+	ts := httptest.NewServer(server.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `claude_webhook_status_total{status="plan_ready"} 1`) {
+		t.Errorf("Expected plan_ready counter in response:\n%s", body)
+	}
+}
@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
+)
+
+const queueFileName = "claude-notifications-webhook-queue.jsonl"
+
+// QueueEntry is a webhook notification that could not be delivered
+type QueueEntry struct {
+	Status      analyzer.Status `json:"status"`
+	Message     string          `json:"message"`
+	SessionID   string          `json:"session_id"`
+	Timestamp   int64           `json:"timestamp"`
+	Destination string          `json:"destination"`
+	// CorrelationID keeps the notification's correlation ID when it is replayed
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Queue persists undelivered webhooks to a JSONL file so they can be replayed
+// by a later hook invocation once the network is back. Hooks run as separate
+// processes, so the file is guarded by an advisory lock as well as mu.
+type Queue struct {
+	path    string
+	maxSize int
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+// NewQueue creates a queue backed by the file at path
+func NewQueue(path string, maxSize int, ttl time.Duration) *Queue {
+	return &Queue{
+		path:    path,
+		maxSize: maxSize,
+		ttl:     ttl,
+	}
+}
+
+// DefaultQueuePath returns the queue file location in the user's cache directory, so
+// queued messages aren't shared with other users. Falls back to the system temp directory.
+func DefaultQueuePath() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "claude-notifications", queueFileName)
+	}
+	return filepath.Join(platform.TempDir(), queueFileName)
+}
+
+// errDropEntry makes Drain remove an entry that can never be delivered without
+// counting it as delivered
+var errDropEntry = errors.New("queued webhook dropped")
+
+// lock takes the advisory lock shared by all processes using the queue file. The queue
+// file itself is replaced on every save, so a separate lock file is locked instead.
+// Where advisory locks are unavailable, the queue is used without one.
+func (q *Queue) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+	f, err := os.OpenFile(q.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open webhook queue lock: %w", err)
+	}
+	if err := platform.LockFile(f); err != nil {
+		logging.Debug("Advisory lock unavailable, using webhook queue without it: %v", err)
+		return func() { f.Close() }, nil
+	}
+	return func() {
+		_ = platform.UnlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// Enqueue appends an entry, dropping expired entries and the oldest ones above maxSize
+func (q *Queue) Enqueue(entry QueueEntry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := q.load()
+	if err != nil {
+		return err
+	}
+
+	entries = append(q.pruneExpired(entries), entry)
+	if q.maxSize > 0 && len(entries) > q.maxSize {
+		entries = entries[len(entries)-q.maxSize:]
+	}
+
+	return q.save(entries)
+}
+
+// Drain replays entries older than minAge through send, removing delivered ones and
+// those send drops with errDropEntry. Replay stops at the first other failure (we are
+// most likely still offline) and the failed entry and everything after it stay queued.
+// Returns the number delivered. The queue stays locked while replaying, so concurrent
+// hooks don't replay the same entries.
+func (q *Queue) Drain(minAge time.Duration, send func(QueueEntry) error) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	unlock, err := q.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := q.load()
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	live := q.pruneExpired(entries)
+	cutoff := time.Now().Add(-minAge).Unix()
+
+	var remaining []QueueEntry
+	delivered := 0
+	failed := false
+	for _, entry := range live {
+		if failed || entry.Timestamp > cutoff {
+			remaining = append(remaining, entry)
+			continue
+		}
+		err := send(entry)
+		switch {
+		case errors.Is(err, errDropEntry):
+			continue
+		case err != nil:
+			logging.Debug("Queued webhook replay failed, keeping it and the entries after it: %v", err)
+			failed = true
+			remaining = append(remaining, entry)
+			continue
+		}
+		delivered++
+	}
+
+	if len(remaining) == len(entries) {
+		return delivered, nil
+	}
+	return delivered, q.save(remaining)
+}
+
+// Len returns the number of queued entries
+func (q *Queue) Len() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	unlock, err := q.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := q.load()
+	return len(entries), err
+}
+
+// pruneExpired drops entries older than the queue TTL
+func (q *Queue) pruneExpired(entries []QueueEntry) []QueueEntry {
+	if q.ttl <= 0 {
+		return entries
+	}
+
+	cutoff := time.Now().Add(-q.ttl).Unix()
+	live := entries[:0]
+	for _, entry := range entries {
+		if entry.Timestamp >= cutoff {
+			live = append(live, entry)
+		}
+	}
+	return live
+}
+
+// load reads all entries from the queue file, skipping corrupted lines
+func (q *Queue) load() ([]QueueEntry, error) {
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read webhook queue: %w", err)
+	}
+
+	var entries []QueueEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry QueueEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			logging.Warn("Skipping corrupted webhook queue entry: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// save atomically rewrites the queue file, removing it when empty
+func (q *Queue) save(entries []QueueEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove webhook queue: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook queue entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write webhook queue: %w", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace webhook queue: %w", err)
+	}
+
+	return nil
+}
+
+// isNetworkError reports whether err was caused by the webhook endpoint being
+// unreachable (as opposed to a rejected request or a local misconfiguration)
+func isNetworkError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,368 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func newTestQueue(t *testing.T, maxSize int, ttl time.Duration) *Queue {
+	t.Helper()
+	return NewQueue(filepath.Join(t.TempDir(), queueFileName), maxSize, ttl)
+}
+
+func TestQueueEnqueueAndDrain(t *testing.T) {
+	q := newTestQueue(t, 10, time.Hour)
+	now := time.Now().Unix()
+
+	for i := 0; i < 3; i++ {
+		entry := QueueEntry{Status: analyzer.StatusTaskComplete, Message: fmt.Sprintf("msg-%d", i), Timestamp: now - 60}
+		if err := q.Enqueue(entry); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	var sent []string
+	delivered, err := q.Drain(time.Second, func(e QueueEntry) error {
+		sent = append(sent, e.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if delivered != 3 {
+		t.Errorf("Expected 3 delivered, got %d", delivered)
+	}
+	if len(sent) != 3 || sent[0] != "msg-0" || sent[2] != "msg-2" {
+		t.Errorf("Expected entries replayed in order, got %v", sent)
+	}
+
+	if _, err := os.Stat(q.path); !os.IsNotExist(err) {
+		t.Error("Expected queue file to be removed after full drain")
+	}
+}
+
+func TestQueueDrainSkipsRecentEntries(t *testing.T) {
+	q := newTestQueue(t, 10, time.Hour)
+
+	_ = q.Enqueue(QueueEntry{Message: "old", Timestamp: time.Now().Unix() - 60})
+	_ = q.Enqueue(QueueEntry{Message: "fresh", Timestamp: time.Now().Unix()})
+
+	delivered, err := q.Drain(10*time.Second, func(e QueueEntry) error { return nil })
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("Expected 1 delivered, got %d", delivered)
+	}
+
+	if n, _ := q.Len(); n != 1 {
+		t.Errorf("Expected fresh entry to stay queued, got %d entries", n)
+	}
+}
+
+func TestQueueDrainStopsOnFailure(t *testing.T) {
+	q := newTestQueue(t, 10, time.Hour)
+	now := time.Now().Unix()
+
+	for i := 0; i < 3; i++ {
+		_ = q.Enqueue(QueueEntry{Message: fmt.Sprintf("msg-%d", i), Timestamp: now - 60})
+	}
+
+	calls := 0
+	delivered, err := q.Drain(0, func(e QueueEntry) error {
+		calls++
+		if e.Message == "msg-1" {
+			return errors.New("still offline")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("Expected 1 delivered, got %d", delivered)
+	}
+	if calls != 2 {
+		t.Errorf("Expected replay to stop after first failure, got %d calls", calls)
+	}
+
+	if n, _ := q.Len(); n != 2 {
+		t.Errorf("Expected 2 entries to remain, got %d", n)
+	}
+}
+
+func TestQueueDrainDropsEntries(t *testing.T) {
+	q := newTestQueue(t, 10, time.Hour)
+	now := time.Now().Unix()
+
+	for i := 0; i < 3; i++ {
+		_ = q.Enqueue(QueueEntry{Message: fmt.Sprintf("msg-%d", i), Timestamp: now - 60})
+	}
+
+	delivered, err := q.Drain(0, func(e QueueEntry) error {
+		if e.Message == "msg-1" {
+			return errDropEntry
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if delivered != 2 {
+		t.Errorf("Expected dropped entry not to count as delivered, got %d delivered", delivered)
+	}
+	if n, _ := q.Len(); n != 0 {
+		t.Errorf("Expected dropped entry to be removed, got %d entries", n)
+	}
+}
+
+func TestQueueConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), queueFileName)
+	now := time.Now().Unix()
+
+	// Separate Queue values share nothing but the file, like hooks in separate processes
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := NewQueue(path, 100, time.Hour)
+			if err := q.Enqueue(QueueEntry{Message: fmt.Sprintf("msg-%d", i), Timestamp: now}); err != nil {
+				t.Errorf("Enqueue failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n, _ := NewQueue(path, 100, time.Hour).Len(); n != writers {
+		t.Errorf("Expected %d entries, got %d", writers, n)
+	}
+}
+
+func TestQueueEnqueueCapsSize(t *testing.T) {
+	q := newTestQueue(t, 2, time.Hour)
+	now := time.Now().Unix()
+
+	for i := 0; i < 5; i++ {
+		_ = q.Enqueue(QueueEntry{Message: fmt.Sprintf("msg-%d", i), Timestamp: now})
+	}
+
+	var sent []string
+	_, _ = q.Drain(0, func(e QueueEntry) error {
+		sent = append(sent, e.Message)
+		return nil
+	})
+	if len(sent) != 2 || sent[0] != "msg-3" || sent[1] != "msg-4" {
+		t.Errorf("Expected only the newest 2 entries, got %v", sent)
+	}
+}
+
+func TestQueueDropsExpiredEntries(t *testing.T) {
+	q := newTestQueue(t, 10, time.Minute)
+
+	_ = q.Enqueue(QueueEntry{Message: "expired", Timestamp: time.Now().Add(-time.Hour).Unix()})
+	_ = q.Enqueue(QueueEntry{Message: "live", Timestamp: time.Now().Unix()})
+
+	var sent []string
+	_, _ = q.Drain(0, func(e QueueEntry) error {
+		sent = append(sent, e.Message)
+		return nil
+	})
+	if len(sent) != 1 || sent[0] != "live" {
+		t.Errorf("Expected only the live entry, got %v", sent)
+	}
+}
+
+func TestQueueSkipsCorruptedLines(t *testing.T) {
+	q := newTestQueue(t, 10, time.Hour)
+
+	_ = q.Enqueue(QueueEntry{Message: "good", Timestamp: time.Now().Unix()})
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("Failed to open queue file: %v", err)
+	}
+	_, _ = f.WriteString("{not json\n")
+	f.Close()
+
+	if n, err := q.Len(); err != nil || n != 1 {
+		t.Errorf("Expected 1 valid entry, got %d (err: %v)", n, err)
+	}
+}
+
+func TestSenderQueuesOnNetworkError(t *testing.T) {
+	// Start and immediately stop a server to get an unreachable URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := server.URL
+	server.Close()
+
+	cfg := newTestConfig(deadURL)
+	sender := New(cfg)
+	sender.queue = newTestQueue(t, 10, time.Hour)
+	sender.replayMinAge = 0
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Offline message", "session-123"); err == nil {
+		t.Fatal("Expected error for unreachable endpoint, got nil")
+	}
+
+	if n, _ := sender.queue.Len(); n != 1 {
+		t.Fatalf("Expected failed webhook to be queued, got %d entries", n)
+	}
+}
+
+func TestSenderDoesNotQueueHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	sender := New(cfg)
+	sender.queue = newTestQueue(t, 10, time.Hour)
+
+	_ = sender.Send(analyzer.StatusTaskComplete, "Rejected message", "session-123")
+
+	if n, _ := sender.queue.Len(); n != 0 {
+		t.Errorf("Expected HTTP errors not to be queued, got %d entries", n)
+	}
+}
+
+func TestSenderReplaysQueueBeforeSending(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Format = "text"
+	sender := New(cfg)
+	sender.queue = newTestQueue(t, 10, time.Hour)
+	sender.replayMinAge = 0
+
+	_ = sender.queue.Enqueue(QueueEntry{
+		Status:      analyzer.StatusQuestion,
+		Message:     "Queued message",
+		SessionID:   "session-123",
+		Timestamp:   time.Now().Unix(),
+		Destination: server.URL,
+	})
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "New message", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("Expected 2 requests (replay + new), got %d", len(bodies))
+	}
+	if bodies[0] != "[question] Queued message" {
+		t.Errorf("Expected queued webhook first, got %q", bodies[0])
+	}
+	if bodies[1] != "[task_complete] New message" {
+		t.Errorf("Expected new webhook second, got %q", bodies[1])
+	}
+
+	if n, _ := sender.queue.Len(); n != 0 {
+		t.Errorf("Expected queue to be empty after replay, got %d entries", n)
+	}
+}
+
+func TestSenderReplayDropsDisabledEndpoint(t *testing.T) {
+	var disabledHits, enabledHits atomic.Int32
+	disabled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		disabledHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer disabled.Close()
+	enabled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enabledHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer enabled.Close()
+
+	cfg := newTestConfig(enabled.URL)
+	off := cfg.Notifications.Webhook[0]
+	off.Enabled = false
+	off.URL = disabled.URL
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, off)
+	sender := New(cfg)
+	sender.queue = newTestQueue(t, 10, time.Hour)
+	sender.replayMinAge = 0
+
+	_ = sender.queue.Enqueue(QueueEntry{
+		Status:      analyzer.StatusQuestion,
+		Message:     "Queued for a disabled endpoint",
+		SessionID:   "session-123",
+		Timestamp:   time.Now().Unix(),
+		Destination: disabled.URL,
+	})
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "New message", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if n := disabledHits.Load(); n != 0 {
+		t.Errorf("Expected no requests to the disabled endpoint, got %d", n)
+	}
+	if n := enabledHits.Load(); n != 1 {
+		t.Errorf("Expected only the new webhook to be sent, got %d requests", n)
+	}
+	if n, _ := sender.queue.Len(); n != 0 {
+		t.Errorf("Expected the entry for the disabled endpoint to be dropped, got %d entries", n)
+	}
+}
+
+func TestSenderReplayKeepsCorrelationID(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Correlation-ID"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].IncludeCorrelationID = true
+	sender := New(cfg)
+	sender.queue = newTestQueue(t, 10, time.Hour)
+	sender.replayMinAge = 0
+
+	_ = sender.queue.Enqueue(QueueEntry{
+		Status:        analyzer.StatusQuestion,
+		Message:       "Queued message",
+		SessionID:     "session-123",
+		Timestamp:     time.Now().Unix(),
+		Destination:   server.URL,
+		CorrelationID: "6f1c2f0e-8b1d-4c55-9b1e-2f8d3c4a5b6c",
+	})
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "New message", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 requests (replay + new), got %d", len(received))
+	}
+	if received[0] != "6f1c2f0e-8b1d-4c55-9b1e-2f8d3c4a5b6c" {
+		t.Errorf("replayed X-Correlation-ID = %q, want the queued one", received[0])
+	}
+	if received[1] == "" || received[1] == received[0] {
+		t.Errorf("new X-Correlation-ID = %q, want a fresh one", received[1])
+	}
+}
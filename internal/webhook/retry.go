@@ -34,8 +34,9 @@ type RetryableFunc func(ctx context.Context) error
 
 // Retryer handles retry logic with exponential backoff
 type Retryer struct {
-	config RetryConfig
-	rand   *rand.Rand
+	config  RetryConfig
+	rand    *rand.Rand
+	onRetry func() // called before each retry attempt (not the first attempt)
 }
 
 // NewRetryer creates a new Retryer
@@ -46,6 +47,11 @@ func NewRetryer(config RetryConfig) *Retryer {
 	}
 }
 
+// SetOnRetry sets a callback run before each retry attempt, e.g. to record metrics
+func (r *Retryer) SetOnRetry(fn func()) {
+	r.onRetry = fn
+}
+
 // Do executes the function with retry logic
 // Returns error if all retries are exhausted
 func (r *Retryer) Do(ctx context.Context, fn RetryableFunc) error {
@@ -55,6 +61,10 @@ func (r *Retryer) Do(ctx context.Context, fn RetryableFunc) error {
 
 	var lastErr error
 	for attempt := 1; attempt <= r.config.MaxAttempts; attempt++ {
+		if attempt > 1 && r.onRetry != nil {
+			r.onRetry()
+		}
+
 		// Execute the function
 		err := fn(ctx)
 
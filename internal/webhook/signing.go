@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// signaturePrefix precedes the hex digest in the signature header, as in GitHub's X-Hub-Signature-256
+const signaturePrefix = "sha256="
+
+// sign returns the signature sent for body with signingSecret: "sha256=" followed by the
+// hex-encoded HMAC-SHA256 of the body
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the HMAC-SHA256 of body with secret, as a
+// receiver would check it. The "sha256=" prefix is optional; the comparison takes
+// constant time.
+func VerifySignature(secret, signature string, body []byte) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
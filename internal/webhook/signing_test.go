@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+)
+
+func TestSign(t *testing.T) {
+	// Known HMAC-SHA256 test vector (RFC 4231, test case 2)
+	got := sign("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("sign() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"status":"task_complete"}`)
+	signature := sign("s3cret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		want      bool
+	}{
+		{"valid", "s3cret", signature, body, true},
+		{"without prefix", "s3cret", strings.TrimPrefix(signature, "sha256="), body, true},
+		{"wrong secret", "other", signature, body, false},
+		{"tampered body", "s3cret", signature, []byte(`{"status":"error"}`), false},
+		{"not hex", "s3cret", "sha256=zz", body, false},
+		{"empty", "s3cret", "", body, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.signature, tt.body); got != tt.want {
+				t.Errorf("VerifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderSendSigned(t *testing.T) {
+	var signature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Hub-Signature-256")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].SigningSecret = "s3cret"
+	cfg.Notifications.Webhook[0].SignatureHeader = "X-Hub-Signature-256"
+	// A custom header of the same name must not replace the signature
+	cfg.Notifications.Webhook[0].CustomHeaderFields = map[string]string{"X-Hub-Signature-256": "forged"}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("X-Hub-Signature-256 = %q, want sha256=<hex>", signature)
+	}
+	if !VerifySignature("s3cret", signature, body) {
+		t.Errorf("signature %q does not match the body", signature)
+	}
+}
+
+func TestSenderSendUnsigned(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	for name, values := range headers {
+		for _, value := range values {
+			if strings.HasPrefix(value, "sha256=") {
+				t.Errorf("unexpected signature header %s: %s", name, value)
+			}
+		}
+	}
+}
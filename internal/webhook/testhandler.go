@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/777genius/claude-notifications/internal/analyzer"
+	"github.com/777genius/claude-notifications/internal/logging"
+)
+
+// testRequest is the body accepted by the test handler
+type testRequest struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	SessionID string `json:"session_id"`
+}
+
+// testResponse is returned by the test handler
+type testResponse struct {
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+var testFormTemplate = template.Must(template.New("test-form").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Webhook Test</title></head>
+<body>
+<h1>Send a test webhook</h1>
+<form method="POST" action="/">
+  <p><label>Status
+    <select name="status">{{range .}}<option value="{{.}}">{{.}}</option>{{end}}</select>
+  </label></p>
+  <p><label>Message <input name="message" value="Test notification" size="40"></label></p>
+  <p><label>Session ID <input name="session_id" value="test-123"></label></p>
+  <p><button type="submit">Send</button></p>
+</form>
+</body>
+</html>
+`))
+
+// NewTestHandler returns an HTTP handler for triggering webhooks manually while debugging.
+// POST / with {"status":"task_complete","message":"test","session_id":"test-123"}
+// (or the same fields form-encoded) sends synchronously via sender.Send.
+// GET /test-form renders a minimal HTML form for sending from a browser.
+func NewTestHandler(sender *Sender) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/test-form", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeTestResponse(w, http.StatusMethodNotAllowed, testResponse{Error: "method not allowed"})
+			return
+		}
+
+		statuses := make([]string, 0, len(sender.cfg.Statuses))
+		for status := range sender.cfg.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := testFormTemplate.Execute(w, statuses); err != nil {
+			logging.Warn("Failed to render test form: %v", err)
+		}
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeTestResponse(w, http.StatusMethodNotAllowed, testResponse{Error: "method not allowed, use POST"})
+			return
+		}
+
+		req, err := parseTestRequest(r)
+		if err != nil {
+			writeTestResponse(w, http.StatusBadRequest, testResponse{Error: err.Error()})
+			return
+		}
+		if req.Status == "" {
+			writeTestResponse(w, http.StatusBadRequest, testResponse{Error: "status is required"})
+			return
+		}
+		if _, exists := sender.cfg.GetStatusInfo(req.Status); !exists {
+			writeTestResponse(w, http.StatusBadRequest, testResponse{Error: "unknown status: " + req.Status})
+			return
+		}
+		if req.Message == "" {
+			req.Message = "Test notification"
+		}
+		if req.SessionID == "" {
+			req.SessionID = "test"
+		}
+
+		if err := sender.Send(analyzer.Status(req.Status), req.Message, req.SessionID); err != nil {
+			writeTestResponse(w, http.StatusBadGateway, testResponse{Error: err.Error()})
+			return
+		}
+		writeTestResponse(w, http.StatusOK, testResponse{OK: true})
+	})
+
+	return mux
+}
+
+// parseTestRequest reads a test request from a JSON or form-encoded body
+func parseTestRequest(r *http.Request) (testRequest, error) {
+	var req testRequest
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			return req, fmt.Errorf("invalid form body: %w", err)
+		}
+		req.Status = r.PostForm.Get("status")
+		req.Message = r.PostForm.Get("message")
+		req.SessionID = r.PostForm.Get("session_id")
+		return req, nil
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 64*1024)).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return req, nil
+}
+
+// writeTestResponse writes a JSON response with the given status code
+func writeTestResponse(w http.ResponseWriter, code int, resp testResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
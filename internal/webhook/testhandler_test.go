@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func decodeTestResponse(t *testing.T, rec *httptest.ResponseRecorder) testResponse {
+	t.Helper()
+	var resp testResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestTestHandlerSendsWebhook(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	handler := NewTestHandler(New(newTestConfig(server.URL)))
+
+	body := `{"status":"task_complete","message":"test","session_id":"test-123"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeTestResponse(t, rec); !resp.OK {
+		t.Errorf("Expected ok response, got %+v", resp)
+	}
+	if received.Load() != 1 {
+		t.Errorf("Expected webhook to be sent synchronously, got %d requests", received.Load())
+	}
+}
+
+func TestTestHandlerFormPost(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	handler := NewTestHandler(New(newTestConfig(server.URL)))
+
+	form := url.Values{"status": {"question"}, "message": {"From the browser"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if received.Load() != 1 {
+		t.Errorf("Expected 1 webhook request, got %d", received.Load())
+	}
+}
+
+func TestTestHandlerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	handler := NewTestHandler(New(newTestConfig(server.URL)))
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+	}{
+		{"GET not allowed", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "{bad", http.StatusBadRequest},
+		{"missing status", http.MethodPost, `{"message":"test"}`, http.StatusBadRequest},
+		{"unknown status", http.MethodPost, `{"status":"bogus"}`, http.StatusBadRequest},
+		{"webhook rejected", http.MethodPost, `{"status":"task_complete"}`, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if resp := decodeTestResponse(t, rec); resp.OK || resp.Error == "" {
+				t.Errorf("Expected error response, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestTestHandlerForm(t *testing.T) {
+	handler := NewTestHandler(New(newTestConfig("http://localhost")))
+
+	req := httptest.NewRequest(http.MethodGet, "/test-form", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected HTML content type, got %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"<form", `value="task_complete"`, `value="question"`, `name="message"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected form to contain %q:\n%s", want, body)
+		}
+	}
+}
@@ -3,11 +3,16 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,23 +20,61 @@ import (
 	"github.com/777genius/claude-notifications/internal/config"
 	"github.com/777genius/claude-notifications/internal/errorhandler"
 	"github.com/777genius/claude-notifications/internal/logging"
+	"github.com/777genius/claude-notifications/internal/platform"
 	"github.com/google/uuid"
 )
 
+// defaultReplayMinAge is how old a queued entry must be before it is replayed,
+// so we don't race a process that is still retrying it
+const defaultReplayMinAge = 5 * time.Second
+
+// DefaultCloseTimeout is how long Close waits for in-flight async sends
+const DefaultCloseTimeout = 5 * time.Second
+
+// DefaultMaxMessageLength caps the message text sent to an endpoint without maxWebhookMessageLength
+const DefaultMaxMessageLength = 500
+
 // Sender sends webhook notifications with professional patterns
 type Sender struct {
-	cfg            *config.Config
-	client         *http.Client
-	retry          *Retryer
-	circuitBreaker *CircuitBreaker
-	rateLimiter    *RateLimiter
-	metrics        *Metrics
-	formatters     map[string]Formatter
+	cfg     *config.Config
+	client  *http.Client
+	metrics *Metrics
+
+	// Configured endpoints keyed by endpointKey, each with its own retry,
+	// circuit breaker and rate limiter; endpointKeys keeps the config order
+	endpoints    map[string]*endpoint
+	endpointKeys []string
+
+	// Machine identity added to payloads when webhook.includeHost is set (empty = disabled)
+	hostname string
+	hostOS   string
+
+	// Offline queue for webhooks that failed due to network errors
+	queue        *Queue
+	replayMinAge time.Duration
+
+	// Metrics file shared by all hook processes; after every send the counts gained
+	// since the last merge are added to it (empty = disabled)
+	statsPath string
+	persisted Stats // metrics already merged into statsPath
+	persistMu sync.Mutex
 
 	// Graceful shutdown
 	wg     sync.WaitGroup
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// closeTimeout bounds how long Close drains in-flight async sends
+	closeTimeout time.Duration
+}
+
+// endpoint is one configured webhook destination and its delivery state
+type endpoint struct {
+	cfg            *config.SingleWebhookConfig
+	retry          *Retryer
+	circuitBreaker *CircuitBreaker
+	rateLimiter    *RateLimiter
+	formatters     map[string]Formatter
 }
 
 // New creates a new professional webhook sender
@@ -41,14 +84,74 @@ func New(cfg *config.Config) *Sender {
 		Timeout: 10 * time.Second,
 	}
 
-	// Parse retry config
-	retryConfig := parseRetryConfig(cfg.Notifications.Webhook.Retry)
-	retry := NewRetryer(retryConfig)
+	metrics := NewMetrics()
+
+	// Identify this machine for multi-machine setups
+	var hostname, hostOS string
+	for _, wh := range cfg.Notifications.Webhook {
+		if wh.IncludeHost {
+			hostname, hostOS = currentHostname(), platform.OS()
+			break
+		}
+	}
+
+	endpoints := make(map[string]*endpoint)
+	var endpointKeys []string
+	var queueCfg *config.OfflineQueueConfig
+	for i := range cfg.Notifications.Webhook {
+		wh := &cfg.Notifications.Webhook[i]
+		key := endpointKey(wh)
+		if _, dup := endpoints[key]; dup {
+			logging.Warn("Ignoring duplicate webhook endpoint %d", i)
+			continue
+		}
+		endpoints[key] = newEndpoint(wh, metrics, hostname, hostOS)
+		endpointKeys = append(endpointKeys, key)
+
+		// The offline queue is shared; the first endpoint that enables it sets its limits
+		if queueCfg == nil && wh.OfflineQueue.Enabled {
+			queueCfg = &wh.OfflineQueue
+		}
+	}
+
+	// Create offline queue
+	var queue *Queue
+	if queueCfg != nil {
+		ttl, _ := time.ParseDuration(queueCfg.TTL)
+		if ttl == 0 {
+			ttl = 24 * time.Hour
+		}
+		queue = NewQueue(DefaultQueuePath(), queueCfg.MaxSize, ttl)
+	}
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Sender{
+		cfg:          cfg,
+		client:       client,
+		metrics:      metrics,
+		endpoints:    endpoints,
+		endpointKeys: endpointKeys,
+		hostname:     hostname,
+		hostOS:       hostOS,
+		queue:        queue,
+		replayMinAge: defaultReplayMinAge,
+		ctx:          ctx,
+		cancel:       cancel,
+		closeTimeout: DefaultCloseTimeout,
+	}
+}
+
+// newEndpoint creates the retry, circuit breaker, rate limiter and formatters for one webhook
+func newEndpoint(wh *config.SingleWebhookConfig, metrics *Metrics, hostname, hostOS string) *endpoint {
+	// Parse retry config and count every retry attempt in the metrics
+	retry := NewRetryer(parseRetryConfig(wh.Retry))
+	retry.SetOnRetry(metrics.RecordRetry)
 
 	// Parse circuit breaker config
-	cbCfg := cfg.Notifications.Webhook.CircuitBreaker
 	var circuitBreaker *CircuitBreaker
-	if cbCfg.Enabled {
+	if cbCfg := wh.CircuitBreaker; cbCfg.Enabled {
 		timeout, _ := time.ParseDuration(cbCfg.Timeout)
 		if timeout == 0 {
 			timeout = 30 * time.Second
@@ -58,51 +161,104 @@ func New(cfg *config.Config) *Sender {
 
 	// Create rate limiter
 	var rateLimiter *RateLimiter
-	if cfg.Notifications.Webhook.RateLimit.Enabled {
-		rateLimiter = NewRateLimiter(cfg.Notifications.Webhook.RateLimit.RequestsPerMinute)
+	if wh.RateLimit.Enabled {
+		rateLimiter = NewRateLimiter(wh.RateLimit.RequestsPerMinute)
+	}
+
+	var hostLabel string
+	if wh.IncludeHost {
+		hostLabel = fmt.Sprintf("%s (%s)", hostname, hostOS)
 	}
 
 	// Create formatters
 	formatters := map[string]Formatter{
-		"slack":    &SlackFormatter{},
-		"discord":  &DiscordFormatter{},
-		"telegram": &TelegramFormatter{ChatID: cfg.Notifications.Webhook.ChatID},
+		"slack":    &SlackFormatter{Host: hostLabel, EscalateLongTasks: wh.EscalateLongTasks, Fields: wh.CustomPayloadFields},
+		"discord":  &DiscordFormatter{Host: hostLabel, EscalateLongTasks: wh.EscalateLongTasks, Fields: wh.CustomPayloadFields},
+		"telegram": &TelegramFormatter{ChatID: wh.ChatID, MessageField: wh.TelegramMessageField, Fields: wh.CustomPayloadFields},
 	}
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-
-	return &Sender{
-		cfg:            cfg,
-		client:         client,
+	return &endpoint{
+		cfg:            wh,
 		retry:          retry,
 		circuitBreaker: circuitBreaker,
 		rateLimiter:    rateLimiter,
-		metrics:        NewMetrics(),
 		formatters:     formatters,
-		ctx:            ctx,
-		cancel:         cancel,
 	}
 }
 
-// Send sends a webhook notification with full professional stack
+// endpointKey identifies a webhook by a hash of its URL (or iMessage recipient)
+func endpointKey(wh *config.SingleWebhookConfig) string {
+	dest := wh.URL
+	if wh.Preset == "imessage" {
+		dest = "imessage:" + wh.RecipientPhone
+	}
+	sum := sha256.Sum256([]byte(dest))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Send sends a webhook notification to every enabled endpoint with full professional stack.
+// Failures are collected with errors.Join; a single failure is returned unwrapped.
 func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
 	if !s.cfg.IsWebhookEnabled() {
 		logging.Debug("Webhooks disabled, skipping")
 		return nil
 	}
+	defer s.persistStats()
 
+	// Deliver webhooks queued while offline before sending the new one
+	if s.queue != nil {
+		s.replayQueue()
+	}
+
+	// One correlation ID per notification, shared by every endpoint and retry
+	correlationID := uuid.New().String()
+
+	// Endpoints are sent to in parallel so a slow one doesn't delay the others;
+	// errors are kept in endpoint order
+	errs := make([]error, len(s.endpointKeys))
+	var wg sync.WaitGroup
+	for i, key := range s.endpointKeys {
+		ep := s.endpoints[key]
+		if !ep.cfg.Enabled {
+			continue
+		}
+		i := i
+		wg.Add(1)
+		errorhandler.SafeGo(func() {
+			defer wg.Done()
+			errs[i] = s.sendToEndpoint(ep, status, message, sessionID, correlationID)
+		})
+	}
+	wg.Wait()
+
+	// Update circuit breaker state in metrics
+	s.updateCircuitBreakerMetrics()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 1 {
+		return failed[0]
+	}
+	return errors.Join(failed...)
+}
+
+// sendToEndpoint sends one notification to one endpoint, honouring its rate limit and circuit breaker
+func (s *Sender) sendToEndpoint(ep *endpoint, status analyzer.Status, message, sessionID, correlationID string) error {
 	// Check rate limit (non-blocking check)
-	if s.rateLimiter != nil && !s.rateLimiter.Allow() {
+	if ep.rateLimiter != nil && !ep.rateLimiter.Allow() {
 		s.metrics.RecordRateLimited()
-		logging.Warn("Rate limit exceeded, dropping webhook")
+		logging.Warn("Rate limit exceeded, dropping %s webhook", ep.cfg.Preset)
 		return ErrRateLimitExceeded
 	}
 
 	// Check circuit breaker
-	if s.circuitBreaker != nil && s.circuitBreaker.GetState() == StateOpen {
+	if ep.circuitBreaker != nil && ep.circuitBreaker.GetState() == StateOpen {
 		s.metrics.RecordCircuitOpen()
-		logging.Warn("Circuit breaker is open, skipping webhook")
+		logging.Warn("Circuit breaker is open, skipping %s webhook", ep.cfg.Preset)
 		return ErrCircuitOpen
 	}
 
@@ -114,69 +270,211 @@ func (s *Sender) Send(status analyzer.Status, message, sessionID string) error {
 	start := time.Now()
 
 	// Execute with retry and circuit breaker
-	err := s.sendWithRetryAndCircuitBreaker(requestID, status, message, sessionID)
+	err := s.sendWithRetryAndCircuitBreaker(ep, requestID, status, message, sessionID, correlationID)
 
 	// Record result
 	latency := time.Since(start)
 	if err != nil {
 		s.metrics.RecordFailure()
-		logging.Error("[%s] Webhook failed after retries: %v (latency: %v)", requestID, err, latency)
+		logging.Error("[%s] Webhook (%s) failed after retries: %v (latency: %v)", requestID, ep.cfg.Preset, err, latency)
+		s.enqueueIfOffline(ep, status, message, sessionID, correlationID, err)
 	} else {
 		s.metrics.RecordSuccess(status, latency)
-		logging.Info("[%s] Webhook sent successfully (latency: %v)", requestID, latency)
+		logging.Info("[%s] Webhook (%s) sent successfully (latency: %v)", requestID, ep.cfg.Preset, latency)
 	}
 
-	// Update circuit breaker state in metrics
-	if s.circuitBreaker != nil {
-		s.metrics.UpdateCircuitBreakerState(s.circuitBreaker.GetState())
+	return err
+}
+
+// updateCircuitBreakerMetrics reports the worst circuit breaker state across endpoints
+func (s *Sender) updateCircuitBreakerMetrics() {
+	var breakers []*CircuitBreaker
+	for _, key := range s.endpointKeys {
+		if cb := s.endpoints[key].circuitBreaker; cb != nil {
+			breakers = append(breakers, cb)
+		}
+	}
+	if len(breakers) == 0 {
+		return
 	}
 
-	return err
+	worst := StateClosed
+	for _, cb := range breakers {
+		switch cb.GetState() {
+		case StateOpen:
+			worst = StateOpen
+		case StateHalfOpen:
+			if worst != StateOpen {
+				worst = StateHalfOpen
+			}
+		}
+	}
+	s.metrics.UpdateCircuitBreakerState(worst)
 }
 
-// sendWithRetryAndCircuitBreaker executes the webhook with retry and circuit breaker
-func (s *Sender) sendWithRetryAndCircuitBreaker(requestID string, status analyzer.Status, message, sessionID string) error {
-	webhookCfg := s.cfg.Notifications.Webhook
+// SetStatsPath enables merging metrics into the stats file at path after every send
+func (s *Sender) SetStatsPath(path string) {
+	s.statsPath = path
+}
 
-	// Build payload
-	payload, contentType, err := s.buildPayload(status, message, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to build payload: %w", err)
+// FlushStats merges the metrics gained since the last merge into the stats file, if a
+// stats path is set
+func (s *Sender) FlushStats() error {
+	if s.statsPath == "" {
+		return nil
 	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	current := s.metrics.GetStats()
+	delta := current.sub(s.persisted)
+	delta.LatenciesMs = s.metrics.takeUnpersistedLatencies()
+	if err := mergeStatsFile(s.statsPath, delta); err != nil {
+		return err
+	}
+	s.persisted = current
+	return nil
+}
 
-	// Validate URL
-	if err := validateURL(webhookCfg.URL); err != nil {
-		return fmt.Errorf("invalid webhook URL: %w", err)
+// persistStats is FlushStats for the end of a send, logging failures
+func (s *Sender) persistStats() {
+	if err := s.FlushStats(); err != nil {
+		logging.Warn("Failed to persist webhook metrics: %v", err)
 	}
+}
 
-	// Create request function for retry
-	sendFn := func(ctx context.Context) error {
-		return s.sendHTTPRequest(ctx, requestID, webhookCfg.URL, payload, contentType, webhookCfg.Headers)
+// sendWithRetryAndCircuitBreaker executes the webhook with the endpoint's retry and circuit breaker
+func (s *Sender) sendWithRetryAndCircuitBreaker(ep *endpoint, requestID string, status analyzer.Status, message, sessionID, correlationID string) error {
+	sendFn, err := s.buildSendFunc(ep, requestID, status, message, sessionID, correlationID)
+	if err != nil {
+		return err
 	}
 
 	// Execute with circuit breaker and retry
 	var executeErr error
-	if s.circuitBreaker != nil {
+	if ep.circuitBreaker != nil {
 		// Wrap with circuit breaker
-		executeErr = s.circuitBreaker.Execute(s.ctx, func() error {
+		executeErr = ep.circuitBreaker.Execute(s.ctx, func() error {
 			// Execute with retry
-			return s.retry.Do(s.ctx, sendFn)
+			return ep.retry.Do(s.ctx, sendFn)
 		})
 	} else {
 		// Just retry without circuit breaker
-		executeErr = s.retry.Do(s.ctx, sendFn)
+		executeErr = ep.retry.Do(s.ctx, sendFn)
 	}
 
 	return executeErr
 }
 
-// buildPayload builds the webhook payload based on preset
-func (s *Sender) buildPayload(status analyzer.Status, message, sessionID string) ([]byte, string, error) {
-	webhookCfg := s.cfg.Notifications.Webhook
+// buildSendFunc prepares the delivery for one notification: an osascript run for the
+// iMessage preset, an HTTP request for everything else
+func (s *Sender) buildSendFunc(ep *endpoint, requestID string, status analyzer.Status, message, sessionID, correlationID string) (RetryableFunc, error) {
+	webhookCfg := ep.cfg
+
+	if webhookCfg.Preset == "imessage" {
+		script, err := s.renderAppleScript(webhookCfg, status, message, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return s.sendIMessage(ctx, script)
+		}, nil
+	}
+
+	// Build payload
+	payload, contentType, err := s.buildPayload(ep, status, message, sessionID, correlationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build payload: %w", err)
+	}
+
+	// Validate URL
+	if err := validateURL(webhookCfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	return func(ctx context.Context) error {
+		return s.sendHTTPRequest(ctx, ep, requestID, payload, contentType, s.statusHeaders(ep, status, correlationID))
+	}, nil
+}
+
+// enqueueIfOffline persists a failed webhook for later replay when the failure was a network error
+func (s *Sender) enqueueIfOffline(ep *endpoint, status analyzer.Status, message, sessionID, correlationID string, sendErr error) {
+	if s.queue == nil || ep.cfg.Preset == "imessage" || !isNetworkError(sendErr) {
+		return
+	}
+
+	entry := QueueEntry{
+		Status:        status,
+		Message:       message,
+		SessionID:     sessionID,
+		Timestamp:     time.Now().Unix(),
+		Destination:   ep.cfg.URL,
+		CorrelationID: correlationID,
+	}
+	if err := s.queue.Enqueue(entry); err != nil {
+		logging.Warn("Failed to queue webhook for replay: %v", err)
+		return
+	}
+	logging.Info("Webhook queued for replay when the network is back")
+}
+
+// replayQueue sends queued webhooks with a single attempt each.
+// Entries rejected by the endpoint (non-network errors) or for endpoints that
+// are no longer configured or have been disabled are dropped.
+func (s *Sender) replayQueue() {
+	delivered, err := s.queue.Drain(s.replayMinAge, func(entry QueueEntry) error {
+		ep, ok := s.endpoints[endpointKey(&config.SingleWebhookConfig{URL: entry.Destination})]
+		if !ok {
+			logging.Warn("Dropping queued webhook, its endpoint is no longer configured")
+			return errDropEntry
+		}
+		if !ep.cfg.Enabled {
+			logging.Warn("Dropping queued webhook, its endpoint is disabled")
+			return errDropEntry
+		}
+
+		// Entries queued before correlation IDs existed get a new one
+		correlationID := entry.CorrelationID
+		if correlationID == "" {
+			correlationID = uuid.New().String()
+		}
+
+		payload, contentType, err := s.buildPayload(ep, entry.Status, entry.Message, entry.SessionID, correlationID)
+		if err != nil {
+			logging.Warn("Dropping queued webhook, failed to build payload: %v", err)
+			return errDropEntry
+		}
+
+		err = s.sendHTTPRequest(s.ctx, ep, uuid.New().String(), payload, contentType, s.statusHeaders(ep, entry.Status, correlationID))
+		if err != nil && !isNetworkError(err) {
+			logging.Warn("Dropping queued webhook, endpoint rejected it: %v", err)
+			return errDropEntry
+		}
+		return err
+	})
+	if err != nil {
+		logging.Warn("Failed to replay webhook queue: %v", err)
+	}
+	if delivered > 0 {
+		logging.Info("Replayed %d queued webhook(s)", delivered)
+	}
+}
+
+// buildPayload builds the webhook payload for an endpoint based on its preset
+func (s *Sender) buildPayload(ep *endpoint, status analyzer.Status, message, sessionID, correlationID string) ([]byte, string, error) {
+	webhookCfg := ep.cfg
 	statusInfo, _ := s.cfg.GetStatusInfo(string(status))
+	message = truncateMessage(message, maxMessageLength(webhookCfg))
+
+	preset := statusPreset(ep, statusInfo)
+
+	// Shortcuts webhooks take the notification as plain text
+	if preset == "shortcuts" {
+		return []byte(fmt.Sprintf("%s: %s", statusInfo.Title, message)), "text/plain", nil
+	}
 
 	// Use formatter if available
-	if formatter, ok := s.formatters[webhookCfg.Preset]; ok {
+	if formatter, ok := ep.formatters[preset]; ok {
 		payload, err := formatter.Format(status, message, sessionID, statusInfo)
 		if err != nil {
 			return nil, "", err
@@ -186,17 +484,55 @@ func (s *Sender) buildPayload(status analyzer.Status, message, sessionID string)
 	}
 
 	// Fallback to custom format
-	return s.buildCustomPayload(status, message, sessionID, webhookCfg.Format, statusInfo)
+	return s.buildCustomPayload(status, message, sessionID, correlationID, webhookCfg, statusInfo)
+}
+
+// statusPreset returns the preset a status is formatted with on ep: the status's
+// webhookPreset if set, otherwise the endpoint's own preset
+func statusPreset(ep *endpoint, statusInfo config.StatusInfo) string {
+	if statusInfo.WebhookPreset != "" && ep.cfg.Preset != "imessage" {
+		return statusInfo.WebhookPreset
+	}
+	return ep.cfg.Preset
+}
+
+// maxMessageLength returns the endpoint's message length limit, or the default if unset
+func maxMessageLength(webhookCfg *config.SingleWebhookConfig) int {
+	if webhookCfg.MaxWebhookMessageLength > 0 {
+		return webhookCfg.MaxWebhookMessageLength
+	}
+	return DefaultMaxMessageLength
+}
+
+// truncateMessage shortens message to at most maxLen characters, cutting at a word
+// boundary when one is close and marking the cut with "..."
+func truncateMessage(message string, maxLen int) string {
+	runes := []rune(message)
+	if len(runes) <= maxLen {
+		return message
+	}
+
+	truncated := string(runes[:maxLen-3])
+	if lastSpace := strings.LastIndex(truncated, " "); lastSpace > len(truncated)/2 {
+		truncated = truncated[:lastSpace]
+	}
+	return strings.TrimRight(truncated, " ") + "..."
 }
 
 // buildCustomPayload builds a custom webhook payload
-func (s *Sender) buildCustomPayload(status analyzer.Status, message, sessionID, format string, statusInfo config.StatusInfo) ([]byte, string, error) {
-	if format == "text" {
+func (s *Sender) buildCustomPayload(status analyzer.Status, message, sessionID, correlationID string, webhookCfg *config.SingleWebhookConfig, statusInfo config.StatusInfo) ([]byte, string, error) {
+	if webhookCfg.Format == "text" {
 		text := fmt.Sprintf("[%s] %s", status, message)
 		return []byte(text), "text/plain", nil
 	}
 
 	// JSON format
+	data, err := json.Marshal(s.customPayloadFields(status, message, sessionID, correlationID, webhookCfg, statusInfo))
+	return data, "application/json", err
+}
+
+// customPayloadFields returns the fields of a custom JSON payload
+func (s *Sender) customPayloadFields(status analyzer.Status, message, sessionID, correlationID string, webhookCfg *config.SingleWebhookConfig, statusInfo config.StatusInfo) map[string]interface{} {
 	payload := map[string]interface{}{
 		"status":     string(status),
 		"message":    message,
@@ -205,14 +541,100 @@ func (s *Sender) buildCustomPayload(status analyzer.Status, message, sessionID,
 		"source":     "claude-notifications",
 		"title":      statusInfo.Title,
 	}
+	if webhookCfg.IncludeHost {
+		payload["host"] = s.hostname
+		payload["os"] = s.hostOS
+	}
+	if webhookCfg.EscalateLongTasks && isLongTask(message) {
+		payload["severity"] = "high"
+	}
+	if webhookCfg.IncludeCorrelationID {
+		payload["correlation_id"] = correlationID
+	}
+	// Custom fields add context but never replace the standard fields
+	for key, value := range webhookCfg.CustomPayloadFields {
+		if _, exists := payload[key]; !exists {
+			payload[key] = value
+		}
+	}
+	return payload
+}
+
+// requestHeaders returns the endpoint's HTTP headers, with customHeaderFields applied last
+func requestHeaders(webhookCfg *config.SingleWebhookConfig) map[string]string {
+	if len(webhookCfg.CustomHeaderFields) == 0 {
+		return webhookCfg.Headers
+	}
 
-	data, err := json.Marshal(payload)
-	return data, "application/json", err
+	headers := make(map[string]string, len(webhookCfg.Headers)+len(webhookCfg.CustomHeaderFields))
+	for key, value := range webhookCfg.Headers {
+		headers[key] = value
+	}
+	for key, value := range webhookCfg.CustomHeaderFields {
+		headers[key] = value
+	}
+	return headers
+}
+
+// defaultPriorities is the priority of each status in the priorityHeader; statuses not
+// listed are normal
+var defaultPriorities = map[analyzer.Status]string{
+	analyzer.StatusQuestion:            config.PriorityHigh,
+	analyzer.StatusPermission:          config.PriorityHigh,
+	analyzer.StatusSessionLimitReached: config.PriorityHigh,
+	analyzer.StatusAPIError:            config.PriorityHigh,
+	analyzer.StatusError:               config.PriorityHigh,
+	analyzer.StatusSubagentComplete:    config.PriorityLow,
+	analyzer.StatusLongRunningCommand:  config.PriorityLow,
+	analyzer.StatusTest:                config.PriorityLow,
+}
+
+// priority returns the priority of status for an endpoint, honoring its priorities overrides
+func priority(webhookCfg *config.SingleWebhookConfig, status analyzer.Status) string {
+	if p, ok := webhookCfg.Priorities[string(status)]; ok {
+		return p
+	}
+	if p, ok := defaultPriorities[status]; ok {
+		return p
+	}
+	return config.PriorityNormal
 }
 
-// sendHTTPRequest sends the actual HTTP request
-func (s *Sender) sendHTTPRequest(ctx context.Context, requestID, url string, payload []byte, contentType string, headers map[string]string) error {
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+// correlationIDHeader carries the notification's correlation ID when includeCorrelationID is set
+const correlationIDHeader = "X-Correlation-ID"
+
+// statusHeaders returns the endpoint's request headers for a notification of status:
+// the X-Correlation-ID when includeCorrelationID is set, and the priorityHeader when the
+// custom preset is used
+func (s *Sender) statusHeaders(ep *endpoint, status analyzer.Status, correlationID string) map[string]string {
+	headers := requestHeaders(ep.cfg)
+	withPriority := ep.cfg.PriorityHeader != ""
+	if withPriority {
+		statusInfo, _ := s.cfg.GetStatusInfo(string(status))
+		if preset := statusPreset(ep, statusInfo); preset != "" && preset != "custom" {
+			withPriority = false
+		}
+	}
+	if !withPriority && !ep.cfg.IncludeCorrelationID {
+		return headers
+	}
+
+	extended := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		extended[key] = value
+	}
+	if withPriority {
+		extended[ep.cfg.PriorityHeader] = priority(ep.cfg, status)
+	}
+	if ep.cfg.IncludeCorrelationID {
+		extended[correlationIDHeader] = correlationID
+	}
+	return extended
+}
+
+// sendHTTPRequest sends the actual HTTP request to the endpoint, signed if it has a signingSecret
+func (s *Sender) sendHTTPRequest(ctx context.Context, ep *endpoint, requestID string, payload []byte, contentType string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", ep.cfg.URL, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -227,6 +649,11 @@ func (s *Sender) sendHTTPRequest(ctx context.Context, requestID, url string, pay
 		req.Header.Set(key, value)
 	}
 
+	// Sign the exact bytes sent, after the headers so a custom header can't replace the signature
+	if ep.cfg.SigningSecret != "" {
+		req.Header.Set(ep.cfg.SignatureHeader, sign(ep.cfg.SigningSecret, payload))
+	}
+
 	// Send request
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -258,6 +685,22 @@ func (s *Sender) SendAsync(status analyzer.Status, message, sessionID string) {
 	})
 }
 
+// Wait blocks until all async webhook sends have finished, without cancelling them
+func (s *Sender) Wait(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("webhook still in flight after %v", timeout)
+	}
+}
+
 // Shutdown gracefully shuts down the webhook sender
 // Waits for in-flight requests to complete (with timeout)
 func (s *Sender) Shutdown(timeout time.Duration) error {
@@ -283,6 +726,26 @@ func (s *Sender) Shutdown(timeout time.Duration) error {
 	}
 }
 
+// SetCloseTimeout sets how long Close waits for in-flight async sends (0 = DefaultCloseTimeout)
+func (s *Sender) SetCloseTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultCloseTimeout
+	}
+	s.closeTimeout = timeout
+}
+
+// Close drains in-flight async sends before the process exits, waiting up to the close
+// timeout. Sends still running after that are cancelled and context.DeadlineExceeded is
+// returned; the sender can't send again once that happened.
+func (s *Sender) Close() error {
+	if err := s.Wait(s.closeTimeout); err != nil {
+		logging.Warn("Webhook requests still in flight after %v, cancelling them", s.closeTimeout)
+		s.cancel()
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
 // GetMetrics returns current metrics
 func (s *Sender) GetMetrics() Stats {
 	return s.metrics.GetStats()
@@ -311,6 +774,15 @@ func parseRetryConfig(cfg config.RetryConfig) RetryConfig {
 	}
 }
 
+// currentHostname returns the machine's hostname, or "unknown" if it can't be determined
+func currentHostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "unknown"
+	}
+	return name
+}
+
 // validateURL validates the webhook URL
 func validateURL(rawURL string) error {
 	if rawURL == "" {
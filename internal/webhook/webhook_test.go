@@ -1,23 +1,31 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/777genius/claude-notifications/internal/analyzer"
 	"github.com/777genius/claude-notifications/internal/config"
+	"github.com/777genius/claude-notifications/internal/platform"
+	"github.com/777genius/claude-notifications/internal/summary"
 )
 
 func newTestConfig(url string) *config.Config {
 	return &config.Config{
 		Notifications: config.NotificationsConfig{
-			Webhook: config.WebhookConfig{
+			Webhook: config.WebhookList{{
 				Enabled: true,
 				URL:     url,
 				Format:  "json",
@@ -38,7 +46,7 @@ func newTestConfig(url string) *config.Config {
 					Enabled:           false,
 					RequestsPerMinute: 60,
 				},
-			},
+			}},
 		},
 		Statuses: map[string]config.StatusInfo{
 			"task_complete": {Title: "Task Complete"},
@@ -73,6 +81,231 @@ func TestSenderSendSuccess(t *testing.T) {
 	}
 }
 
+func TestSenderSendIncludeHost(t *testing.T) {
+	for _, includeHost := range []bool{false, true} {
+		var body map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		cfg := newTestConfig(server.URL)
+		cfg.Notifications.Webhook[0].IncludeHost = includeHost
+		sender := New(cfg)
+
+		if err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		server.Close()
+
+		host, hasHost := body["host"]
+		osName, hasOS := body["os"]
+		if !includeHost {
+			if hasHost || hasOS {
+				t.Errorf("host fields should be omitted by default, got host=%v os=%v", host, osName)
+			}
+			continue
+		}
+		if expected, _ := os.Hostname(); host != expected {
+			t.Errorf("host = %v, want %q", host, expected)
+		}
+		if osName != platform.OS() {
+			t.Errorf("os = %v, want %q", osName, platform.OS())
+		}
+	}
+}
+
+func TestSenderSendTruncatesMessage(t *testing.T) {
+	long := strings.Repeat("word ", 200)
+
+	tests := []struct {
+		name   string
+		maxLen int
+		want   int
+	}{
+		{"default", 0, DefaultMaxMessageLength},
+		{"configured", 60, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := newTestConfig(server.URL)
+			cfg.Notifications.Webhook[0].MaxWebhookMessageLength = tt.maxLen
+			if err := New(cfg).Send(analyzer.StatusTaskComplete, long, "session-123"); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			message, _ := body["message"].(string)
+			if len(message) > tt.want || !strings.HasSuffix(message, "...") {
+				t.Errorf("expected message truncated to %d chars with ellipsis, got %d: %q", tt.want, len(message), message)
+			}
+		})
+	}
+}
+
+func TestSenderSendLongTaskSeverity(t *testing.T) {
+	for _, escalate := range []bool{false, true} {
+		var body map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		cfg := newTestConfig(server.URL)
+		cfg.Notifications.Webhook[0].EscalateLongTasks = escalate
+		if err := New(cfg).Send(analyzer.StatusTaskComplete, "[bold-cat] "+summary.LongTaskPrefix+"Done", "session-123"); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		server.Close()
+
+		severity, ok := body["severity"]
+		if escalate && severity != "high" {
+			t.Errorf("expected severity high for escalated long task, got %v", severity)
+		}
+		if !escalate && ok {
+			t.Errorf("severity should be omitted without escalateLongTasks, got %v", severity)
+		}
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		maxLen   int
+		expected string
+	}{
+		{"fits", "All tests passed", 20, "All tests passed"},
+		{"word boundary", "Refactored the webhook sender", 20, "Refactored the..."},
+		{"no spaces", strings.Repeat("a", 30), 20, strings.Repeat("a", 17) + "..."},
+		{"multibyte", strings.Repeat("é", 30), 20, strings.Repeat("é", 17) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateMessage(tt.message, tt.maxLen); got != tt.expected {
+				t.Errorf("truncateMessage() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSenderSendMultipleEndpoints(t *testing.T) {
+	hits := [2]atomic.Int32{}
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i].Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer servers[i].Close()
+	}
+
+	cfg := newTestConfig(servers[0].URL)
+	second := cfg.Notifications.Webhook[0]
+	second.URL = servers[1].URL
+	disabled := second
+	disabled.Enabled = false
+	disabled.URL = "http://127.0.0.1:1/disabled"
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, second, disabled)
+
+	sender := New(cfg)
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	for i := range hits {
+		if got := hits[i].Load(); got != 1 {
+			t.Errorf("endpoint %d received %d requests, want 1", i, got)
+		}
+	}
+	if stats := sender.GetMetrics(); stats.SuccessfulRequests != 2 {
+		t.Errorf("expected 2 successful requests, got %d", stats.SuccessfulRequests)
+	}
+}
+
+func TestSenderSendEndpointsInParallel(t *testing.T) {
+	// Each endpoint waits until both requests have arrived, so a sequential send
+	// would run into the timeout
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			w.WriteHeader(http.StatusOK)
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	cfg := newTestConfig(first.URL)
+	other := cfg.Notifications.Webhook[0]
+	other.URL = second.URL
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, other)
+
+	if err := New(cfg).Send(analyzer.StatusTaskComplete, "Test message", "session-123"); err != nil {
+		t.Fatalf("Send() error = %v, want both endpoints served in parallel", err)
+	}
+}
+
+func TestSenderSendMultipleEndpointsPartialFailure(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	cfg := newTestConfig(failing.URL)
+	second := cfg.Notifications.Webhook[0]
+	second.URL = ok.URL
+	third := second
+	third.URL = failing.URL + "/other"
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, second, third)
+
+	sender := New(cfg)
+	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	if err == nil {
+		t.Fatal("expected error from failing endpoints")
+	}
+	if n := len(strings.Split(err.Error(), "\n")); n != 2 {
+		t.Errorf("expected both failures to be joined, got %q", err)
+	}
+
+	// Each endpoint has its own circuit breaker: the failing one opening must not block the other
+	for i := 0; i < 3; i++ {
+		_ = sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+	}
+	healthy := sender.endpoints[endpointKey(&cfg.Notifications.Webhook[1])]
+	if state := healthy.circuitBreaker.GetState(); state != StateClosed {
+		t.Errorf("healthy endpoint circuit breaker = %v, want closed", state)
+	}
+	broken := sender.endpoints[endpointKey(&cfg.Notifications.Webhook[0])]
+	if state := broken.circuitBreaker.GetState(); state != StateOpen {
+		t.Errorf("failing endpoint circuit breaker = %v, want open", state)
+	}
+}
+
 func TestSenderSendWithRetry(t *testing.T) {
 	attempts := atomic.Int32{}
 
@@ -104,6 +337,56 @@ func TestSenderSendWithRetry(t *testing.T) {
 	}
 }
 
+func TestSenderSendRecordsRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int32 // requests answered with 503 before the server recovers
+		wantRetries   int64
+		wantSuccesses int64
+		wantFailures  int64
+	}{
+		{"first attempt succeeds", 0, 0, 1, 0},
+		{"one flaky response", 1, 1, 1, 0},
+		{"two flaky responses", 2, 2, 1, 0},
+		{"all attempts fail", 10, 2, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := atomic.Int32{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := newTestConfig(server.URL)
+			cfg.Notifications.Webhook[0].CircuitBreaker.Enabled = false
+			sender := New(cfg)
+
+			_ = sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
+
+			stats := sender.GetMetrics()
+			if stats.RetriedRequests != int64(attempts.Load())-1 {
+				t.Errorf("RetriedRequests = %d, want attempts-1 = %d", stats.RetriedRequests, attempts.Load()-1)
+			}
+			if stats.RetriedRequests != tt.wantRetries {
+				t.Errorf("RetriedRequests = %d, want %d", stats.RetriedRequests, tt.wantRetries)
+			}
+			if stats.SuccessfulRequests != tt.wantSuccesses || stats.FailedRequests != tt.wantFailures {
+				t.Errorf("successes/failures = %d/%d, want %d/%d",
+					stats.SuccessfulRequests, stats.FailedRequests, tt.wantSuccesses, tt.wantFailures)
+			}
+			if stats.TotalRequests != 1 {
+				t.Errorf("TotalRequests = %d, want 1 per send", stats.TotalRequests)
+			}
+		})
+	}
+}
+
 func TestSenderSendMaxRetriesExceeded(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -157,8 +440,8 @@ func TestSenderSendRateLimit(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.RateLimit.Enabled = true
-	cfg.Notifications.Webhook.RateLimit.RequestsPerMinute = 60 // 1 per second, capacity 60
+	cfg.Notifications.Webhook[0].RateLimit.Enabled = true
+	cfg.Notifications.Webhook[0].RateLimit.RequestsPerMinute = 60 // 1 per second, capacity 60
 	sender := New(cfg)
 
 	// Exhaust the rate limiter bucket (starts with 60 tokens)
@@ -189,7 +472,7 @@ func TestSenderSendSlackFormat(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.Preset = "slack"
+	cfg.Notifications.Webhook[0].Preset = "slack"
 	sender := New(cfg)
 
 	err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123")
@@ -220,7 +503,7 @@ func TestSenderSendDiscordFormat(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.Preset = "discord"
+	cfg.Notifications.Webhook[0].Preset = "discord"
 	sender := New(cfg)
 
 	err := sender.Send(analyzer.StatusQuestion, "What should we do?", "session-456")
@@ -241,6 +524,43 @@ func TestSenderSendDiscordFormat(t *testing.T) {
 	}
 }
 
+func TestSenderSendStatusWebhookPreset(t *testing.T) {
+	var payloads []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &payload)
+		payloads = append(payloads, payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Preset = "slack"
+	cfg.Statuses["question"] = config.StatusInfo{Title: "Question", WebhookPreset: "discord"}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusQuestion, "Which database?", "session-1"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done", "session-1"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 payloads, got %d", len(payloads))
+	}
+	// The question uses its Discord override on the Slack endpoint
+	if _, ok := payloads[0]["embeds"]; !ok {
+		t.Errorf("expected Discord embeds for question, got %v", payloads[0])
+	}
+	// Other statuses keep the endpoint's preset
+	if _, ok := payloads[1]["attachments"]; !ok {
+		t.Errorf("expected Slack attachments for task_complete, got %v", payloads[1])
+	}
+}
+
 func TestSenderSendTelegramFormat(t *testing.T) {
 	var receivedPayload map[string]interface{}
 
@@ -252,8 +572,8 @@ func TestSenderSendTelegramFormat(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.Preset = "telegram"
-	cfg.Notifications.Webhook.ChatID = "123456789"
+	cfg.Notifications.Webhook[0].Preset = "telegram"
+	cfg.Notifications.Webhook[0].ChatID = "123456789"
 	sender := New(cfg)
 
 	err := sender.Send(analyzer.StatusTaskComplete, "Done!", "session-789")
@@ -275,6 +595,62 @@ func TestSenderSendTelegramFormat(t *testing.T) {
 	}
 }
 
+func TestSenderSendTelegramCaptionField(t *testing.T) {
+	var receivedPayload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Preset = "telegram"
+	cfg.Notifications.Webhook[0].ChatID = "123456789"
+	cfg.Notifications.Webhook[0].TelegramMessageField = "caption"
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Done!", "session-789"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	caption, ok := receivedPayload["caption"].(string)
+	if !ok || !strings.Contains(caption, "Done!") {
+		t.Errorf("Expected message in caption field, got %v", receivedPayload)
+	}
+	if _, exists := receivedPayload["text"]; exists {
+		t.Errorf("Did not expect text field, got %v", receivedPayload)
+	}
+}
+
+func TestSenderSendShortcutsFormat(t *testing.T) {
+	var receivedBody, receivedContentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receivedBody = string(body)
+		receivedContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Preset = "shortcuts"
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test message", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if receivedContentType != "text/plain" {
+		t.Errorf("Expected text/plain, got %s", receivedContentType)
+	}
+	if receivedBody != "Task Complete: Test message" {
+		t.Errorf("Unexpected body: %q", receivedBody)
+	}
+}
+
 func TestSenderSendCustomHeaders(t *testing.T) {
 	var receivedHeaders http.Header
 
@@ -285,7 +661,7 @@ func TestSenderSendCustomHeaders(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.Headers = map[string]string{
+	cfg.Notifications.Webhook[0].Headers = map[string]string{
 		"Authorization": "Bearer secret-token",
 		"X-Custom":      "CustomValue",
 	}
@@ -316,6 +692,183 @@ func TestSenderSendCustomHeaders(t *testing.T) {
 	}
 }
 
+func TestSenderSendCustomFields(t *testing.T) {
+	var receivedHeaders http.Header
+	var receivedPayload map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].Headers = map[string]string{
+		"Authorization": "Bearer old-token",
+		"X-Custom":      "CustomValue",
+	}
+	cfg.Notifications.Webhook[0].CustomHeaderFields = map[string]string{
+		"Authorization": "Bearer new-token",
+		"X-Environment": "staging",
+	}
+	cfg.Notifications.Webhook[0].CustomPayloadFields = map[string]string{
+		"git_commit": "abc1234",
+		"status":     "overridden",
+	}
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	// Custom header fields are applied after headers
+	if receivedHeaders.Get("Authorization") != "Bearer new-token" {
+		t.Errorf("expected customHeaderFields to override headers, got %q", receivedHeaders.Get("Authorization"))
+	}
+	if receivedHeaders.Get("X-Custom") != "CustomValue" || receivedHeaders.Get("X-Environment") != "staging" {
+		t.Errorf("expected headers and custom header fields, got %v", receivedHeaders)
+	}
+
+	if receivedPayload["git_commit"] != "abc1234" {
+		t.Errorf("expected custom payload field, got %v", receivedPayload)
+	}
+	if receivedPayload["status"] != "task_complete" {
+		t.Errorf("custom fields must not replace standard fields, got status %v", receivedPayload["status"])
+	}
+
+	// The configured headers themselves are left untouched
+	if cfg.Notifications.Webhook[0].Headers["Authorization"] != "Bearer old-token" {
+		t.Error("requestHeaders must not modify the configured headers")
+	}
+}
+
+func TestSenderSendPriorityHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     analyzer.Status
+		preset     string
+		priorities map[string]string
+		want       string
+	}{
+		{"question is high", analyzer.StatusQuestion, "", nil, "high"},
+		{"error is high", analyzer.StatusError, "custom", nil, "high"},
+		{"task complete is normal", analyzer.StatusTaskComplete, "", nil, "normal"},
+		{"subagent is low", analyzer.StatusSubagentComplete, "", nil, "low"},
+		{"override", analyzer.StatusTaskComplete, "", map[string]string{"task_complete": "low"}, "low"},
+		{"not sent for other presets", analyzer.StatusQuestion, "slack", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			cfg := newTestConfig(server.URL)
+			cfg.Notifications.Webhook[0].Preset = tt.preset
+			cfg.Notifications.Webhook[0].PriorityHeader = "X-Priority"
+			cfg.Notifications.Webhook[0].Priorities = tt.priorities
+			sender := New(cfg)
+
+			if err := sender.Send(tt.status, "Test", "session-123"); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if got := received.Get("X-Priority"); got != tt.want {
+				t.Errorf("X-Priority = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderSendCorrelationID(t *testing.T) {
+	// request is what one endpoint received
+	type request struct {
+		header string
+		field  interface{}
+	}
+	// Endpoints are sent to in parallel, so the custom endpoint's requests are kept first
+	var mu sync.Mutex
+	var received, slackReceived []request
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		req := request{header: r.Header.Get("X-Correlation-ID"), field: payload["correlation_id"]}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/slack" {
+			slackReceived = append(slackReceived, req)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		received = append(received, req)
+		// Fail the first attempt so the retry has to carry the same ID
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig(server.URL)
+	cfg.Notifications.Webhook[0].IncludeCorrelationID = true
+	slack := cfg.Notifications.Webhook[0]
+	slack.Preset = "slack"
+	slack.URL = server.URL + "/slack"
+	cfg.Notifications.Webhook = append(cfg.Notifications.Webhook, slack)
+	sender := New(cfg)
+
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	received = append(received, slackReceived...)
+	if len(received) != 3 {
+		t.Fatalf("received %d requests, want 3 (a retry and one per endpoint)", len(received))
+	}
+
+	id := received[0].header
+	if _, err := uuid.Parse(id); err != nil {
+		t.Fatalf("X-Correlation-ID = %q, want a UUID", id)
+	}
+	for i, r := range received {
+		if r.header != id {
+			t.Errorf("request %d X-Correlation-ID = %q, want %q for every endpoint and retry", i, r.header, id)
+		}
+	}
+	for i, r := range received[:2] {
+		if r.field != id {
+			t.Errorf("request %d correlation_id = %v, want it to match the header %q", i, r.field, id)
+		}
+	}
+	if received[2].field != nil {
+		t.Errorf("slack payload correlation_id = %v, want it only in custom payloads", received[2].field)
+	}
+
+	// Every notification gets its own ID
+	received, slackReceived = nil, nil
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(received) == 0 || received[0].header == id {
+		t.Errorf("second notification reused correlation ID %q", id)
+	}
+
+	// Disabled by default
+	received = nil
+	sender = New(newTestConfig(server.URL))
+	if err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(received) != 1 || received[0].header != "" || received[0].field != nil {
+		t.Errorf("received %+v, want no correlation ID when includeCorrelationID is off", received)
+	}
+}
+
 func TestSenderSendDisabled(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("Server should not be called when webhooks disabled")
@@ -324,7 +877,7 @@ func TestSenderSendDisabled(t *testing.T) {
 	defer server.Close()
 
 	cfg := newTestConfig(server.URL)
-	cfg.Notifications.Webhook.Enabled = false
+	cfg.Notifications.Webhook[0].Enabled = false
 	sender := New(cfg)
 
 	err := sender.Send(analyzer.StatusTaskComplete, "Test", "session-123")
@@ -399,6 +952,94 @@ func TestSenderShutdown(t *testing.T) {
 	}
 }
 
+func TestSenderWait(t *testing.T) {
+	var delivered atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		delivered.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	sender.SendAsync(analyzer.StatusTest, "Test", "session-123")
+
+	if err := sender.Wait(2 * time.Second); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if delivered.Load() != 1 {
+		t.Errorf("Expected webhook to be delivered before Wait returned, got %d", delivered.Load())
+	}
+}
+
+func TestSenderWaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sender := New(newTestConfig(server.URL))
+	sender.SendAsync(analyzer.StatusTest, "Test", "session-123")
+
+	if err := sender.Wait(50 * time.Millisecond); err == nil {
+		t.Error("Expected Wait to time out while the request is in flight")
+	}
+}
+
+func TestSenderCloseDrainsInFlight(t *testing.T) {
+	var delivered atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		delivered.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := New(newTestConfig(server.URL))
+	for i := 0; i < 3; i++ {
+		sender.SendAsync(analyzer.StatusTaskComplete, "Test", "session-123")
+	}
+
+	if err := sender.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if delivered.Load() != 3 {
+		t.Errorf("Expected all webhooks delivered before Close returned, got %d", delivered.Load())
+	}
+}
+
+func TestSenderCloseTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sender := New(newTestConfig(server.URL))
+	sender.SetCloseTimeout(50 * time.Millisecond)
+	sender.SendAsync(analyzer.StatusTest, "Test", "session-123")
+
+	err := sender.Close()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The cancelled request must not keep the sender busy
+	if err := sender.Wait(2 * time.Second); err != nil {
+		t.Errorf("in-flight request was not cancelled: %v", err)
+	}
+}
+
 func TestSenderShutdownCancelsRequests(t *testing.T) {
 	requestCount := atomic.Int32{}
 
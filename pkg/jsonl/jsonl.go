@@ -2,14 +2,20 @@ package jsonl
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 )
 
 // Message represents a Claude Code transcript message
 type Message struct {
+	UUID       string         `json:"uuid"`
 	ParentUUID string         `json:"parentUuid"`
 	Type       string         `json:"type"`
 	Message    MessageContent `json:"message"`
@@ -20,16 +26,50 @@ type Message struct {
 // Content can be either a string (user text messages) or an array (tool results, assistant messages)
 type MessageContent struct {
 	Role          string    `json:"role"`
-	Content       []Content `json:"-"` // Array content (tool_result, assistant messages)
-	ContentString string    `json:"-"` // String content (user text messages)
+	Content       []Content `json:"-"`                     // Array content (tool_result, assistant messages)
+	ContentString string    `json:"-"`                     // String content (user text messages)
+	StopReason    string    `json:"stop_reason,omitempty"` // assistant: why the response ended ("end_turn", "tool_use", ...)
 }
 
 // Content represents a content block in a message
 type Content struct {
-	Type  string                 `json:"type"`
-	Name  string                 `json:"name,omitempty"`
-	Text  string                 `json:"text,omitempty"`
-	Input map[string]interface{} `json:"input,omitempty"`
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id,omitempty"` // tool_use ID, referenced by the matching tool_result
+	Name      string                 `json:"name,omitempty"`
+	Text      string                 `json:"text,omitempty"`
+	Input     map[string]interface{} `json:"input,omitempty"`
+	ToolUseID string                 `json:"tool_use_id,omitempty"` // tool_result: ID of the tool_use it answers
+	IsError   bool                   `json:"is_error,omitempty"`    // tool_result: tool failed (e.g. non-zero Bash exit)
+	Output    ToolOutput             `json:"content,omitempty"`     // tool_result: text output of the tool
+}
+
+// ToolOutput is the text of a tool_result. Transcripts store it either as a string
+// or as an array of content blocks; only the text blocks are kept.
+type ToolOutput string
+
+// UnmarshalJSON accepts string content or an array of content blocks.
+// Other shapes are ignored rather than failing the whole message.
+func (o *ToolOutput) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*o = ToolOutput(str)
+		return nil
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &blocks); err == nil {
+		var texts []string
+		for _, block := range blocks {
+			if block.Type == "text" && block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
+		*o = ToolOutput(strings.Join(texts, "\n"))
+	}
+	return nil
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for MessageContent
@@ -72,10 +112,12 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 func (m MessageContent) MarshalJSON() ([]byte, error) {
 	// Create auxiliary struct with content as interface{}
 	aux := &struct {
-		Role    string      `json:"role"`
-		Content interface{} `json:"content,omitempty"`
+		Role       string      `json:"role"`
+		Content    interface{} `json:"content,omitempty"`
+		StopReason string      `json:"stop_reason,omitempty"`
 	}{
-		Role: m.Role,
+		Role:       m.Role,
+		StopReason: m.StopReason,
 	}
 
 	// Choose content format based on which field is set
@@ -88,48 +130,303 @@ func (m MessageContent) MarshalJSON() ([]byte, error) {
 	return json.Marshal(aux)
 }
 
-// ParseFile parses a JSONL file and returns all messages
+// ParseFile parses a JSONL file and returns all messages.
+// Gzipped files (e.g. rotated .jsonl.gz transcripts) are decompressed transparently.
 func ParseFile(path string) ([]Message, error) {
+	r, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return Parse(r)
+}
+
+// gzipMagic are the first bytes of every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// openFile opens path for reading, decompressing it if it starts with the gzip magic bytes
+func openFile(path string) (io.ReadCloser, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
+
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return readCloser{Reader: br, closers: []io.Closer{f}}, nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open gzip file: %w", err)
+	}
+	return readCloser{Reader: gz, closers: []io.Closer{gz, f}}, nil
+}
+
+// isGzipFile reports whether the file at path starts with the gzip magic bytes
+func isGzipFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
 	defer f.Close()
 
-	return Parse(f)
+	magic := make([]byte, len(gzipMagic))
+	n, _ := io.ReadFull(f, magic)
+	return bytes.Equal(magic[:n], gzipMagic), nil
+}
+
+// readCloser reads from Reader and closes closers in order
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
 }
 
+func (r readCloser) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// utf8BOM is the byte order mark some Windows editors write at the start of a file
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// tailChunkSize is how much ParseLastN reads from the end of a file at a time
+const tailChunkSize = 64 * 1024
+
+// maxLineSize is the longest line parsed; longer lines are skipped when reading backwards
+const maxLineSize = 1024 * 1024
+
+// maxTailBytes bounds how far back ParseLastN and TailMessages read, so a tail of
+// skipped lines can't make them read a huge file in full
+var maxTailBytes int64 = 64 * 1024 * 1024
+
 // Parse parses JSONL from a reader and returns all messages
 func Parse(r io.Reader) ([]Message, error) {
 	var messages []Message
+	err := ParseStream(r, func(msg Message) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// ParseStream parses JSONL from a reader and calls fn for each message without keeping them.
+// Invalid lines are skipped; an error returned by fn stops parsing and is returned as-is.
+func ParseStream(r io.Reader, fn func(Message) error) error {
 	scanner := bufio.NewScanner(r)
 
 	// Increase buffer size for large lines
 	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024) // Max 1MB per line
+	scanner.Buffer(buf, maxLineSize)
 
 	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
+		msg, ok := parseLine(scanner.Bytes())
+		if !ok {
 			continue
 		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
 
-		var msg Message
-		if err := json.Unmarshal(line, &msg); err != nil {
-			// Skip invalid lines instead of failing
-			continue
+	return scanner.Err()
+}
+
+// ParseLastN parses the last n messages of a JSONL file.
+// The file is read backwards, so only its tail is decoded no matter how long the session is.
+// Gzipped files can't be read backwards; they are parsed in full and trimmed.
+func ParseLastN(path string, n int) ([]Message, error) {
+	compressed, err := isGzipFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		return parseFileTail(path, n)
+	}
+
+	var reversed []Message
+	err = scanLinesBackward(path, func(line []byte) bool {
+		if len(reversed) >= n {
+			return false
 		}
+		if msg, ok := parseLine(line); ok {
+			reversed = append(reversed, msg)
+		}
+		return len(reversed) < n
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		messages = append(messages, msg)
+	return reverseMessages(reversed), nil
+}
+
+// TailMessages parses the messages in the last maxLines non-blank lines of a JSONL file.
+// Like ParseLastN it reads from EOF, but bounds the lines read rather than the messages
+// kept, so a tail full of invalid lines can't make it scan the whole file.
+// Gzipped files are parsed in full and trimmed to the last maxLines messages.
+func TailMessages(path string, maxLines int) ([]Message, error) {
+	compressed, err := isGzipFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		return parseFileTail(path, maxLines)
 	}
 
-	if err := scanner.Err(); err != nil {
+	var reversed []Message
+	lines := 0
+	err = scanLinesBackward(path, func(line []byte) bool {
+		if lines >= maxLines {
+			return false
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			return true
+		}
+		lines++
+		if msg, ok := parseLine(line); ok {
+			reversed = append(reversed, msg)
+		}
+		return lines < maxLines
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	return reverseMessages(reversed), nil
+}
+
+// parseFileTail parses the whole file and returns its last n messages
+func parseFileTail(path string, n int) ([]Message, error) {
+	messages, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return []Message{}, nil
+	}
+	if len(messages) > n {
+		messages = messages[len(messages)-n:]
+	}
 	return messages, nil
 }
 
+// scanLinesBackward calls fn with each line of a file, last line first, until fn returns false.
+// The file is read from EOF in chunks; lines longer than a chunk are joined before fn sees them.
+// Lines longer than maxLineSize are skipped, and reading stops after maxTailBytes.
+func scanLinesBackward(path string, fn func(line []byte) bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var (
+		partial    [][]byte // pieces of a line continuing in the previous chunk, last piece first
+		partialLen int
+		tooLong    bool // the line being read exceeds maxLineSize and is skipped
+	)
+	// emit passes fn the line made of head followed by the partial pieces
+	emit := func(head []byte) bool {
+		defer func() { partial, partialLen, tooLong = partial[:0], 0, false }()
+		if tooLong || len(head)+partialLen > maxLineSize {
+			return true
+		}
+		if len(partial) == 0 {
+			return fn(head)
+		}
+		line := make([]byte, 0, len(head)+partialLen)
+		line = append(line, head...)
+		for i := len(partial) - 1; i >= 0; i-- {
+			line = append(line, partial[i]...)
+		}
+		return fn(line)
+	}
+
+	chunk := make([]byte, tailChunkSize)
+	offset := info.Size()
+	stop := offset - maxTailBytes
+
+	for offset > 0 {
+		if offset <= stop {
+			// The line being read is cut off by the limit, so it is dropped
+			return nil
+		}
+		size := int64(tailChunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		if _, err := f.ReadAt(chunk[:size], offset); err != nil {
+			return err
+		}
+
+		end := int(size)
+		for {
+			i := bytes.LastIndexByte(chunk[:end], '\n')
+			if i < 0 {
+				break
+			}
+			if !emit(chunk[i+1 : end]) {
+				return nil
+			}
+			end = i
+		}
+
+		// The rest of the chunk starts a line that continues in the previous chunk
+		if partialLen += end; partialLen > maxLineSize {
+			tooLong, partial = true, nil
+		}
+		if !tooLong {
+			partial = append(partial, append([]byte(nil), chunk[:end]...))
+		}
+	}
+
+	// The first line of the file has no newline before it
+	emit(nil)
+	return nil
+}
+
+// reverseMessages returns messages in the opposite order
+func reverseMessages(messages []Message) []Message {
+	reversed := make([]Message, len(messages))
+	for i, msg := range messages {
+		reversed[len(messages)-1-i] = msg
+	}
+	return reversed
+}
+
+// parseLine decodes one JSONL line; ok is false for blank or invalid lines
+func parseLine(line []byte) (msg Message, ok bool) {
+	// Strip a BOM and surrounding whitespace so the first line isn't lost
+	line = bytes.TrimSpace(bytes.TrimPrefix(line, utf8BOM))
+	if len(line) == 0 {
+		return msg, false
+	}
+
+	if err := json.Unmarshal(line, &msg); err != nil {
+		// Skip invalid lines instead of failing
+		return Message{}, false
+	}
+	return msg, true
+}
+
 // GetLastAssistantMessages returns the last N assistant messages
 func GetLastAssistantMessages(messages []Message, count int) []Message {
 	var assistantMessages []Message
@@ -170,6 +467,42 @@ type ToolUse struct {
 	Name     string
 }
 
+// ToolResult represents a tool's output, paired with the tool_use it answers
+type ToolResult struct {
+	Position  int    // index of the message holding the tool_result
+	ToolUseID string // ID of the tool_use it answers
+	Name      string // tool name from the matching tool_use ("" if it isn't in messages)
+	Output    string
+	IsError   bool
+}
+
+// ExtractToolResults extracts all tool results in order, each with the name of the tool that produced it
+func ExtractToolResults(messages []Message) []ToolResult {
+	var results []ToolResult
+	names := make(map[string]string)
+
+	for pos, msg := range messages {
+		for _, content := range msg.Message.Content {
+			switch content.Type {
+			case "tool_use":
+				if content.ID != "" {
+					names[content.ID] = content.Name
+				}
+			case "tool_result":
+				results = append(results, ToolResult{
+					Position:  pos,
+					ToolUseID: content.ToolUseID,
+					Name:      names[content.ToolUseID],
+					Output:    string(content.Output),
+					IsError:   content.IsError,
+				})
+			}
+		}
+	}
+
+	return results
+}
+
 // GetLastTool returns the last tool used, or empty string if none
 func GetLastTool(tools []ToolUse) string {
 	if len(tools) == 0 {
@@ -235,6 +568,49 @@ func FindLastToolUse(messages []Message, toolName string) *Content {
 	return lastTool
 }
 
+// FindToolResult finds the tool_result block answering the tool_use with the given ID
+// Returns nil if not found
+func FindToolResult(messages []Message, toolUseID string) *Content {
+	if toolUseID == "" {
+		return nil
+	}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Type != "user" {
+			continue
+		}
+		for j := range msg.Message.Content {
+			if msg.Message.Content[j].Type == "tool_result" && msg.Message.Content[j].ToolUseID == toolUseID {
+				return &msg.Message.Content[j]
+			}
+		}
+	}
+
+	return nil
+}
+
+// FindPendingToolUse returns the last tool_use in the transcript if no tool_result answers it yet
+// (e.g. the tool is waiting for permission). Returns nil if the last tool_use has a result or no ID.
+func FindPendingToolUse(messages []Message) *Content {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Type != "assistant" {
+			continue
+		}
+		content := messages[i].Message.Content
+		for j := len(content) - 1; j >= 0; j-- {
+			if content[j].Type != "tool_use" {
+				continue
+			}
+			if content[j].ID == "" || FindToolResult(messages[i+1:], content[j].ID) != nil {
+				return nil
+			}
+			return &content[j]
+		}
+	}
+	return nil
+}
+
 // ExtractToolInput extracts the input parameters from a specific tool use
 // Returns empty map if tool not found
 func ExtractToolInput(messages []Message, toolName string) map[string]interface{} {
@@ -249,20 +625,36 @@ func ExtractToolInput(messages []Message, toolName string) map[string]interface{
 // Includes both string content (normal user messages) and array content with type="text" (interrupted tool use)
 // Excludes tool_result messages
 func GetLastUserTimestamp(messages []Message) string {
+	if i := lastUserMessage(messages); i >= 0 {
+		return messages[i].Timestamp
+	}
+	return ""
+}
+
+// GetLastUserUUID returns the uuid of the last user message with text content (see GetLastUserTimestamp)
+func GetLastUserUUID(messages []Message) string {
+	if i := lastUserMessage(messages); i >= 0 {
+		return messages[i].UUID
+	}
+	return ""
+}
+
+// lastUserMessage returns the index of the last user message with text content, or -1
+func lastUserMessage(messages []Message) int {
 	for i := len(messages) - 1; i >= 0; i-- {
 		msg := messages[i]
 		if msg.Type == "user" {
 			// Check for string content (normal user text messages)
 			if msg.Message.ContentString != "" {
-				return msg.Timestamp
+				return i
 			}
 			// Check for array content with type="text" (interrupted tool use: "[Request interrupted by user for tool use]")
 			if len(msg.Message.Content) > 0 && msg.Message.Content[0].Type == "text" {
-				return msg.Timestamp
+				return i
 			}
 		}
 	}
-	return ""
+	return -1
 }
 
 // GetLastAssistantTimestamp returns the timestamp of the last assistant message
@@ -315,6 +707,82 @@ func FilterMessagesAfterTimestamp(messages []Message, afterTimestamp string) []M
 	return filtered
 }
 
+// HasOutOfOrderTimestamps reports whether a message is timestamped before an earlier one,
+// which happens when async processing writes messages late. Timestamp filtering can't
+// tell conversation turns apart then. Missing or invalid timestamps are ignored.
+func HasOutOfOrderTimestamps(messages []Message) bool {
+	var latest time.Time
+	for _, msg := range messages {
+		ts, err := time.Parse(time.RFC3339, msg.Timestamp)
+		if err != nil {
+			continue
+		}
+		if ts.Before(latest) {
+			return true
+		}
+		latest = ts
+	}
+	return false
+}
+
+// BuildMessageTree indexes messages by uuid, so their parentUuid chain can be followed.
+// Messages without a uuid are left out.
+func BuildMessageTree(messages []Message) map[string]*Message {
+	tree := make(map[string]*Message, len(messages))
+	for i := range messages {
+		if messages[i].UUID != "" {
+			tree[messages[i].UUID] = &messages[i]
+		}
+	}
+	return tree
+}
+
+// GetMessageChainSince returns the messages whose parentUuid chain leads back to the
+// message sinceUUID, in transcript order and without that message itself. Unlike
+// FilterMessagesAfterTimestamp it doesn't depend on timestamps. Returns nil if sinceUUID
+// is empty or not in messages.
+func GetMessageChainSince(messages []Message, sinceUUID string) []Message {
+	tree := BuildMessageTree(messages)
+	if _, ok := tree[sinceUUID]; !ok {
+		return nil
+	}
+
+	// descends caches, per uuid, whether its chain reaches sinceUUID
+	descends := map[string]bool{sinceUUID: true}
+	chain := []Message{}
+	for _, msg := range messages {
+		if descendsFrom(msg, tree, descends) {
+			chain = append(chain, msg)
+		}
+	}
+	return chain
+}
+
+// descendsFrom walks msg's parent chain until it reaches a uuid whose answer is cached
+// in descends, and caches the answer for every uuid on the way. A chain that leaves the
+// tree, or loops, doesn't descend.
+func descendsFrom(msg Message, tree map[string]*Message, descends map[string]bool) bool {
+	var walked []string
+	result := false
+	for parent := msg.ParentUUID; parent != "" && len(walked) <= len(tree); {
+		if cached, ok := descends[parent]; ok {
+			result = cached
+			break
+		}
+		walked = append(walked, parent)
+		next, ok := tree[parent]
+		if !ok {
+			break
+		}
+		parent = next.ParentUUID
+	}
+
+	for _, uuid := range walked {
+		descends[uuid] = result
+	}
+	return result
+}
+
 // filterAssistantMessages returns only assistant messages from the list
 func filterAssistantMessages(messages []Message) []Message {
 	var filtered []Message
@@ -1,8 +1,14 @@
 package jsonl
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -35,6 +41,19 @@ invalid json line
 	assert.Len(t, messages, 2)
 }
 
+func TestParseBOMAndWhitespace(t *testing.T) {
+	jsonl := "\xEF\xBB\xBF{\"type\":\"user\"}\r\n" +
+		"   \t\n" +
+		"  {\"type\":\"assistant\"}  \n"
+
+	messages, err := Parse(strings.NewReader(jsonl))
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, "user", messages[0].Type)
+	assert.Equal(t, "assistant", messages[1].Type)
+}
+
 func TestGetLastAssistantMessages(t *testing.T) {
 	messages := []Message{
 		{Type: "user"},
@@ -491,6 +510,250 @@ func TestParseFile_LargeFile(t *testing.T) {
 	assert.Len(t, messages, 1000)
 }
 
+func TestParseFile_Gzip(t *testing.T) {
+	jsonlData := "\xEF\xBB\xBF" + `{"type":"user","message":{"role":"user","content":"hello"},"timestamp":"2025-01-01T10:00:00Z"}
+invalid json line
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash"}]},"timestamp":"2025-01-01T10:00:01Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]},"timestamp":"2025-01-01T10:00:02Z"}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]},"timestamp":"2025-01-01T10:00:03Z"}
+`
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "transcript.jsonl")
+	require.NoError(t, os.WriteFile(plainPath, []byte(jsonlData), 0644))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(jsonlData))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	want, err := ParseFile(plainPath)
+	require.NoError(t, err)
+	require.Len(t, want, 4)
+
+	// Detected by magic bytes, so a compressed file without the .gz extension works too
+	for _, name := range []string{"transcript.jsonl.gz", "rotated.jsonl"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+			got, err := ParseFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			tail, err := TailMessages(path, 2)
+			require.NoError(t, err)
+			assert.Equal(t, want[2:], tail)
+
+			last, err := ParseLastN(path, 3)
+			require.NoError(t, err)
+			assert.Equal(t, want[1:], last)
+		})
+	}
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		path := filepath.Join(dir, "corrupt.jsonl.gz")
+		require.NoError(t, os.WriteFile(path, []byte{0x1f, 0x8b, 0x00}, 0644))
+
+		_, err := ParseFile(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseStream(t *testing.T) {
+	input := `{"type":"user"}
+invalid json line
+{"type":"assistant"}
+{"type":"assistant"}`
+
+	var types []string
+	err := ParseStream(strings.NewReader(input), func(msg Message) error {
+		types = append(types, msg.Type)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user", "assistant", "assistant"}, types)
+
+	// An error from the callback stops parsing
+	errStop := errors.New("stop")
+	count := 0
+	err = ParseStream(strings.NewReader(input), func(msg Message) error {
+		count++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+}
+
+func TestParseLastN(t *testing.T) {
+	// Lines longer than the read chunk make messages span chunk boundaries
+	long := strings.Repeat("x", tailChunkSize+100)
+	var sb strings.Builder
+	sb.WriteString("\xEF\xBB\xBF{\"type\":\"user\",\"timestamp\":\"0\"}\r\n")
+	for i := 1; i < 50; i++ {
+		text := "short"
+		if i%10 == 0 {
+			text = long
+		}
+		fmt.Fprintf(&sb, `{"type":"assistant","timestamp":"%d","message":{"role":"assistant","content":[{"type":"text","text":"%s"}]}}`+"\n", i, text)
+		if i%7 == 0 {
+			sb.WriteString("invalid json line\n\n")
+		}
+	}
+
+	for _, trailing := range []string{"", "\n"} {
+		path := filepath.Join(t.TempDir(), "transcript.jsonl")
+		content := strings.TrimSuffix(sb.String(), "\n") + trailing
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+		all, err := ParseFile(path)
+		require.NoError(t, err)
+		require.Len(t, all, 50)
+
+		for _, n := range []int{1, 3, 10, 49, 50, 100} {
+			last, err := ParseLastN(path, n)
+			require.NoError(t, err)
+			want := all
+			if n < len(all) {
+				want = all[len(all)-n:]
+			}
+			assert.Equal(t, want, last, "n=%d trailing=%q", n, trailing)
+		}
+
+		last, err := ParseLastN(path, 0)
+		require.NoError(t, err)
+		assert.Empty(t, last)
+	}
+}
+
+func TestParseLastN_Errors(t *testing.T) {
+	_, err := ParseLastN("/nonexistent/file.jsonl", 10)
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	messages, err := ParseLastN(path, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestParseLastN_Limits(t *testing.T) {
+	line := func(i int, text string) string {
+		return fmt.Sprintf(`{"type":"assistant","timestamp":"%d","message":{"role":"assistant","content":[{"type":"text","text":"%s"}]}}`, i, text)
+	}
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	lines := []string{line(0, "a"), line(1, strings.Repeat("x", maxLineSize)), line(2, "c")}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	// The over-long line is skipped, not joined into one huge buffer
+	last, err := ParseLastN(path, 10)
+	require.NoError(t, err)
+	require.Len(t, last, 2)
+	assert.Equal(t, "0", last[0].Timestamp)
+	assert.Equal(t, "2", last[1].Timestamp)
+
+	// Reading stops after maxTailBytes; the line cut off by the limit is dropped
+	original := maxTailBytes
+	maxTailBytes = 2 * tailChunkSize
+	t.Cleanup(func() { maxTailBytes = original })
+	last, err = ParseLastN(path, 10)
+	require.NoError(t, err)
+	require.Len(t, last, 1)
+	assert.Equal(t, "2", last[0].Timestamp)
+}
+
+func TestTailMessages(t *testing.T) {
+	long := strings.Repeat("x", 2*tailChunkSize)
+	line := func(i int, text string) string {
+		return fmt.Sprintf(`{"type":"assistant","timestamp":"%d","message":{"role":"assistant","content":[{"type":"text","text":"%s"}]}}`, i, text)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"smaller than one chunk", []string{line(0, "a"), line(1, "b"), line(2, "c")}},
+		{"lines larger than a chunk", []string{line(0, long), line(1, "b"), line(2, long), line(3, "d")}},
+		{"single line", []string{line(0, "a")}},
+	}
+
+	for _, tt := range tests {
+		for _, trailing := range []string{"", "\n", "\n\n"} {
+			t.Run(tt.name+fmt.Sprintf(" trailing=%q", trailing), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "transcript.jsonl")
+				require.NoError(t, os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+trailing), 0644))
+
+				all, err := ParseFile(path)
+				require.NoError(t, err)
+
+				for maxLines := 1; maxLines <= len(tt.lines)+1; maxLines++ {
+					tail, err := TailMessages(path, maxLines)
+					require.NoError(t, err)
+					want := all
+					if maxLines < len(all) {
+						want = all[len(all)-maxLines:]
+					}
+					assert.Equal(t, want, tail, "maxLines=%d", maxLines)
+				}
+			})
+		}
+	}
+}
+
+func TestTailMessages_InvalidLinesCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"type\":\"user\"}\n{\"type\":\"assistant\"}\ninvalid json line\n\n"), 0644))
+
+	// Blank lines are skipped, but the invalid line uses up one of the two lines
+	tail, err := TailMessages(path, 2)
+	require.NoError(t, err)
+	require.Len(t, tail, 1)
+	assert.Equal(t, "assistant", tail[0].Type)
+
+	_, err = TailMessages("/nonexistent/file.jsonl", 10)
+	assert.Error(t, err)
+}
+
+// writeSyntheticTranscript writes a transcript of alternating user and assistant messages
+func writeSyntheticTranscript(b *testing.B, lines int) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "transcript.jsonl")
+	f, err := os.Create(path)
+	require.NoError(b, err)
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for i := 0; i < lines; i++ {
+		if i%2 == 0 {
+			fmt.Fprintf(w, `{"type":"user","message":{"role":"user","content":"request %d"},"timestamp":"2025-01-01T10:00:00Z"}`+"\n", i)
+		} else {
+			fmt.Fprintf(w, `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Working on it"},{"type":"tool_use","id":"toolu_%d","name":"Edit","input":{"file_path":"main.go"}}]},"timestamp":"2025-01-01T10:00:01Z"}`+"\n", i)
+		}
+	}
+	require.NoError(b, w.Flush())
+	return path
+}
+
+func BenchmarkParseFile(b *testing.B) {
+	path := writeSyntheticTranscript(b, 500000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseFile(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseLastN(b *testing.B) {
+	path := writeSyntheticTranscript(b, 500000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseLastN(path, 100); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // === Tests for FindLastToolUse ===
 
 func TestFindLastToolUse_Found(t *testing.T) {
@@ -723,6 +986,87 @@ func TestMessageContent_UnmarshalJSON_ArrayContent(t *testing.T) {
 	assert.Equal(t, "tool_result", msg.Message.Content[0].Type)
 }
 
+func TestMessageContent_UnmarshalJSON_StopReason(t *testing.T) {
+	jsonStr := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done"}],"stop_reason":"end_turn"}}`
+
+	var msg Message
+	err := json.Unmarshal([]byte(jsonStr), &msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "end_turn", msg.Message.StopReason)
+	assert.Equal(t, 1, len(msg.Message.Content))
+}
+
+func TestFindToolResult(t *testing.T) {
+	input := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"go test"}}]},"timestamp":"2025-01-01T10:00:01Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"Exit code 1","is_error":true}]},"timestamp":"2025-01-01T10:00:02Z"}`
+
+	messages, err := Parse(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, "toolu_1", messages[0].Message.Content[0].ID)
+
+	result := FindToolResult(messages, "toolu_1")
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "toolu_1", result.ToolUseID)
+		assert.True(t, result.IsError)
+	}
+
+	assert.Nil(t, FindToolResult(messages, "toolu_missing"))
+	assert.Nil(t, FindToolResult(messages, ""))
+}
+
+func TestToolResultOutput(t *testing.T) {
+	input := `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok  \tpkg\t0.01s"}]},"timestamp":"2025-01-01T10:00:02Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_2","content":[{"type":"text","text":"line 1"},{"type":"image","source":{}},{"type":"text","text":"line 2"}]}]},"timestamp":"2025-01-01T10:00:03Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_3","content":{"unexpected":true}}]},"timestamp":"2025-01-01T10:00:04Z"}`
+
+	messages, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, ToolOutput("ok  \tpkg\t0.01s"), FindToolResult(messages, "toolu_1").Output)
+	assert.Equal(t, ToolOutput("line 1\nline 2"), FindToolResult(messages, "toolu_2").Output)
+	assert.Empty(t, FindToolResult(messages, "toolu_3").Output)
+}
+
+func TestExtractToolResults(t *testing.T) {
+	input := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Read","input":{"file_path":"a.go"}},{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"go test"}}]},"timestamp":"2025-01-01T10:00:01Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"package main"}]},{"type":"tool_result","tool_use_id":"toolu_2","content":"FAIL","is_error":true}]},"timestamp":"2025-01-01T10:00:02Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_old","content":"orphan"}]},"timestamp":"2025-01-01T10:00:03Z"}
+{"type":"user","message":{"role":"user","content":"thanks"},"timestamp":"2025-01-01T10:00:04Z"}`
+
+	messages, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+
+	results := ExtractToolResults(messages)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, ToolResult{Position: 1, ToolUseID: "toolu_1", Name: "Read", Output: "package main"}, results[0])
+	assert.Equal(t, ToolResult{Position: 1, ToolUseID: "toolu_2", Name: "Bash", Output: "FAIL", IsError: true}, results[1])
+	assert.Equal(t, ToolResult{Position: 2, ToolUseID: "toolu_old", Output: "orphan"}, results[2])
+
+	assert.Empty(t, ExtractToolResults(nil))
+}
+
+func TestFindPendingToolUse(t *testing.T) {
+	answered := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash","input":{"command":"ls"}}]},"timestamp":"2025-01-01T10:00:01Z"}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]},"timestamp":"2025-01-01T10:00:02Z"}`
+	pending := answered + `
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Removing build output"},{"type":"tool_use","id":"toolu_2","name":"Bash","input":{"command":"rm -rf dist"}}]},"timestamp":"2025-01-01T10:00:03Z"}`
+
+	messages, err := Parse(strings.NewReader(answered))
+	assert.NoError(t, err)
+	assert.Nil(t, FindPendingToolUse(messages))
+
+	messages, err = Parse(strings.NewReader(pending))
+	assert.NoError(t, err)
+	if result := FindPendingToolUse(messages); assert.NotNil(t, result) {
+		assert.Equal(t, "toolu_2", result.ID)
+		assert.Equal(t, "rm -rf dist", result.Input["command"])
+	}
+
+	assert.Nil(t, FindPendingToolUse(nil))
+}
+
 func TestMessageContent_UnmarshalJSON_ArrayTextContent(t *testing.T) {
 	// Test parsing of user message with array content type="text" (interrupted tool use)
 	jsonStr := `{
@@ -805,6 +1149,15 @@ func TestMessageContent_MarshalJSON(t *testing.T) {
 			},
 			expected: `{"role":"user","content":"Hello world"}`,
 		},
+		{
+			name: "with StopReason",
+			content: MessageContent{
+				Role:       "assistant",
+				Content:    []Content{{Type: "text", Text: "Done"}},
+				StopReason: "end_turn",
+			},
+			expected: `{"role":"assistant","content":[{"type":"text","text":"Done"}],"stop_reason":"end_turn"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -815,3 +1168,71 @@ func TestMessageContent_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+// === Tests for parentUuid chains ===
+
+// threadedMessages returns two turns where the first turn's last reply was written late,
+// with a timestamp after the second user message
+func threadedMessages() []Message {
+	return []Message{
+		{UUID: "u1", Type: "user", Message: MessageContent{ContentString: "plan it"}, Timestamp: "2025-01-01T10:00:00Z"},
+		{UUID: "a1", ParentUUID: "u1", Type: "assistant", Timestamp: "2025-01-01T10:00:01Z"},
+		{UUID: "u2", ParentUUID: "a1", Type: "user", Message: MessageContent{ContentString: "now do it"}, Timestamp: "2025-01-01T10:00:02Z"},
+		{UUID: "a1-late", ParentUUID: "u1", Type: "assistant", Timestamp: "2025-01-01T10:00:05Z"},
+		{UUID: "a2", ParentUUID: "u2", Type: "assistant", Timestamp: "2025-01-01T10:00:03Z"},
+		{UUID: "r2", ParentUUID: "a2", Type: "user", Message: MessageContent{Content: []Content{{Type: "tool_result"}}}, Timestamp: "2025-01-01T10:00:04Z"},
+		{UUID: "a3", ParentUUID: "r2", Type: "assistant", Timestamp: "2025-01-01T10:00:06Z"},
+	}
+}
+
+func TestBuildMessageTree(t *testing.T) {
+	messages := append(threadedMessages(), Message{Type: "summary"})
+
+	tree := BuildMessageTree(messages)
+	assert.Len(t, tree, 7, "messages without a uuid are left out")
+	require.Contains(t, tree, "a2")
+	assert.Equal(t, "u2", tree["a2"].ParentUUID)
+}
+
+func TestGetMessageChainSince(t *testing.T) {
+	messages := threadedMessages()
+
+	var uuids []string
+	for _, msg := range GetMessageChainSince(messages, "u2") {
+		uuids = append(uuids, msg.UUID)
+	}
+	assert.Equal(t, []string{"a2", "r2", "a3"}, uuids, "the late reply to u1 is not part of the chain")
+
+	assert.Empty(t, GetMessageChainSince(messages, "a3"))
+	assert.NotNil(t, GetMessageChainSince(messages, "a3"), "a known uuid without replies gives an empty chain")
+	assert.Nil(t, GetMessageChainSince(messages, "missing"))
+	assert.Nil(t, GetMessageChainSince(messages, ""))
+}
+
+func TestGetMessageChainSince_Loop(t *testing.T) {
+	messages := []Message{
+		{UUID: "u1", Type: "user"},
+		{UUID: "a", ParentUUID: "b", Type: "assistant"},
+		{UUID: "b", ParentUUID: "a", Type: "assistant"},
+	}
+
+	assert.Empty(t, GetMessageChainSince(messages, "u1"))
+}
+
+func TestGetLastUserUUID(t *testing.T) {
+	assert.Equal(t, "u2", GetLastUserUUID(threadedMessages()), "tool results are not user requests")
+	assert.Equal(t, "", GetLastUserUUID(nil))
+}
+
+func TestHasOutOfOrderTimestamps(t *testing.T) {
+	assert.True(t, HasOutOfOrderTimestamps(threadedMessages()))
+
+	ordered := []Message{
+		{Timestamp: "2025-01-01T10:00:00Z"},
+		{Timestamp: ""},
+		{Timestamp: "not a timestamp"},
+		{Timestamp: "2025-01-01T10:00:00Z"},
+		{Timestamp: "2025-01-01T10:00:01Z"},
+	}
+	assert.False(t, HasOutOfOrderTimestamps(ordered))
+}